@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/api"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+// components is the part of acceleration crosh on or crosh off switches
+type components struct {
+	proxy   bool
+	mirrors bool
+	names   []string // mirrors to switch, nil for all of them
+}
+
+// allComponents is everything crosh on and crosh off switch by default
+var allComponents = components{proxy: true, mirrors: true}
+
+// all reports whether c covers the proxy and every mirror
+func (c components) all() bool {
+	return c.proxy && c.mirrors && c.names == nil
+}
+
+// parseComponents parses the component names given to crosh on or crosh off:
+// proxy, mirrors, or single mirrors such as npm. No names means everything.
+func parseComponents(names []string) components {
+	if len(names) == 0 {
+		return allComponents
+	}
+
+	c := components{}
+	allMirrors := false
+	for _, name := range names {
+		name = strings.ToLower(name)
+		switch {
+		case name == "proxy":
+			c.proxy = true
+		case name == "mirrors":
+			c.mirrors = true
+			allMirrors = true
+		case slices.Contains(accelerator.MirrorNames, name):
+			c.mirrors = true
+			if !slices.Contains(c.names, name) {
+				c.names = append(c.names, name)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown component: %s (use proxy, mirrors or %s)\n"), name, strings.Join(accelerator.MirrorNames, ", "))
+			os.Exit(exitUsage)
+		}
+	}
+	if allMirrors {
+		c.names = nil
+	}
+	return c
+}
+
+// toolsOn returns the mirrors of names that aren't turned off in the config
+func toolsOn(cfg *config.Config, names []string) []string {
+	on := []string{}
+	for _, name := range names {
+		if cfg.Mirror.Tools.Enabled(name) {
+			on = append(on, name)
+		}
+	}
+	return on
+}
+
+func runOn(a *app, args []string) {
+	fs := newFlagSet("on", "[component...]")
+	duration := fs.Duration("for", 0, "turn acceleration off automatically after this long (e.g. 2h)")
+	allowLAN := fs.Bool("allow-lan", false, "share the proxy with other devices by listening on 0.0.0.0 (saved; --allow-lan=false undoes it)")
+	dryRun := fs.Bool("dry-run", false, "print the files, variables and processes crosh on would change, without changing anything")
+	parts := parseComponents(parseInterspersed(fs, args))
+
+	// Asking for the proxy by name is an error without a subscription, not
+	// something to skip quietly as a plain crosh on does
+	if parts.proxy && !parts.all() && a.cfg.Proxy.SubscriptionURL == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("✗ Proxy is not configured, run: crosh proxy set <subscription-url>"))
+		os.Exit(exitConfig)
+	}
+
+	if *duration < 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("✗ --for must be a positive duration"))
+		os.Exit(exitUsage)
+	}
+	// The timer turns all of acceleration off, not just the parts named here
+	if *duration > 0 && !parts.all() {
+		fmt.Fprintln(os.Stderr, i18n.T("✗ --for turns everything off when it ends, so it can't be combined with components"))
+		os.Exit(exitUsage)
+	}
+
+	if *dryRun {
+		listen := ""
+		if flagGiven(fs, "allow-lan") {
+			listen = lanListen(*allowLAN)
+		}
+		dryRunOn(a, listen, *duration, parts)
+		return
+	}
+
+	applyAllowLAN(a, fs, *allowLAN)
+	complete := handleOn(a.manager, a.cfg, parts)
+
+	if *duration > 0 {
+		a.cfg.ExpiresAt = time.Now().Add(*duration).Truncate(time.Second)
+		if err := a.cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+
+		if err := daemon.EnsureRunning(); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("⚠ Failed to start background daemon: %v\n"), err)
+			fmt.Println(i18n.T("  Acceleration will be turned off the next time crosh runs after expiry."))
+		}
+
+		fmt.Printf(i18n.T("⏱ Acceleration will turn off automatically at %s\n"), a.cfg.ExpiresAt.Local().Format("15:04 (Jan 2)"))
+	}
+
+	if !complete {
+		os.Exit(exitPartial)
+	}
+}
+
+// handleOn enables the mirrors and, if a subscription is configured, the proxy,
+// or just the parts chosen. It reports whether all of them were enabled.
+func handleOn(manager *accelerator.Manager, cfg *config.Config, parts components) bool {
+	fmt.Println(i18n.T("Enabling acceleration..."))
+	fmt.Println()
+
+	// A plain "on" makes acceleration permanent again
+	if parts.all() {
+		cfg.ExpiresAt = time.Time{}
+	}
+
+	complete := true
+
+	// Mirrors are safe and beneficial, so a plain "on" always enables them
+	if parts.mirrors {
+		cfg.Mirror.Enabled = true
+		enabled, err := manager.EnableMirrors(parts.names...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to enable mirrors: %v\n"), err)
+			complete = false
+		}
+		if len(enabled) > 0 {
+			fmt.Printf(i18n.T("✓ Mirrors enabled (%s)\n"), strings.Join(enabled, ", "))
+		}
+	}
+
+	// Enable proxy if subscription is configured
+	if parts.proxy && cfg.Proxy.SubscriptionURL != "" {
+		cfg.Proxy.Enabled = true
+		if enableProxy(manager) == nil {
+			printEnvHint()
+		} else {
+			complete = false
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	startDaemon(cfg)
+	fmt.Println(i18n.T("\n✓ Acceleration enabled"))
+	return complete
+}
+
+// startDaemon starts the background daemon if cfg has work for it, such as proxy failover
+func startDaemon(cfg *config.Config) {
+	if !daemon.HasWork(cfg) {
+		return
+	}
+
+	if err := daemon.EnsureRunning(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("⚠ Failed to start background daemon: %v\n"), err)
+	}
+}
+
+// enableProxy starts the proxy, downloading the proxy core and retrying once on failure.
+// It prints why the proxy failed and returns the error, nil if the proxy is running.
+func enableProxy(manager *accelerator.Manager) error {
+	err := manager.EnableProxy()
+	if err == nil {
+		fmt.Println(i18n.T("✓ Proxy enabled"))
+		return nil
+	}
+
+	// If proxy fails, might be missing the core binary
+	fmt.Fprintf(os.Stderr, i18n.T("✗ Proxy failed: %v\n"), err)
+	core := manager.GetProxyCore()
+	fmt.Printf(i18n.T("\nTrying to download %s...\n"), core.Name())
+
+	if downloadErr := core.Download(); downloadErr != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to download %s: %v\n"), core.Name(), downloadErr)
+		fmt.Println(i18n.T("\nProxy acceleration is unavailable."))
+		fmt.Println(i18n.T("Mirrors are still enabled and working."))
+		return downloadErr
+	}
+
+	// Retry enabling proxy after download
+	if retryErr := manager.EnableProxy(); retryErr != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Proxy still failed: %v\n"), retryErr)
+		return retryErr
+	}
+
+	fmt.Println(i18n.T("✓ Proxy enabled"))
+	return nil
+}
+
+func runOff(a *app, args []string) {
+	fs := newFlagSet("off", "[component...]")
+	dryRun := fs.Bool("dry-run", false, "print the files crosh off would change and the processes it would stop, without changing anything")
+	parts := parseComponents(parseInterspersed(fs, args))
+
+	if *dryRun {
+		dryRunOff(a, parts)
+		return
+	}
+
+	if !handleOff(a.manager, a.cfg, parts) {
+		os.Exit(exitPartial)
+	}
+}
+
+// handleOff disables the mirrors and the proxy, or just the parts chosen.
+// It reports whether all of them were disabled.
+func handleOff(manager *accelerator.Manager, cfg *config.Config, parts components) bool {
+	fmt.Println(i18n.T("Disabling acceleration..."))
+	fmt.Println()
+	complete := true
+
+	// Disable mirrors
+	if parts.mirrors {
+		if err := manager.DisableMirrors(parts.names...); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to disable mirrors: %v\n"), err)
+			complete = false
+		} else if parts.names == nil {
+			fmt.Println(i18n.T("✓ Mirrors disabled"))
+		} else if names := toolsOn(cfg, parts.names); len(names) > 0 {
+			fmt.Printf(i18n.T("✓ Mirrors disabled (%s)\n"), strings.Join(names, ", "))
+		}
+		// Mirrors left on keep the setting enabled
+		if parts.names == nil {
+			cfg.Mirror.Enabled = false
+		}
+	}
+
+	// Disable proxy
+	if parts.proxy {
+		if err := manager.DisableProxy(); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to disable proxy: %v\n"), err)
+			complete = false
+		} else {
+			if cfg.Proxy.Enabled {
+				fmt.Println(i18n.T("✓ Proxy disabled"))
+			}
+		}
+		cfg.Proxy.Enabled = false
+	}
+
+	if parts.all() {
+		cfg.ExpiresAt = time.Time{}
+	}
+	cfg.Save()
+
+	fmt.Println(i18n.T("\n✓ Acceleration disabled"))
+	return complete
+}
+
+func runStatus(a *app, args []string) {
+	fs := newFlagSet("status", "")
+	fs.Parse(args)
+
+	if a.json {
+		printJSON(api.NewStatusResponse(a.cfg, a.manager))
+		return
+	}
+
+	fmt.Println(i18n.T("Current Status"))
+	fmt.Println("==============")
+	fmt.Println()
+
+	printMirrorStatus(a.manager, a.cfg)
+	fmt.Println()
+	printProxyStatus(a.manager, a.cfg)
+
+	if !a.cfg.ExpiresAt.IsZero() {
+		fmt.Printf(i18n.T("\n⏱ Auto-off in %s (at %s)\n"),
+			time.Until(a.cfg.ExpiresAt).Round(time.Minute), a.cfg.ExpiresAt.Local().Format("15:04"))
+	}
+}
+
+// printMirrorStatus prints whether mirrors are enabled and where each points
+func printMirrorStatus(manager *accelerator.Manager, cfg *config.Config) {
+	if cfg.Mirror.Enabled {
+		fmt.Println(i18n.T("✓ Mirrors: enabled"))
+		mirrorStatus := manager.GetMirrorStatus()
+		for name, status := range mirrorStatus {
+			if status != "disabled" {
+				fmt.Printf("  • %s: %s\n", name, status)
+			}
+		}
+	} else {
+		fmt.Println(i18n.T("✗ Mirrors: disabled"))
+	}
+}
+
+// proxiedServices returns the names of the installed services that have the proxy crosh set
+func proxiedServices() []string {
+	services := []string{}
+	for _, name := range proxy.ServiceNames() {
+		if service := proxy.Services[name]; service.Installed() && service.UsesProxy() {
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
+// printProxyStatus prints the proxy state and subscription
+func printProxyStatus(manager *accelerator.Manager, cfg *config.Config) {
+	if cfg.Proxy.SubscriptionURL != "" {
+		if cfg.Proxy.Enabled {
+			fmt.Printf(i18n.T("✓ Proxy: enabled (%s)\n"), manager.GetProxyStatus())
+		} else {
+			fmt.Println(i18n.T("✗ Proxy: disabled"))
+		}
+		if cfg.Proxy.PinnedNode != "" {
+			fmt.Printf(i18n.T("  Pinned node: %s\n"), cfg.Proxy.PinnedNode)
+		}
+		if (proxy.Inbound{Listen: cfg.Proxy.Listen}).Exposed() {
+			fmt.Printf(i18n.T("  ⚠ Shared with your network (listening on %s)\n"), cfg.Proxy.Listen)
+		}
+		if cfg.Proxy.TUN.Enabled {
+			fmt.Println(i18n.T("  TUN mode: all system traffic goes through the proxy"))
+		}
+		if services := proxiedServices(); len(services) > 0 {
+			fmt.Printf(i18n.T("  Services using the proxy: %s\n"), strings.Join(services, ", "))
+		}
+		fmt.Printf(i18n.T("  Subscription: %s\n"), cfg.Proxy.SubscriptionURL)
+		if upstream, err := proxy.ParseUpstream(cfg.Proxy.Upstream); err != nil {
+			fmt.Printf("  ⚠ %v\n", err)
+		} else if upstream != nil {
+			fmt.Printf(i18n.T("  Upstream proxy: %s\n"), upstream.Redacted())
+		}
+	} else {
+		fmt.Println(i18n.T("○ Proxy: not configured"))
+		fmt.Println(i18n.T("\n  To configure proxy, run:"))
+		fmt.Println("    crosh proxy set https://your-subscription-url")
+	}
+}
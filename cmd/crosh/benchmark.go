@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+// benchmark is an endpoint fetched directly, from its mirror and through the proxy
+type benchmark struct {
+	name   string
+	url    string
+	mirror func(cfg *config.Config) string // the same file on the configured mirror, or ""
+}
+
+// benchmarks are small, stable files representative of everyday downloads
+var benchmarks = []benchmark{
+	{
+		name: "npm metadata",
+		url:  "https://registry.npmjs.org/left-pad",
+		mirror: func(cfg *config.Config) string {
+			return mirrorURL(cfg.Mirror.NPM, "left-pad")
+		},
+	},
+	{
+		name: "PyPI package",
+		url:  "https://pypi.org/simple/six/",
+		mirror: func(cfg *config.Config) string {
+			return mirrorURL(cfg.Mirror.Pip, "six/")
+		},
+	},
+	{
+		name: "Go module zip",
+		url:  "https://proxy.golang.org/github.com/google/uuid/@v/v1.6.0.zip",
+		mirror: func(cfg *config.Config) string {
+			return mirrorURL(goProxyMirror(cfg.Mirror.Go), "github.com/google/uuid/@v/v1.6.0.zip")
+		},
+	},
+	{
+		name:   "GitHub raw file",
+		url:    "https://raw.githubusercontent.com/golang/go/master/README.md",
+		mirror: func(*config.Config) string { return "" },
+	},
+}
+
+// mirrorURL joins a mirror base URL and a path, or returns "" without a mirror
+func mirrorURL(base, path string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + path
+}
+
+// goProxyMirror returns the first module proxy in a GOPROXY list
+func goProxyMirror(goproxy string) string {
+	for _, entry := range strings.Split(goproxy, ",") {
+		if entry = strings.TrimSpace(entry); entry != "direct" && entry != "off" {
+			return entry
+		}
+	}
+	return ""
+}
+
+func runTest(a *app, args []string) {
+	fs := newFlagSet("test", "")
+	timeout := fs.Duration("timeout", 20*time.Second, "give up on a fetch after this long")
+	fs.Parse(args)
+
+	direct := proxy.NewHTTPClient(a.cfg.Proxy.Upstream, *timeout)
+
+	var proxied *http.Client
+	core := a.manager.GetProxyCore()
+	if core.IsRunning() {
+		proxied = proxy.NewHTTPClient(core.GetProxyEnvVars()["ALL_PROXY"], *timeout)
+	} else {
+		fmt.Println(i18n.T("⚠ Proxy is not running, only testing direct and mirror fetches (start it with: crosh proxy on)"))
+	}
+
+	fmt.Printf(i18n.T("Fetching %d endpoints directly, from mirrors and through the proxy...\n\n"), len(benchmarks))
+
+	// Fixed columns rather than a tabwriter, so each row shows as soon as it is measured
+	fmt.Printf("%-16s %-8s %-8s %-8s %s\n", "ENDPOINT", "DIRECT", "MIRROR", "PROXY", "RESULT")
+	for _, b := range benchmarks {
+		directTime, directErr := timeFetch(direct, b.url)
+
+		mirrorTime, mirrorErr := time.Duration(0), errSkipped
+		if u := b.mirror(a.cfg); u != "" {
+			mirrorTime, mirrorErr = timeFetch(direct, u)
+		}
+
+		proxyTime, proxyErr := time.Duration(0), errSkipped
+		if proxied != nil {
+			proxyTime, proxyErr = timeFetch(proxied, b.url)
+		}
+
+		fmt.Printf("%-16s %-8s %-8s %-8s %s\n", b.name,
+			formatFetch(directTime, directErr), formatFetch(mirrorTime, mirrorErr), formatFetch(proxyTime, proxyErr),
+			compareFetch(directTime, directErr, []time.Duration{mirrorTime, proxyTime}, []error{mirrorErr, proxyErr}))
+	}
+}
+
+// errSkipped marks a fetch that wasn't made, e.g. for lack of a mirror
+var errSkipped = errors.New("skipped")
+
+// timeFetch downloads url with client and returns how long it took
+func timeFetch(client *http.Client, url string) (time.Duration, error) {
+	start := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// formatFetch formats the time of a fetch, or why it has none
+func formatFetch(d time.Duration, err error) string {
+	switch {
+	case err == errSkipped:
+		return "-"
+	case err != nil:
+		return "failed"
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
+
+// compareFetch describes how the fastest accelerated fetch compares to the direct one
+func compareFetch(direct time.Duration, directErr error, accelerated []time.Duration, errs []error) string {
+	var best time.Duration
+	tried := false
+	for i, d := range accelerated {
+		tried = tried || errs[i] != errSkipped
+		if errs[i] == nil && (best == 0 || d < best) {
+			best = d
+		}
+	}
+
+	switch {
+	case best == 0 && directErr != nil:
+		return i18n.T("✗ unreachable")
+	case best == 0 && tried:
+		return i18n.T("✗ only reachable directly")
+	case best == 0:
+		return "-"
+	case directErr != nil:
+		return i18n.T("✓ only reachable with crosh")
+	case best < direct:
+		return fmt.Sprintf(i18n.T("✓ %.1fx faster"), direct.Seconds()/best.Seconds())
+	default:
+		return i18n.T("○ no faster than direct")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/bundle"
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+// readPassphrase reads a passphrase from CROSH_BUNDLE_PASSPHRASE or the terminal
+func readPassphrase(prompt string) string {
+	if v := os.Getenv("CROSH_BUNDLE_PASSPHRASE"); v != "" {
+		return v
+	}
+
+	fmt.Print(prompt)
+
+	// Hide input where stty is available
+	if runtime.GOOS != "windows" {
+		stty := exec.Command("stty", "-echo")
+		stty.Stdin = os.Stdin
+		if stty.Run() == nil {
+			defer func() {
+				restore := exec.Command("stty", "echo")
+				restore.Stdin = os.Stdin
+				restore.Run()
+				fmt.Println()
+			}()
+		}
+	}
+
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func runExportBundle(a *app, args []string) {
+	fs := newFlagSet("export bundle", "")
+	output := fs.String("o", "crosh-bundle.yaml", "output file")
+	withSubscription := fs.Bool("with-subscription", false, "include the subscription URL")
+	encrypt := fs.Bool("encrypt", false, "encrypt the subscription URL with a passphrase")
+	fs.Parse(args)
+
+	opts := bundle.Options{IncludeSubscription: *withSubscription || *encrypt}
+	if *encrypt {
+		opts.Passphrase = readPassphrase(i18n.T("Passphrase for subscription: "))
+		if opts.Passphrase == "" {
+			fmt.Fprintln(os.Stderr, i18n.T("✗ Passphrase must not be empty"))
+			os.Exit(exitUsage)
+		}
+	}
+
+	b, err := bundle.New(a.cfg, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to create bundle: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := b.Save(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf(i18n.T("✓ Bundle written to %s\n"), *output)
+	if b.Subscription == nil {
+		fmt.Println(i18n.T("  Subscription not included (use --with-subscription or --encrypt)"))
+	} else if b.IsEncrypted() {
+		fmt.Println(i18n.T("  Subscription is encrypted, share the passphrase separately"))
+	} else {
+		fmt.Println(i18n.T("  ⚠ Subscription URL is stored in plain text"))
+	}
+	fmt.Printf(i18n.T("\nOnboard a teammate with: crosh import bundle %s\n"), filepath.Base(*output))
+}
+
+func runImportBundle(a *app, args []string) {
+	fs := newFlagSet("import bundle", "<file>")
+	noEnable := fs.Bool("no-enable", false, "only update config, don't enable acceleration")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+
+	b, err := bundle.Load(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	passphrase := ""
+	if b.IsEncrypted() {
+		passphrase = readPassphrase(i18n.T("Bundle passphrase: "))
+	}
+
+	if err := b.Apply(a.cfg, passphrase); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to import bundle: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf(i18n.T("✓ Imported bundle %s\n"), positional[0])
+	if b.Subscription != nil {
+		fmt.Println(i18n.T("✓ Subscription configured"))
+	}
+
+	if *noEnable {
+		fmt.Println(i18n.T("\nRun 'crosh on' to enable acceleration"))
+		return
+	}
+
+	fmt.Println()
+	if !handleOn(a.manager, a.cfg, allComponents) {
+		os.Exit(exitPartial)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// app carries the state shared by all commands
+type app struct {
+	cfg     *config.Config
+	manager *accelerator.Manager
+	// json is set by the global --json flag; commands that support it print
+	// JSON instead of text
+	json bool
+}
+
+// command is a node in the crosh command tree. Nodes with subcommands
+// are groups; leaf nodes have a run function that parses its own flags.
+type command struct {
+	name     string
+	args     string // positional argument synopsis, e.g. "<user@host>"
+	summary  string
+	hidden   bool
+	commands []*command
+	run      func(a *app, args []string)
+}
+
+// find returns the direct subcommand with the given name
+func (c *command) find(name string) *command {
+	for _, sub := range c.commands {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// execute walks args down the tree and runs the matching command.
+// path is the list of command names leading to c (used in help output).
+func (c *command) execute(a *app, path []string, args []string) {
+	if len(args) > 0 {
+		if sub := c.find(args[0]); sub != nil {
+			sub.execute(a, append(path, sub.name), args[1:])
+			return
+		}
+	}
+
+	if c.run != nil {
+		c.run(a, args)
+		return
+	}
+
+	// Group without a matching subcommand
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.printHelp(path)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, i18n.T("Unknown command: %s %s\n\n"), strings.Join(path, " "), args[0])
+	c.printHelp(path)
+	os.Exit(exitUsage)
+}
+
+// printHelp prints the usage of a command group
+func (c *command) printHelp(path []string) {
+	fmt.Printf("%s - %s\n\n", strings.Join(path, " "), i18n.T(c.summary))
+	fmt.Println(i18n.T("USAGE:"))
+	fmt.Printf("    %s <command> [flags]\n\n", strings.Join(path, " "))
+	fmt.Println(i18n.T("COMMANDS:"))
+	c.printCommands()
+	fmt.Printf(i18n.T("\nRun '%s <command> -h' for command flags.\n"), strings.Join(path, " "))
+}
+
+// printCommands prints the visible subcommands of c in a two-column list
+func (c *command) printCommands() {
+	for _, sub := range c.commands {
+		if sub.hidden {
+			continue
+		}
+		synopsis := sub.name
+		if len(sub.commands) > 0 {
+			synopsis += " <command>"
+		}
+		if sub.args != "" {
+			synopsis += " " + sub.args
+		}
+		fmt.Printf("    %-22s %s\n", synopsis, i18n.T(sub.summary))
+	}
+}
+
+// newFlagSet creates a flag set whose -h output describes the command
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		synopsis := "crosh " + name
+		if args != "" {
+			synopsis += " " + args
+		}
+		fmt.Fprintf(fs.Output(), i18n.T("Usage: %s [flags]\n"), synopsis)
+		hasFlags := false
+		fs.VisitAll(func(f *flag.Flag) {
+			hasFlags = true
+			f.Usage = i18n.T(f.Usage)
+		})
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), i18n.T("\nFlags:"))
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	positional := []string{}
+	for {
+		fs.Parse(args)
+		rest := fs.Args()
+
+		// flag stops after a "--" terminator; everything after it is positional
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...)
+		}
+
+		if len(rest) == 0 {
+			return positional
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// listFlag is a flag that collects comma-separated values and may be repeated.
+// Passing an empty value sets an empty list.
+type listFlag struct {
+	values []string
+	set    bool
+}
+
+func (l *listFlag) String() string {
+	return strings.Join(l.values, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	l.set = true
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			l.values = append(l.values, v)
+		}
+	}
+	return nil
+}
+
+// requireArgs exits with the command usage unless exactly n positional args were given
+func requireArgs(fs *flag.FlagSet, args []string, n int) {
+	if len(args) != n {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+}
+
+// flagGiven reports whether the flag name was set on the command line
+func flagGiven(fs *flag.FlagSet, name string) bool {
+	given := false
+	fs.Visit(func(f *flag.Flag) { given = given || f.Name == name })
+	return given
+}
+
+// resultOut is the real standard output. --quiet discards what is printed to
+// os.Stdout, but results such as JSON and the output of crosh run still go here.
+var resultOut = os.Stdout
+
+// extractGlobalFlags removes the global --json, --quiet and --verbose flags
+// from args. It stops at "--" and at crosh run, whose command may take flags
+// of the same name.
+func extractGlobalFlags(a *app, args []string) []string {
+	rest := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" || arg == "run" && len(rest) == 0 {
+			return append(rest, args[i:]...)
+		}
+		switch arg {
+		case "--json", "-json":
+			a.json = true
+		case "--quiet", "-quiet", "-q":
+			logging.SetLevel(logging.Quiet)
+		case "--verbose", "-verbose":
+			logging.SetLevel(logging.Verbose)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// silenceStdout discards everything printed to os.Stdout, leaving errors on stderr
+func silenceStdout() {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	os.Stdout = devNull
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Failed to encode JSON: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Fprintln(resultOut, string(data))
+}
+
+// toStderr runs fn with its progress messages sent to stderr, keeping stdout
+// clean for JSON or the output of a command. Silenced messages stay silenced.
+func toStderr(fn func()) {
+	stdout := os.Stdout
+	if stdout == resultOut {
+		os.Stdout = os.Stderr
+	}
+	defer func() { os.Stdout = stdout }()
+	fn()
+}
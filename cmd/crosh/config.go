@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+func runConfigShow(a *app, args []string) {
+	fs := newFlagSet("config show", "")
+	fs.Parse(args)
+
+	data, err := yaml.Marshal(a.cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to marshal config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Print(string(data))
+}
+
+func runConfigPath(a *app, args []string) {
+	fs := newFlagSet("config path", "")
+	fs.Parse(args)
+
+	path, err := config.GetConfigPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Println(path)
+}
+
+func runConfigGet(a *app, args []string) {
+	fs := newFlagSet("config get", "<key>")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+
+	root, err := configNode(a.cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	node, err := lookupConfigKey(root, positional[0], false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		fmt.Println(node.Value)
+		return
+	}
+	data, _ := yaml.Marshal(node)
+	fmt.Print(string(data))
+}
+
+func runConfigSet(a *app, args []string) {
+	fs := newFlagSet("config set", "<key> <value>")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 2)
+	key, value := positional[0], positional[1]
+
+	root, err := configNode(a.cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	node, err := lookupConfigKey(root, key, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Parse the value as YAML so numbers, booleans and [lists] work
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil || len(parsed.Content) == 0 {
+		parsed = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: value}}}
+	}
+	*node = *parsed.Content[0]
+
+	// Strictly decoding into a fresh config validates the key and the value's type
+	updated, err := decodeConfig(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Invalid value for %s: %v\n"), key, err)
+		os.Exit(exitCode(err))
+	}
+
+	*a.cfg = *updated
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf("✓ %s = %s\n", key, value)
+}
+
+// configNode encodes cfg as a YAML node tree
+func configNode(cfg *config.Config) (*yaml.Node, error) {
+	root := &yaml.Node{}
+	if err := root.Encode(cfg); err != nil {
+		return nil, fmt.Errorf(i18n.T("failed to encode config: %w"), err)
+	}
+	return root, nil
+}
+
+// decodeConfig decodes a config node tree, rejecting unknown keys
+func decodeConfig(root *yaml.Node) (*config.Config, error) {
+	data, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config.Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, &config.Error{Err: err}
+	}
+	return cfg, nil
+}
+
+// lookupConfigKey finds the value node for a dotted key such as "proxy.local_port".
+// With create, a missing last key is added (fields left out by omitempty).
+func lookupConfigKey(root *yaml.Node, key string, create bool) (*yaml.Node, error) {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+
+	node := root
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if node.Kind != yaml.MappingNode {
+			return nil, &config.Error{Err: fmt.Errorf(i18n.T("unknown config key: %s"), key)}
+		}
+
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil && create && i == len(parts)-1 {
+			next = &yaml.Node{Kind: yaml.ScalarNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, next)
+		}
+		if next == nil {
+			return nil, &config.Error{Err: fmt.Errorf(i18n.T("unknown config key: %s"), key)}
+		}
+		node = next
+	}
+	return node, nil
+}
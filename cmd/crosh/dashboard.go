@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+	"github.com/boomyao/crosh/internal/term"
+)
+
+const (
+	// dashboardLogLines is how many log lines crosh dashboard keeps
+	dashboardLogLines = 100
+	// dashboardMessageLines is how many lines of an action's output crosh
+	// dashboard shows; the last ones hold the outcome
+	dashboardMessageLines = 3
+)
+
+// dashboard is the state of the crosh dashboard UI
+type dashboard struct {
+	a       *app
+	running bool
+	traffic proxy.Traffic
+	rate    proxy.Traffic // bytes per second since the previous sample
+	sampled time.Time
+	stats   *proxy.NodeStats
+	mirrors map[string]string
+	logs    []string
+	logSize int64 // bytes of the log read so far
+	message []string
+	width   int // of the terminal, read before each redraw
+	height  int
+}
+
+func runDashboard(a *app, args []string) {
+	fs := newFlagSet("dashboard", "")
+	fs.Parse(args)
+
+	d := &dashboard{a: a}
+	t := startTUI("crosh dashboard")
+	defer t.stop()
+
+	d.refreshMirrors()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		d.refresh()
+		d.width, d.height = term.Size()
+		d.draw()
+
+		select {
+		case <-ticker.C:
+		case key, open := <-t.keys:
+			if !open {
+				return
+			}
+			switch {
+			case key.Name == "esc" || key.Name == "ctrl-c" || key.Rune == 'q':
+				return
+			case key.Rune == 'p':
+				d.act(i18n.T("Switching the proxy..."), d.toggleProxy)
+			case key.Rune == 'm':
+				d.act(i18n.T("Switching mirrors..."), d.toggleMirrors)
+				d.refreshMirrors()
+			case key.Rune == 'n':
+				d.pickNode(t.keys)
+			case key.Rune == 'a':
+				d.act(i18n.T("Restoring automatic node selection..."), d.unpin)
+			}
+		}
+	}
+}
+
+// act shows busy while fn runs, then the end of what fn printed
+func (d *dashboard) act(busy string, fn func()) {
+	d.message = []string{busy}
+	d.draw()
+	output := captureOutput(fn)
+	d.message = output[max(len(output)-dashboardMessageLines, 0):]
+}
+
+// toggleProxy starts the proxy if it is stopped and stops it otherwise
+func (d *dashboard) toggleProxy() {
+	a := d.a
+	if a.manager.GetProxyCore().IsRunning() {
+		if err := a.manager.DisableProxy(); err != nil {
+			fmt.Printf(i18n.T("✗ Failed to disable proxy: %v\n"), err)
+			return
+		}
+		a.cfg.Proxy.Enabled = false
+		a.cfg.Save()
+		fmt.Println(i18n.T("✓ Proxy disabled"))
+		return
+	}
+
+	if a.cfg.Proxy.SubscriptionURL == "" {
+		fmt.Println(i18n.T("✗ Proxy is not configured, run: crosh proxy set <subscription-url>"))
+		return
+	}
+	a.cfg.Proxy.Enabled = true
+	if enableProxy(a.manager) != nil {
+		a.cfg.Proxy.Enabled = false
+		a.cfg.Save()
+		return
+	}
+	a.cfg.Save()
+	startDaemon(a.cfg)
+}
+
+// toggleMirrors disables the mirrors if they are enabled and enables them otherwise
+func (d *dashboard) toggleMirrors() {
+	a := d.a
+	if a.cfg.Mirror.Enabled {
+		if err := a.manager.DisableMirrors(); err != nil {
+			fmt.Printf(i18n.T("Warning: Failed to disable mirrors: %v\n"), err)
+		}
+		a.cfg.Mirror.Enabled = false
+	} else {
+		a.cfg.Mirror.Enabled = true
+		if _, err := a.manager.EnableMirrors(); err != nil {
+			fmt.Printf(i18n.T("Warning: Failed to enable mirrors: %v\n"), err)
+		}
+	}
+
+	if err := a.cfg.Save(); err != nil {
+		fmt.Printf(i18n.T("Error saving config: %v\n"), err)
+		return
+	}
+	if a.cfg.Mirror.Enabled {
+		fmt.Println(i18n.T("✓ Mirrors enabled"))
+	} else {
+		fmt.Println(i18n.T("✓ Mirrors disabled"))
+	}
+}
+
+// pickNode opens the node picker and pins the node picked, if any
+func (d *dashboard) pickNode(keys <-chan term.Key) {
+	var p *picker
+	var err error
+	d.act(i18n.T("Fetching nodes..."), func() { p, err = newPicker(d.a, false) })
+	if err != nil {
+		d.message = []string{"✗ " + err.Error()}
+		return
+	}
+
+	chosen, ok := p.run(keys)
+	d.message = captureOutput(p.saveLatency)
+	if !ok {
+		return
+	}
+
+	d.act(fmt.Sprintf(i18n.T("Switching to %s..."), chosen), func() {
+		node, err := d.a.manager.SwitchNode(chosen)
+		if err != nil {
+			fmt.Printf(i18n.T("✗ Failed to switch node: %v\n"), err)
+			return
+		}
+		startDaemon(d.a.cfg)
+		fmt.Printf(i18n.T("✓ Proxy now using node: %s\n"), node.Name)
+	})
+}
+
+// unpin returns to automatic node selection
+func (d *dashboard) unpin() {
+	if d.a.cfg.Proxy.PinnedNode == "" {
+		fmt.Println(i18n.T("✓ Node selection is already automatic"))
+		return
+	}
+	if err := d.a.manager.UnpinNode(); err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+	fmt.Println(i18n.T("✓ Automatic node selection restored"))
+}
+
+// refresh reads the proxy state, traffic counters and new log lines
+func (d *dashboard) refresh() {
+	// The daemon saves node changes, such as failovers, to the config; update
+	// the config in place, as the manager shares it
+	if cfg, err := config.Load(); err == nil {
+		*d.a.cfg = *cfg
+	}
+
+	core := d.a.manager.GetProxyCore()
+	d.running = core.IsRunning()
+
+	if stats, err := d.a.manager.NodeStats(); err == nil {
+		d.stats = stats
+	}
+
+	traffic, err := core.Traffic()
+	now := time.Now()
+	if err != nil {
+		d.traffic, d.rate, d.sampled = proxy.Traffic{}, proxy.Traffic{}, time.Time{}
+	} else {
+		// Counters restart with the core
+		if !d.sampled.IsZero() && traffic.Up >= d.traffic.Up && traffic.Down >= d.traffic.Down {
+			elapsed := now.Sub(d.sampled).Seconds()
+			d.rate.Up = int64(float64(traffic.Up-d.traffic.Up) / elapsed)
+			d.rate.Down = int64(float64(traffic.Down-d.traffic.Down) / elapsed)
+		}
+		d.traffic, d.sampled = traffic, now
+	}
+
+	d.readLog(core.LogPath())
+}
+
+// refreshMirrors reads where each mirror points. It runs tools like npm, so
+// it is only done on start and after toggling mirrors.
+func (d *dashboard) refreshMirrors() {
+	captureOutput(func() { d.mirrors = d.a.manager.GetMirrorStatus() })
+}
+
+// readLog appends the lines written to the log since it was last read,
+// starting over when the log is truncated or rotated
+func (d *dashboard) readLog(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	if info.Size() < d.logSize {
+		d.logs, d.logSize = nil, 0
+	}
+	if info.Size() == d.logSize {
+		return
+	}
+
+	file.Seek(d.logSize, io.SeekStart)
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		// Leave a partly written last line to read whole next time
+		if err != nil {
+			break
+		}
+		d.logSize += int64(len(line))
+		d.logs = append(d.logs, strings.TrimRight(line, "\r\n"))
+	}
+	if len(d.logs) > dashboardLogLines {
+		d.logs = d.logs[len(d.logs)-dashboardLogLines:]
+	}
+}
+
+// draw redraws the whole screen
+func (d *dashboard) draw() {
+	cfg := d.a.cfg
+	s := newScreen(d.width)
+	s.line("", fmt.Sprintf("crosh dashboard  %s", time.Now().Format("15:04:05")))
+	s.line("", "")
+
+	label := func(text string) string { return term.Pad(i18n.T(text), 10) }
+	switch {
+	case cfg.Proxy.SubscriptionURL == "":
+		s.line("", label("Proxy")+i18n.T("○ not configured"))
+	case d.running:
+		s.line("", label("Proxy")+"● "+d.a.manager.GetProxyStatus())
+	default:
+		s.line("", label("Proxy")+i18n.T("○ stopped"))
+	}
+
+	if d.running {
+		s.line("", label("Node")+d.nodeSummary())
+		if d.sampled.IsZero() {
+			s.line(term.Dim, label("Traffic")+i18n.T("unavailable"))
+		} else {
+			s.line("", label("Traffic")+fmt.Sprintf("↑ %s (%s/s)   ↓ %s (%s/s)",
+				formatBytes(d.traffic.Up), formatBytes(d.rate.Up), formatBytes(d.traffic.Down), formatBytes(d.rate.Down)))
+		}
+	}
+
+	if cfg.Mirror.Enabled {
+		s.line("", label("Mirrors")+i18n.T("✓ enabled"))
+	} else {
+		s.line("", label("Mirrors")+i18n.T("✗ disabled"))
+	}
+	names := make([]string, 0, len(d.mirrors))
+	for name := range d.mirrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		status := d.mirrors[name]
+		if status == "disabled" {
+			s.line(term.Dim, "  "+term.Pad(name, 8)+i18n.T("disabled"))
+		} else {
+			s.line("", "  "+term.Pad(name, 8)+status)
+		}
+	}
+	s.line("", "")
+
+	// The log takes the rows left above the message and key help
+	used := 6 + len(names)
+	if d.running {
+		used += 2
+	}
+	rows := max(d.height-used-len(d.message)-3, 0)
+	s.line("", i18n.T("Recent log"))
+	logs := d.logs[max(len(d.logs)-rows, 0):]
+	for _, line := range logs {
+		s.line(term.Dim, line)
+	}
+	for i := len(logs); i < rows; i++ {
+		s.line("", "")
+	}
+
+	s.line("", "")
+	for _, line := range d.message {
+		s.line("", line)
+	}
+	s.line(term.Reverse, term.Pad(i18n.T(" p proxy on/off  m mirrors on/off  n pick node  a auto node  q quit"), d.width))
+	s.draw()
+}
+
+// nodeSummary describes the nodes the proxy is using
+func (d *dashboard) nodeSummary() string {
+	cfg := d.a.cfg
+	if balanced := cfg.Proxy.BalancedNodes; len(balanced) > 1 {
+		return fmt.Sprintf(i18n.T("balancing %d nodes: %s"), len(balanced), strings.Join(balanced, ", "))
+	}
+
+	summary := cfg.Proxy.CurrentNode
+	if d.stats != nil {
+		if latency := formatLatency(d.stats, summary); latency != "-" {
+			summary += "  " + latency
+		}
+	}
+	if cfg.Proxy.PinnedNode != "" {
+		summary += "  " + i18n.T("(pinned)")
+	}
+	return summary
+}
+
+// formatBytes describes a byte count with a binary unit, e.g. "1.5 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, i := float64(n)/unit, 0
+	for value >= unit && i < 4 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[i])
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/mirror"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+// doctor prints the outcome of diagnostic checks and counts the failures.
+// Messages are translated here; formatted ones by the caller.
+type doctor struct {
+	failures int
+}
+
+func (d *doctor) ok(format string, args ...interface{}) {
+	fmt.Printf("✓ "+i18n.T(format)+"\n", args...)
+}
+
+// warn reports a problem that doesn't stop crosh from working, with a fix
+func (d *doctor) warn(problem, fix string) {
+	fmt.Printf("⚠ %s\n  → %s\n", i18n.T(problem), i18n.T(fix))
+}
+
+// fail reports a problem that breaks acceleration, with a fix
+func (d *doctor) fail(problem, fix string) {
+	d.failures++
+	fmt.Printf("✗ %s\n  → %s\n", i18n.T(problem), i18n.T(fix))
+}
+
+func runDoctor(a *app, args []string) {
+	fs := newFlagSet("doctor", "")
+	fs.Parse(args)
+
+	fmt.Println("crosh doctor")
+	fmt.Println("============")
+	fmt.Println()
+
+	d := &doctor{}
+	// With a broken config the other checks would only describe the defaults
+	// main fell back to
+	if d.checkConfig() {
+		d.checkCore(a)
+		d.checkGeoData(a)
+		d.checkProxy(a)
+		d.checkSubscription(a)
+		d.checkDNS()
+		d.checkMirrors(a)
+	}
+
+	fmt.Println()
+	switch d.failures {
+	case 0:
+		fmt.Println(i18n.T("No problems found"))
+	case 1:
+		fmt.Println(i18n.T("1 problem found"))
+		os.Exit(1)
+	default:
+		fmt.Printf(i18n.T("%d problems found\n"), d.failures)
+		os.Exit(1)
+	}
+}
+
+// checkConfig reports whether the config file can be read
+func (d *doctor) checkConfig() bool {
+	path, err := config.GetConfigPath()
+	if err != nil {
+		d.fail(fmt.Sprintf(i18n.T("Config directory: %v"), err), "Make sure your home directory exists and is writable")
+		return false
+	}
+
+	if _, err := config.Load(); err != nil {
+		d.fail(fmt.Sprintf(i18n.T("Config: %v"), err), fmt.Sprintf(i18n.T("Fix the YAML in %s, or move it away to start from the defaults"), path))
+		return false
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		d.ok("Config: not created yet, using the defaults")
+		return true
+	}
+	d.ok("Config: %s", path)
+	return true
+}
+
+// checkCore checks that the proxy core binary is installed and executable
+func (d *doctor) checkCore(a *app) {
+	core := a.manager.GetProxyCore()
+	path := core.BinaryPath()
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		d.fail(fmt.Sprintf(i18n.T("%s: not installed (%s)"), core.Name(), path), "Run: crosh proxy on (downloads it)")
+		return
+	}
+	if err != nil {
+		d.fail(fmt.Sprintf("%s: %v", core.Name(), err), fmt.Sprintf(i18n.T("Check the permissions of %s"), path))
+		return
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		d.fail(fmt.Sprintf(i18n.T("%s: %s is not executable"), core.Name(), path), "Run: chmod +x "+path)
+		return
+	}
+
+	if core.Name() != proxy.CoreXray {
+		d.ok("%s: %s", core.Name(), path)
+		return
+	}
+	installed, err := a.manager.GetXrayManager().InstalledVersion()
+	if err != nil {
+		d.fail(fmt.Sprintf(i18n.T("xray: %v"), err), "Reinstall it with: crosh xray upgrade")
+		return
+	}
+	d.ok("xray: %s (%s)", installed, path)
+}
+
+// checkGeoData checks that the geo data files used by routing rules exist
+func (d *doctor) checkGeoData(a *app) {
+	// sing-box downloads rule sets instead
+	if a.manager.GetProxyCore().Name() == proxy.CoreSingBox {
+		return
+	}
+
+	age, ok := a.manager.GetXrayManager().GeoDataAge()
+	if !ok {
+		d.fail("Geo data: geoip.dat or geosite.dat is missing", "Run: crosh geodata update")
+		return
+	}
+
+	if maxAge := a.cfg.Proxy.GeoDataMaxAge; maxAge > 0 && age > maxAge {
+		d.warn(fmt.Sprintf(i18n.T("Geo data: %s old"), age.Round(time.Hour)), "Run: crosh geodata update")
+		return
+	}
+	d.ok("Geo data: updated %s ago", age.Round(time.Minute))
+}
+
+// checkProxy checks that a stopped proxy can take its ports, and that an
+// enabled proxy is running and reaches the probe URL
+func (d *doctor) checkProxy(a *app) {
+	core := a.manager.GetProxyCore()
+	running := core.IsRunning()
+
+	if !running {
+		ports := []int{a.cfg.Proxy.LocalPort}
+		keys := []string{"proxy.local_port"}
+		if a.cfg.Proxy.HTTPPort > 0 {
+			ports = append(ports, a.cfg.Proxy.HTTPPort)
+			keys = append(keys, "proxy.http_port")
+		}
+		if a.cfg.Proxy.StatsPort > 0 {
+			ports = append(ports, a.cfg.Proxy.StatsPort)
+			keys = append(keys, "proxy.stats_port")
+		}
+		free := true
+		for i, port := range ports {
+			if err := portFree(a.cfg.Proxy.Listen, port); err != nil {
+				free = false
+				d.fail(fmt.Sprintf(i18n.T("Port %d is in use by another program"), port),
+					fmt.Sprintf(i18n.T("Stop that program, or pick another port: crosh config set %s %d"), keys[i], port+10))
+			}
+		}
+		if free {
+			d.ok("Ports: %s free", joinPorts(ports))
+		}
+	}
+
+	if a.cfg.Proxy.SubscriptionURL == "" {
+		d.warn("Proxy: not configured", "Run: crosh proxy set <subscription-url>")
+		return
+	}
+	if !a.cfg.Proxy.Enabled {
+		d.ok("Proxy: disabled")
+		return
+	}
+	if !running {
+		d.fail(fmt.Sprintf(i18n.T("Proxy: enabled but %s is not running"), core.Name()),
+			"Run: crosh proxy on (if it keeps stopping, see: crosh logs --level warning)")
+		return
+	}
+
+	if err := a.manager.CheckProxy(); err != nil {
+		d.fail(fmt.Sprintf(i18n.T("Proxy: node %s does not respond: %v"), a.cfg.Proxy.CurrentNode, err),
+			"Switch to the fastest working node: crosh nodes auto")
+		return
+	}
+	d.ok("Proxy: %s", a.manager.GetProxyStatus())
+}
+
+// portFree reports whether a proxy port can be bound on the listen address
+func portFree(listen string, port int) error {
+	if listen == "" {
+		listen = "127.0.0.1"
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(listen, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	return ln.Close()
+}
+
+// joinPorts formats ports as "7676, 7677 and 7678"
+func joinPorts(ports []int) string {
+	s := strconv.Itoa(ports[0])
+	for i, port := range ports[1:] {
+		if i == len(ports)-2 {
+			s += i18n.T(" and ")
+		} else {
+			s += ", "
+		}
+		s += strconv.Itoa(port)
+	}
+	return s
+}
+
+// checkSubscription fetches the subscription, bypassing the cache
+func (d *doctor) checkSubscription(a *app) {
+	if a.cfg.Proxy.SubscriptionURL == "" {
+		return
+	}
+
+	sub, err := proxy.FetchSubscription(a.cfg.Proxy.SubscriptionURL, a.cfg.Proxy.Upstream)
+	if err != nil {
+		fix := i18n.T("Check the subscription URL with your provider, then run: crosh proxy set <subscription-url>")
+		if a.cfg.Proxy.Upstream == "" {
+			fix += i18n.T("\n  → Behind a company proxy, set it with: crosh config set proxy.upstream http://host:port")
+		}
+		d.fail(fmt.Sprintf(i18n.T("Subscription: %v"), err), fix)
+		return
+	}
+	d.ok("Subscription: %d nodes", len(sub.Nodes))
+}
+
+// checkDNS resolves github.com with the system resolver
+func (d *doctor) checkDNS() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, "github.com")
+	if err != nil {
+		d.fail(fmt.Sprintf(i18n.T("DNS: cannot resolve github.com: %v"), err),
+			"Check your network connection and DNS servers (e.g. set 223.5.5.5 as DNS server)")
+		return
+	}
+	d.ok("DNS: github.com resolves to %s", addrs[0])
+}
+
+// checkMirrors reports the mirror each package manager actually uses
+func (d *doctor) checkMirrors(a *app) {
+	if !a.cfg.Mirror.Enabled {
+		d.warn("Mirrors: disabled", "Run: crosh mirrors on")
+		return
+	}
+
+	configured := map[string]bool{
+		"NPM":          a.cfg.Mirror.NPM != "",
+		"Yarn":         a.cfg.Mirror.NPM != "" && mirror.HasYarn(),
+		"pnpm":         a.cfg.Mirror.NPM != "" && mirror.HasPnpm(),
+		"Pip":          a.cfg.Mirror.Pip != "",
+		"pyenv":        a.cfg.Mirror.Pyenv != "" && mirror.HasPyenv(),
+		"Apt":          a.cfg.Mirror.Apt != "",
+		"Apk":          a.cfg.Mirror.Apk != "",
+		"Pacman":       a.cfg.Mirror.Pacman != "",
+		"Cargo":        a.cfg.Mirror.Cargo != "",
+		"rustup":       a.cfg.Mirror.Cargo != "" && a.cfg.Mirror.Rustup != "",
+		"Go":           a.cfg.Mirror.Go != "",
+		"Go sumdb":     a.cfg.Mirror.Go != "" && a.cfg.Mirror.GoSumDB != "",
+		"Maven":        a.cfg.Mirror.Maven != "",
+		"Gradle":       a.cfg.Mirror.Gradle.Central != "",
+		"sbt":          a.cfg.Mirror.Sbt.Maven != "" && mirror.HasSbt(),
+		"SDKMAN":       a.cfg.Mirror.Sdkman != "" && mirror.HasSdkman(),
+		"Composer":     a.cfg.Mirror.Composer != "",
+		"NuGet":        a.cfg.Mirror.NuGet != "",
+		"Conan":        a.cfg.Mirror.Conan != "" && mirror.HasConan(),
+		"Conda":        a.cfg.Mirror.Conda != "",
+		"Hugging Face": a.cfg.Mirror.HuggingFace != "" && mirror.HasHuggingFace(),
+		"CRAN":         a.cfg.Mirror.CRAN != "" && mirror.HasR(),
+		"CPAN":         a.cfg.Mirror.CPAN != "" && mirror.HasCPAN(),
+		"TeX Live":     a.cfg.Mirror.TeXLive != "" && mirror.HasTeXLive(),
+		"Hex":          a.cfg.Mirror.Hex != "" && mirror.HasHex(),
+		"opam":         a.cfg.Mirror.Opam != "" && mirror.HasOpam(),
+		"LuaRocks":     a.cfg.Mirror.LuaRocks != "" && mirror.HasLuaRocks(),
+		"Haskell":      a.cfg.Mirror.Haskell.Hackage != "" && mirror.HasHaskell(),
+		"Nix":          len(a.cfg.Mirror.Nix) > 0 && mirror.HasNix(),
+		"Bazel":        len(a.cfg.Mirror.Bazel) > 0 && mirror.HasBazel(),
+		"Deno":         a.cfg.Mirror.NPM != "" && mirror.HasDeno(),
+		"Bun":          a.cfg.Mirror.NPM != "" && mirror.HasBun(),
+		"Node.js":      a.cfg.Mirror.Node != "" && mirror.HasNode(),
+		"Electron":     a.cfg.Mirror.Electron.Binaries != "" && mirror.HasNPM(),
+		"npm binaries": len(a.cfg.Mirror.Binaries) > 0 && mirror.HasNPM(),
+		"Browsers":     len(a.cfg.Mirror.Browsers) > 0 && mirror.HasNPM(),
+		"CocoaPods":    a.cfg.Mirror.CocoaPods != "" && mirror.HasCocoaPods(),
+		"Helm":         len(a.cfg.Mirror.Helm) > 0 && mirror.HasHelm(),
+		"Kubernetes":   (a.cfg.Mirror.Kubernetes.Registry != "" || a.cfg.Mirror.Kubernetes.Packages != "") && mirror.HasKubernetes(),
+		"minikube":     a.cfg.Mirror.Minikube != "" && mirror.HasMinikube(),
+		"Terraform":    a.cfg.Mirror.Terraform != "" && mirror.HasTerraform(),
+		"winget":       a.cfg.Mirror.Winget != "" && mirror.HasWinget(),
+		"Scoop":        len(a.cfg.Mirror.Scoop) > 0 && mirror.HasScoop(),
+		"MSYS2":        a.cfg.Mirror.MSYS2 != "" && mirror.HasMSYS2(),
+		"Git":          len(a.cfg.Mirror.Git) > 0 && mirror.HasGit(),
+		"Docker":       len(a.cfg.Mirror.Docker) > 0,
+	}
+
+	status := a.manager.GetMirrorStatus()
+	names := make([]string, 0, len(status))
+	for name := range status {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if status[name] == "disabled" && configured[name] {
+			d.warn(fmt.Sprintf(i18n.T("Mirrors: %s is not using a mirror"), name), "Run: crosh mirrors on")
+			continue
+		}
+		if status[name] != "disabled" {
+			d.ok("Mirrors: %s uses %s", name, status[name])
+		}
+	}
+}
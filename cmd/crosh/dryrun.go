@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+	"github.com/boomyao/crosh/internal/mirror"
+)
+
+// dryRunOn prints the files crosh on would change, the variables it would set
+// and the processes it would start, without changing anything. listen is the
+// address chosen with --allow-lan, if given.
+func dryRunOn(a *app, listen string, duration time.Duration, parts components) {
+	cfg := a.cfg
+	if listen != "" && listen != cfg.Proxy.Listen {
+		cfg.Proxy.Listen = listen
+		a.manager = accelerator.NewManager(cfg)
+	}
+
+	var mirrorErr error
+	var output []string
+	changes := logging.DryRun(func() {
+		output = captureOutput(func() {
+			if parts.all() {
+				cfg.ExpiresAt = time.Time{}
+			}
+			if duration > 0 {
+				cfg.ExpiresAt = time.Now().Add(duration).Truncate(time.Second)
+			}
+			if parts.mirrors {
+				cfg.Mirror.Enabled = true
+				_, mirrorErr = a.manager.EnableMirrors(parts.names...)
+			}
+			if parts.proxy && cfg.Proxy.SubscriptionURL != "" {
+				cfg.Proxy.Enabled = true
+			}
+			cfg.Save()
+		})
+	})
+
+	fmt.Println(i18n.T("Dry run, nothing was changed. crosh on would:"))
+	printFileChanges(changes)
+	printCommands(changes)
+	printMirrorProblems(output, mirrorErr)
+
+	core := a.manager.GetProxyCore()
+	processes := []string{}
+	proxyOn := parts.proxy && cfg.Proxy.SubscriptionURL != ""
+	if proxyOn {
+		if core.IsRunning() {
+			fmt.Printf(i18n.T("\nKeep %s running\n"), core.Name())
+		} else {
+			if _, err := os.Stat(core.BinaryPath()); err != nil {
+				processes = append(processes, fmt.Sprintf(i18n.T("download %s to %s"), core.Name(), core.BinaryPath()))
+			}
+			processes = append(processes, fmt.Sprintf(i18n.T("fetch the subscription, pick a node and write %s"), core.ConfigPath()))
+			processes = append(processes, strings.Join(core.RunCommand(), " "))
+		}
+	}
+	if daemon.HasWork(cfg) && !daemon.IsRunning() {
+		if exe, err := os.Executable(); err == nil {
+			processes = append(processes, exe+" daemon")
+		}
+	}
+	if len(processes) > 0 {
+		fmt.Println(i18n.T("\nStart:"))
+		for _, process := range processes {
+			fmt.Printf("  %s\n", process)
+		}
+	}
+
+	if proxyOn {
+		vars := core.GetProxyEnvVars()
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println(i18n.T("\nSet, in shells that run crosh env or crosh init:"))
+		for _, name := range names {
+			fmt.Printf("  %s=%s\n", name, vars[name])
+		}
+	}
+}
+
+// dryRunOff prints the files crosh off would change and the processes it
+// would stop, without changing anything
+func dryRunOff(a *app, parts components) {
+	cfg := a.cfg
+	core := a.manager.GetProxyCore()
+	running := core.IsRunning()
+
+	var mirrorErr error
+	var output []string
+	changes := logging.DryRun(func() {
+		output = captureOutput(func() {
+			if parts.mirrors {
+				mirrorErr = a.manager.DisableMirrors(parts.names...)
+				if parts.names == nil {
+					cfg.Mirror.Enabled = false
+				}
+			}
+			if parts.proxy {
+				cfg.Proxy.Enabled = false
+				cfg.Proxy.CurrentNode = ""
+			}
+			if parts.all() {
+				cfg.ExpiresAt = time.Time{}
+			}
+			cfg.Save()
+		})
+	})
+
+	fmt.Println(i18n.T("Dry run, nothing was changed. crosh off would:"))
+	printFileChanges(changes)
+	printCommands(changes)
+	printMirrorProblems(output, mirrorErr)
+
+	if running && parts.proxy {
+		fmt.Println(i18n.T("\nStop:"))
+		fmt.Printf("  %s\n", strings.Join(core.RunCommand(), " "))
+	}
+}
+
+// printFileChanges prints the files changes would write or remove, with the
+// lines added and removed
+func printFileChanges(changes []logging.Change) {
+	backupDir, _ := mirror.BackupDir()
+	printed := false
+	for _, change := range changes {
+		if change.Command != nil {
+			continue
+		}
+		// The originals crosh saves before changing files are its own bookkeeping
+		if backupDir != "" && (change.Path == backupDir || strings.HasPrefix(change.Path, backupDir+string(filepath.Separator))) {
+			continue
+		}
+
+		old, err := os.ReadFile(change.Path)
+		exists := err == nil
+
+		var header string
+		var diff []string
+		switch {
+		case change.Data == nil:
+			header = fmt.Sprintf(i18n.T("%s (removed)"), change.Path)
+		case !exists:
+			header = fmt.Sprintf(i18n.T("%s (new)"), change.Path)
+			diff = lineDiff(nil, splitLines(string(change.Data)))
+		default:
+			diff = lineDiff(splitLines(string(old)), splitLines(string(change.Data)))
+			if len(diff) == 0 {
+				continue
+			}
+			header = change.Path
+		}
+
+		if !printed {
+			fmt.Println(i18n.T("\nChange files:"))
+			printed = true
+		}
+		fmt.Printf("  %s\n", header)
+		for _, line := range diff {
+			fmt.Printf("    %s\n", strings.TrimSpace(line))
+		}
+	}
+	if !printed {
+		fmt.Println(i18n.T("\nNo files to change"))
+	}
+}
+
+// printCommands prints the commands changes would run, such as tlmgr setting its repository
+func printCommands(changes []logging.Change) {
+	printed := false
+	for _, change := range changes {
+		if change.Command == nil {
+			continue
+		}
+		if !printed {
+			fmt.Println(i18n.T("\nRun:"))
+			printed = true
+		}
+		fmt.Printf("  %s\n", strings.Join(change.Command, " "))
+	}
+}
+
+// printMirrorProblems prints the warnings, skipped mirrors and errors in the output of
+// enabling or disabling the mirrors, leaving out headings such as the
+// Docker restart instructions
+func printMirrorProblems(output []string, err error) {
+	problems := []string{}
+	for _, line := range output {
+		if (strings.HasPrefix(line, "⚠") || strings.HasPrefix(line, "○") || strings.HasPrefix(line, "- ")) && !strings.HasSuffix(line, ":") {
+			problems = append(problems, line)
+		}
+	}
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("⚠ %v", err))
+	}
+
+	if len(problems) > 0 {
+		fmt.Println()
+	}
+	for _, line := range problems {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// lineDiff returns the lines removed from old, prefixed with "- ", and the
+// lines added in new, prefixed with "+ ", in file order
+func lineDiff(old, new []string) []string {
+	// common[i][j] is the length of the longest common subsequence of old[i:] and new[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	diff := []string{}
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i++
+			j++
+		case j == len(new) || i < len(old) && common[i+1][j] >= common[i][j+1]:
+			diff = append(diff, "- "+old[i])
+			i++
+		default:
+			diff = append(diff, "+ "+new[j])
+			j++
+		}
+	}
+	return diff
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+// envShells are the shells crosh env can print commands for
+var envShells = []string{"bash", "zsh", "fish", "powershell", "cmd"}
+
+func runEnv(a *app, args []string) {
+	fs := newFlagSet("env", "")
+	shell := fs.String("shell", "", "shell to print commands for: "+strings.Join(envShells, ", ")+" (default: detected)")
+	unset := fs.Bool("unset", false, "print commands that remove the proxy variables instead")
+	fs.Parse(args)
+
+	if *shell == "" {
+		*shell = detectShell()
+	}
+	if !slices.Contains(envShells, *shell) {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown shell %q, use one of: %s\n"), *shell, strings.Join(envShells, ", "))
+		os.Exit(exitUsage)
+	}
+
+	vars := a.manager.GetProxyCore().GetProxyEnvVars()
+	if !*unset && !a.manager.GetProxyCore().IsRunning() {
+		// Unset stale variables so eval leaves the shell working without the proxy
+		fmt.Fprintln(os.Stderr, i18n.T("⚠ Proxy is not running, removing the proxy variables (start it with: crosh proxy on)"))
+		*unset = true
+	}
+
+	for _, line := range envCommands(*shell, vars, *unset) {
+		fmt.Println(line)
+	}
+}
+
+// detectShell guesses the shell crosh was started from
+func detectShell() string {
+	if shell := filepath.Base(os.Getenv("SHELL")); slices.Contains(envShells, shell) {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	return "bash"
+}
+
+// envCommands returns the commands that set (or unset) vars in shell
+func envCommands(shell string, vars map[string]string, unset bool) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		// Windows variable names are case-insensitive, so the lowercase
+		// copies would only overwrite the uppercase ones
+		if (shell == "powershell" || shell == "cmd") && key != strings.ToUpper(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := vars[key]
+		switch {
+		case shell == "fish" && unset:
+			lines = append(lines, "set -e "+key)
+		case shell == "fish":
+			lines = append(lines, fmt.Sprintf("set -gx %s %s", key, shellQuote(value)))
+		case shell == "powershell" && unset:
+			lines = append(lines, fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", key))
+		case shell == "powershell":
+			lines = append(lines, fmt.Sprintf("$env:%s = '%s'", key, strings.ReplaceAll(value, "'", "''")))
+		case shell == "cmd" && unset:
+			lines = append(lines, fmt.Sprintf("set %s=", key))
+		case shell == "cmd":
+			lines = append(lines, fmt.Sprintf(`set "%s=%s"`, key, value))
+		case unset:
+			lines = append(lines, "unset "+key)
+		default:
+			lines = append(lines, fmt.Sprintf("export %s=%s", key, shellQuote(value)))
+		}
+	}
+	return lines
+}
+
+// shellQuote quotes s for POSIX shells and fish
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// printEnvHint prints how to load the proxy variables into the current shell
+func printEnvHint() {
+	hint := `eval "$(crosh env)"`
+	switch detectShell() {
+	case "fish":
+		hint = "crosh env | source"
+	case "powershell":
+		hint = "crosh env | Invoke-Expression"
+	}
+	fmt.Printf(i18n.T("\nTo use the proxy in this shell, run: %s\n"), hint)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+// Exit codes, so scripts can tell failures apart without parsing messages
+const (
+	exitError       = 1 // any other failure
+	exitUsage       = 2 // bad flags or arguments, as the flag package exits with
+	exitConfig      = 3 // unreadable config file, invalid setting or proxy not configured
+	exitNetwork     = 4 // subscription or download server unreachable
+	exitCoreMissing = 5 // proxy core not installed
+	exitNoNodes     = 6 // no node of the subscription is reachable
+	exitPartial     = 7 // some steps failed, the others took effect
+)
+
+// exitCode returns the exit code for err
+func exitCode(err error) int {
+	var configErr *config.Error
+	var coreErr *proxy.CoreMissingError
+	var networkErr *proxy.NetworkError
+	var partialErr *accelerator.PartialError
+	switch {
+	case errors.As(err, &configErr) || errors.Is(err, config.ErrNoSubscription):
+		return exitConfig
+	case errors.As(err, &coreErr):
+		return exitCoreMissing
+	case errors.Is(err, proxy.ErrNoNodes) || errors.Is(err, proxy.ErrNoReachableNodes):
+		return exitNoNodes
+	case errors.As(err, &networkErr):
+		return exitNetwork
+	case errors.As(err, &partialErr):
+		return exitPartial
+	}
+	return exitError
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+func runGeoDataUpdate(a *app, args []string) {
+	fs := newFlagSet("geodata update", "")
+	variant := fs.String("variant", "", fmt.Sprintf(i18n.T("switch to another rule set: %s (saved)"), strings.Join(proxy.GeoDataVariants(), ", ")))
+	fs.Parse(args)
+
+	if *variant != "" {
+		if !slices.Contains(proxy.GeoDataVariants(), *variant) {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown geo data variant %q, use one of: %s\n"), *variant, strings.Join(proxy.GeoDataVariants(), ", "))
+			os.Exit(exitUsage)
+		}
+		rules := make([]proxy.Rule, len(a.cfg.Proxy.Rules))
+		for i, rule := range a.cfg.Proxy.Rules {
+			rules[i] = proxy.Rule(rule)
+		}
+		if missing := proxy.GeoIPMissing(*variant, rules); len(missing) > 0 && len(a.cfg.Proxy.GeoData.GeoIPURLs) == 0 {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ The %s geo data has no %s, which your routing rules use (see: crosh rules list)\n"), *variant, strings.Join(missing, ", "))
+			os.Exit(exitUsage)
+		}
+		a.cfg.Proxy.GeoData.Variant = *variant
+		saveProxySettings(a)
+	}
+
+	updated, err := a.manager.UpdateGeoData()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if len(updated) == 0 {
+		fmt.Println(i18n.T("✓ Geo data is up to date"))
+		return
+	}
+	fmt.Printf(i18n.T("✓ Updated %s\n"), strings.Join(updated, ", "))
+}
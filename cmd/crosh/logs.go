@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+func runLogs(a *app, args []string) {
+	fs := newFlagSet("logs", "")
+	follow := fs.Bool("follow", false, "keep printing new lines as the proxy writes them")
+	fs.BoolVar(follow, "f", false, "shorthand for --follow")
+	tail := fs.Int("tail", 50, "number of lines to show first (0 shows the whole log)")
+	level := fs.String("level", "", fmt.Sprintf(i18n.T("only show lines at this level or above: %s"), strings.Join(proxy.LogLevels, ", ")))
+	fs.Parse(args)
+
+	filter := newLogFilter(*level)
+	path := a.manager.GetProxyCore().LogPath()
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && !*follow {
+		fmt.Printf(i18n.T("No %s log yet, it is written once the proxy starts: %s\n"), a.manager.GetProxyCore().Name(), path)
+		return
+	}
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to open log: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	var offset int64
+	if file != nil {
+		offset = printLogTail(file, *tail, filter)
+		file.Close()
+	}
+
+	if *follow {
+		followLog(path, offset, filter)
+	}
+}
+
+// logFilter drops lines below a minimum level; lines without a level, such
+// as stack traces, share the fate of the line before them
+type logFilter struct {
+	min  int
+	keep bool
+}
+
+func newLogFilter(level string) *logFilter {
+	if level == "" {
+		return &logFilter{min: -1, keep: true}
+	}
+
+	min := proxy.LogLevelRank(strings.ToLower(level))
+	if min < 0 {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown log level %q, use one of: %s\n"), level, strings.Join(proxy.LogLevels, ", "))
+		os.Exit(exitUsage)
+	}
+	return &logFilter{min: min}
+}
+
+// Keep reports whether line should be printed
+func (f *logFilter) Keep(line string) bool {
+	if f.min < 0 {
+		return true
+	}
+	if level := proxy.LogLevel(line); level != "" {
+		f.keep = proxy.LogLevelRank(level) >= f.min
+	}
+	return f.keep
+}
+
+// printLogTail prints the last n matching lines of r (all if n <= 0) and
+// returns the number of bytes read
+func printLogTail(r io.Reader, n int, filter *logFilter) int64 {
+	var lines []string
+	var offset int64
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		// Leave a partly written last line for followLog to pick up whole
+		if err != nil {
+			break
+		}
+		offset += int64(len(line))
+
+		if !filter.Keep(line) {
+			continue
+		}
+		lines = append(lines, line)
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Print(line)
+	}
+	return offset
+}
+
+// followLog prints lines appended to the log after offset until interrupted,
+// starting over when the log is truncated or replaced
+func followLog(path string, offset int64, filter *logFilter) {
+	var pending string
+	for {
+		time.Sleep(500 * time.Millisecond)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			offset, pending = 0, ""
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		file.Seek(offset, io.SeekStart)
+		reader := bufio.NewReader(file)
+		for {
+			chunk, err := reader.ReadString('\n')
+			offset += int64(len(chunk))
+			pending += chunk
+			if err != nil {
+				break
+			}
+			if filter.Keep(pending) {
+				fmt.Print(pending)
+			}
+			pending = ""
+		}
+		file.Close()
+	}
+}
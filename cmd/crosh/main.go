@@ -7,59 +7,219 @@ import (
 
 	"github.com/boomyao/crosh/internal/accelerator"
 	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
 )
 
 // version will be set by ldflags during build
 var version = "dev"
 
+// rootCommand is the crosh command tree
+var rootCommand = &command{
+	name:    "crosh",
+	summary: "Network acceleration for Chinese developers",
+	commands: []*command{
+		{name: "on", args: "[component...]", summary: "Enable acceleration", run: runOn},
+		{name: "off", args: "[component...]", summary: "Disable acceleration", run: runOff},
+		{name: "status", summary: "Show current status", run: runStatus},
+		{name: "dashboard", summary: "Show live proxy, traffic, mirror and log status", run: runDashboard},
+		{name: "env", summary: "Print shell commands that set the proxy variables, for eval", run: runEnv},
+		{name: "init", args: "<bash|zsh|fish>", summary: "Print a shell hook that keeps the proxy variables in sync", run: runInit},
+		{name: "run", args: "-- <command> [args...]", summary: "Run a command through the proxy, starting it if needed", run: runRun},
+		{name: "doctor", summary: "Diagnose common problems and suggest fixes", run: runDoctor},
+		{name: "test", summary: "Compare download times direct, from mirrors and through the proxy", run: runTest},
+		{name: "logs", summary: "Show the proxy core log (--follow to stream it)", run: runLogs},
+		{
+			name:    "proxy",
+			summary: "Manage the proxy",
+			commands: []*command{
+				{name: "set", args: "<subscription-url>", summary: "Configure proxy subscription and auto-start", run: runProxySet},
+				{name: "load", args: "<config.yaml>", summary: "Use local YAML file (one-time configuration)", run: runProxyLoad},
+				{name: "on", summary: "Start the proxy", run: runProxyOn},
+				{name: "off", summary: "Stop the proxy", run: runProxyOff},
+				{name: "status", summary: "Show proxy status", run: runProxyStatus},
+			},
+		},
+		{
+			name:    "sub",
+			summary: "Manage the proxy subscription",
+			commands: []*command{
+				{name: "update", summary: "Re-fetch the subscription and refresh the local cache", run: runSubUpdate},
+			},
+		},
+		{
+			name:    "nodes",
+			summary: "Inspect and select subscription nodes",
+			commands: []*command{
+				{name: "list", summary: "List nodes in the subscription", run: runNodesList},
+				{name: "filter", summary: "Show or set which nodes automatic selection may use", run: runNodesFilter},
+				{name: "pick", summary: "Pick a node from a live, filterable list and pin it", run: runNodesPick},
+				{name: "use", args: "<name|index>", summary: "Pin a node and restart the proxy on it", run: runNodesUse},
+				{name: "auto", summary: "Unpin the node and select the fastest one again", run: runNodesAuto},
+				{name: "speedtest", args: "[name|index...]", summary: "Measure download speed through each node", run: runNodesSpeedtest},
+			},
+		},
+		{
+			name:    "rules",
+			summary: "Manage routing rules",
+			commands: []*command{
+				{name: "list", summary: "List routing rules", run: runRulesList},
+				{name: "add", summary: "Send domains or IPs direct, through the proxy or nowhere", run: runRulesAdd},
+				{name: "remove", args: "<index|domain|ip>...", summary: "Remove rules, or domains and IPs from them", run: runRulesRemove},
+			},
+		},
+		{
+			name:    "xray",
+			summary: "Manage the Xray-core binary",
+			commands: []*command{
+				{name: "version", summary: "Show the installed and latest Xray-core versions", run: runXrayVersion},
+				{name: "upgrade", args: "[version]", summary: "Install the latest (or given) Xray-core and restart the proxy", run: runXrayUpgrade},
+			},
+		},
+		{
+			name:    "geodata",
+			summary: "Manage the geoip and geosite data used by routing rules",
+			commands: []*command{
+				{name: "update", summary: "Download the latest geo data and restart the proxy if it changed", run: runGeoDataUpdate},
+			},
+		},
+		{
+			name:    "mirrors",
+			summary: "Manage package manager mirrors",
+			commands: []*command{
+				{name: "on", summary: "Enable mirrors", run: runMirrorsOn},
+				{name: "off", summary: "Disable mirrors", run: runMirrorsOff},
+				{name: "status", summary: "Show mirror status", run: runMirrorsStatus},
+				{name: "bench", args: "[tool...]", summary: "Measure the candidate mirrors of each tool from this network", run: runMirrorsBench},
+				{name: "gradle-wrapper", args: "[dir]", summary: "Download Gradle from the mirror in the project in dir (default: current directory)", run: runMirrorsGradleWrapper},
+				{name: "preset", args: "[name] [tool...]", summary: "Switch mirrors to a provider such as tsinghua, or list the providers", run: runMirrorsPreset},
+			},
+		},
+		{
+			name:    "config",
+			summary: "View and edit crosh configuration",
+			commands: []*command{
+				{name: "show", summary: "Print the configuration", run: runConfigShow},
+				{name: "path", summary: "Print the configuration file path", run: runConfigPath},
+				{name: "get", args: "<key>", summary: "Print a value (e.g. proxy.local_port)", run: runConfigGet},
+				{name: "set", args: "<key> <value>", summary: "Set a value (e.g. mirror.npm https://...)", run: runConfigSet},
+			},
+		},
+		{
+			name:    "remote",
+			summary: "Configure remote machines over SSH",
+			commands: []*command{
+				{name: "apply", args: "<user@host>", summary: "Apply mirrors (and optionally proxy) to a remote Linux machine", run: runRemoteApply},
+			},
+		},
+		{
+			name:    "export",
+			summary: "Export settings",
+			commands: []*command{
+				{name: "bundle", summary: "Export team settings to a shareable bundle file", run: runExportBundle},
+			},
+		},
+		{
+			name:    "import",
+			summary: "Import settings",
+			commands: []*command{
+				{name: "bundle", args: "<file>", summary: "Import a team bundle and enable acceleration", run: runImportBundle},
+			},
+		},
+		{
+			name:    "service",
+			summary: "Start crosh with your session, so acceleration survives reboots",
+			commands: []*command{
+				{name: "install", summary: "Register the daemon with the system service manager and start it", run: runServiceInstall},
+				{name: "uninstall", summary: "Stop the daemon from starting with your session", run: runServiceUninstall},
+				{name: "status", summary: "Show whether the service is installed and the daemon running", run: runServiceStatus},
+			},
+		},
+		{name: "restore", summary: "Put back the tool config files crosh changed as they were before", run: runRestore},
+		{name: "uninstall", summary: "Turn crosh off, undo its changes and delete its files", run: runUninstall},
+		{name: "serve", summary: "Start the local HTTP API server (for GUI frontends)", run: runServe},
+		{name: "daemon", summary: "Run the background scheduler (started automatically)", run: runDaemon},
+		{name: "version", summary: "Show version", run: runVersion},
+		{name: "help", args: "[command]", summary: "Show help"},
+	},
+}
+
+func init() {
+	// Set here rather than in the literal: runHelp walks rootCommand itself
+	rootCommand.find("help").run = runHelp
+}
+
 func main() {
+	// Follow the locale until the config is loaded, so its errors are translated too
+	i18n.SetLanguage("")
+
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
+		// doctor diagnoses a broken config file itself
+		if len(os.Args) < 2 || os.Args[1] != "doctor" {
+			fmt.Fprintf(os.Stderr, i18n.T("Error loading config: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+		cfg = config.DefaultConfig()
 	}
+	i18n.SetLanguage(cfg.Language)
 
 	// Create manager
 	manager := accelerator.NewManager(cfg)
+	a := &app{cfg: cfg, manager: manager}
 
-	// No arguments: default to "on"
-	if len(os.Args) < 2 {
-		handleOn(manager, cfg)
-		return
+	args := extractGlobalFlags(a, os.Args[1:])
+	if logging.GetLevel() == logging.Quiet {
+		silenceStdout()
 	}
 
-	arg := os.Args[1]
+	// Enforce an expired "on --for" even if the daemon wasn't running. Its
+	// messages go to stderr, as stdout may be eval'd (crosh env) or parsed (--json).
+	toStderr(func() {
+		if expired, err := manager.ExpireIfDue(); expired {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, i18n.T("Warning: %v\n"), err)
+			}
+			fmt.Println(i18n.T("⏱ Temporary acceleration expired, acceleration disabled"))
+			fmt.Println()
+		}
+	})
 
-	// Check if argument is a URL (proxy subscription)
-	if isHTTPURL(arg) {
-		handleConfigureProxy(manager, cfg, arg)
+	// No arguments: default to "on"
+	if len(args) == 0 {
+		if !handleOn(manager, cfg, allComponents) {
+			os.Exit(exitPartial)
+		}
 		return
 	}
 
-	// Check if argument is a local YAML file
-	if isYAMLFile(arg) {
-		handleLocalYAMLFile(manager, cfg, arg)
+	// Shorthands kept for compatibility: "crosh <url>" and "crosh <file.yaml>"
+	if isHTTPURL(args[0]) {
+		handleConfigureProxy(manager, cfg, args[0])
+		return
+	}
+	if isYAMLFile(args[0]) {
+		handleLocalYAMLFile(manager, cfg, args[0])
 		return
 	}
 
-	// Handle simple commands
-	switch arg {
-	case "on":
-		handleOn(manager, cfg)
-	case "off":
-		handleOff(manager, cfg)
-	case "status":
-		handleStatus(manager, cfg)
-	case "version", "-v", "--version":
-		fmt.Printf("crosh version %s\n", strings.TrimSpace(version))
-	case "help", "-h", "--help":
+	switch args[0] {
+	case "-v", "--version":
+		runVersion(a, nil)
+		return
+	case "-h", "--help":
 		printUsage()
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", arg)
+		return
+	}
+
+	if rootCommand.find(args[0]) == nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Unknown command: %s\n\n"), args[0])
 		printUsage()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
+
+	rootCommand.execute(a, []string{"crosh"}, args)
 }
 
 // isHTTPURL checks if a string is an HTTP/HTTPS URL
@@ -79,268 +239,106 @@ func isYAMLFile(s string) bool {
 	return false
 }
 
-func printUsage() {
-	fmt.Println(`crosh - Network acceleration for Chinese developers
-
-USAGE:
-    crosh [command]
-
-COMMANDS:
-    (no args)           Enable acceleration (default)
-    on                  Enable acceleration
-    off                 Disable acceleration
-    status              Show current status
-    <subscription-url>  Configure proxy subscription and auto-start
-    <config.yaml>       Use local YAML file (one-time configuration)
-    version             Show version
-    help                Show this help
-
-EXAMPLES:
-    # Enable acceleration
-    crosh
-    crosh on
-
-    # Disable acceleration
-    crosh off
-
-    # Configure proxy subscription (auto-starts proxy and mirrors)
-    crosh https://your-subscription-url
-
-    # Use local YAML file (one-time use, not saved)
-    crosh config.yaml
-    crosh /path/to/proxies.yml
-
-    # Check status
-    crosh status
-
-For more information, visit: https://github.com/boomyao/crosh`)
+func runVersion(a *app, args []string) {
+	fmt.Printf(i18n.T("crosh version %s\n"), strings.TrimSpace(version))
 }
 
-func handleOn(manager *accelerator.Manager, cfg *config.Config) {
-	fmt.Println("Enabling acceleration...")
-	fmt.Println()
-
-	// Always enable mirrors (safe and beneficial)
-	cfg.Mirror.Enabled = true
-	if err := manager.EnableMirrors(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to enable mirrors: %v\n", err)
-	} else {
-		fmt.Println("✓ Mirrors enabled (npm, pip, apt, cargo, go)")
+func runHelp(a *app, args []string) {
+	if len(args) == 0 {
+		printUsage()
+		return
 	}
 
-	// Enable proxy if subscription is configured
-	if cfg.Proxy.SubscriptionURL != "" {
-		cfg.Proxy.Enabled = true
-		if err := manager.EnableProxy(); err != nil {
-			// If proxy fails, might be missing xray-core
-			fmt.Fprintf(os.Stderr, "✗ Proxy failed: %v\n", err)
-			fmt.Println("\nTrying to download Xray-core...")
-
-			xray := manager.GetXrayManager()
-			if downloadErr := xray.Download(); downloadErr != nil {
-				fmt.Fprintf(os.Stderr, "✗ Failed to download Xray-core: %v\n", downloadErr)
-				fmt.Println("\nProxy acceleration is unavailable.")
-				fmt.Println("Mirrors are still enabled and working.")
-			} else {
-				// Retry enabling proxy after download
-				if retryErr := manager.EnableProxy(); retryErr != nil {
-					fmt.Fprintf(os.Stderr, "✗ Proxy still failed: %v\n", retryErr)
-				} else {
-					fmt.Println("✓ Proxy enabled")
-				}
-			}
-		} else {
-			fmt.Println("✓ Proxy enabled")
+	// Help for a command group, e.g. "crosh help proxy"
+	cmd := rootCommand
+	path := []string{"crosh"}
+	for _, name := range args {
+		sub := cmd.find(name)
+		if sub == nil {
+			fmt.Fprintf(os.Stderr, i18n.T("Unknown command: %s\n"), strings.Join(append(path, name), " "))
+			os.Exit(exitUsage)
 		}
+		cmd = sub
+		path = append(path, name)
 	}
 
-	cfg.Save()
-	fmt.Println("\n✓ Acceleration enabled")
-}
-
-func handleOff(manager *accelerator.Manager, cfg *config.Config) {
-	fmt.Println("Disabling acceleration...")
-	fmt.Println()
-
-	// Disable mirrors
-	if err := manager.DisableMirrors(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to disable mirrors: %v\n", err)
-	} else {
-		fmt.Println("✓ Mirrors disabled")
-	}
-
-	// Disable proxy
-	if err := manager.DisableProxy(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to disable proxy: %v\n", err)
-	} else {
-		if cfg.Proxy.Enabled {
-			fmt.Println("✓ Proxy disabled")
-		}
+	if len(cmd.commands) > 0 {
+		cmd.printHelp(path)
+		return
 	}
-
-	cfg.Mirror.Enabled = false
-	cfg.Proxy.Enabled = false
-	cfg.Save()
-
-	fmt.Println("\n✓ Acceleration disabled")
+	fmt.Printf(i18n.T("%s - %s\n\nRun '%s -h' for flags.\n"), strings.Join(path, " "), i18n.T(cmd.summary), strings.Join(path, " "))
 }
 
-func handleStatus(manager *accelerator.Manager, cfg *config.Config) {
-	fmt.Println("Current Status")
-	fmt.Println("==============")
-	fmt.Println()
-
-	// Mirror status
-	if cfg.Mirror.Enabled {
-		fmt.Println("✓ Mirrors: enabled")
-		mirrorStatus := manager.GetMirrorStatus()
-		for name, status := range mirrorStatus {
-			if status != "disabled" {
-				fmt.Printf("  • %s: %s\n", name, status)
-			}
-		}
-	} else {
-		fmt.Println("✗ Mirrors: disabled")
-	}
-
-	fmt.Println()
-
-	// Proxy status
-	if cfg.Proxy.SubscriptionURL != "" {
-		if cfg.Proxy.Enabled {
-			fmt.Printf("✓ Proxy: enabled (%s)\n", manager.GetProxyStatus())
-		} else {
-			fmt.Println("✗ Proxy: disabled")
-		}
-		fmt.Printf("  Subscription: %s\n", cfg.Proxy.SubscriptionURL)
-	} else {
-		fmt.Println("○ Proxy: not configured")
-		fmt.Println("\n  To configure proxy, run:")
-		fmt.Println("    crosh https://your-subscription-url")
-	}
+// usageRow is a line of the two-column lists in printUsage
+type usageRow struct {
+	synopsis, summary string
 }
 
-func handleConfigureProxy(manager *accelerator.Manager, cfg *config.Config, url string) {
-	fmt.Printf("Configuring proxy subscription...\n\n")
-
-	// Save subscription URL
-	cfg.Proxy.SubscriptionURL = url
-	if err := cfg.Save(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving config: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("✓ Subscription URL saved: %s\n", url)
-
-	// Check if xray-core is installed
-	if _, err := os.Stat(cfg.Proxy.XrayPath); os.IsNotExist(err) {
-		fmt.Println("\nXray-core not found. Downloading...")
-		xray := manager.GetXrayManager()
-		if err := xray.Download(); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ Failed to download Xray-core: %v\n", err)
-			fmt.Println("\nYou can try again later with: crosh on")
-			return
-		}
-		fmt.Println("✓ Xray-core downloaded successfully")
-	}
-
-	fmt.Println("\n✓ Proxy configured successfully")
-
-	// Automatically enable mirrors
-	fmt.Println("\nEnabling mirrors...")
-	cfg.Mirror.Enabled = true
-	if err := manager.EnableMirrors(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to enable mirrors: %v\n", err)
-	}
-
-	// Automatically enable proxy
-	fmt.Println("\nStarting proxy...")
-	cfg.Proxy.Enabled = true
-	if err := manager.EnableProxy(); err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Failed to start proxy: %v\n", err)
-		fmt.Println("\nYou can try again with: crosh on")
-		return
-	}
-
-	cfg.Save()
-
-	fmt.Println("\n✓ Acceleration enabled")
-	fmt.Println("\nProxy is running in background.")
+// usageExample is a commented example in printUsage
+type usageExample struct {
+	comment  string
+	commands []string
 }
 
-func handleLocalYAMLFile(manager *accelerator.Manager, cfg *config.Config, filePath string) {
-	fmt.Printf("Loading proxy configuration from local YAML file...\n\n")
-
-	// Clear subscription URL (one-time use, don't save file path)
-	cfg.Proxy.SubscriptionURL = ""
-
-	// Check if xray-core is installed
-	if _, err := os.Stat(cfg.Proxy.XrayPath); os.IsNotExist(err) {
-		fmt.Println("Xray-core not found. Downloading...")
-		xray := manager.GetXrayManager()
-		if err := xray.Download(); err != nil {
-			fmt.Fprintf(os.Stderr, "✗ Failed to download Xray-core: %v\n", err)
-			fmt.Println("\nPlease try again later.")
-			return
-		}
-		fmt.Println("✓ Xray-core downloaded successfully")
-	}
-
-	// Load nodes from local YAML file
-	fmt.Println("\nParsing YAML file...")
-	sub, err := manager.LoadProxyFromFile(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Failed to load YAML file: %v\n", err)
-		fmt.Println("\nPlease check your YAML file format and try again.")
-		return
+var (
+	usageShorthands = []usageRow{
+		{"<subscription-url>", "Same as: crosh proxy set <subscription-url>"},
+		{"<config.yaml>", "Same as: crosh proxy load <config.yaml>"},
 	}
-
-	fmt.Printf("✓ Found %d nodes in YAML file\n", len(sub.Nodes))
-
-	// Select fastest node
-	fmt.Println("\nTesting node latency...")
-	node, err := sub.SelectFastestNode()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Failed to select node: %v\n", err)
-		return
+	usageGlobalFlags = []usageRow{
+		{"--json", "Print JSON from status, proxy status, nodes list and mirrors status"},
+		{"-q, --quiet", "Print errors only"},
+		{"--verbose", "Also print HTTP requests, files written and commands run"},
 	}
-
-	fmt.Printf("✓ Selected node: %s (latency: %dms)\n", node.Name, node.Latency)
-
-	// Generate Xray config
-	xray := manager.GetXrayManager()
-	if err := xray.GenerateConfig(node); err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Failed to generate Xray config: %v\n", err)
-		return
+	usageExamples = []usageExample{
+		{"Enable acceleration", []string{"crosh", "crosh on"}},
+		{"Enable acceleration for the next two hours only", []string{"crosh on --for 2h"}},
+		{"Disable acceleration", []string{"crosh off"}},
+		{"Configure proxy subscription (auto-starts proxy and mirrors)", []string{"crosh proxy set https://your-subscription-url"}},
+		{"Use local YAML file (one-time use, not saved)", []string{"crosh proxy load config.yaml"}},
+		{"Check status", []string{"crosh status"}},
+		{"Change the local proxy port", []string{"crosh config set proxy.local_port 7890"}},
+		{"Share team settings (subscription encrypted with a passphrase)", []string{"crosh export bundle -o team.yaml --encrypt", "crosh import bundle team.yaml"}},
+		{"Accelerate a cloud dev server, using this machine's proxy over a reverse tunnel", []string{"crosh remote apply user@devbox --proxy tunnel"}},
+		{"Serve the local API (token is stored in ~/.crosh/api.token)", []string{"crosh serve --listen 127.0.0.1:7680"}},
 	}
+)
 
-	fmt.Println("\n✓ Proxy configured successfully (one-time use)")
+func printUsage() {
+	fmt.Printf("crosh - %s\n\n", i18n.T(rootCommand.summary))
+	fmt.Println(i18n.T("USAGE:"))
+	fmt.Println("    crosh [command]")
+	fmt.Println()
+	fmt.Println(i18n.T("COMMANDS:"))
+	fmt.Printf("    %-22s %s\n", "(no args)", i18n.T("Enable acceleration (default)"))
+	rootCommand.printCommands()
 
-	// Automatically enable mirrors
-	fmt.Println("\nEnabling mirrors...")
-	cfg.Mirror.Enabled = true
-	if err := manager.EnableMirrors(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to enable mirrors: %v\n", err)
+	fmt.Println()
+	fmt.Println(i18n.T("SHORTHANDS:"))
+	for _, row := range usageShorthands {
+		fmt.Printf("    %-22s %s\n", row.synopsis, i18n.T(row.summary))
 	}
 
-	// Start Xray
-	fmt.Println("\nStarting proxy...")
-	if err := xray.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Failed to start proxy: %v\n", err)
-		return
+	fmt.Println()
+	fmt.Println(i18n.T("GLOBAL FLAGS:"))
+	for _, row := range usageGlobalFlags {
+		fmt.Printf("    %-22s %s\n", row.synopsis, i18n.T(row.summary))
 	}
 
-	cfg.Proxy.Enabled = true
-	cfg.Proxy.CurrentNode = node.Name
-	cfg.Save()
-
-	// Print proxy environment variables
-	fmt.Println("\n✓ Acceleration enabled")
-	fmt.Println("\nProxy is running in background.")
-	fmt.Println("\nTo use the proxy, set these environment variables:")
-	envVars := xray.GetProxyEnvVars()
-	for key, value := range envVars {
-		fmt.Printf("  export %s=%s\n", key, value)
+	fmt.Println()
+	fmt.Println(i18n.T("EXAMPLES:"))
+	for i, example := range usageExamples {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("    # %s\n", i18n.T(example.comment))
+		for _, command := range example.commands {
+			fmt.Printf("    %s\n", command)
+		}
 	}
 
-	fmt.Println("\nNote: This is a one-time configuration. To use this YAML file again, run: crosh " + filePath)
+	fmt.Println()
+	fmt.Println(i18n.T("Run 'crosh help <command>' for more about a command group."))
+	fmt.Println()
+	fmt.Println(i18n.T("For more information, visit:"), "https://github.com/boomyao/crosh")
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+// benchTools are the tools crosh mirrors bench measures mirrors for
+var benchTools = []string{"npm", "pip", "go", "cargo", "maven", "composer", "nuget", "conda", "docker"}
+
+// mirrorFetch is a measured fetch from a mirror
+type mirrorFetch struct {
+	mirror  string
+	latency time.Duration // until the response headers arrived
+	total   time.Duration
+	bytes   int64
+	err     error
+}
+
+func runMirrorsBench(a *app, args []string) {
+	fs := newFlagSet("mirrors bench", "[tool...]")
+	timeout := fs.Duration("timeout", 10*time.Second, "give up on a mirror after this long")
+	save := fs.Bool("save", false, "use the fastest mirror of each tool")
+	tools := parseInterspersed(fs, args)
+
+	for _, tool := range tools {
+		if !slices.Contains(benchTools, tool) {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown tool: %s (use %s)\n"), tool, strings.Join(benchTools, ", "))
+			os.Exit(exitUsage)
+		}
+	}
+	if len(tools) == 0 {
+		tools = benchTools
+	}
+
+	client := proxy.NewHTTPClient(a.cfg.Proxy.Upstream, *timeout)
+	fmt.Println(i18n.T("Measuring mirrors from this network..."))
+
+	winners := map[string][]mirrorFetch{}
+	for _, tool := range tools {
+		current := currentMirror(a.cfg, tool)
+		fmt.Printf("\n%s:\n", tool)
+		fmt.Printf("  %-8s %-12s %s\n", i18n.T("LATENCY"), i18n.T("SPEED"), i18n.T("MIRROR"))
+
+		fetches := []mirrorFetch{}
+		for _, mirror := range benchCandidates(a.cfg, tool) {
+			fetch := measureMirror(client, tool, mirror)
+			note := ""
+			if mirror == current {
+				note = i18n.T(" (current)")
+			}
+			if fetch.err != nil {
+				fmt.Printf("  %-8s %-12s %s%s (%v)\n", i18n.T("failed"), "-", mirror, note, fetch.err)
+				continue
+			}
+			fmt.Printf("  %-8s %-12s %s%s\n", fmt.Sprintf("%dms", fetch.latency.Milliseconds()),
+				formatBytes(int64(float64(fetch.bytes)/fetch.total.Seconds()))+"/s", mirror, note)
+			fetches = append(fetches, fetch)
+		}
+
+		if len(fetches) == 0 {
+			fmt.Println(i18n.T("  ✗ No mirror answered"))
+			continue
+		}
+		sort.SliceStable(fetches, func(i, j int) bool { return fetches[i].total < fetches[j].total })
+		winners[tool] = fetches
+		fmt.Printf(i18n.T("  → Fastest: %s\n"), fetches[0].mirror)
+	}
+
+	changed := []string{}
+	for _, tool := range tools {
+		fetches, ok := winners[tool]
+		if !ok || fetches[0].mirror == currentMirror(a.cfg, tool) {
+			continue
+		}
+		changed = append(changed, tool)
+		if !*save {
+			continue
+		}
+
+		if tool == "docker" {
+			// Docker tries registry mirrors in order, so keep the answering ones, fastest first
+			a.cfg.Mirror.Docker = nil
+			for _, fetch := range fetches {
+				a.cfg.Mirror.Docker = append(a.cfg.Mirror.Docker, fetch.mirror)
+			}
+		} else {
+			a.cfg.Mirror.SetURL(tool, fetches[0].mirror)
+		}
+	}
+
+	if len(winners) == 0 {
+		os.Exit(exitNetwork)
+	}
+	if len(changed) == 0 {
+		fmt.Println(i18n.T("\n✓ The configured mirrors are the fastest"))
+		return
+	}
+	if !*save {
+		fmt.Printf(i18n.T("\nFaster mirrors found for %s; run 'crosh mirrors bench --save' to use them\n"), strings.Join(changed, ", "))
+		return
+	}
+
+	fmt.Println()
+	for _, tool := range changed {
+		fmt.Printf("✓ %s: %s\n", tool, currentMirror(a.cfg, tool))
+	}
+	saveMirrors(a, changed)
+}
+
+// currentMirror returns the configured mirror of tool, the first one for Docker
+func currentMirror(cfg *config.Config, tool string) string {
+	if tool == "docker" {
+		if len(cfg.Mirror.Docker) == 0 {
+			return ""
+		}
+		return cfg.Mirror.Docker[0]
+	}
+	return cfg.Mirror.URL(tool)
+}
+
+// benchCandidates returns the mirrors of tool to measure: the configured ones,
+// crosh's defaults and those of the mirror presets
+func benchCandidates(cfg *config.Config, tool string) []string {
+	defaults := config.DefaultConfig().Mirror
+	var candidates []string
+	if tool == "docker" {
+		candidates = append(slices.Clone(cfg.Mirror.Docker), defaults.Docker...)
+	} else {
+		candidates = []string{cfg.Mirror.URL(tool), defaults.URL(tool)}
+		for _, preset := range config.MirrorPresets {
+			candidates = append(candidates, preset.URL(tool))
+		}
+	}
+
+	unique := []string{}
+	for _, candidate := range candidates {
+		if candidate != "" && !slices.Contains(unique, candidate) {
+			unique = append(unique, candidate)
+		}
+	}
+	return unique
+}
+
+// benchURL returns the file fetched to measure a mirror of tool: the same
+// small, stable files crosh test uses, or what the tool itself asks for first
+func benchURL(tool, mirror string) string {
+	switch tool {
+	case "npm":
+		return mirrorURL(mirror, "left-pad")
+	case "pip":
+		return mirrorURL(mirror, "six/")
+	case "go":
+		return mirrorURL(goProxyMirror(mirror), "github.com/google/uuid/@v/v1.6.0.zip")
+	case "cargo":
+		if sparse, ok := strings.CutPrefix(mirror, "sparse+"); ok {
+			return mirrorURL(sparse, "se/rd/serde")
+		}
+		return mirrorURL(mirror, "info/refs?service=git-upload-pack")
+	case "maven":
+		return mirrorURL(mirror, "junit/junit/maven-metadata.xml")
+	case "composer":
+		return mirrorURL(mirror, "packages.json")
+	case "nuget":
+		return mirror // The service index
+	case "conda":
+		return mirrorURL(mirror, "pkgs/main/noarch/repodata.json")
+	case "docker":
+		if !strings.Contains(mirror, "://") {
+			mirror = "https://" + mirror
+		}
+		return mirrorURL(mirror, "v2/")
+	}
+	return ""
+}
+
+// measureMirror fetches the benchmark file of tool from mirror, timing the
+// response headers and the whole download
+func measureMirror(client *http.Client, tool, mirror string) mirrorFetch {
+	fetch := mirrorFetch{mirror: mirror}
+	start := time.Now()
+	resp, err := client.Get(benchURL(tool, mirror))
+	if err != nil {
+		// The URL is the mirror's, which the row already shows
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		fetch.err = err
+		return fetch
+	}
+	defer resp.Body.Close()
+	fetch.latency = time.Since(start)
+
+	// Registries answer anonymous /v2/ requests with 401 and a login challenge
+	ok := resp.StatusCode == http.StatusOK || tool == "docker" && resp.StatusCode == http.StatusUnauthorized
+	if !ok {
+		fetch.err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		return fetch
+	}
+	fetch.bytes, fetch.err = io.Copy(io.Discard, resp.Body)
+	fetch.total = time.Since(start)
+	return fetch
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/api"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/mirror"
+)
+
+func runMirrorsOn(a *app, args []string) {
+	fs := newFlagSet("mirrors on", "")
+	fs.Parse(args)
+
+	a.cfg.Mirror.Enabled = true
+	_, mirrorErr := a.manager.EnableMirrors()
+	if mirrorErr != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to enable mirrors: %v\n"), mirrorErr)
+	}
+
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	var partialErr *accelerator.PartialError
+	if mirrorErr == nil || errors.As(mirrorErr, &partialErr) {
+		fmt.Println(i18n.T("\n✓ Mirrors enabled"))
+	}
+	if mirrorErr != nil {
+		os.Exit(exitCode(mirrorErr))
+	}
+}
+
+func runMirrorsOff(a *app, args []string) {
+	fs := newFlagSet("mirrors off", "")
+	fs.Parse(args)
+
+	mirrorErr := a.manager.DisableMirrors()
+	if mirrorErr != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to disable mirrors: %v\n"), mirrorErr)
+	}
+
+	a.cfg.Mirror.Enabled = false
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Println(i18n.T("\n✓ Mirrors disabled"))
+	if mirrorErr != nil {
+		os.Exit(exitCode(mirrorErr))
+	}
+}
+
+func runMirrorsStatus(a *app, args []string) {
+	fs := newFlagSet("mirrors status", "")
+	fs.Parse(args)
+
+	if a.json {
+		printJSON(api.NewMirrorsResponse(a.cfg, a.manager))
+		return
+	}
+
+	printMirrorStatus(a.manager, a.cfg)
+}
+
+func runMirrorsPreset(a *app, args []string) {
+	fs := newFlagSet("mirrors preset", "[name] [tool...]")
+	positional := parseInterspersed(fs, args)
+
+	if len(positional) == 0 {
+		printMirrorPresets(os.Stdout)
+		return
+	}
+
+	preset, ok := config.FindMirrorPreset(strings.ToLower(positional[0]))
+	if !ok {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown mirror preset: %s\n\n"), positional[0])
+		printMirrorPresets(os.Stderr)
+		os.Exit(exitUsage)
+	}
+
+	tools := config.PresetTools
+	if len(positional) > 1 {
+		tools = positional[1:]
+		for _, tool := range tools {
+			if !slices.Contains(config.PresetTools, tool) {
+				fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown tool: %s (use %s)\n"), tool, strings.Join(config.PresetTools, ", "))
+				os.Exit(exitUsage)
+			}
+		}
+	}
+
+	changed := []string{}
+	for _, tool := range tools {
+		url := preset.URL(tool)
+		if url == "" {
+			fmt.Printf(i18n.T("○ %s has no %s mirror, keeping %s\n"), preset.Title, tool, a.cfg.Mirror.URL(tool))
+			continue
+		}
+		a.cfg.Mirror.SetURL(tool, url)
+		changed = append(changed, tool)
+		fmt.Printf("✓ %s: %s\n", tool, url)
+	}
+
+	saveMirrors(a, changed)
+}
+
+func runMirrorsGradleWrapper(a *app, args []string) {
+	fs := newFlagSet("mirrors gradle-wrapper", "[dir]")
+	positional := parseInterspersed(fs, args)
+
+	dir := "."
+	if len(positional) > 0 {
+		dir = positional[0]
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	gradle := a.cfg.Mirror.Gradle
+	url, err := mirror.NewGradleMirror(gradle.Central, gradle.Google, gradle.Plugins, gradle.Distributions).EnableWrapper(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Printf(i18n.T("✓ Gradle wrapper downloads %s\n"), url)
+	fmt.Println(i18n.T("  crosh off gradle puts the original download URL back"))
+}
+
+// saveMirrors saves the config after the mirrors of the tools in changed
+// were set, and switches those tools over if mirrors are enabled
+func saveMirrors(a *app, changed []string) {
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	// Mirrors already in use switch now; otherwise the next crosh on uses the new ones
+	if !a.cfg.Mirror.Enabled {
+		fmt.Println(i18n.T("\nRun 'crosh on' to use them"))
+		return
+	}
+	fmt.Println()
+	if _, err := a.manager.EnableMirrors(changed...); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to enable mirrors: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// printMirrorPresets prints the mirror presets and the tools each covers to w
+func printMirrorPresets(w io.Writer) {
+	fmt.Fprintln(w, i18n.T("Mirror presets:"))
+	for _, preset := range config.MirrorPresets {
+		fmt.Fprintf(w, "  %-10s %-26s %s\n", preset.Name, preset.Title, strings.Join(preset.Tools(), ", "))
+	}
+	fmt.Fprintln(w, i18n.T("\nRun: crosh mirrors preset <name> [tool...]"))
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+	"github.com/boomyao/crosh/internal/term"
+)
+
+// pickerTests is how many nodes crosh nodes pick tests at once
+const pickerTests = 16
+
+// pickerNode is a node in the crosh nodes pick list with its latest test result
+type pickerNode struct {
+	node    proxy.Node
+	region  string
+	latency int  // in milliseconds, -1 if unreachable, 0 if not tested
+	testing bool // a test is running
+	stale   bool // latency is from an earlier run
+}
+
+// picker is the state of the crosh nodes pick UI
+type picker struct {
+	nodes   []pickerNode
+	filter  string
+	visible []int // indexes into nodes of the rows shown, in order
+	cursor  int   // index into nodes of the highlighted row, -1 if none
+	offset  int   // first row scrolled into view
+	active  string
+	pinned  string
+	stats   *proxy.NodeStats
+	width   int // of the terminal, read before each redraw
+	height  int
+}
+
+// latencyResult is the outcome of testing nodes[index]
+type latencyResult struct {
+	index   int
+	latency int
+}
+
+func runNodesPick(a *app, args []string) {
+	fs := newFlagSet("nodes pick", "")
+	all := fs.Bool("all", false, "also list nodes excluded by the node filter")
+	fs.Parse(args)
+
+	if !term.IsTerminal(os.Stdin) || !term.IsTerminal(resultOut) {
+		fmt.Fprintln(os.Stderr, i18n.T("✗ crosh nodes pick needs a terminal, use: crosh nodes use <name|index>"))
+		os.Exit(1)
+	}
+
+	var p *picker
+	var err error
+	toStderr(func() { p, err = newPicker(a, *all) })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	t := startTUI("crosh nodes pick")
+	chosen, ok := p.run(t.keys)
+	t.stop()
+	p.saveLatency()
+	if !ok {
+		fmt.Println(i18n.T("No node picked"))
+		return
+	}
+
+	pinNode(a, chosen)
+}
+
+// newPicker lists the nodes of the subscription, leaving out those excluded
+// by the node filter unless all is set
+func newPicker(a *app, all bool) (*picker, error) {
+	nodes, err := a.manager.FetchNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := a.manager.NodeFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := a.manager.NodeStats()
+	if err != nil {
+		fmt.Printf(i18n.T("Warning: %v\n"), err)
+		stats = &proxy.NodeStats{Nodes: map[string]proxy.NodeStat{}}
+	}
+
+	p := &picker{cursor: -1, active: a.cfg.Proxy.CurrentNode, pinned: a.cfg.Proxy.PinnedNode, stats: stats}
+	for _, node := range nodes {
+		if !all && !filter.Match(&node) {
+			continue
+		}
+		n := pickerNode{node: node, region: proxy.DetectRegion(node.Name)}
+		if stat, ok := stats.Get(node.Name); ok && !stat.TestedAt.IsZero() {
+			n.latency, n.stale = stat.Latency, true
+		}
+		p.nodes = append(p.nodes, n)
+	}
+	if len(p.nodes) == 0 {
+		return nil, errors.New(i18n.T("No nodes to pick from (see: crosh nodes filter)"))
+	}
+	return p, nil
+}
+
+// saveLatency stores the latency of the nodes tested while the picker was open
+func (p *picker) saveLatency() {
+	p.stats.RecordLatency(p.tested())
+	if err := p.stats.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: failed to save node latency: %v\n"), err)
+	}
+}
+
+// run shows the picker until a node is chosen, returning its name, or the
+// user cancels
+func (p *picker) run(keys <-chan term.Key) (string, bool) {
+	// Buffered so tests still running when the picker closes don't block forever
+	results := make(chan latencyResult, len(p.nodes))
+	p.testAll(results)
+
+	for {
+		p.width, p.height = term.Size()
+		p.update()
+		p.draw()
+
+		select {
+		case result := <-results:
+			n := &p.nodes[result.index]
+			n.latency, n.testing, n.stale = result.latency, false, false
+		case key, open := <-keys:
+			if !open {
+				return "", false
+			}
+			switch key.Name {
+			case "esc", "ctrl-c", "ctrl-d":
+				return "", false
+			case "enter":
+				if p.cursor >= 0 {
+					return p.nodes[p.cursor].node.Name, true
+				}
+			case "up", "ctrl-p":
+				p.move(-1)
+			case "down", "ctrl-n", "tab":
+				p.move(1)
+			case "pgup":
+				p.move(-p.rows())
+			case "pgdown":
+				p.move(p.rows())
+			case "home":
+				p.move(-len(p.nodes))
+			case "end":
+				p.move(len(p.nodes))
+			case "ctrl-r":
+				p.testAll(results)
+			case "backspace":
+				if p.filter != "" {
+					runes := []rune(p.filter)
+					p.filter = string(runes[:len(runes)-1])
+				}
+			case "ctrl-u":
+				p.filter = ""
+			case "":
+				if unicode.IsPrint(key.Rune) {
+					p.filter += string(key.Rune)
+				}
+			}
+		}
+	}
+}
+
+// testAll tests the latency of every node not being tested already, sending
+// results as they come in
+func (p *picker) testAll(results chan<- latencyResult) {
+	sem := make(chan struct{}, pickerTests)
+	for i := range p.nodes {
+		if p.nodes[i].testing {
+			continue
+		}
+		p.nodes[i].testing = true
+		node := p.nodes[i].node
+		go func(i int) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			node.TestLatency()
+			results <- latencyResult{index: i, latency: node.Latency}
+		}(i)
+	}
+}
+
+// tested returns the nodes tested while the picker was open
+func (p *picker) tested() []proxy.Node {
+	nodes := []proxy.Node{}
+	for _, n := range p.nodes {
+		if n.latency != 0 && !n.stale {
+			node := n.node
+			node.Latency = n.latency
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// update filters and sorts the rows, keeping the highlighted node highlighted
+func (p *picker) update() {
+	p.visible = p.visible[:0]
+	for i, n := range p.nodes {
+		if fuzzyMatch(p.filter, n.node.Name+" "+n.node.Type+" "+n.region) {
+			p.visible = append(p.visible, i)
+		}
+	}
+
+	// Fastest first, then untested nodes, then unreachable ones
+	rank := func(n pickerNode) int {
+		switch {
+		case n.latency > 0:
+			return n.latency
+		case n.latency == 0:
+			return 1 << 30
+		default:
+			return 1<<30 + 1
+		}
+	}
+	sort.SliceStable(p.visible, func(i, j int) bool {
+		return rank(p.nodes[p.visible[i]]) < rank(p.nodes[p.visible[j]])
+	})
+
+	row := p.row()
+	if row < 0 {
+		p.cursor = -1
+		if len(p.visible) > 0 {
+			p.cursor = p.visible[0]
+			row = 0
+		}
+	}
+
+	// Scroll the highlighted row into view
+	rows := p.rows()
+	if row < p.offset {
+		p.offset = row
+	}
+	if row >= p.offset+rows {
+		p.offset = row - rows + 1
+	}
+	p.offset = max(min(p.offset, len(p.visible)-rows), 0)
+}
+
+// row returns the position of the highlighted node among the rows, or -1
+func (p *picker) row() int {
+	for row, i := range p.visible {
+		if i == p.cursor {
+			return row
+		}
+	}
+	return -1
+}
+
+// move moves the highlight by delta rows
+func (p *picker) move(delta int) {
+	if len(p.visible) == 0 {
+		return
+	}
+	row := max(min(p.row()+delta, len(p.visible)-1), 0)
+	p.cursor = p.visible[row]
+}
+
+// rows returns how many node rows fit on the screen below the header
+func (p *picker) rows() int {
+	return max(p.height-5, 1)
+}
+
+// draw redraws the whole screen
+func (p *picker) draw() {
+	width := p.width
+	s := newScreen(width)
+	s.line("", i18n.T("Pick a node: ↑/↓ move, type to filter, enter pins, ctrl-r retests, esc cancels"))
+	s.line("", "> "+p.filter+"▏")
+
+	testing, reachable := 0, 0
+	for _, n := range p.nodes {
+		if n.testing {
+			testing++
+		}
+		if n.latency > 0 {
+			reachable++
+		}
+	}
+	status := fmt.Sprintf(i18n.T("%d of %d nodes shown, %d reachable"), len(p.visible), len(p.nodes), reachable)
+	if testing > 0 {
+		status += fmt.Sprintf(i18n.T(", testing %d..."), testing)
+	}
+	s.line(term.Dim, status)
+
+	nameWidth := max(width-2-10-8-12-8, 12)
+	s.line("", fmt.Sprintf("  %s %s %s %s", term.Pad(i18n.T("NAME"), nameWidth), term.Pad(i18n.T("TYPE"), 9),
+		term.Pad(i18n.T("REGION"), 7), i18n.T("LATENCY")))
+
+	end := min(p.offset+p.rows(), len(p.visible))
+	for _, i := range p.visible[p.offset:end] {
+		n := p.nodes[i]
+		region := n.region
+		if region == "" {
+			region = "-"
+		}
+		var marks []string
+		if n.node.Name == p.active {
+			marks = append(marks, i18n.T("active"))
+		}
+		if n.node.Name == p.pinned {
+			marks = append(marks, i18n.T("pinned"))
+		}
+
+		row := fmt.Sprintf("  %s %s %s %s %s", term.Pad(n.node.Name, nameWidth), term.Pad(n.node.Type, 9),
+			term.Pad(region, 7), term.Pad(pickerLatency(n), 11), strings.Join(marks, ", "))
+		switch {
+		case i == p.cursor:
+			s.line(term.Reverse, term.Pad(row, width))
+		case n.stale || n.latency < 0:
+			s.line(term.Dim, row)
+		default:
+			s.line("", row)
+		}
+	}
+	s.draw()
+}
+
+// pickerLatency describes the latency of a node in the picker
+func pickerLatency(n pickerNode) string {
+	switch {
+	case n.latency > 0:
+		return fmt.Sprintf("%dms", n.latency)
+	case n.testing:
+		return "…"
+	case proxy.UDPOnly(n.node.Type):
+		// These don't answer a TCP dial, so there is nothing to measure
+		return "n/a"
+	case n.latency < 0:
+		return i18n.T("unreachable")
+	default:
+		return "-"
+	}
+}
+
+// fuzzyMatch reports whether every word of pattern appears in s in order,
+// not necessarily contiguously, ignoring case
+func fuzzyMatch(pattern, s string) bool {
+	s = strings.ToLower(s)
+	for _, word := range strings.Fields(strings.ToLower(pattern)) {
+		rest := s
+		for _, r := range word {
+			i := strings.IndexRune(rest, r)
+			if i < 0 {
+				return false
+			}
+			rest = rest[i+len(string(r)):]
+		}
+	}
+	return true
+}
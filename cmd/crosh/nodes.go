@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+func runNodesList(a *app, args []string) {
+	fs := newFlagSet("nodes list", "")
+	all := fs.Bool("all", false, "also list nodes excluded by the node filter")
+	fs.Parse(args)
+
+	var nodes []proxy.Node
+	var err error
+	toStderr(func() { nodes, err = a.manager.FetchNodes() })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	filter, err := a.manager.NodeFilter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	stats, err := a.manager.NodeStats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: %v\n"), err)
+		stats = &proxy.NodeStats{Nodes: map[string]proxy.NodeStat{}}
+	}
+
+	if a.json {
+		printJSON(nodesJSON(a, nodes, filter, stats, *all))
+		return
+	}
+
+	excluded := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  #\tNAME\tTYPE\tREGION\tSERVER\tPORT\tLATENCY\tSPEED")
+	for i, node := range nodes {
+		marker := " "
+		if !filter.Match(&node) {
+			excluded++
+			if !*all {
+				continue
+			}
+			marker = "-"
+		}
+		if node.Name == a.cfg.Proxy.CurrentNode || slices.Contains(a.cfg.Proxy.BalancedNodes, node.Name) {
+			marker = "*"
+		}
+		region := proxy.DetectRegion(node.Name)
+		if region == "" {
+			region = "-"
+		}
+		fmt.Fprintf(w, "%s %d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			marker, i+1, node.Name, node.Type, region, node.Server, node.Port, formatLatency(stats, node.Name), formatSpeed(stats, node.Name))
+	}
+
+	fmt.Printf(i18n.T("Found %d nodes"), len(nodes))
+	if excluded > 0 {
+		fmt.Printf(i18n.T(", %d excluded by the node filter"), excluded)
+		if !*all {
+			fmt.Print(i18n.T(" (show them with --all)"))
+		}
+	}
+	fmt.Print("\n\n")
+	w.Flush()
+
+	if len(a.cfg.Proxy.BalancedNodes) > 1 {
+		fmt.Printf(i18n.T("\n* balanced nodes: %s\n"), strings.Join(a.cfg.Proxy.BalancedNodes, ", "))
+	} else if a.cfg.Proxy.CurrentNode != "" {
+		fmt.Printf(i18n.T("\n* active node: %s\n"), a.cfg.Proxy.CurrentNode)
+	}
+	if a.cfg.Proxy.PinnedNode != "" {
+		fmt.Printf(i18n.T("  pinned node: %s (undo with: crosh nodes auto)\n"), a.cfg.Proxy.PinnedNode)
+	}
+}
+
+// nodeJSON is a node as printed by crosh nodes list --json
+type nodeJSON struct {
+	Index    int        `json:"index"` // for crosh nodes use
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Region   string     `json:"region,omitempty"`
+	Server   string     `json:"server"`
+	Port     int        `json:"port"`
+	Latency  *int       `json:"latency,omitempty"` // in milliseconds, -1 if unreachable
+	TestedAt *time.Time `json:"tested_at,omitempty"`
+	Speed    float64    `json:"speed,omitempty"` // in MB/s, -1 if the test failed
+	Active   bool       `json:"active"`
+	Pinned   bool       `json:"pinned"`
+	Excluded bool       `json:"excluded"` // by the node filter
+}
+
+// nodesJSON describes nodes with their last test results, leaving out nodes
+// excluded by filter unless all is set
+func nodesJSON(a *app, nodes []proxy.Node, filter *proxy.NodeFilter, stats *proxy.NodeStats, all bool) []nodeJSON {
+	list := []nodeJSON{}
+	for i, node := range nodes {
+		excluded := !filter.Match(&node)
+		if excluded && !all {
+			continue
+		}
+
+		n := nodeJSON{
+			Index:    i + 1,
+			Name:     node.Name,
+			Type:     node.Type,
+			Region:   proxy.DetectRegion(node.Name),
+			Server:   node.Server,
+			Port:     node.Port,
+			Active:   node.Name == a.cfg.Proxy.CurrentNode || slices.Contains(a.cfg.Proxy.BalancedNodes, node.Name),
+			Pinned:   node.Name == a.cfg.Proxy.PinnedNode,
+			Excluded: excluded,
+		}
+		if stat, ok := stats.Get(node.Name); ok {
+			if !stat.TestedAt.IsZero() {
+				n.Latency, n.TestedAt = &stat.Latency, &stat.TestedAt
+			}
+			n.Speed = stat.Speed
+		}
+		list = append(list, n)
+	}
+	return list
+}
+
+func runNodesFilter(a *app, args []string) {
+	fs := newFlagSet("nodes filter", "")
+	var include, exclude, types, regions, prefer, avoid listFlag
+	fs.Var(&include, "include", "only use nodes whose name matches one of these regexes (comma-separated, repeatable)")
+	fs.Var(&exclude, "exclude", "skip nodes whose name matches one of these regexes")
+	fs.Var(&types, "type", "only use these node types, e.g. trojan,vless")
+	fs.Var(&regions, "region", "only use nodes in these regions, e.g. HK,JP,SG")
+	fs.Var(&prefer, "prefer", "prefer nodes in these regions, using others only if none is available")
+	fs.Var(&avoid, "avoid", "avoid nodes in these regions unless nothing else is available, e.g. US")
+	clear := fs.Bool("clear", false, "remove all filters, including the default exclude list")
+	fs.Parse(args)
+
+	filter := &a.cfg.Proxy.Filter
+	changed := *clear || include.set || exclude.set || types.set || regions.set || prefer.set || avoid.set
+	if *clear {
+		*filter = config.NodeFilterConfig{}
+	}
+	if include.set {
+		filter.Include = include.values
+	}
+	if exclude.set {
+		filter.Exclude = exclude.values
+	}
+	if types.set {
+		filter.Types = types.values
+	}
+	if regions.set {
+		filter.Regions = regions.values
+	}
+	if prefer.set {
+		filter.Prefer = prefer.values
+	}
+	if avoid.set {
+		filter.Avoid = avoid.values
+	}
+
+	if changed {
+		// Validate the patterns before saving
+		if _, err := a.manager.NodeFilter(); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		if err := a.cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+		fmt.Println(i18n.T("✓ Node filter saved"))
+	}
+
+	fmt.Println(i18n.T("Node filter:"))
+	fmt.Printf(i18n.T("  include: %s\n"), formatList(filter.Include, i18n.T("(any)")))
+	fmt.Printf(i18n.T("  exclude: %s\n"), formatList(filter.Exclude, i18n.T("(none)")))
+	fmt.Printf(i18n.T("  types:   %s\n"), formatList(filter.Types, i18n.T("(any)")))
+	fmt.Printf(i18n.T("  regions: %s\n"), formatList(filter.Regions, i18n.T("(any)")))
+	fmt.Printf(i18n.T("  prefer:  %s\n"), formatList(filter.Prefer, i18n.T("(none)")))
+	fmt.Printf(i18n.T("  avoid:   %s\n"), formatList(filter.Avoid, i18n.T("(none)")))
+
+	if changed && a.manager.GetProxyCore().IsRunning() {
+		fmt.Println(i18n.T("\nThe filter applies the next time a node is selected, e.g.: crosh proxy off && crosh proxy on"))
+	}
+}
+
+// formatList joins a filter list for display, or returns empty if there are no values
+func formatList(values []string, empty string) string {
+	if len(values) == 0 {
+		return empty
+	}
+	return strings.Join(values, ", ")
+}
+
+func runNodesUse(a *app, args []string) {
+	fs := newFlagSet("nodes use", "<name|index>")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+
+	pinNode(a, positional[0])
+}
+
+// pinNode pins the node with the given name or index and restarts the proxy on it
+func pinNode(a *app, ref string) {
+	node, err := a.manager.SwitchNode(ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to switch node: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	startDaemon(a.cfg)
+	fmt.Printf(i18n.T("✓ Proxy now using node: %s\n"), node.Name)
+	fmt.Println(i18n.T("  This node stays selected until you run: crosh nodes auto"))
+}
+
+func runNodesSpeedtest(a *app, args []string) {
+	fs := newFlagSet("nodes speedtest", "[name|index...]")
+	testURL := fs.String("url", "", "URL to download through each node (default: proxy.speed_test_url or a Cloudflare test file)")
+	positional := parseInterspersed(fs, args)
+
+	nodes, err := a.manager.FetchNodes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	// Test only the requested nodes, if any
+	if len(positional) > 0 {
+		selected := []proxy.Node{}
+		for _, ref := range positional {
+			node, err := proxy.FindNode(nodes, ref)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			selected = append(selected, *node)
+		}
+		nodes = selected
+	}
+
+	if *testURL != "" {
+		a.cfg.Proxy.SpeedTestURL = *testURL
+	}
+
+	if err := a.manager.GetProxyCore().Download(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to download %s: %v\n"), a.manager.GetProxyCore().Name(), err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf(i18n.T("Testing download speed of %d nodes (up to %s each)...\n\n"), len(nodes), proxy.SpeedTestDuration)
+
+	var fastest *proxy.Node
+	err = a.manager.SpeedTest(nodes, func(node *proxy.Node, err error) {
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", node.Name, err)
+			return
+		}
+		fmt.Printf(i18n.T("✓ %s: %.1f MB/s\n"), node.Name, node.Speed)
+		if fastest == nil || node.Speed > fastest.Speed {
+			fastest = node
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if fastest == nil {
+		fmt.Println(i18n.T("\n✗ No node completed the speed test"))
+		os.Exit(exitNoNodes)
+	}
+
+	fmt.Printf(i18n.T("\nFastest download: %s (%.1f MB/s)\n"), fastest.Name, fastest.Speed)
+	if a.cfg.Proxy.Selection != config.SelectionBandwidth {
+		fmt.Println(i18n.T("  To prefer bandwidth over ping when selecting nodes, run:"))
+		fmt.Println("    crosh config set proxy.selection bandwidth")
+	}
+}
+
+func runNodesAuto(a *app, args []string) {
+	fs := newFlagSet("nodes auto", "")
+	fs.Parse(args)
+
+	if a.cfg.Proxy.PinnedNode == "" {
+		fmt.Println(i18n.T("✓ Node selection is already automatic"))
+		return
+	}
+
+	if err := a.manager.UnpinNode(); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Println(i18n.T("✓ Automatic node selection restored"))
+}
+
+// formatLatency describes the last tested latency of a node, e.g. "85ms (2h ago)"
+func formatLatency(stats *proxy.NodeStats, name string) string {
+	stat, ok := stats.Get(name)
+	if !ok || stat.TestedAt.IsZero() {
+		return "-"
+	}
+
+	latency := fmt.Sprintf("%dms", stat.Latency)
+	if stat.Latency < 0 {
+		latency = "unreachable"
+	}
+	return fmt.Sprintf("%s (%s ago)", latency, formatAge(time.Since(stat.TestedAt)))
+}
+
+// formatSpeed describes the last measured download speed of a node, e.g. "12.5 MB/s"
+func formatSpeed(stats *proxy.NodeStats, name string) string {
+	stat, ok := stats.Get(name)
+	if !ok || stat.Speed == 0 {
+		return "-"
+	}
+	if stat.Speed < 0 {
+		return "failed"
+	}
+	return fmt.Sprintf("%.1f MB/s", stat.Speed)
+}
+
+// formatAge rounds d to a short human readable age
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/api"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+func runProxySet(a *app, args []string) {
+	fs := newFlagSet("proxy set", "<subscription-url>")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+
+	if !isHTTPURL(positional[0]) {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Not an http(s) subscription URL: %s\n"), positional[0])
+		os.Exit(exitUsage)
+	}
+
+	handleConfigureProxy(a.manager, a.cfg, positional[0])
+}
+
+func runProxyLoad(a *app, args []string) {
+	fs := newFlagSet("proxy load", "<config.yaml>")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+
+	if _, err := os.Stat(positional[0]); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Cannot read %s: %v\n"), positional[0], err)
+		os.Exit(exitCode(err))
+	}
+
+	handleLocalYAMLFile(a.manager, a.cfg, positional[0])
+}
+
+func runProxyOn(a *app, args []string) {
+	fs := newFlagSet("proxy on", "")
+	allowLAN := fs.Bool("allow-lan", false, "share the proxy with other devices by listening on 0.0.0.0 (saved; --allow-lan=false undoes it)")
+	tun := fs.Bool("tun", false, "route all system traffic through the proxy; needs root (saved; --tun=false undoes it)")
+	core := fs.String("core", "", "proxy core to run the nodes with: xray, sing-box or mihomo (saved)")
+	fs.Parse(args)
+
+	applyAllowLAN(a, fs, *allowLAN)
+	applyTUN(a, fs, *tun)
+	applyCore(a, *core)
+
+	if a.cfg.Proxy.SubscriptionURL == "" {
+		fmt.Fprintln(os.Stderr, i18n.T("✗ Proxy is not configured, run: crosh proxy set <subscription-url>"))
+		os.Exit(exitConfig)
+	}
+
+	if a.manager.GetProxyCore().IsRunning() {
+		fmt.Printf(i18n.T("✓ Proxy already running (%s)\n"), a.manager.GetProxyStatus())
+		return
+	}
+
+	a.cfg.Proxy.Enabled = true
+	if err := enableProxy(a.manager); err != nil {
+		a.cfg.Proxy.Enabled = false
+		a.cfg.Save()
+		os.Exit(exitCode(err))
+	}
+	a.cfg.Save()
+	startDaemon(a.cfg)
+	printEnvHint()
+}
+
+// applyAllowLAN saves the listen address chosen with --allow-lan, if the flag
+// was given, restarting a running proxy so it takes effect
+func applyAllowLAN(a *app, fs *flag.FlagSet, allowLAN bool) {
+	if !flagGiven(fs, "allow-lan") {
+		return
+	}
+
+	listen := lanListen(allowLAN)
+	if listen == a.cfg.Proxy.Listen {
+		return
+	}
+
+	stopForReconfigure(a)
+	a.cfg.Proxy.Listen = listen
+	saveProxySettings(a)
+}
+
+// lanListen returns the proxy listen address for --allow-lan
+func lanListen(allowLAN bool) string {
+	if allowLAN {
+		return "0.0.0.0"
+	}
+	return "127.0.0.1"
+}
+
+// applyTUN saves TUN mode as chosen with --tun, if the flag was given,
+// restarting a running proxy so it takes effect
+func applyTUN(a *app, fs *flag.FlagSet, tun bool) {
+	if !flagGiven(fs, "tun") || tun == a.cfg.Proxy.TUN.Enabled {
+		return
+	}
+
+	stopForReconfigure(a)
+	a.cfg.Proxy.TUN.Enabled = tun
+	saveProxySettings(a)
+	if !tun {
+		fmt.Println(i18n.T("✓ TUN mode disabled"))
+	}
+}
+
+// applyCore saves the proxy core chosen with --core, if given, stopping the
+// proxy running on the previous core
+func applyCore(a *app, core string) {
+	if core == "" || core == a.cfg.Proxy.Core {
+		return
+	}
+	if !slices.Contains(proxy.Cores(), core) {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Unknown proxy core: %s (expected %s)\n"), core, strings.Join(proxy.Cores(), ", "))
+		os.Exit(exitUsage)
+	}
+
+	stopForReconfigure(a)
+	a.cfg.Proxy.Core = core
+	saveProxySettings(a)
+	fmt.Printf(i18n.T("✓ Using the %s core\n"), core)
+}
+
+// stopForReconfigure stops a running proxy so it restarts with new settings
+func stopForReconfigure(a *app) {
+	if !a.manager.GetProxyCore().IsRunning() {
+		return
+	}
+
+	fmt.Println(i18n.T("Restarting the proxy with the new settings..."))
+	if err := a.manager.DisableProxy(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to stop proxy: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// saveProxySettings saves changed proxy settings and recreates the manager,
+// whose core settings are fixed when it is created
+func saveProxySettings(a *app) {
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	a.manager = accelerator.NewManager(a.cfg)
+}
+
+func runProxyOff(a *app, args []string) {
+	fs := newFlagSet("proxy off", "")
+	fs.Parse(args)
+
+	if err := a.manager.DisableProxy(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to disable proxy: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	a.cfg.Proxy.Enabled = false
+	a.cfg.Save()
+	fmt.Println(i18n.T("✓ Proxy disabled"))
+}
+
+func runProxyStatus(a *app, args []string) {
+	fs := newFlagSet("proxy status", "")
+	fs.Parse(args)
+
+	if a.json {
+		printJSON(api.NewStatusResponse(a.cfg, a.manager).Proxy)
+		return
+	}
+
+	printProxyStatus(a.manager, a.cfg)
+}
+
+func handleConfigureProxy(manager *accelerator.Manager, cfg *config.Config, url string) {
+	fmt.Printf("Configuring proxy subscription...\n\n")
+
+	// Save subscription URL
+	cfg.Proxy.SubscriptionURL = url
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Printf(i18n.T("✓ Subscription URL saved: %s\n"), url)
+
+	// Download the proxy core if it isn't installed yet
+	if err := manager.GetProxyCore().Download(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to download %s: %v\n"), manager.GetProxyCore().Name(), err)
+		fmt.Println(i18n.T("\nYou can try again later with: crosh on"))
+		return
+	}
+
+	fmt.Println(i18n.T("\n✓ Proxy configured successfully"))
+
+	// Automatically enable mirrors
+	fmt.Println(i18n.T("\nEnabling mirrors..."))
+	cfg.Mirror.Enabled = true
+	if _, err := manager.EnableMirrors(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to enable mirrors: %v\n"), err)
+	}
+
+	// Automatically enable proxy
+	fmt.Println(i18n.T("\nStarting proxy..."))
+	cfg.Proxy.Enabled = true
+	if err := manager.EnableProxy(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to start proxy: %v\n"), err)
+		fmt.Println(i18n.T("\nYou can try again with: crosh on"))
+		return
+	}
+
+	cfg.Save()
+	startDaemon(cfg)
+
+	fmt.Println(i18n.T("\n✓ Acceleration enabled"))
+	fmt.Println(i18n.T("\nProxy is running in background."))
+	printEnvHint()
+}
+
+func handleLocalYAMLFile(manager *accelerator.Manager, cfg *config.Config, filePath string) {
+	fmt.Printf("Loading proxy configuration from local YAML file...\n\n")
+
+	// Clear subscription URL (one-time use, don't save file path)
+	cfg.Proxy.SubscriptionURL = ""
+
+	// Download the proxy core if it isn't installed yet
+	if err := manager.GetProxyCore().Download(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to download %s: %v\n"), manager.GetProxyCore().Name(), err)
+		fmt.Println(i18n.T("\nPlease try again later."))
+		return
+	}
+
+	// Load nodes from local YAML file
+	fmt.Println(i18n.T("\nParsing YAML file..."))
+	sub, err := manager.LoadProxyFromFile(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to load YAML file: %v\n"), err)
+		fmt.Println(i18n.T("\nPlease check your YAML file format and try again."))
+		return
+	}
+
+	fmt.Printf(i18n.T("✓ Found %d nodes in YAML file\n"), len(sub.Nodes))
+
+	// Select fastest node
+	fmt.Println(i18n.T("\nTesting node latency..."))
+	core := manager.GetProxyCore()
+	node, err := core.SelectFastestNodeByLatency(sub, cfg.Proxy.ProbeURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to select node: %v\n"), err)
+		return
+	}
+
+	fmt.Printf(i18n.T("✓ Selected node: %s (latency: %dms)\n"), node.Name, node.Latency)
+
+	// Generate the core config
+	if err := core.GenerateConfig(node); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to generate %s config: %v\n"), core.Name(), err)
+		return
+	}
+
+	fmt.Println(i18n.T("\n✓ Proxy configured successfully (one-time use)"))
+
+	// Automatically enable mirrors
+	fmt.Println(i18n.T("\nEnabling mirrors..."))
+	cfg.Mirror.Enabled = true
+	if _, err := manager.EnableMirrors(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to enable mirrors: %v\n"), err)
+	}
+
+	// Start the core
+	fmt.Println(i18n.T("\nStarting proxy..."))
+	if err := core.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to start proxy: %v\n"), err)
+		return
+	}
+
+	cfg.Proxy.Enabled = true
+	cfg.Proxy.CurrentNode = node.Name
+	cfg.Proxy.BalancedNodes = nil
+	cfg.Save()
+
+	// Print proxy environment variables
+	fmt.Println(i18n.T("\n✓ Acceleration enabled"))
+	fmt.Println(i18n.T("\nProxy is running in background."))
+	printEnvHint()
+
+	fmt.Println(i18n.T("\nNote: This is a one-time configuration. To use this YAML file again, run: crosh proxy load ") + filePath)
+}
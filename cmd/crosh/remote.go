@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+	"github.com/boomyao/crosh/internal/remote"
+)
+
+func runRemoteApply(a *app, args []string) {
+	fs := newFlagSet("remote apply", "<user@host>")
+	proxyMode := fs.String("proxy", remote.ProxyNone, "proxy deployment: none, tunnel (use this machine's proxy) or node (run Xray on the remote)")
+	port := fs.String("p", "", "SSH port")
+	identity := fs.String("i", "", "SSH identity file")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+	target := positional[0]
+
+	sshArgs := []string{}
+	if *port != "" {
+		sshArgs = append(sshArgs, "-p", *port)
+	}
+	if *identity != "" {
+		sshArgs = append(sshArgs, "-i", *identity)
+	}
+
+	provisioner := remote.NewProvisioner(target, sshArgs)
+
+	fmt.Printf(i18n.T("Applying mirrors on %s...\n\n"), target)
+	if err := provisioner.ApplyMirrors(a.cfg); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to apply mirrors: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	switch *proxyMode {
+	case remote.ProxyNone:
+	case remote.ProxyTunnel:
+		if !a.manager.GetProxyCore().IsRunning() {
+			fmt.Fprintln(os.Stderr, i18n.T("✗ Local proxy is not running, start it first with: crosh on"))
+			os.Exit(1)
+		}
+		env := a.manager.GetProxyCore().GetProxyEnvVars()
+		fmt.Println(i18n.T("\nConfiguring remote proxy environment..."))
+		if err := provisioner.ApplyTunnelProxy(env["HTTPS_PROXY"], env["ALL_PROXY"]); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to configure remote proxy: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+		ports := strconv.Itoa(a.cfg.Proxy.LocalPort)
+		if a.cfg.Proxy.HTTPPort > 0 {
+			ports += ", " + strconv.Itoa(a.cfg.Proxy.HTTPPort)
+		}
+		fmt.Printf(i18n.T("\nOpening reverse tunnel (remote 127.0.0.1 ports %s → local proxy). Press Ctrl+C to close.\n"), ports)
+		if err := provisioner.Tunnel(env["HTTPS_PROXY"], env["ALL_PROXY"]); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Tunnel closed: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+	case remote.ProxyNode:
+		fmt.Println(i18n.T("\nDeploying proxy to remote..."))
+		node, err := currentNode(a)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		// The remote runs Xray-core, with a config of its own: this machine's
+		// listen address, login, TUN and upstream proxy don't apply there
+		xray := a.manager.GetXrayManager()
+		if !xray.Supports(node.Type) {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Xray-core on the remote can't run %s node %s, pick another with: crosh nodes use\n"), node.Type, node.Name)
+			os.Exit(1)
+		}
+		xrayConfig, err := xray.RemoteConfig(node)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to generate the remote's Xray config: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+		// The remote's inbounds have the same ports as this machine's
+		socksURL := fmt.Sprintf("socks5://127.0.0.1:%d", a.cfg.Proxy.LocalPort)
+		httpURL := socksURL
+		if a.cfg.Proxy.HTTPPort > 0 {
+			httpURL = fmt.Sprintf("http://127.0.0.1:%d", a.cfg.Proxy.HTTPPort)
+		}
+		if err := provisioner.ApplyNodeProxy(xrayConfig, httpURL, socksURL); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to deploy proxy: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+	default:
+		fmt.Fprintf(os.Stderr, i18n.T("Unknown proxy mode: %s (expected none, tunnel or node)\n"), *proxyMode)
+		os.Exit(exitUsage)
+	}
+
+	fmt.Printf(i18n.T("\n✓ Remote %s configured\n"), target)
+}
+
+// currentNode returns the node the local proxy runs on, from the subscription
+func currentNode(a *app) (*proxy.Node, error) {
+	if a.cfg.Proxy.CurrentNode == "" {
+		return nil, errors.New(i18n.T("no node selected yet, start the proxy first with: crosh on"))
+	}
+	nodes, err := a.manager.FetchNodes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		if nodes[i].Name == a.cfg.Proxy.CurrentNode {
+			return &nodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf(i18n.T("node %s is no longer in the subscription, pick another with: crosh nodes use"), a.cfg.Proxy.CurrentNode)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/mirror"
+)
+
+func runRestore(a *app, args []string) {
+	fs := newFlagSet("restore", "")
+	force := fs.Bool("force", false, "also put back files changed since crosh edited them, losing those changes")
+	fs.Parse(args)
+
+	backups, err := mirror.Backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if len(backups) == 0 {
+		fmt.Println(i18n.T("Nothing to restore: crosh hasn't changed any tool config files"))
+		return
+	}
+
+	complete := true
+	for _, backup := range backups {
+		_, err := mirror.Restore(backup.Path, *force)
+		switch {
+		case errors.Is(err, mirror.ErrChanged):
+			fmt.Fprintf(os.Stderr, i18n.T("⚠ %s changed since crosh edited it, skipped (--force puts the original back anyway)\n"), backup.Path)
+			complete = false
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			complete = false
+		case backup.Existed:
+			fmt.Printf(i18n.T("✓ Restored %s\n"), backup.Path)
+		default:
+			fmt.Printf(i18n.T("✓ Removed %s, which crosh created\n"), backup.Path)
+		}
+	}
+
+	// With the originals back, no mirror crosh set is left
+	a.cfg.Mirror.Enabled = false
+	if err := a.cfg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Error saving config: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	if !complete {
+		os.Exit(exitPartial)
+	}
+}
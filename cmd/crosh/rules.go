@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+func runRulesList(a *app, args []string) {
+	fs := newFlagSet("rules list", "")
+	fs.Parse(args)
+
+	if len(a.cfg.Proxy.Rules) == 0 {
+		fmt.Println(i18n.T("No routing rules, add one with: crosh rules add --direct internal.corp.com"))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tOUTBOUND\tMATCH\tPORTS")
+		for i, rule := range a.cfg.Proxy.Rules {
+			ports := rule.Ports
+			if ports == "" {
+				ports = "any"
+			}
+			match := strings.Join(append(slices.Clone(rule.Domains), rule.IPs...), ", ")
+			if match == "" {
+				match = "*"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, rule.Outbound, match, ports)
+		}
+		w.Flush()
+	}
+
+	fmt.Printf(i18n.T("\nRules apply in order before %d always-proxied domains (proxy.always_proxy)\n"), len(a.cfg.Proxy.AlwaysProxy))
+	fmt.Println(i18n.T("and the built-in rules that send private and Chinese addresses direct."))
+}
+
+func runRulesAdd(a *app, args []string) {
+	fs := newFlagSet("rules add", "")
+	var direct, viaProxy, block listFlag
+	fs.Var(&direct, "direct", "connect to these domains or IPs directly (comma-separated, repeatable)")
+	fs.Var(&viaProxy, "proxy", "connect to these domains or IPs through the proxy")
+	fs.Var(&block, "block", "block connections to these domains or IPs")
+	ports := fs.String("port", "", `only match these ports, e.g. "22" or "8000-9000,443"`)
+	fs.Parse(args)
+
+	added := 0
+	for _, flagged := range []struct {
+		outbound string
+		list     *listFlag
+	}{{"direct", &direct}, {"proxy", &viaProxy}, {"block", &block}} {
+		// An empty list with --port matches those ports on any address
+		if !flagged.list.set || (len(flagged.list.values) == 0 && *ports == "") {
+			continue
+		}
+		rule := config.RoutingRule{Outbound: flagged.outbound, Ports: *ports}
+		for _, value := range flagged.list.values {
+			if isIPMatch(value) {
+				rule.IPs = append(rule.IPs, value)
+			} else {
+				rule.Domains = append(rule.Domains, value)
+			}
+		}
+		a.cfg.Proxy.Rules = append(a.cfg.Proxy.Rules, rule)
+		added++
+	}
+
+	if added == 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	saveRules(a)
+	fmt.Printf(i18n.T("✓ Added %d routing rule(s)\n"), added)
+}
+
+func runRulesRemove(a *app, args []string) {
+	fs := newFlagSet("rules remove", "<index|domain|ip>...")
+	positional := parseInterspersed(fs, args)
+	if len(positional) == 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	rules := a.cfg.Proxy.Rules
+	removed := make([]bool, len(rules))
+	for _, ref := range positional {
+		found := false
+		if index, err := strconv.Atoi(ref); err == nil && index >= 1 && index <= len(rules) {
+			removed[index-1] = true
+			found = true
+		}
+		for i := range rules {
+			domains := slices.DeleteFunc(rules[i].Domains, func(d string) bool { return d == ref })
+			ips := slices.DeleteFunc(rules[i].IPs, func(ip string) bool { return ip == ref })
+			if len(domains) != len(rules[i].Domains) || len(ips) != len(rules[i].IPs) {
+				found = true
+				rules[i].Domains, rules[i].IPs = domains, ips
+				// A rule left without domains or IPs would match everything
+				if len(domains) == 0 && len(ips) == 0 {
+					removed[i] = true
+				}
+			}
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ No routing rule matches %s\n"), ref)
+			os.Exit(1)
+		}
+	}
+
+	kept := []config.RoutingRule{}
+	for i, rule := range rules {
+		if !removed[i] {
+			kept = append(kept, rule)
+		}
+	}
+	a.cfg.Proxy.Rules = kept
+
+	saveRules(a)
+	fmt.Printf(i18n.T("✓ Removed %s from the routing rules\n"), strings.Join(positional, ", "))
+}
+
+// saveRules saves changed routing rules and reloads a running proxy with them
+func saveRules(a *app) {
+	saveProxySettings(a)
+
+	if !a.manager.GetProxyCore().IsRunning() {
+		return
+	}
+	fmt.Println(i18n.T("Reloading the proxy with the new rules..."))
+	if err := a.manager.ReloadProxy(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to reload proxy: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// isIPMatch reports whether a rule value matches IPs rather than domains
+func isIPMatch(value string) bool {
+	if strings.HasPrefix(value, "geoip:") || net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+func runRun(a *app, args []string) {
+	fs := newFlagSet("run", "-- <command> [args...]")
+	fs.Parse(args)
+	command := fs.Args()
+	if len(command) == 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	core := a.manager.GetProxyCore()
+	if !core.IsRunning() {
+		if a.cfg.Proxy.SubscriptionURL == "" {
+			fmt.Fprintln(os.Stderr, i18n.T("✗ Proxy is not configured, run: crosh proxy set <subscription-url>"))
+			os.Exit(exitConfig)
+		}
+
+		a.cfg.Proxy.Enabled = true
+		var err error
+		toStderr(func() {
+			if err = enableProxy(a.manager); err == nil {
+				a.cfg.Save()
+				startDaemon(a.cfg)
+			}
+		})
+		if err != nil {
+			os.Exit(exitCode(err))
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = resultOut
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for key, value := range core.GetProxyEnvVars() {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	// The command gets Ctrl-C itself; wait for it to exit rather than dying first
+	signal.Ignore(os.Interrupt)
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(127)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/api"
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+func runServe(a *app, args []string) {
+	fs := newFlagSet("serve", "")
+	listen := fs.String("listen", a.cfg.API.Listen, "address to listen on")
+	web := fs.Bool("web", a.cfg.API.Web, "also serve a web dashboard (api.web in the config turns it on by default)")
+	fs.Parse(args)
+
+	token := a.cfg.API.Token
+	tokenSource := i18n.T("api.token in ~/.crosh/config.yaml")
+	if token == "" {
+		var err error
+		tokenSource = "~/.crosh/api.token"
+		token, err = api.LoadOrCreateToken()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to load API token: %v\n"), err)
+			os.Exit(exitCode(err))
+		}
+	}
+
+	if !strings.HasPrefix(*listen, "127.0.0.1:") && !strings.HasPrefix(*listen, "localhost:") {
+		fmt.Printf(i18n.T("⚠ API is listening on %s, which may be reachable from other machines\n"), *listen)
+	}
+
+	fmt.Printf(i18n.T("✓ crosh API listening on http://%s\n"), *listen)
+	fmt.Printf(i18n.T("  Authenticate with: Authorization: Bearer <token from %s>\n"), tokenSource)
+
+	server := api.NewServer(*listen, token)
+	if *web {
+		server.EnableWeb()
+		fmt.Printf(i18n.T("✓ Web dashboard: %s\n"), dashboardURL(*listen, token))
+	}
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ API server stopped: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// dashboardURL returns the link to the web dashboard served on listen. The
+// token goes in the fragment, which browsers don't send to the server.
+func dashboardURL(listen, token string) string {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "http://" + listen + "/"
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/#token=" + url.QueryEscape(token)
+}
+
+func runDaemon(a *app, args []string) {
+	fs := newFlagSet("daemon", "")
+	service := fs.Bool("service", false, "keep running without work and start the proxy if it is enabled (used by crosh service)")
+	detach := fs.Bool("detach", false, "start the daemon in the background and return")
+	fs.Parse(args)
+
+	d := daemon.New()
+	if *service {
+		d = daemon.NewService()
+	}
+
+	if *detach {
+		var flags []string
+		if *service {
+			flags = append(flags, "--service")
+		}
+		if err := daemon.Start(flags...); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	if err := d.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Daemon failed: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+}
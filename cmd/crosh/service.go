@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/service"
+)
+
+func runServiceInstall(a *app, args []string) {
+	fs := newFlagSet("service install", "")
+	fs.Parse(args)
+
+	if err := service.Install(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to install the service: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+
+	status, err := service.GetStatus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Printf(i18n.T("✓ crosh service installed (%s: %s)\n"), i18n.T(status.Kind), status.Path)
+	fmt.Println(i18n.T("  The daemon now starts the proxy again after a reboot if it was on"))
+	fmt.Printf("  %s\n", i18n.T(service.Hint))
+}
+
+func runServiceUninstall(a *app, args []string) {
+	fs := newFlagSet("service uninstall", "")
+	fs.Parse(args)
+
+	status, err := service.GetStatus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	if !status.Installed {
+		fmt.Println(i18n.T("○ crosh service is not installed"))
+		return
+	}
+
+	if err := service.Uninstall(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to uninstall the service: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Println(i18n.T("✓ crosh service uninstalled"))
+	fmt.Println(i18n.T("  Acceleration stays as it is; after a reboot, run: crosh on"))
+}
+
+func runServiceStatus(a *app, args []string) {
+	fs := newFlagSet("service status", "")
+	fs.Parse(args)
+
+	status, err := service.GetStatus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	if status.Installed {
+		fmt.Printf(i18n.T("✓ Service: installed (%s: %s)\n"), i18n.T(status.Kind), status.Path)
+	} else {
+		fmt.Println(i18n.T("○ Service: not installed (run: crosh service install)"))
+	}
+	if daemon.IsRunning() {
+		fmt.Println(i18n.T("✓ Daemon: running"))
+	} else {
+		fmt.Println(i18n.T("○ Daemon: stopped"))
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+// shellHooks keep the proxy variables in sync with the proxy before each
+// prompt. They only unset variables they set themselves (marked by
+// CROSH_PROXY), so proxies the user sets by hand survive while crosh is off.
+var shellHooks = map[string]string{
+	"bash": `_crosh_hook() {
+  local out
+  out="$(command %[1]s env --shell bash 2>/dev/null)" || return
+  case "$out" in
+    export*) eval "$out"; export CROSH_PROXY=1 ;;
+    *) if [ -n "$CROSH_PROXY" ]; then eval "$out"; unset CROSH_PROXY; fi ;;
+  esac
+}
+case ";$PROMPT_COMMAND;" in
+  *";_crosh_hook;"*) ;;
+  *) PROMPT_COMMAND="_crosh_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+_crosh_hook
+`,
+	"zsh": `_crosh_hook() {
+  local out
+  out="$(command %[1]s env --shell zsh 2>/dev/null)" || return
+  case "$out" in
+    export*) eval "$out"; export CROSH_PROXY=1 ;;
+    *) if [ -n "$CROSH_PROXY" ]; then eval "$out"; unset CROSH_PROXY; fi ;;
+  esac
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd _crosh_hook
+_crosh_hook
+`,
+	"fish": `function __crosh_hook --on-event fish_prompt
+    set -l out (command %[1]s env --shell fish 2>/dev/null); or return
+    if string match -q 'set -gx *' -- $out[1]
+        printf '%%s\n' $out | source
+        set -gx CROSH_PROXY 1
+    else if set -q CROSH_PROXY
+        printf '%%s\n' $out | source
+        set -e CROSH_PROXY
+    end
+end
+__crosh_hook
+`,
+}
+
+func runInit(a *app, args []string) {
+	fs := newFlagSet("init", "<bash|zsh|fish>")
+	positional := parseInterspersed(fs, args)
+	requireArgs(fs, positional, 1)
+
+	hook, ok := shellHooks[positional[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Unsupported shell %q, use bash, zsh or fish\n"), positional[0])
+		os.Exit(exitUsage)
+	}
+
+	// Call crosh by its full path, so the hook works even if it isn't on PATH
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "crosh"
+	}
+	fmt.Printf(hook, shellQuote(exe))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+func runSubUpdate(a *app, args []string) {
+	fs := newFlagSet("sub update", "")
+	fs.Parse(args)
+
+	sub, err := a.manager.UpdateSubscription()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Printf(i18n.T("✓ Subscription updated: %d nodes\n"), len(sub.Nodes))
+	if !sub.Expire.IsZero() {
+		fmt.Printf(i18n.T("  Expires: %s (%d days left)\n"),
+			sub.Expire.Local().Format("2006-01-02"), int(time.Until(sub.Expire).Hours()/24))
+	}
+	fmt.Printf(i18n.T("  Cached for %s (proxy.subscription_max_age)\n"), a.cfg.Proxy.SubscriptionMaxAge)
+}
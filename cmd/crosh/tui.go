@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+	"github.com/boomyao/crosh/internal/term"
+)
+
+// tui is a full-screen terminal UI session, such as crosh dashboard.
+// Screens are drawn to resultOut, so they show even with --quiet.
+type tui struct {
+	// keys delivers key presses; it is closed when stdin is
+	keys    chan term.Key
+	restore func()
+	level   logging.Level
+}
+
+// startTUI switches the terminal to raw mode and the alternate screen, or
+// exits if crosh isn't running in a terminal
+func startTUI(command string) *tui {
+	if !term.IsTerminal(os.Stdin) || !term.IsTerminal(resultOut) {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ %s needs a terminal\n"), command)
+		os.Exit(1)
+	}
+
+	restore, err := term.MakeRaw()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	term.EnableVT()
+	fmt.Fprint(resultOut, term.EnterAltScreen)
+
+	// Verbose details would be printed over the screen
+	t := &tui{keys: make(chan term.Key), restore: restore, level: logging.GetLevel()}
+	if t.level == logging.Verbose {
+		logging.SetLevel(logging.Normal)
+	}
+
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			key, err := term.ReadKey(reader)
+			if err != nil {
+				close(t.keys)
+				return
+			}
+			t.keys <- key
+		}
+	}()
+	return t
+}
+
+// stop restores the terminal
+func (t *tui) stop() {
+	fmt.Fprint(resultOut, term.ExitAltScreen)
+	t.restore()
+	logging.SetLevel(t.level)
+}
+
+// screen builds a frame line by line, fitting each line to the terminal width
+type screen struct {
+	b     strings.Builder
+	width int
+}
+
+// newScreen starts a frame for a terminal width columns wide
+func newScreen(width int) *screen {
+	s := &screen{width: width}
+	s.b.WriteString(term.Home)
+	return s
+}
+
+// line adds a line of text shown in style, e.g. term.Dim, or "" for plain text
+func (s *screen) line(style, text string) {
+	s.b.WriteString(style + term.Truncate(text, s.width) + term.Reset + term.ClearLine + "\r\n")
+}
+
+// draw clears what is left of the previous frame and shows this one
+func (s *screen) draw() {
+	s.b.WriteString(term.ClearBelow)
+	fmt.Fprint(resultOut, s.b.String())
+}
+
+// captureOutput runs fn with what it prints to stdout and stderr captured,
+// rather than printed over the screen, and returns the non-empty lines
+func captureOutput(fn func()) []string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		fn()
+		return nil
+	}
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		r.Close()
+		done <- data
+	}()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+	fn()
+	w.Close()
+
+	lines := []string{}
+	for _, line := range strings.Split(string(<-done), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
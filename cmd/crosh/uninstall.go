@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+	"github.com/boomyao/crosh/internal/mirror"
+	"github.com/boomyao/crosh/internal/service"
+)
+
+var (
+	// shellHookLine matches the lines of a shell startup file that run crosh
+	// init or crosh env
+	shellHookLine = regexp.MustCompile(`\bcrosh(\.exe)?["']?\s+(init|env)\b`)
+	// documentedHook matches the lines crosh init and crosh env tell users to
+	// add, as they are written; only these are removed, as taking out a line
+	// the user wrapped, e.g. in an if, could break the file
+	documentedHook = regexp.MustCompile(`^(eval "\$\(crosh (env|init (bash|zsh))\)"|crosh (env|init fish) \| source)\s*$`)
+)
+
+func runUninstall(a *app, args []string) {
+	fs := newFlagSet("uninstall", "")
+	yes := fs.Bool("yes", false, "don't ask for confirmation")
+	fs.BoolVar(yes, "y", false, "shorthand for --yes")
+	force := fs.Bool("force", false, "delete the originals of config files crosh couldn't put back, too")
+	fs.Parse(args)
+
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	rcFiles := shellHookFiles()
+
+	if !*yes {
+		fmt.Println(i18n.T("This turns acceleration off, undoes the package manager settings crosh changed and removes:"))
+		fmt.Printf(i18n.T("  • %s (config, subscription cache, proxy cores, geo data and logs)\n"), dir)
+		if status, err := service.GetStatus(); err == nil && status.Installed {
+			fmt.Printf(i18n.T("  • the crosh service (%s)\n"), status.Path)
+		}
+		for _, path := range rcFiles {
+			fmt.Printf(i18n.T("  • crosh lines in %s\n"), path)
+		}
+		fmt.Print(i18n.T("Continue? [y/N] "))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Println(i18n.T("Nothing was removed"))
+			return
+		}
+		fmt.Println()
+	}
+
+	// The service daemon would start the proxy again, so it goes first
+	if status, err := service.GetStatus(); err == nil && status.Installed {
+		if err := service.Uninstall(); err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("⚠ Failed to uninstall the service: %v\n"), err)
+		} else {
+			fmt.Println(i18n.T("✓ crosh service uninstalled"))
+		}
+	}
+	if err := daemon.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: %v\n"), err)
+	}
+
+	// Tools turned off in the config may still have a mirror from before
+	a.cfg.Mirror.Tools = config.DefaultConfig().Mirror.Tools
+	if err := a.manager.DisableMirrors(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to disable mirrors: %v\n"), err)
+	}
+	if err := a.manager.DisableProxy(); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: Failed to disable proxy: %v\n"), err)
+	} else {
+		fmt.Println(i18n.T("✓ Proxy stopped"))
+	}
+
+	for _, path := range rcFiles {
+		removed, left, err := removeShellHooks(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.T("⚠ Failed to remove crosh lines from %s: %v\n"), path, err)
+			continue
+		}
+		if removed > 0 {
+			fmt.Printf(i18n.T("✓ Removed crosh lines from %s\n"), path)
+		}
+		if len(left) > 0 {
+			fmt.Printf(i18n.T("⚠ Remove these crosh lines from %s by hand:\n"), path)
+			for _, line := range left {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	// Originals crosh couldn't put back, as the file changed since, live in
+	// the directory about to be removed
+	if backups, err := mirror.Backups(); err == nil && len(backups) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("⚠ crosh still holds the originals of these files, as they changed since crosh edited them:"))
+		for _, backup := range backups {
+			fmt.Fprintf(os.Stderr, "    %s\n", backup.Path)
+		}
+		if !*force {
+			fmt.Fprintln(os.Stderr, i18n.T("  Put them back with: crosh restore --force, or delete them with: crosh uninstall --force"))
+			os.Exit(exitPartial)
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to remove %s: %v\n"), dir, err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Printf(i18n.T("✓ Removed %s\n"), dir)
+
+	// A core installed by hand elsewhere isn't crosh's to delete
+	if core := a.cfg.Proxy.XrayPath; core != "" && !strings.HasPrefix(core, dir+string(filepath.Separator)) {
+		fmt.Printf(i18n.T("  Left %s in place\n"), core)
+	}
+
+	fmt.Println(i18n.T("\n✓ crosh uninstalled"))
+	fmt.Println(i18n.T("  Shells that are still open keep the proxy variables; to remove them, run:"))
+	for _, line := range envCommands(detectShell(), a.manager.GetProxyCore().GetProxyEnvVars(), true) {
+		fmt.Printf("    %s\n", line)
+	}
+	if exe, err := os.Executable(); err == nil {
+		fmt.Printf(i18n.T("  To remove crosh itself, delete: %s\n"), exe)
+	}
+}
+
+// shellHookFiles returns the shell startup files with crosh init or crosh env lines
+func shellHookFiles() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	files := []string{}
+	for _, name := range []string{".bashrc", ".bash_profile", ".profile", ".zshrc", filepath.Join(".config", "fish", "config.fish")} {
+		path := filepath.Join(home, name)
+		data, err := os.ReadFile(path)
+		if err == nil && shellHookLine.Match(data) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// removeShellHooks removes the crosh init and crosh env lines written as
+// documented from a shell startup file. It returns how many it removed and
+// the other lines that run crosh init or crosh env, with their line numbers.
+func removeShellHooks(path string) (int, []string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var kept, left []string
+	removed := 0
+	for i, line := range strings.SplitAfter(string(data), "\n") {
+		switch {
+		case documentedHook.MatchString(line):
+			removed++
+			continue
+		case shellHookLine.MatchString(line):
+			left = append(left, fmt.Sprintf("%d: %s", i+1, strings.TrimRight(line, "\r\n")))
+		}
+		kept = append(kept, line)
+	}
+	if removed == 0 {
+		return 0, left, nil
+	}
+	return removed, left, logging.WriteFile(path, []byte(strings.Join(kept, "")), info.Mode().Perm())
+}
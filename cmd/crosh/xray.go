@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/proxy"
+)
+
+func runXrayVersion(a *app, args []string) {
+	fs := newFlagSet("xray version", "")
+	fs.Parse(args)
+
+	xray := a.manager.GetXrayManager()
+	installed, err := xray.InstalledVersion()
+	if err != nil {
+		installed = fmt.Sprintf(i18n.T("unknown (%v)"), err)
+		if _, statErr := os.Stat(a.cfg.Proxy.XrayPath); os.IsNotExist(statErr) {
+			installed = i18n.T("not installed")
+		}
+	}
+	fmt.Printf(i18n.T("Installed: %s\n"), installed)
+
+	if pinned := xray.PinnedVersion(); pinned != "" {
+		fmt.Printf(i18n.T("Pinned:    %s (proxy.xray_version)\n"), pinned)
+	}
+
+	latest, err := xray.LatestVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Printf(i18n.T("Latest:    %s\n"), latest)
+
+	if xray.PinnedVersion() == "" && proxy.NewerVersion(latest, installed) {
+		fmt.Println(i18n.T("\nUpgrade with: crosh xray upgrade"))
+	}
+}
+
+func runXrayUpgrade(a *app, args []string) {
+	fs := newFlagSet("xray upgrade", "[version]")
+	pin := fs.Bool("pin", false, "pin this version in proxy.xray_version, so new downloads use it too")
+	positional := parseInterspersed(fs, args)
+	if len(positional) > 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	xray := a.manager.GetXrayManager()
+	target := xray.PinnedVersion()
+	if len(positional) == 1 {
+		target = "v" + strings.TrimPrefix(positional[0], "v")
+	}
+	if target == "" {
+		latest, err := xray.LatestVersion()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		target = latest
+	}
+
+	if *pin {
+		a.cfg.Proxy.XrayVersion = target
+		saveProxySettings(a)
+		xray = a.manager.GetXrayManager()
+	}
+
+	if installed, err := xray.InstalledVersion(); err == nil && installed == target {
+		fmt.Printf(i18n.T("✓ Xray-core %s is already installed\n"), target)
+		return
+	}
+
+	if err := xray.Upgrade(target); err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("✗ Failed to upgrade Xray-core: %v\n"), err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Printf(i18n.T("✓ Xray-core %s installed\n"), target)
+}
@@ -2,4 +2,7 @@ module github.com/boomyao/crosh
 
 go 1.21
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/crypto v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
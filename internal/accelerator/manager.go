@@ -1,336 +1,2437 @@
 package accelerator
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
 	"github.com/boomyao/crosh/internal/mirror"
 	"github.com/boomyao/crosh/internal/proxy"
 )
 
+// bandwidthCandidates is how many of the lowest-latency nodes are speed-tested
+// when selecting by bandwidth
+const bandwidthCandidates = 5
+
+// PartialError is returned when some steps of an operation failed and the
+// others took effect, such as some of the mirrors
+type PartialError struct {
+	Err error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
 // Manager orchestrates mirror and proxy acceleration
 type Manager struct {
 	config *config.Config
-	xray   *proxy.XrayManager
+	core   proxy.ProxyCore
+	// xray is the Xray-core manager, also when another core is used, for the
+	// geo data and Xray-core upgrades
+	xray *proxy.XrayManager
 }
 
 // NewManager creates a new acceleration manager
 func NewManager(cfg *config.Config) *Manager {
-	xray := proxy.NewXrayManager(cfg.Proxy.XrayPath, cfg.Proxy.LocalPort)
+	inbound := proxy.Inbound{
+		Listen:    cfg.Proxy.Listen,
+		SocksPort: cfg.Proxy.LocalPort,
+		HTTPPort:  cfg.Proxy.HTTPPort,
+		StatsPort: cfg.Proxy.StatsPort,
+		Username:  cfg.Proxy.Auth.Username,
+		Password:  cfg.Proxy.Auth.Password,
+		TUN: proxy.TUN{
+			Enabled: cfg.Proxy.TUN.Enabled,
+			Name:    cfg.Proxy.TUN.Name,
+			MTU:     cfg.Proxy.TUN.MTU,
+		},
+	}
+	rules := make([]proxy.Rule, len(cfg.Proxy.Rules))
+	for i, rule := range cfg.Proxy.Rules {
+		rules[i] = proxy.Rule(rule)
+	}
+	opts := proxy.Options{
+		Inbound:     inbound,
+		Upstream:    cfg.Proxy.Upstream,
+		Rules:       rules,
+		AlwaysProxy: cfg.Proxy.AlwaysProxy,
+		DNS: proxy.DNS{
+			Enabled:  cfg.Proxy.DNS.Enabled,
+			Servers:  cfg.Proxy.DNS.Servers,
+			Domestic: cfg.Proxy.DNS.Domestic,
+		},
+		Sniffing: proxy.Sniffing{
+			Enabled: cfg.Proxy.Sniffing.Enabled,
+			FakeDNS: cfg.Proxy.Sniffing.FakeDNS,
+		},
+		GeoData: proxy.GeoData{
+			Variant: cfg.Proxy.GeoData.Variant,
+			GeoIP:   cfg.Proxy.GeoData.GeoIPURLs,
+			GeoSite: cfg.Proxy.GeoData.GeoSiteURLs,
+		},
+		XrayVersion: cfg.Proxy.XrayVersion,
+		ClashRules:  cfg.Proxy.ClashRules,
+		Log: proxy.Log{
+			Level:    cfg.Proxy.Log.Level,
+			MaxSize:  int64(cfg.Proxy.Log.MaxSizeMB) << 20,
+			MaxAge:   cfg.Proxy.Log.MaxAge,
+			MaxFiles: cfg.Proxy.Log.MaxFiles,
+		},
+	}
+
+	core, err := proxy.NewProxyCore(cfg.Proxy.Core, cfg.Proxy.XrayPath, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, i18n.T("Warning: %v, using xray\n"), err)
+		core = proxy.NewXrayManager(cfg.Proxy.XrayPath, opts)
+	}
+	xray, ok := core.(*proxy.XrayManager)
+	if !ok {
+		xray = proxy.NewXrayManager(cfg.Proxy.XrayPath, opts)
+	}
 
 	return &Manager{
 		config: cfg,
+		core:   core,
 		xray:   xray,
 	}
 }
 
-// EnableMirrors enables all configured mirrors
-func (m *Manager) EnableMirrors() error {
+// MirrorNames are the names of the mirrors, as crosh on and crosh off take them
+var MirrorNames = []string{"npm", "yarn", "pnpm", "pip", "pyenv", "apt", "apk", "pacman", "cargo", "go", "maven", "gradle", "sbt", "sdkman", "composer", "nuget", "conan", "conda", "huggingface", "cran", "cpan", "texlive", "hex", "opam", "luarocks", "haskell", "nix", "bazel", "deno", "bun", "node", "electron", "binaries", "browsers", "cocoapods", "helm", "kubernetes", "minikube", "terraform", "winget", "scoop", "msys2", "git", "docker"}
+
+// selected reports whether the mirror with the given name is one of names,
+// or names is empty, and isn't turned off in the config
+func (m *Manager) selected(names []string, name string) bool {
+	return m.config.Mirror.Tools.Enabled(name) && (len(names) == 0 || slices.Contains(names, name))
+}
+
+// printToolsOff prints which of names are turned off in the config and so are left alone
+func (m *Manager) printToolsOff(names []string) {
+	for _, name := range names {
+		if !m.config.Mirror.Tools.Enabled(name) {
+			fmt.Printf(i18n.T("○ %s mirror is turned off in config, skipped (crosh config set mirror.tools.%s true)\n"), name, name)
+		}
+	}
+}
+
+// printKept prints the settings of the user's that Enable kept in place of
+// the mirror of tool, reporting whether there were any
+func printKept(tool string, found []mirror.Conflict) bool {
+	kept := false
+	for _, conflict := range found {
+		if conflict.Action == mirror.ConflictKept {
+			fmt.Printf(i18n.T("○ %s mirror skipped: kept your %s in %s\n"), tool, conflict.Setting, conflict.Path)
+			kept = true
+		}
+	}
+	return kept
+}
+
+// printChanged prints the settings of the user's that Enable kept next to
+// the mirror or replaced with it
+func printChanged(found []mirror.Conflict) {
+	for _, conflict := range found {
+		switch conflict.Action {
+		case mirror.ConflictMerged:
+			fmt.Printf(i18n.T("  Kept your %s next to it\n"), conflict.Setting)
+		case mirror.ConflictReplaced:
+			fmt.Printf(i18n.T("  Replaced your %s; crosh off puts it back\n"), conflict.Setting)
+		}
+	}
+}
+
+// EnableMirrors enables the configured mirrors with the given names, or all of
+// them, and returns the names of those it enabled. If some fail it returns a
+// PartialError, and a plain error if none was enabled.
+func (m *Manager) EnableMirrors(names ...string) ([]string, error) {
 	if !m.config.Mirror.Enabled {
-		return fmt.Errorf("mirrors are not enabled in config")
+		return nil, &config.Error{Err: fmt.Errorf("mirrors are not enabled in config")}
 	}
 
+	m.printToolsOff(names)
 	var errors []error
+	kept := false
+	// enabled are the mirrors set up, leaving out those where the user's own
+	// settings were kept
+	var enabled []string
+	enable := func(name string, tool interface{ Enable() error }) error {
+		err := tool.Enable()
+		if err == nil {
+			enabled = append(enabled, name)
+		}
+		return err
+	}
+	skip := func(name string, found []mirror.Conflict) bool {
+		if !printKept(name, found) {
+			return false
+		}
+		enabled = slices.DeleteFunc(enabled, func(tool string) bool { return tool == name })
+		return true
+	}
 
 	// Enable NPM mirror
-	if m.config.Mirror.NPM != "" {
+	if m.config.Mirror.NPM != "" && m.selected(names, "npm") {
 		npm := mirror.NewNPMMirror(m.config.Mirror.NPM)
-		if err := npm.Enable(); err != nil {
-			errors = append(errors, fmt.Errorf("NPM mirror: %w", err))
+		npm.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("npm", npm); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("NPM mirror: %w"), err))
+		} else if skip("npm", npm.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ NPM mirror enabled:"), m.config.Mirror.NPM)
+			printChanged(npm.Conflicts())
+		}
+	}
+
+	// Enable Yarn mirror (if Yarn is installed, unless asked for by name)
+	if m.config.Mirror.NPM != "" && m.selected(names, "yarn") && (len(names) > 0 || mirror.HasYarn()) {
+		yarn := mirror.NewYarnMirror(m.config.Mirror.NPM)
+		yarn.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("yarn", yarn); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Yarn mirror: %w"), err))
+		} else if skip("yarn", yarn.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Yarn mirror enabled:"), m.config.Mirror.NPM)
+			printChanged(yarn.Conflicts())
+		}
+	}
+
+	// Enable pnpm mirror (if pnpm is installed, unless asked for by name)
+	if m.config.Mirror.NPM != "" && m.selected(names, "pnpm") && (len(names) > 0 || mirror.HasPnpm()) {
+		pnpm := mirror.NewPnpmMirror(m.config.Mirror.NPM)
+		pnpm.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("pnpm", pnpm); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("pnpm mirror: %w"), err))
+		} else if skip("pnpm", pnpm.Conflicts()) {
+			kept = true
 		} else {
-			fmt.Println("✓ NPM mirror enabled:", m.config.Mirror.NPM)
+			fmt.Println(i18n.T("✓ pnpm mirror enabled:"), m.config.Mirror.NPM)
+			printChanged(pnpm.Conflicts())
 		}
 	}
 
 	// Enable Pip mirror
-	if m.config.Mirror.Pip != "" {
+	if m.config.Mirror.Pip != "" && m.selected(names, "pip") {
 		pip := mirror.NewPipMirror(m.config.Mirror.Pip)
-		if err := pip.Enable(); err != nil {
-			errors = append(errors, fmt.Errorf("Pip mirror: %w", err))
+		pip.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("pip", pip); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Pip mirror: %w"), err))
+		} else if skip("pip", pip.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Pip mirror enabled:"), m.config.Mirror.Pip)
+			printChanged(pip.Conflicts())
+		}
+	}
+
+	// Enable pyenv mirror (if pyenv is installed, unless asked for by name)
+	if m.config.Mirror.Pyenv != "" && m.selected(names, "pyenv") && (len(names) > 0 || mirror.HasPyenv()) {
+		pyenv := mirror.NewPyenvMirror(m.config.Mirror.Pyenv)
+		pyenv.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("pyenv", pyenv); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("pyenv mirror: %w"), err))
+		} else if skip("pyenv", pyenv.Conflicts()) {
+			kept = true
 		} else {
-			fmt.Println("✓ Pip mirror enabled:", m.config.Mirror.Pip)
+			fmt.Println(i18n.T("✓ pyenv mirror enabled:"), m.config.Mirror.Pyenv)
+			printChanged(pyenv.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "pyenv install")
 		}
 	}
 
 	// Enable Apt mirror (Linux only)
-	if m.config.Mirror.Apt != "" {
+	if m.config.Mirror.Apt != "" && m.selected(names, "apt") {
 		apt := mirror.NewAptMirror(m.config.Mirror.Apt)
-		if err := apt.Enable(); err != nil {
+		if err := enable("apt", apt); err != nil {
 			// Don't fail on apt error (might not be Linux)
-			fmt.Printf("⚠ Apt mirror skipped: %v\n", err)
+			fmt.Printf(i18n.T("⚠ Apt mirror skipped: %v\n"), err)
+		} else {
+			fmt.Println(i18n.T("✓ Apt mirror enabled:"), m.config.Mirror.Apt)
+		}
+	}
+
+	// Enable apk mirror (Alpine only, unless asked for by name)
+	if m.config.Mirror.Apk != "" && m.selected(names, "apk") && (len(names) > 0 || mirror.IsAlpine()) {
+		apk := mirror.NewApkMirror(m.config.Mirror.Apk)
+		if err := enable("apk", apk); err != nil {
+			// Don't fail on apk error (most systems aren't Alpine)
+			fmt.Printf(i18n.T("⚠ Apk mirror skipped: %v\n"), err)
+		} else {
+			fmt.Println(i18n.T("✓ Apk mirror enabled:"), m.config.Mirror.Apk)
+		}
+	}
+
+	// Enable pacman mirror (Arch only, unless asked for by name)
+	if m.config.Mirror.Pacman != "" && m.selected(names, "pacman") && (len(names) > 0 || mirror.IsArch()) {
+		pacman := mirror.NewPacmanMirror(m.config.Mirror.Pacman, m.config.Mirror.ArchLinuxCN)
+		if err := enable("pacman", pacman); err != nil {
+			fmt.Printf(i18n.T("⚠ Pacman mirror skipped: %v\n"), err)
 		} else {
-			fmt.Println("✓ Apt mirror enabled:", m.config.Mirror.Apt)
+			fmt.Println(i18n.T("✓ Pacman mirror enabled:"), m.config.Mirror.Pacman)
+			if m.config.Mirror.ArchLinuxCN {
+				fmt.Println(i18n.T("  With archlinuxcn; its packages need its keys: sudo pacman -Sy archlinuxcn-keyring"))
+			}
 		}
 	}
 
 	// Enable Cargo mirror
-	if m.config.Mirror.Cargo != "" {
-		cargo := mirror.NewCargoMirror(m.config.Mirror.Cargo)
-		if err := cargo.Enable(); err != nil {
-			errors = append(errors, fmt.Errorf("Cargo mirror: %w", err))
+	if m.config.Mirror.Cargo != "" && m.selected(names, "cargo") {
+		cargo := mirror.NewCargoMirror(m.config.Mirror.Cargo, m.config.Mirror.Rustup)
+		cargo.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("cargo", cargo); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Cargo mirror: %w"), err))
+		} else if skip("cargo", cargo.Conflicts()) {
+			kept = true
 		} else {
-			fmt.Println("✓ Cargo mirror enabled:", m.config.Mirror.Cargo)
+			fmt.Println(i18n.T("✓ Cargo mirror enabled:"), m.config.Mirror.Cargo)
+			if m.config.Mirror.Rustup != "" {
+				fmt.Printf(i18n.T("  Additional: %s\n"), m.config.Mirror.Rustup)
+			}
+			printChanged(cargo.Conflicts())
 		}
 	}
 
 	// Enable Go proxy
-	if m.config.Mirror.Go != "" {
-		goMirror := mirror.NewGoMirror(m.config.Mirror.Go)
-		if err := goMirror.Enable(); err != nil {
-			errors = append(errors, fmt.Errorf("Go proxy: %w", err))
+	if m.config.Mirror.Go != "" && m.selected(names, "go") {
+		goMirror := mirror.NewGoMirror(m.config.Mirror.Go, m.config.Mirror.GoSumDB, m.config.Mirror.GoPrivate, m.config.Mirror.GoNoSumDB)
+		goMirror.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("go", goMirror); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Go proxy: %w"), err))
+		} else if skip("go", goMirror.Conflicts()) {
+			kept = true
 		} else {
-			fmt.Println("✓ Go proxy enabled:", m.config.Mirror.Go)
+			fmt.Println(i18n.T("✓ Go proxy enabled:"), m.config.Mirror.Go)
+			if m.config.Mirror.GoSumDB != "" {
+				fmt.Printf(i18n.T("  Additional: %s\n"), m.config.Mirror.GoSumDB)
+			}
+			printChanged(goMirror.Conflicts())
 		}
 	}
 
-	// Enable Docker registry mirrors
-	dockerEnabled := false
-	if len(m.config.Mirror.Docker) > 0 {
-		dockerMirror := mirror.NewDockerMirror(m.config.Mirror.Docker)
-		if err := dockerMirror.Enable(); err != nil {
-			errors = append(errors, fmt.Errorf("Docker mirror: %w", err))
+	// Enable Maven mirror
+	if m.config.Mirror.Maven != "" && m.selected(names, "maven") {
+		maven := mirror.NewMavenMirror(m.config.Mirror.Maven)
+		maven.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("maven", maven); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Maven mirror: %w"), err))
+		} else if skip("maven", maven.Conflicts()) {
+			kept = true
 		} else {
-			dockerEnabled = true
-			// Format display string (remove https:// prefix for cleaner output)
-			displayRegistries := make([]string, len(m.config.Mirror.Docker))
-			for i, reg := range m.config.Mirror.Docker {
-				displayRegistries[i] = reg
-			}
-			fmt.Printf("✓ Docker mirror enabled: %s\n", displayRegistries[0])
-			if len(displayRegistries) > 1 {
-				for _, reg := range displayRegistries[1:] {
-					fmt.Printf("  Additional: %s\n", reg)
-				}
+			fmt.Println(i18n.T("✓ Maven mirror enabled:"), m.config.Mirror.Maven)
+			printChanged(maven.Conflicts())
+		}
+	}
+
+	// Enable Gradle mirrors
+	if gradle := m.config.Mirror.Gradle; gradle.Central != "" && m.selected(names, "gradle") {
+		gradleMirror := mirror.NewGradleMirror(gradle.Central, gradle.Google, gradle.Plugins, gradle.Distributions)
+		if err := enable("gradle", gradleMirror); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Gradle mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Gradle mirror enabled:"), gradle.Central)
+			if gradle.Distributions != "" {
+				fmt.Println(i18n.T("  Run 'crosh mirrors gradle-wrapper' in a project to download Gradle from the mirror too"))
 			}
 		}
 	}
 
-	if len(errors) > 0 {
-		fmt.Printf("\n%d errors occurred:\n", len(errors))
-		for _, err := range errors {
-			fmt.Printf("  - %v\n", err)
+	// Enable sbt mirrors (if sbt has been used, unless asked for by name)
+	if sbt := m.config.Mirror.Sbt; sbt.Maven != "" && m.selected(names, "sbt") && (len(names) > 0 || mirror.HasSbt()) {
+		sbtMirror := mirror.NewSbtMirror(sbt.Maven, sbt.Ivy)
+		sbtMirror.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("sbt", sbtMirror); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("sbt mirror: %w"), err))
+		} else if skip("sbt", sbtMirror.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ sbt mirror enabled:"), sbt.Maven)
+			printChanged(sbtMirror.Conflicts())
+			// Resolvers a build adds itself win over ~/.sbt/repositories otherwise
+			fmt.Println(i18n.T("  Builds with their own resolvers use it with: sbt -Dsbt.override.build.repos=true"))
 		}
-		return fmt.Errorf("some mirrors failed to enable")
 	}
 
-	// Show Docker restart instructions if Docker was enabled
-	if dockerEnabled {
-		m.printDockerRestartInstructions()
+	// Enable SDKMAN mirror (if SDKMAN is installed, unless asked for by name)
+	if m.config.Mirror.Sdkman != "" && m.selected(names, "sdkman") && (len(names) > 0 || mirror.HasSdkman()) {
+		sdkman := mirror.NewSdkmanMirror(m.config.Mirror.Sdkman)
+		sdkman.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("sdkman", sdkman); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("SDKMAN mirror: %w"), err))
+		} else if skip("sdkman", sdkman.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ SDKMAN mirror enabled:"), m.config.Mirror.Sdkman)
+			printChanged(sdkman.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "sdk")
+		}
 	}
 
-	return nil
-}
+	// Enable Composer mirror
+	if m.config.Mirror.Composer != "" && m.selected(names, "composer") {
+		composer := mirror.NewComposerMirror(m.config.Mirror.Composer)
+		composer.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("composer", composer); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Composer mirror: %w"), err))
+		} else if skip("composer", composer.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Composer mirror enabled:"), m.config.Mirror.Composer)
+			printChanged(composer.Conflicts())
+		}
+	}
 
-// DisableMirrors disables all mirrors
-func (m *Manager) DisableMirrors() error {
-	var errors []error
+	// Enable NuGet mirror
+	if m.config.Mirror.NuGet != "" && m.selected(names, "nuget") {
+		nuget := mirror.NewNuGetMirror(m.config.Mirror.NuGet)
+		if err := enable("nuget", nuget); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("NuGet mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ NuGet mirror enabled:"), m.config.Mirror.NuGet)
+			printChanged(nuget.Conflicts())
+		}
+	}
 
-	// Disable NPM mirror
-	npm := mirror.NewNPMMirror("")
-	if err := npm.Disable(); err != nil {
-		errors = append(errors, fmt.Errorf("NPM mirror: %w", err))
-	} else {
-		fmt.Println("✓ NPM mirror disabled")
+	// Enable Conan mirror (if Conan is installed, unless asked for by name)
+	if m.config.Mirror.Conan != "" && m.selected(names, "conan") && (len(names) > 0 || mirror.HasConan()) {
+		conan := mirror.NewConanMirror(m.config.Mirror.Conan)
+		conan.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("conan", conan); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Conan mirror: %w"), err))
+		} else if skip("conan", conan.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Conan mirror enabled:"), m.config.Mirror.Conan)
+			printChanged(conan.Conflicts())
+		}
 	}
 
-	// Disable Pip mirror
-	pip := mirror.NewPipMirror("")
-	if err := pip.Disable(); err != nil {
-		errors = append(errors, fmt.Errorf("Pip mirror: %w", err))
-	} else {
-		fmt.Println("✓ Pip mirror disabled")
+	// Enable conda mirror
+	if m.config.Mirror.Conda != "" && m.selected(names, "conda") {
+		conda := mirror.NewCondaMirror(m.config.Mirror.Conda)
+		conda.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("conda", conda); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Conda mirror: %w"), err))
+		} else if skip("conda", conda.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Conda mirror enabled:"), m.config.Mirror.Conda)
+			printChanged(conda.Conflicts())
+		}
 	}
 
-	// Disable Apt mirror
-	apt := mirror.NewAptMirror("")
-	if err := apt.Disable(); err != nil {
-		fmt.Printf("⚠ Apt mirror skipped: %v\n", err)
-	} else {
-		fmt.Println("✓ Apt mirror disabled")
+	// Enable Hugging Face mirror (if the Hugging Face CLI or cache is there, unless asked for by name)
+	if m.config.Mirror.HuggingFace != "" && m.selected(names, "huggingface") && (len(names) > 0 || mirror.HasHuggingFace()) {
+		huggingface := mirror.NewHuggingFaceMirror(m.config.Mirror.HuggingFace)
+		huggingface.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("huggingface", huggingface); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Hugging Face mirror: %w"), err))
+		} else if skip("huggingface", huggingface.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Hugging Face mirror enabled:"), m.config.Mirror.HuggingFace)
+			printChanged(huggingface.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "huggingface_hub")
+		}
 	}
 
-	// Disable Cargo mirror
-	cargo := mirror.NewCargoMirror("")
-	if err := cargo.Disable(); err != nil {
-		errors = append(errors, fmt.Errorf("Cargo mirror: %w", err))
-	} else {
-		fmt.Println("✓ Cargo mirror disabled")
+	// Enable CRAN mirror (if R is installed, unless asked for by name)
+	if m.config.Mirror.CRAN != "" && m.selected(names, "cran") && (len(names) > 0 || mirror.HasR()) {
+		cran := mirror.NewCRANMirror(m.config.Mirror.CRAN, m.config.Mirror.Bioconductor)
+		cran.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("cran", cran); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("CRAN mirror: %w"), err))
+		} else if skip("cran", cran.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ CRAN mirror enabled:"), m.config.Mirror.CRAN)
+			printChanged(cran.Conflicts())
+		}
 	}
 
-	// Disable Go proxy
-	goMirror := mirror.NewGoMirror("")
-	if err := goMirror.Disable(); err != nil {
-		errors = append(errors, fmt.Errorf("Go proxy: %w", err))
-	} else {
-		fmt.Println("✓ Go proxy disabled")
+	// Enable CPAN mirror (if cpanm or cpan is installed, unless asked for by name)
+	if m.config.Mirror.CPAN != "" && m.selected(names, "cpan") && (len(names) > 0 || mirror.HasCPAN()) {
+		cpan := mirror.NewCPANMirror(m.config.Mirror.CPAN)
+		cpan.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("cpan", cpan); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("CPAN mirror: %w"), err))
+		} else if skip("cpan", cpan.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ CPAN mirror enabled:"), m.config.Mirror.CPAN)
+			printChanged(cpan.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "cpanm")
+		}
 	}
 
-	// Disable Docker registry mirrors
-	dockerMirror := mirror.NewDockerMirror(nil)
-	if err := dockerMirror.Disable(); err != nil {
-		errors = append(errors, fmt.Errorf("Docker mirror: %w", err))
-	} else {
-		fmt.Println("✓ Docker mirror disabled")
+	// Enable TeX Live mirror (if tlmgr is installed, unless asked for by name)
+	if m.config.Mirror.TeXLive != "" && m.selected(names, "texlive") && (len(names) > 0 || mirror.HasTeXLive()) {
+		texlive := mirror.NewTeXLiveMirror(m.config.Mirror.TeXLive)
+		texlive.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("texlive", texlive); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("TeX Live mirror: %w"), err))
+		} else if skip("texlive", texlive.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ TeX Live mirror enabled:"), m.config.Mirror.TeXLive)
+			printChanged(texlive.Conflicts())
+		}
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("some mirrors failed to disable")
+	// Enable Hex mirror (if Mix is installed, unless asked for by name)
+	if m.config.Mirror.Hex != "" && m.selected(names, "hex") && (len(names) > 0 || mirror.HasHex()) {
+		hex := mirror.NewHexMirror(m.config.Mirror.Hex)
+		hex.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("hex", hex); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Hex mirror: %w"), err))
+		} else if skip("hex", hex.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Hex mirror enabled:"), m.config.Mirror.Hex)
+			printChanged(hex.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "mix")
+		}
 	}
 
-	return nil
-}
+	// Enable opam repository mirror (if opam is installed, unless asked for by name)
+	if m.config.Mirror.Opam != "" && m.selected(names, "opam") && (len(names) > 0 || mirror.HasOpam()) {
+		opam := mirror.NewOpamMirror(m.config.Mirror.Opam)
+		opam.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("opam", opam); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("opam mirror: %w"), err))
+		} else if skip("opam", opam.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ opam mirror enabled:"), m.config.Mirror.Opam)
+			printChanged(opam.Conflicts())
+			fmt.Println(i18n.T("  Run 'opam update' to fetch the packages from the mirror"))
+		}
+	}
 
-// GetMirrorStatus returns the status of all mirrors
-func (m *Manager) GetMirrorStatus() map[string]string {
-	status := make(map[string]string)
+	// Enable LuaRocks mirror (if LuaRocks is installed, unless asked for by name)
+	if m.config.Mirror.LuaRocks != "" && m.selected(names, "luarocks") && (len(names) > 0 || mirror.HasLuaRocks()) {
+		luarocks := mirror.NewLuaRocksMirror(m.config.Mirror.LuaRocks)
+		luarocks.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("luarocks", luarocks); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("LuaRocks mirror: %w"), err))
+		} else if skip("luarocks", luarocks.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ LuaRocks mirror enabled:"), m.config.Mirror.LuaRocks)
+			printChanged(luarocks.Conflicts())
+		}
+	}
 
-	// NPM status
-	npm := mirror.NewNPMMirror(m.config.Mirror.NPM)
-	if enabled, url, err := npm.Status(); err == nil {
-		if enabled {
-			status["NPM"] = url
+	// Enable Haskell mirrors (if Stack or cabal is installed, unless asked for by name)
+	if m.config.Mirror.Haskell.Hackage != "" && m.selected(names, "haskell") && (len(names) > 0 || mirror.HasHaskell()) {
+		haskell := mirror.NewHaskellMirror(m.config.Mirror.Haskell.Hackage, m.config.Mirror.Haskell.Stackage)
+		haskell.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("haskell", haskell); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Haskell mirror: %w"), err))
+		} else if skip("haskell", haskell.Conflicts()) {
+			kept = true
 		} else {
-			status["NPM"] = "disabled"
+			fmt.Println(i18n.T("✓ Haskell mirror enabled:"), m.config.Mirror.Haskell.Hackage)
+			printChanged(haskell.Conflicts())
 		}
 	}
 
-	// Pip status
-	pip := mirror.NewPipMirror(m.config.Mirror.Pip)
-	if enabled, url, err := pip.Status(); err == nil {
-		if enabled {
-			status["Pip"] = url
+	// Enable Nix substituter mirrors (if Nix is installed, unless asked for by name)
+	if len(m.config.Mirror.Nix) > 0 && m.selected(names, "nix") && (len(names) > 0 || mirror.HasNix()) {
+		nix := mirror.NewNixMirror(m.config.Mirror.Nix)
+		nix.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("nix", nix); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Nix mirror: %w"), err))
+		} else if skip("nix", nix.Conflicts()) {
+			kept = true
 		} else {
-			status["Pip"] = "disabled"
+			fmt.Println(i18n.T("✓ Nix mirror enabled:"), strings.Join(m.config.Mirror.Nix, ", "))
+			printChanged(nix.Conflicts())
 		}
 	}
 
-	// Apt status
-	apt := mirror.NewAptMirror(m.config.Mirror.Apt)
-	if enabled, url, err := apt.Status(); err == nil {
-		if enabled {
-			status["Apt"] = url
+	// Enable Bazel download mirrors (if Bazel is installed, unless asked for by name)
+	if len(m.config.Mirror.Bazel) > 0 && m.selected(names, "bazel") && (len(names) > 0 || mirror.HasBazel()) {
+		bazel := mirror.NewBazelMirror(m.config.Mirror.Bazel)
+		bazel.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("bazel", bazel); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Bazel mirror: %w"), err))
+		} else if skip("bazel", bazel.Conflicts()) {
+			kept = true
 		} else {
-			status["Apt"] = "disabled"
+			fmt.Println(i18n.T("✓ Bazel mirror enabled:"), strings.Join(bazel.Upstreams(), ", "))
+			printChanged(bazel.Conflicts())
 		}
 	}
 
-	// Cargo status
-	cargo := mirror.NewCargoMirror(m.config.Mirror.Cargo)
-	if enabled, url, err := cargo.Status(); err == nil {
-		if enabled {
-			status["Cargo"] = url
+	// Enable Deno mirrors (if Deno is installed, unless asked for by name)
+	if m.config.Mirror.NPM != "" && m.selected(names, "deno") && (len(names) > 0 || mirror.HasDeno()) {
+		deno := mirror.NewDenoMirror(m.config.Mirror.NPM, m.config.Mirror.JSR)
+		deno.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("deno", deno); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Deno mirror: %w"), err))
+		} else if skip("deno", deno.Conflicts()) {
+			kept = true
 		} else {
-			status["Cargo"] = "disabled"
+			fmt.Println(i18n.T("✓ Deno mirror enabled:"), m.config.Mirror.NPM)
+			if m.config.Mirror.JSR != "" {
+				fmt.Printf(i18n.T("  Additional: %s\n"), m.config.Mirror.JSR)
+			}
+			printChanged(deno.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "deno")
 		}
 	}
 
-	// Go status
-	goMirror := mirror.NewGoMirror(m.config.Mirror.Go)
-	if enabled, url, err := goMirror.Status(); err == nil {
-		if enabled {
-			status["Go"] = url
+	// Enable Bun mirror (if Bun is installed, unless asked for by name)
+	if m.config.Mirror.NPM != "" && m.selected(names, "bun") && (len(names) > 0 || mirror.HasBun()) {
+		bun := mirror.NewBunMirror(m.config.Mirror.NPM)
+		bun.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("bun", bun); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Bun mirror: %w"), err))
+		} else if skip("bun", bun.Conflicts()) {
+			kept = true
 		} else {
-			status["Go"] = "disabled"
+			fmt.Println(i18n.T("✓ Bun mirror enabled:"), m.config.Mirror.NPM)
+			printChanged(bun.Conflicts())
 		}
 	}
 
-	// Docker status
-	dockerMirror := mirror.NewDockerMirror(m.config.Mirror.Docker)
-	if enabled, url, err := dockerMirror.Status(); err == nil {
-		if enabled {
-			status["Docker"] = url
+	// Enable Node.js mirrors (if Corepack or a Node.js version manager is installed, unless asked for by name)
+	if m.config.Mirror.Node != "" && m.selected(names, "node") && (len(names) > 0 || mirror.HasNode()) {
+		node := mirror.NewNodeMirror(m.config.Mirror.NPM, m.config.Mirror.Node)
+		node.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("node", node); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Node.js mirror: %w"), err))
+		} else if skip("node", node.Conflicts()) {
+			kept = true
 		} else {
-			// Use the custom message (e.g., "check Docker Desktop settings")
-			status["Docker"] = url
+			fmt.Println(i18n.T("✓ Node.js mirror enabled:"), m.config.Mirror.Node)
+			printChanged(node.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "corepack, fnm and nvm")
 		}
 	}
 
-	return status
-}
+	// Enable Electron mirrors (if npm is installed, unless asked for by name)
+	if electron := m.config.Mirror.Electron; electron.Binaries != "" && m.selected(names, "electron") && (len(names) > 0 || mirror.HasNPM()) {
+		electronMirror := mirror.NewElectronMirror(electron.Binaries, electron.BuilderBinaries)
+		electronMirror.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("electron", electronMirror); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Electron mirror: %w"), err))
+		} else if skip("electron", electronMirror.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Electron mirror enabled:"), electron.Binaries)
+			if electron.BuilderBinaries != "" {
+				fmt.Printf(i18n.T("  Additional: %s\n"), electron.BuilderBinaries)
+			}
+			printChanged(electronMirror.Conflicts())
+		}
+	}
 
-// LoadProxyFromFile loads proxy configuration from a local YAML file
-func (m *Manager) LoadProxyFromFile(filePath string) (*proxy.Subscription, error) {
-	return proxy.LoadFromFile(filePath)
-}
+	// Enable native package binary mirrors (if npm is installed, unless asked for by name)
+	if len(m.config.Mirror.Binaries) > 0 && m.selected(names, "binaries") && (len(names) > 0 || mirror.HasNPM()) {
+		binaries := mirror.NewBinaryMirror(m.config.Mirror.Binaries)
+		binaries.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("binaries", binaries); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Binary mirrors: %w"), err))
+		} else if skip("binaries", binaries.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Binary mirrors enabled:"), strings.Join(binaries.Keys(), ", "))
+			printChanged(binaries.Conflicts())
+		}
+	}
 
-// EnableProxy enables proxy via Xray
-func (m *Manager) EnableProxy() error {
-	if !m.config.Proxy.Enabled {
-		return fmt.Errorf("proxy is not enabled in config")
+	// Enable browser download mirrors (if npm is installed, unless asked for by name)
+	if len(m.config.Mirror.Browsers) > 0 && m.selected(names, "browsers") && (len(names) > 0 || mirror.HasNPM()) {
+		browsers := mirror.NewBrowserMirror(m.config.Mirror.Browsers)
+		browsers.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("browsers", browsers); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Browser mirrors: %w"), err))
+		} else if skip("browsers", browsers.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Browser mirrors enabled:"), strings.Join(browsers.Names(), ", "))
+			printChanged(browsers.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "Puppeteer, Playwright and Cypress")
+		}
 	}
 
-	if m.config.Proxy.SubscriptionURL == "" {
-		return fmt.Errorf("no subscription URL configured")
+	// Enable CocoaPods mirror (if CocoaPods is set up, unless asked for by name)
+	if m.config.Mirror.CocoaPods != "" && m.selected(names, "cocoapods") && (len(names) > 0 || mirror.HasCocoaPods()) {
+		cocoapods := mirror.NewCocoaPodsMirror(m.config.Mirror.CocoaPods)
+		if err := enable("cocoapods", cocoapods); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("CocoaPods mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ CocoaPods mirror enabled:"), m.config.Mirror.CocoaPods)
+			// Projects on the CDN only switch with a source in their Podfile
+			fmt.Printf(i18n.T("  Put this first in your Podfile: source '%s'\n"), m.config.Mirror.CocoaPods)
+		}
 	}
 
-	// Download Xray if needed
-	if err := m.xray.Download(); err != nil {
-		return fmt.Errorf("failed to download Xray: %w", err)
+	// Enable Helm chart repository mirrors (if Helm has repositories, unless asked for by name)
+	if len(m.config.Mirror.Helm) > 0 && m.selected(names, "helm") && (len(names) > 0 || mirror.HasHelm()) {
+		helm := mirror.NewHelmMirror(m.config.Mirror.Helm)
+		helm.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("helm", helm); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Helm mirror: %w"), err))
+		} else {
+			if skip("helm", helm.Conflicts()) {
+				kept = true
+			}
+			if repos := helm.Mirrored(); len(repos) > 0 {
+				fmt.Println(i18n.T("✓ Helm mirror enabled:"), strings.Join(repos, ", "))
+				printChanged(helm.Conflicts())
+				fmt.Println(i18n.T("  Run 'helm repo update' to fetch the charts from the mirrors"))
+			}
+		}
 	}
 
-	// Fetch subscription
-	fmt.Println("Fetching subscription...")
-	sub, err := proxy.FetchSubscription(m.config.Proxy.SubscriptionURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch subscription: %w", err)
+	// Enable Kubernetes mirrors (if containerd or the Kubernetes packages are installed, unless asked for by name)
+	if k8s := m.config.Mirror.Kubernetes; (k8s.Registry != "" || k8s.Packages != "") && m.selected(names, "kubernetes") && (len(names) > 0 || mirror.HasKubernetes()) {
+		k8sMirror := mirror.NewKubernetesMirror(k8s.Registry, k8s.Packages)
+		k8sMirror.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("kubernetes", k8sMirror); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Kubernetes mirror: %w"), err))
+		} else if skip("kubernetes", k8sMirror.Conflicts()) {
+			kept = true
+		} else if enabled, url, _ := k8sMirror.Status(); enabled {
+			fmt.Println(i18n.T("✓ Kubernetes mirror enabled:"), url)
+			printChanged(k8sMirror.Conflicts())
+			if k8s.Registry != "" {
+				// Docker's registry-mirrors only cover Docker Hub, so kubeadm needs telling
+				fmt.Printf(i18n.T("  kubeadm pulls through it with: kubeadm init --image-repository %s\n"), k8s.Registry)
+			}
+		}
 	}
 
-	fmt.Printf("Found %d nodes in subscription\n", len(sub.Nodes))
+	// Enable minikube image mirror (if minikube is installed, unless asked for by name)
+	if m.config.Mirror.Minikube != "" && m.selected(names, "minikube") && (len(names) > 0 || mirror.HasMinikube()) {
+		minikube := mirror.NewMinikubeMirror(m.config.Mirror.Minikube)
+		minikube.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("minikube", minikube); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("minikube mirror: %w"), err))
+		} else if skip("minikube", minikube.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ minikube mirror enabled:"), m.config.Mirror.Minikube)
+			printChanged(minikube.Conflicts())
+			fmt.Printf(i18n.T("  Open a new terminal for %s to use it\n"), "minikube start")
+		}
+	}
 
-	// Select fastest node
-	fmt.Println("Testing node latency...")
-	node, err := sub.SelectFastestNode()
-	if err != nil {
-		return fmt.Errorf("failed to select node: %w", err)
+	// Enable Terraform provider mirror (if Terraform is installed, unless asked for by name)
+	if m.config.Mirror.Terraform != "" && m.selected(names, "terraform") && (len(names) > 0 || mirror.HasTerraform()) {
+		terraform := mirror.NewTerraformMirror(m.config.Mirror.Terraform)
+		terraform.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("terraform", terraform); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Terraform mirror: %w"), err))
+		} else if skip("terraform", terraform.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Terraform mirror enabled:"), m.config.Mirror.Terraform)
+			printChanged(terraform.Conflicts())
+		}
 	}
 
-	fmt.Printf("Selected node: %s (latency: %dms)\n", node.Name, node.Latency)
+	// Enable winget source mirror (if winget is installed, unless asked for by name)
+	if m.config.Mirror.Winget != "" && m.selected(names, "winget") && (len(names) > 0 || mirror.HasWinget()) {
+		winget := mirror.NewWingetMirror(m.config.Mirror.Winget)
+		winget.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("winget", winget); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("winget mirror: %w"), err))
+		} else if skip("winget", winget.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ winget mirror enabled:"), m.config.Mirror.Winget)
+			printChanged(winget.Conflicts())
+		}
+	}
 
-	// Generate Xray config
-	if err := m.xray.GenerateConfig(node); err != nil {
-		return fmt.Errorf("failed to generate Xray config: %w", err)
+	// Enable Scoop bucket mirrors (if Scoop has buckets, unless asked for by name)
+	if len(m.config.Mirror.Scoop) > 0 && m.selected(names, "scoop") && (len(names) > 0 || mirror.HasScoop()) {
+		scoop := mirror.NewScoopMirror(m.config.Mirror.Scoop)
+		scoop.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("scoop", scoop); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Scoop mirror: %w"), err))
+		} else {
+			if skip("scoop", scoop.Conflicts()) {
+				kept = true
+			}
+			if buckets := scoop.Mirrored(); len(buckets) > 0 {
+				fmt.Println(i18n.T("✓ Scoop mirror enabled:"), strings.Join(buckets, ", "))
+				printChanged(scoop.Conflicts())
+				fmt.Println(i18n.T("  Run 'scoop update' to fetch the buckets from the mirrors"))
+			}
+		}
 	}
 
-	// Start Xray
-	if err := m.xray.Start(); err != nil {
-		return fmt.Errorf("failed to start Xray: %w", err)
+	// Enable MSYS2 pacman mirror (if MSYS2 is installed, unless asked for by name)
+	if m.config.Mirror.MSYS2 != "" && m.selected(names, "msys2") && (len(names) > 0 || mirror.HasMSYS2()) {
+		msys2 := mirror.NewMSYS2Mirror(m.config.Mirror.MSYS2)
+		if err := enable("msys2", msys2); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("MSYS2 mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ MSYS2 mirror enabled:"), m.config.Mirror.MSYS2)
+		}
 	}
 
-	// Update config with current node
-	m.config.Proxy.CurrentNode = node.Name
-	if err := m.config.Save(); err != nil {
-		fmt.Printf("Warning: failed to save config: %v\n", err)
+	// Enable git clone mirrors (if git is installed, unless asked for by name)
+	if len(m.config.Mirror.Git) > 0 && m.selected(names, "git") && (len(names) > 0 || mirror.HasGit()) {
+		git := mirror.NewGitMirror(m.config.Mirror.Git)
+		git.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("git", git); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Git mirror: %w"), err))
+		} else if skip("git", git.Conflicts()) {
+			kept = true
+		} else {
+			fmt.Println(i18n.T("✓ Git mirror enabled:"), strings.Join(git.Hosts(), ", "))
+			printChanged(git.Conflicts())
+		}
 	}
 
-	// Print proxy environment variables
-	fmt.Println("\nTo use the proxy, set these environment variables:")
-	envVars := m.xray.GetProxyEnvVars()
-	for key, value := range envVars {
-		fmt.Printf("  export %s=%s\n", key, value)
+	// Enable Docker registry mirrors
+	dockerEnabled := false
+	if len(m.config.Mirror.Docker) > 0 && m.selected(names, "docker") {
+		dockerMirror := mirror.NewDockerMirror(m.config.Mirror.Docker)
+		dockerMirror.Overwrite = m.config.Mirror.Overwrite
+		if err := enable("docker", dockerMirror); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Docker mirror: %w"), err))
+		} else {
+			dockerEnabled = true
+			// Format display string (remove https:// prefix for cleaner output)
+			displayRegistries := make([]string, len(m.config.Mirror.Docker))
+			for i, reg := range m.config.Mirror.Docker {
+				displayRegistries[i] = reg
+			}
+			fmt.Printf(i18n.T("✓ Docker mirror enabled: %s\n"), displayRegistries[0])
+			if len(displayRegistries) > 1 {
+				for _, reg := range displayRegistries[1:] {
+					fmt.Printf(i18n.T("  Additional: %s\n"), reg)
+				}
+			}
+			printChanged(dockerMirror.Conflicts())
+		}
 	}
 
-	return nil
-}
+	if kept {
+		fmt.Println(i18n.T("\nTo use crosh's mirrors in place of your own settings: crosh config set mirror.overwrite true"))
+	}
 
-// DisableProxy stops the proxy
-func (m *Manager) DisableProxy() error {
-	if err := m.xray.Stop(); err != nil {
-		return err
+	if len(errors) > 0 {
+		fmt.Printf(i18n.T("\n%d errors occurred:\n"), len(errors))
+		for _, err := range errors {
+			fmt.Printf("  - %v\n", err)
+		}
+		if len(enabled) == 0 && !kept {
+			return nil, fmt.Errorf("no mirror could be enabled")
+		}
+		return enabled, &PartialError{Err: fmt.Errorf("some mirrors failed to enable")}
 	}
 
-	m.config.Proxy.CurrentNode = ""
-	m.config.Save()
+	// Show Docker restart instructions if Docker was enabled
+	if dockerEnabled {
+		m.printDockerRestartInstructions()
+	}
 
-	return nil
+	return enabled, nil
 }
 
-// GetProxyStatus returns the proxy status
-func (m *Manager) GetProxyStatus() string {
-	if m.xray.IsRunning() {
-		return fmt.Sprintf("running (port %d, node: %s)", m.config.Proxy.LocalPort, m.config.Proxy.CurrentNode)
+// DisableMirrors disables the mirrors with the given names, or all of them
+func (m *Manager) DisableMirrors(names ...string) error {
+	m.printToolsOff(names)
+	var errors []error
+
+	// Disable NPM mirror
+	if m.selected(names, "npm") {
+		npm := mirror.NewNPMMirror("")
+		if err := npm.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("NPM mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ NPM mirror disabled"))
+		}
 	}
-	return "stopped"
+
+	// Disable Yarn mirror
+	if m.selected(names, "yarn") && (len(names) > 0 || mirror.HasYarn()) {
+		yarn := mirror.NewYarnMirror("")
+		if err := yarn.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Yarn mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Yarn mirror disabled"))
+		}
+	}
+
+	// Disable pnpm mirror
+	if m.selected(names, "pnpm") && (len(names) > 0 || mirror.HasPnpm()) {
+		pnpm := mirror.NewPnpmMirror("")
+		if err := pnpm.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("pnpm mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ pnpm mirror disabled"))
+		}
+	}
+
+	// Disable Pip mirror
+	if m.selected(names, "pip") {
+		pip := mirror.NewPipMirror("")
+		if err := pip.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Pip mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Pip mirror disabled"))
+		}
+	}
+
+	// Disable pyenv mirror
+	if m.selected(names, "pyenv") && (len(names) > 0 || mirror.HasPyenv()) {
+		pyenv := mirror.NewPyenvMirror("")
+		if err := pyenv.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("pyenv mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ pyenv mirror disabled"))
+		}
+	}
+
+	// Disable Apt mirror
+	if m.selected(names, "apt") {
+		apt := mirror.NewAptMirror("")
+		if err := apt.Disable(); err != nil {
+			fmt.Printf(i18n.T("⚠ Apt mirror skipped: %v\n"), err)
+		} else {
+			fmt.Println(i18n.T("✓ Apt mirror disabled"))
+		}
+	}
+
+	// Disable apk mirror
+	if m.selected(names, "apk") && (len(names) > 0 || mirror.IsAlpine()) {
+		apk := mirror.NewApkMirror("")
+		if err := apk.Disable(); err != nil {
+			fmt.Printf(i18n.T("⚠ Apk mirror skipped: %v\n"), err)
+		} else {
+			fmt.Println(i18n.T("✓ Apk mirror disabled"))
+		}
+	}
+
+	// Disable pacman mirror
+	if m.selected(names, "pacman") && (len(names) > 0 || mirror.IsArch()) {
+		pacman := mirror.NewPacmanMirror("", false)
+		if err := pacman.Disable(); err != nil {
+			fmt.Printf(i18n.T("⚠ Pacman mirror skipped: %v\n"), err)
+		} else {
+			fmt.Println(i18n.T("✓ Pacman mirror disabled"))
+		}
+	}
+
+	// Disable Cargo mirror
+	if m.selected(names, "cargo") {
+		cargo := mirror.NewCargoMirror("", "")
+		if err := cargo.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Cargo mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Cargo mirror disabled"))
+		}
+	}
+
+	// Disable Go proxy
+	if m.selected(names, "go") {
+		goMirror := mirror.NewGoMirror("", "", "", "")
+		if err := goMirror.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Go proxy: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Go proxy disabled"))
+		}
+	}
+
+	// Disable Maven mirror
+	if m.selected(names, "maven") {
+		maven := mirror.NewMavenMirror("")
+		if err := maven.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Maven mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Maven mirror disabled"))
+		}
+	}
+
+	// Disable Gradle mirrors
+	if m.selected(names, "gradle") {
+		gradleMirror := mirror.NewGradleMirror("", "", "", "")
+		if err := gradleMirror.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Gradle mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Gradle mirror disabled"))
+		}
+	}
+
+	// Disable sbt mirrors
+	if m.selected(names, "sbt") && (len(names) > 0 || mirror.HasSbt()) {
+		sbtMirror := mirror.NewSbtMirror("", "")
+		if err := sbtMirror.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("sbt mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ sbt mirror disabled"))
+		}
+	}
+
+	// Disable SDKMAN mirror
+	if m.selected(names, "sdkman") && (len(names) > 0 || mirror.HasSdkman()) {
+		sdkman := mirror.NewSdkmanMirror("")
+		if err := sdkman.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("SDKMAN mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ SDKMAN mirror disabled"))
+		}
+	}
+
+	// Disable Composer mirror
+	if m.selected(names, "composer") {
+		composer := mirror.NewComposerMirror("")
+		if err := composer.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Composer mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Composer mirror disabled"))
+		}
+	}
+
+	// Disable NuGet mirror
+	if m.selected(names, "nuget") {
+		nuget := mirror.NewNuGetMirror("")
+		if err := nuget.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("NuGet mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ NuGet mirror disabled"))
+		}
+	}
+
+	// Disable Conan mirror
+	if m.selected(names, "conan") && (len(names) > 0 || mirror.HasConan()) {
+		conan := mirror.NewConanMirror("")
+		if err := conan.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Conan mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Conan mirror disabled"))
+		}
+	}
+
+	// Disable conda mirror
+	if m.selected(names, "conda") {
+		conda := mirror.NewCondaMirror("")
+		if err := conda.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Conda mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Conda mirror disabled"))
+		}
+	}
+
+	// Disable Hugging Face mirror
+	if m.selected(names, "huggingface") && (len(names) > 0 || mirror.HasHuggingFace()) {
+		huggingface := mirror.NewHuggingFaceMirror("")
+		if err := huggingface.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Hugging Face mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Hugging Face mirror disabled"))
+		}
+	}
+
+	// Disable CRAN mirror
+	if m.selected(names, "cran") && (len(names) > 0 || mirror.HasR()) {
+		cran := mirror.NewCRANMirror("", "")
+		if err := cran.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("CRAN mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ CRAN mirror disabled"))
+		}
+	}
+
+	// Disable CPAN mirror
+	if m.selected(names, "cpan") && (len(names) > 0 || mirror.HasCPAN()) {
+		cpan := mirror.NewCPANMirror(m.config.Mirror.CPAN)
+		if err := cpan.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("CPAN mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ CPAN mirror disabled"))
+		}
+	}
+
+	// Disable TeX Live mirror
+	if m.selected(names, "texlive") && (len(names) > 0 || mirror.HasTeXLive()) {
+		texlive := mirror.NewTeXLiveMirror("")
+		if err := texlive.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("TeX Live mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ TeX Live mirror disabled"))
+		}
+	}
+
+	// Disable Hex mirror
+	if m.selected(names, "hex") && (len(names) > 0 || mirror.HasHex()) {
+		hex := mirror.NewHexMirror("")
+		if err := hex.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Hex mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Hex mirror disabled"))
+		}
+	}
+
+	// Disable opam repository mirror
+	if m.selected(names, "opam") && (len(names) > 0 || mirror.HasOpam()) {
+		opam := mirror.NewOpamMirror(m.config.Mirror.Opam)
+		if err := opam.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("opam mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ opam mirror disabled"))
+		}
+	}
+
+	// Disable LuaRocks mirror
+	if m.selected(names, "luarocks") && (len(names) > 0 || mirror.HasLuaRocks()) {
+		luarocks := mirror.NewLuaRocksMirror("")
+		if err := luarocks.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("LuaRocks mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ LuaRocks mirror disabled"))
+		}
+	}
+
+	// Disable Haskell mirrors
+	if m.selected(names, "haskell") && (len(names) > 0 || mirror.HasHaskell()) {
+		haskell := mirror.NewHaskellMirror(m.config.Mirror.Haskell.Hackage, m.config.Mirror.Haskell.Stackage)
+		if err := haskell.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Haskell mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Haskell mirror disabled"))
+		}
+	}
+
+	// Disable Nix substituter mirrors
+	if m.selected(names, "nix") && (len(names) > 0 || mirror.HasNix()) {
+		nix := mirror.NewNixMirror(nil)
+		if err := nix.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Nix mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Nix mirror disabled"))
+		}
+	}
+
+	// Disable Bazel download mirrors
+	if m.selected(names, "bazel") && (len(names) > 0 || mirror.HasBazel()) {
+		bazel := mirror.NewBazelMirror(nil)
+		if err := bazel.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Bazel mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Bazel mirror disabled"))
+		}
+	}
+
+	// Disable Deno mirrors
+	if m.selected(names, "deno") && (len(names) > 0 || mirror.HasDeno()) {
+		deno := mirror.NewDenoMirror("", "")
+		if err := deno.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Deno mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Deno mirror disabled"))
+		}
+	}
+
+	// Disable Bun mirror
+	if m.selected(names, "bun") && (len(names) > 0 || mirror.HasBun()) {
+		bun := mirror.NewBunMirror("")
+		if err := bun.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Bun mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Bun mirror disabled"))
+		}
+	}
+
+	// Disable Node.js mirrors
+	if m.selected(names, "node") && (len(names) > 0 || mirror.HasNode()) {
+		node := mirror.NewNodeMirror("", "")
+		if err := node.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Node.js mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Node.js mirror disabled"))
+		}
+	}
+
+	// Disable Electron mirrors
+	if m.selected(names, "electron") && (len(names) > 0 || mirror.HasNPM()) {
+		electronMirror := mirror.NewElectronMirror("", "")
+		if err := electronMirror.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Electron mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Electron mirror disabled"))
+		}
+	}
+
+	// Disable native package binary mirrors
+	if m.selected(names, "binaries") && (len(names) > 0 || mirror.HasNPM()) {
+		binaries := mirror.NewBinaryMirror(nil)
+		if err := binaries.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Binary mirrors: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Binary mirrors disabled"))
+		}
+	}
+
+	// Disable browser download mirrors
+	if m.selected(names, "browsers") && (len(names) > 0 || mirror.HasNPM()) {
+		browsers := mirror.NewBrowserMirror(nil)
+		if err := browsers.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Browser mirrors: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Browser mirrors disabled"))
+		}
+	}
+
+	// Disable CocoaPods mirror
+	if m.selected(names, "cocoapods") && (len(names) > 0 || mirror.HasCocoaPods()) {
+		cocoapods := mirror.NewCocoaPodsMirror("")
+		if err := cocoapods.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("CocoaPods mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ CocoaPods mirror disabled"))
+		}
+	}
+
+	// Disable Helm chart repository mirrors
+	if m.selected(names, "helm") && (len(names) > 0 || mirror.HasHelm()) {
+		helm := mirror.NewHelmMirror(m.config.Mirror.Helm)
+		if err := helm.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Helm mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Helm mirror disabled"))
+		}
+	}
+
+	// Disable Kubernetes mirrors
+	if m.selected(names, "kubernetes") && (len(names) > 0 || mirror.HasKubernetes()) {
+		k8sMirror := mirror.NewKubernetesMirror("", "")
+		if err := k8sMirror.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Kubernetes mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Kubernetes mirror disabled"))
+		}
+	}
+
+	// Disable minikube image mirror
+	if m.selected(names, "minikube") && (len(names) > 0 || mirror.HasMinikube()) {
+		minikube := mirror.NewMinikubeMirror("")
+		if err := minikube.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("minikube mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ minikube mirror disabled"))
+		}
+	}
+
+	// Disable Terraform provider mirror
+	if m.selected(names, "terraform") && (len(names) > 0 || mirror.HasTerraform()) {
+		terraform := mirror.NewTerraformMirror("")
+		if err := terraform.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Terraform mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Terraform mirror disabled"))
+		}
+	}
+
+	// Disable winget source mirror
+	if m.selected(names, "winget") && (len(names) > 0 || mirror.HasWinget()) {
+		winget := mirror.NewWingetMirror(m.config.Mirror.Winget)
+		if err := winget.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("winget mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ winget mirror disabled"))
+		}
+	}
+
+	// Disable Scoop bucket mirrors
+	if m.selected(names, "scoop") && (len(names) > 0 || mirror.HasScoop()) {
+		scoop := mirror.NewScoopMirror(m.config.Mirror.Scoop)
+		if err := scoop.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Scoop mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Scoop mirror disabled"))
+		}
+	}
+
+	// Disable MSYS2 pacman mirror
+	if m.selected(names, "msys2") && (len(names) > 0 || mirror.HasMSYS2()) {
+		msys2 := mirror.NewMSYS2Mirror("")
+		if err := msys2.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("MSYS2 mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ MSYS2 mirror disabled"))
+		}
+	}
+
+	// Disable git clone mirrors
+	if m.selected(names, "git") && (len(names) > 0 || mirror.HasGit()) {
+		git := mirror.NewGitMirror(nil)
+		if err := git.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Git mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Git mirror disabled"))
+		}
+	}
+
+	// Disable Docker registry mirrors
+	if m.selected(names, "docker") {
+		dockerMirror := mirror.NewDockerMirror(nil)
+		if err := dockerMirror.Disable(); err != nil {
+			errors = append(errors, fmt.Errorf(i18n.T("Docker mirror: %w"), err))
+		} else {
+			fmt.Println(i18n.T("✓ Docker mirror disabled"))
+		}
+	}
+
+	if len(errors) > 0 {
+		return &PartialError{Err: fmt.Errorf("some mirrors failed to disable")}
+	}
+
+	return nil
+}
+
+// GetMirrorStatus returns the status of all mirrors
+func (m *Manager) GetMirrorStatus() map[string]string {
+	status := make(map[string]string)
+
+	// NPM status
+	npm := mirror.NewNPMMirror(m.config.Mirror.NPM)
+	if enabled, url, err := npm.Status(); err == nil {
+		if enabled {
+			status["NPM"] = url
+		} else {
+			status["NPM"] = "disabled"
+		}
+	}
+
+	// Yarn status
+	yarn := mirror.NewYarnMirror(m.config.Mirror.NPM)
+	if enabled, url, err := yarn.Status(); err == nil {
+		if enabled {
+			status["Yarn"] = url
+		} else {
+			status["Yarn"] = "disabled"
+		}
+	}
+
+	// pnpm status
+	pnpm := mirror.NewPnpmMirror(m.config.Mirror.NPM)
+	if enabled, url, err := pnpm.Status(); err == nil {
+		if enabled {
+			status["pnpm"] = url
+		} else {
+			status["pnpm"] = "disabled"
+		}
+	}
+
+	// Pip status
+	pip := mirror.NewPipMirror(m.config.Mirror.Pip)
+	if enabled, url, err := pip.Status(); err == nil {
+		if enabled {
+			status["Pip"] = url
+		} else {
+			status["Pip"] = "disabled"
+		}
+	}
+
+	// pyenv status
+	pyenv := mirror.NewPyenvMirror(m.config.Mirror.Pyenv)
+	if enabled, url, err := pyenv.Status(); err == nil {
+		if enabled {
+			status["pyenv"] = url
+		} else {
+			status["pyenv"] = "disabled"
+		}
+	}
+
+	// Apt status
+	apt := mirror.NewAptMirror(m.config.Mirror.Apt)
+	if enabled, url, err := apt.Status(); err == nil {
+		if enabled {
+			status["Apt"] = url
+		} else {
+			status["Apt"] = "disabled"
+		}
+	}
+
+	// Apk status
+	apk := mirror.NewApkMirror(m.config.Mirror.Apk)
+	if enabled, url, err := apk.Status(); err == nil {
+		if enabled {
+			status["Apk"] = url
+		} else {
+			status["Apk"] = "disabled"
+		}
+	}
+
+	// Pacman status
+	pacman := mirror.NewPacmanMirror(m.config.Mirror.Pacman, m.config.Mirror.ArchLinuxCN)
+	if enabled, url, err := pacman.Status(); err == nil {
+		if enabled {
+			status["Pacman"] = url
+		} else {
+			status["Pacman"] = "disabled"
+		}
+	}
+
+	// Cargo status
+	cargo := mirror.NewCargoMirror(m.config.Mirror.Cargo, m.config.Mirror.Rustup)
+	if enabled, url, err := cargo.Status(); err == nil {
+		if enabled {
+			status["Cargo"] = url
+		} else {
+			status["Cargo"] = "disabled"
+		}
+	}
+	if enabled, url, err := cargo.RustupStatus(); err == nil {
+		if enabled {
+			status["rustup"] = url
+		} else {
+			status["rustup"] = "disabled"
+		}
+	}
+
+	// Go status
+	goMirror := mirror.NewGoMirror(m.config.Mirror.Go, m.config.Mirror.GoSumDB, m.config.Mirror.GoPrivate, m.config.Mirror.GoNoSumDB)
+	if enabled, url, err := goMirror.Status(); err == nil {
+		if enabled {
+			status["Go"] = url
+		} else {
+			status["Go"] = "disabled"
+		}
+	}
+	if enabled, sumDB, err := goMirror.SumDBStatus(); err == nil {
+		if enabled {
+			status["Go sumdb"] = sumDB
+		} else {
+			status["Go sumdb"] = "disabled"
+		}
+	}
+
+	// Maven status
+	maven := mirror.NewMavenMirror(m.config.Mirror.Maven)
+	if enabled, url, err := maven.Status(); err == nil {
+		if enabled {
+			status["Maven"] = url
+		} else {
+			status["Maven"] = "disabled"
+		}
+	}
+
+	// Gradle status
+	gradle := m.config.Mirror.Gradle
+	gradleMirror := mirror.NewGradleMirror(gradle.Central, gradle.Google, gradle.Plugins, gradle.Distributions)
+	if enabled, url, err := gradleMirror.Status(); err == nil {
+		if enabled {
+			status["Gradle"] = url
+		} else {
+			status["Gradle"] = "disabled"
+		}
+	}
+
+	// sbt status
+	sbtMirror := mirror.NewSbtMirror(m.config.Mirror.Sbt.Maven, m.config.Mirror.Sbt.Ivy)
+	if enabled, url, err := sbtMirror.Status(); err == nil {
+		if enabled {
+			status["sbt"] = url
+		} else {
+			status["sbt"] = "disabled"
+		}
+	}
+
+	// SDKMAN status
+	sdkman := mirror.NewSdkmanMirror(m.config.Mirror.Sdkman)
+	if enabled, url, err := sdkman.Status(); err == nil {
+		if enabled {
+			status["SDKMAN"] = url
+		} else {
+			status["SDKMAN"] = "disabled"
+		}
+	}
+
+	// Composer status
+	composer := mirror.NewComposerMirror(m.config.Mirror.Composer)
+	if enabled, url, err := composer.Status(); err == nil {
+		if enabled {
+			status["Composer"] = url
+		} else {
+			status["Composer"] = "disabled"
+		}
+	}
+
+	// NuGet status
+	nuget := mirror.NewNuGetMirror(m.config.Mirror.NuGet)
+	if enabled, url, err := nuget.Status(); err == nil {
+		if enabled {
+			status["NuGet"] = url
+		} else {
+			status["NuGet"] = "disabled"
+		}
+	}
+
+	// Conan status
+	conan := mirror.NewConanMirror(m.config.Mirror.Conan)
+	if enabled, url, err := conan.Status(); err == nil {
+		if enabled {
+			status["Conan"] = url
+		} else {
+			status["Conan"] = "disabled"
+		}
+	}
+
+	// Conda status
+	conda := mirror.NewCondaMirror(m.config.Mirror.Conda)
+	if enabled, url, err := conda.Status(); err == nil {
+		if enabled {
+			status["Conda"] = url
+		} else {
+			status["Conda"] = "disabled"
+		}
+	}
+
+	// Hugging Face status
+	huggingface := mirror.NewHuggingFaceMirror(m.config.Mirror.HuggingFace)
+	if enabled, url, err := huggingface.Status(); err == nil {
+		if enabled {
+			status["Hugging Face"] = url
+		} else {
+			status["Hugging Face"] = "disabled"
+		}
+	}
+
+	// CRAN status
+	cran := mirror.NewCRANMirror(m.config.Mirror.CRAN, m.config.Mirror.Bioconductor)
+	if enabled, url, err := cran.Status(); err == nil {
+		if enabled {
+			status["CRAN"] = url
+		} else {
+			status["CRAN"] = "disabled"
+		}
+	}
+
+	// CPAN status
+	cpan := mirror.NewCPANMirror(m.config.Mirror.CPAN)
+	if enabled, url, err := cpan.Status(); err == nil {
+		if enabled {
+			status["CPAN"] = url
+		} else {
+			status["CPAN"] = "disabled"
+		}
+	}
+
+	// TeX Live status
+	texlive := mirror.NewTeXLiveMirror(m.config.Mirror.TeXLive)
+	if enabled, url, err := texlive.Status(); err == nil {
+		if enabled {
+			status["TeX Live"] = url
+		} else {
+			status["TeX Live"] = "disabled"
+		}
+	}
+
+	// Hex status
+	hex := mirror.NewHexMirror(m.config.Mirror.Hex)
+	if enabled, url, err := hex.Status(); err == nil {
+		if enabled {
+			status["Hex"] = url
+		} else {
+			status["Hex"] = "disabled"
+		}
+	}
+
+	// opam status
+	opam := mirror.NewOpamMirror(m.config.Mirror.Opam)
+	if enabled, url, err := opam.Status(); err == nil {
+		if enabled {
+			status["opam"] = url
+		} else {
+			status["opam"] = "disabled"
+		}
+	}
+
+	// LuaRocks status
+	luarocks := mirror.NewLuaRocksMirror(m.config.Mirror.LuaRocks)
+	if enabled, url, err := luarocks.Status(); err == nil {
+		if enabled {
+			status["LuaRocks"] = url
+		} else {
+			status["LuaRocks"] = "disabled"
+		}
+	}
+
+	// Haskell status
+	haskell := mirror.NewHaskellMirror(m.config.Mirror.Haskell.Hackage, m.config.Mirror.Haskell.Stackage)
+	if enabled, url, err := haskell.Status(); err == nil {
+		if enabled {
+			status["Haskell"] = url
+		} else {
+			status["Haskell"] = "disabled"
+		}
+	}
+
+	// Nix status
+	nix := mirror.NewNixMirror(m.config.Mirror.Nix)
+	if enabled, url, err := nix.Status(); err == nil {
+		if enabled {
+			status["Nix"] = url
+		} else {
+			status["Nix"] = "disabled"
+		}
+	}
+
+	// Bazel status
+	bazel := mirror.NewBazelMirror(m.config.Mirror.Bazel)
+	if enabled, url, err := bazel.Status(); err == nil {
+		if enabled {
+			status["Bazel"] = url
+		} else {
+			status["Bazel"] = "disabled"
+		}
+	}
+
+	// Deno status
+	deno := mirror.NewDenoMirror(m.config.Mirror.NPM, m.config.Mirror.JSR)
+	if enabled, url, err := deno.Status(); err == nil {
+		if enabled {
+			status["Deno"] = url
+		} else {
+			status["Deno"] = "disabled"
+		}
+	}
+
+	// Bun status
+	bun := mirror.NewBunMirror(m.config.Mirror.NPM)
+	if enabled, url, err := bun.Status(); err == nil {
+		if enabled {
+			status["Bun"] = url
+		} else {
+			status["Bun"] = "disabled"
+		}
+	}
+
+	// Node.js status
+	node := mirror.NewNodeMirror(m.config.Mirror.NPM, m.config.Mirror.Node)
+	if enabled, url, err := node.Status(); err == nil {
+		if enabled {
+			status["Node.js"] = url
+		} else {
+			status["Node.js"] = "disabled"
+		}
+	}
+
+	// Electron status
+	electronMirror := mirror.NewElectronMirror(m.config.Mirror.Electron.Binaries, m.config.Mirror.Electron.BuilderBinaries)
+	if enabled, url, err := electronMirror.Status(); err == nil {
+		if enabled {
+			status["Electron"] = url
+		} else {
+			status["Electron"] = "disabled"
+		}
+	}
+
+	// Native package binaries status
+	binaries := mirror.NewBinaryMirror(m.config.Mirror.Binaries)
+	if enabled, url, err := binaries.Status(); err == nil {
+		if enabled {
+			status["npm binaries"] = url
+		} else {
+			status["npm binaries"] = "disabled"
+		}
+	}
+
+	// Browser downloads status
+	browsers := mirror.NewBrowserMirror(m.config.Mirror.Browsers)
+	if enabled, url, err := browsers.Status(); err == nil {
+		if enabled {
+			status["Browsers"] = url
+		} else {
+			status["Browsers"] = "disabled"
+		}
+	}
+
+	// CocoaPods status
+	cocoapods := mirror.NewCocoaPodsMirror(m.config.Mirror.CocoaPods)
+	if enabled, url, err := cocoapods.Status(); err == nil {
+		if enabled {
+			status["CocoaPods"] = url
+		} else {
+			status["CocoaPods"] = "disabled"
+		}
+	}
+
+	// Helm status
+	helm := mirror.NewHelmMirror(m.config.Mirror.Helm)
+	if enabled, repos, err := helm.Status(); err == nil {
+		if enabled {
+			status["Helm"] = repos
+		} else {
+			status["Helm"] = "disabled"
+		}
+	}
+
+	// Kubernetes status
+	k8sMirror := mirror.NewKubernetesMirror(m.config.Mirror.Kubernetes.Registry, m.config.Mirror.Kubernetes.Packages)
+	if enabled, url, err := k8sMirror.Status(); err == nil {
+		if enabled {
+			status["Kubernetes"] = url
+		} else {
+			status["Kubernetes"] = "disabled"
+		}
+	}
+
+	// minikube status
+	minikube := mirror.NewMinikubeMirror(m.config.Mirror.Minikube)
+	if enabled, url, err := minikube.Status(); err == nil {
+		if enabled {
+			status["minikube"] = url
+		} else {
+			status["minikube"] = "disabled"
+		}
+	}
+
+	// Terraform status
+	terraform := mirror.NewTerraformMirror(m.config.Mirror.Terraform)
+	if enabled, url, err := terraform.Status(); err == nil {
+		if enabled {
+			status["Terraform"] = url
+		} else {
+			status["Terraform"] = "disabled"
+		}
+	}
+
+	// winget status
+	winget := mirror.NewWingetMirror(m.config.Mirror.Winget)
+	if enabled, url, err := winget.Status(); err == nil {
+		if enabled {
+			status["winget"] = url
+		} else {
+			status["winget"] = "disabled"
+		}
+	}
+
+	// Scoop status
+	scoop := mirror.NewScoopMirror(m.config.Mirror.Scoop)
+	if enabled, url, err := scoop.Status(); err == nil {
+		if enabled {
+			status["Scoop"] = url
+		} else {
+			status["Scoop"] = "disabled"
+		}
+	}
+
+	// MSYS2 status
+	msys2 := mirror.NewMSYS2Mirror(m.config.Mirror.MSYS2)
+	if enabled, url, err := msys2.Status(); err == nil {
+		if enabled {
+			status["MSYS2"] = url
+		} else {
+			status["MSYS2"] = "disabled"
+		}
+	}
+
+	// Git status
+	git := mirror.NewGitMirror(m.config.Mirror.Git)
+	if enabled, url, err := git.Status(); err == nil {
+		if enabled {
+			status["Git"] = url
+		} else {
+			status["Git"] = "disabled"
+		}
+	}
+
+	// Docker status
+	dockerMirror := mirror.NewDockerMirror(m.config.Mirror.Docker)
+	if enabled, url, err := dockerMirror.Status(); err == nil {
+		if enabled {
+			status["Docker"] = url
+		} else {
+			// Use the custom message (e.g., "check Docker Desktop settings")
+			status["Docker"] = url
+		}
+	}
+
+	return status
+}
+
+// LoadProxyFromFile loads proxy configuration from a local YAML file
+func (m *Manager) LoadProxyFromFile(filePath string) (*proxy.Subscription, error) {
+	sub, err := proxy.LoadFromFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	printSkippedNodes(sub)
+	return sub, nil
+}
+
+// EnableProxy enables the proxy via the configured core
+func (m *Manager) EnableProxy() error {
+	if !m.config.Proxy.Enabled {
+		return &config.Error{Err: fmt.Errorf("proxy is not enabled in config")}
+	}
+
+	if m.config.Proxy.SubscriptionURL == "" {
+		return config.ErrNoSubscription
+	}
+
+	// Download the core if needed
+	if err := m.core.Download(); err != nil {
+		return fmt.Errorf(i18n.T("failed to download %s: %w"), m.core.Name(), err)
+	}
+	if m.GeoDataDue() {
+		fmt.Println(i18n.T("Refreshing geoip and geosite data files..."))
+		if _, err := m.xray.UpdateGeoData(); err != nil {
+			fmt.Printf(i18n.T("Warning: failed to refresh geo data: %v\n"), err)
+		}
+	}
+
+	sub, err := m.FetchSubscription()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.T("Found %d nodes in subscription\n"), len(sub.Nodes))
+
+	node, err := m.selectNode(sub)
+	if err != nil {
+		return fmt.Errorf(i18n.T("failed to select node: %w"), err)
+	}
+	nodes := m.balanceNodes(sub, node)
+
+	// Generate the core config
+	if err := m.generateConfig(nodes); err != nil {
+		return fmt.Errorf(i18n.T("failed to generate %s config: %w"), m.core.Name(), err)
+	}
+
+	// Start the core
+	if err := m.core.Start(); err != nil {
+		return fmt.Errorf(i18n.T("failed to start %s: %w"), m.core.Name(), err)
+	}
+
+	// Update config with current node
+	m.setCurrentNodes(nodes)
+	if err := m.config.Save(); err != nil {
+		fmt.Printf(i18n.T("Warning: failed to save config: %v\n"), err)
+	}
+
+	m.enableServiceProxies()
+	return nil
+}
+
+// enableServiceProxies gives the installed services in proxy.services the
+// proxy; one that fails only gets a warning, as the proxy itself runs
+func (m *Manager) enableServiceProxies() {
+	proxyURL := m.core.GetProxyEnvVars()["HTTPS_PROXY"]
+	for _, name := range m.config.Proxy.Services {
+		service, ok := proxy.Services[name]
+		if !ok || !service.Installed() {
+			continue
+		}
+		if changed, err := service.EnableProxy(proxyURL); err != nil {
+			fmt.Printf(i18n.T("⚠ %s doesn't use the proxy: %v\n"), name, err)
+		} else if changed {
+			fmt.Printf(i18n.T("✓ %s uses the proxy\n"), name)
+		}
+	}
+}
+
+// disableServiceProxies removes the proxy from every service crosh gave it
+// to, including ones taken out of proxy.services since
+func (m *Manager) disableServiceProxies() {
+	for _, name := range proxy.ServiceNames() {
+		if changed, err := proxy.Services[name].DisableProxy(); err != nil {
+			fmt.Printf(i18n.T("⚠ %s still uses the stopped proxy: %v\n"), name, err)
+		} else if changed {
+			fmt.Printf(i18n.T("✓ %s no longer uses the proxy\n"), name)
+		}
+	}
+}
+
+// selectNode returns the pinned node if it is still in the subscription,
+// otherwise the best node that passes the node filter
+func (m *Manager) selectNode(sub *proxy.Subscription) (*proxy.Node, error) {
+	if pinned := m.config.Proxy.PinnedNode; pinned != "" {
+		for i := range sub.Nodes {
+			if sub.Nodes[i].Name == pinned {
+				fmt.Printf(i18n.T("Using pinned node: %s\n"), pinned)
+				return &sub.Nodes[i], nil
+			}
+		}
+		fmt.Printf(i18n.T("⚠ Pinned node %s is no longer in the subscription, selecting automatically\n"), pinned)
+	}
+
+	filter, err := m.NodeFilter()
+	if err != nil {
+		return nil, err
+	}
+	filtered := filter.Apply(sub.Nodes)
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf(i18n.T("none of the %d nodes pass the node filter (see: crosh nodes filter)"), len(sub.Nodes))
+	}
+	if skipped := len(sub.Nodes) - len(filtered); skipped > 0 {
+		fmt.Printf(i18n.T("Skipping %d nodes excluded by the node filter\n"), skipped)
+	}
+	runnable := slices.DeleteFunc(slices.Clone(filtered), func(node proxy.Node) bool {
+		return !m.core.Supports(node.Type)
+	})
+	if len(runnable) == 0 {
+		return nil, fmt.Errorf(i18n.T("%s can't run any of the %d nodes, try: crosh proxy on --core sing-box"), m.core.Name(), len(filtered))
+	}
+	if skipped := len(filtered) - len(runnable); skipped > 0 {
+		fmt.Printf(i18n.T("Skipping %d nodes %s can't run (use sing-box for hysteria2 and tuic nodes)\n"), skipped, m.core.Name())
+	}
+	filtered = runnable
+	if f := m.config.Proxy.Filter; len(f.Prefer) > 0 || len(f.Avoid) > 0 {
+		preferred := proxy.PreferRegions(filtered, f.Prefer, f.Avoid)
+		if len(preferred) < len(filtered) {
+			fmt.Printf(i18n.T("Testing %d of %d nodes by region preference\n"), len(preferred), len(filtered))
+		}
+		filtered = preferred
+	}
+	// Keep the tested nodes in sub so callers can see their latency
+	sub.Nodes = filtered
+
+	var node *proxy.Node
+	if m.config.Proxy.Selection == config.SelectionURLTest {
+		fmt.Println(i18n.T("Testing nodes through the proxy (url-test)..."))
+		node, err = m.core.SelectFastestNodeByURLTest(sub, m.config.Proxy.ProbeURL)
+	} else {
+		fmt.Println(i18n.T("Testing node latency..."))
+		node, err = m.core.SelectFastestNodeByLatency(sub, m.config.Proxy.ProbeURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.recordLatency(sub.Nodes)
+
+	if m.config.Proxy.Selection == config.SelectionBandwidth {
+		if fastest := m.selectByBandwidth(sub.Nodes); fastest != nil {
+			fmt.Printf(i18n.T("Selected node: %s (%.1f MB/s)\n"), fastest.Name, fastest.Speed)
+			return fastest, nil
+		}
+		fmt.Println(i18n.T("⚠ Speed tests failed, falling back to latency"))
+	}
+
+	fmt.Printf(i18n.T("Selected node: %s (latency: %dms)\n"), node.Name, node.Latency)
+
+	return node, nil
+}
+
+// balanceNodes returns the nodes to run: just node, or with balancing enabled,
+// node followed by the next-fastest tested nodes of sub
+func (m *Manager) balanceNodes(sub *proxy.Subscription, node *proxy.Node) []*proxy.Node {
+	nodes := []*proxy.Node{node}
+	if m.config.Proxy.BalanceNodes <= 1 || m.config.Proxy.PinnedNode != "" {
+		return nodes
+	}
+
+	candidates := fastestNodes(sub.Nodes, m.config.Proxy.BalanceNodes)
+	for i := range candidates {
+		if len(nodes) == m.config.Proxy.BalanceNodes {
+			break
+		}
+		if candidates[i].Name != node.Name {
+			nodes = append(nodes, &candidates[i])
+		}
+	}
+
+	if len(nodes) > 1 {
+		fmt.Printf(i18n.T("Balancing across %d nodes\n"), len(nodes))
+	}
+	return nodes
+}
+
+// fastestNodes returns up to n reachable nodes, lowest latency first
+func fastestNodes(nodes []proxy.Node, n int) []proxy.Node {
+	candidates := []proxy.Node{}
+	for _, node := range nodes {
+		if node.Latency > 0 {
+			candidates = append(candidates, node)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Latency < candidates[j].Latency
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// generateConfig generates the core config for a single node, or a balancer
+// config if there are several
+func (m *Manager) generateConfig(nodes []*proxy.Node) error {
+	if len(nodes) == 1 {
+		return m.core.GenerateConfig(nodes[0])
+	}
+	return m.core.GenerateBalancerConfig(nodes, m.config.Proxy.ProbeURL)
+}
+
+// setCurrentNodes records the nodes the core runs on in the config
+func (m *Manager) setCurrentNodes(nodes []*proxy.Node) {
+	m.config.Proxy.CurrentNode = nodes[0].Name
+	m.config.Proxy.BalancedNodes = nil
+	if len(nodes) > 1 {
+		for _, node := range nodes {
+			m.config.Proxy.BalancedNodes = append(m.config.Proxy.BalancedNodes, node.Name)
+		}
+	}
+}
+
+// selectByBandwidth speed-tests the lowest-latency nodes and returns the one
+// with the highest download speed, or nil if every test failed
+func (m *Manager) selectByBandwidth(nodes []proxy.Node) *proxy.Node {
+	candidates := fastestNodes(nodes, bandwidthCandidates)
+
+	fmt.Printf(i18n.T("Testing bandwidth of %d nodes...\n"), len(candidates))
+	m.SpeedTest(candidates, nil)
+
+	var fastest *proxy.Node
+	for i := range candidates {
+		if candidates[i].Speed > 0 && (fastest == nil || candidates[i].Speed > fastest.Speed) {
+			fastest = &candidates[i]
+		}
+	}
+	return fastest
+}
+
+// SpeedTest downloads a test payload through each node using a temporary core
+// instance and records the speeds. report, if set, is called after each node.
+func (m *Manager) SpeedTest(nodes []proxy.Node, report func(node *proxy.Node, err error)) error {
+	for i := range nodes {
+		err := m.core.SpeedTest(&nodes[i], m.config.Proxy.SpeedTestURL)
+		if report != nil {
+			report(&nodes[i], err)
+		}
+	}
+
+	stats, err := m.NodeStats()
+	if err != nil {
+		return err
+	}
+	stats.RecordSpeed(nodes)
+	return stats.Save()
+}
+
+// NodeFilter returns the configured node filter
+func (m *Manager) NodeFilter() (*proxy.NodeFilter, error) {
+	f := m.config.Proxy.Filter
+	filter, err := proxy.NewNodeFilter(f.Include, f.Exclude, f.Types, f.Regions)
+	if err != nil {
+		return nil, &config.Error{Err: err}
+	}
+	return filter, nil
+}
+
+// DisableProxy stops the proxy
+func (m *Manager) DisableProxy() error {
+	if err := m.core.Stop(); err != nil {
+		return err
+	}
+	m.disableServiceProxies()
+
+	m.config.Proxy.CurrentNode = ""
+	m.config.Save()
+
+	return nil
+}
+
+// GetProxyStatus returns the proxy status
+func (m *Manager) GetProxyStatus() string {
+	if m.core.IsRunning() {
+		ports := fmt.Sprintf("port %d", m.config.Proxy.LocalPort)
+		if m.config.Proxy.HTTPPort > 0 {
+			ports = fmt.Sprintf("socks %d, http %d", m.config.Proxy.LocalPort, m.config.Proxy.HTTPPort)
+		}
+		if balanced := len(m.config.Proxy.BalancedNodes); balanced > 1 {
+			return fmt.Sprintf("running (%s, balancing %d nodes)", ports, balanced)
+		}
+		return fmt.Sprintf("running (%s, node: %s)", ports, m.config.Proxy.CurrentNode)
+	}
+	return "stopped"
+}
+
+// FetchSubscription returns the configured subscription. A cached copy younger
+// than the configured max age is used as is; otherwise the subscription is
+// re-fetched, falling back to the cache if that fails.
+func (m *Manager) FetchSubscription() (*proxy.Subscription, error) {
+	if m.config.Proxy.SubscriptionURL == "" {
+		return nil, config.ErrNoSubscription
+	}
+
+	cache, err := m.loadSubscriptionCache()
+	if err != nil {
+		fmt.Printf(i18n.T("Warning: %v\n"), err)
+	}
+
+	if cache != nil && time.Since(cache.FetchedAt) < m.config.Proxy.SubscriptionMaxAge {
+		fmt.Printf(i18n.T("Using cached subscription from %s\n"), cache.FetchedAt.Local().Format("2006-01-02 15:04"))
+		return cache.Subscription(), nil
+	}
+
+	sub, err := m.UpdateSubscription()
+	if err != nil {
+		if cache == nil {
+			return nil, err
+		}
+		fmt.Printf("⚠ %v\n", err)
+		fmt.Printf(i18n.T("  Using cached subscription from %s\n"), cache.FetchedAt.Local().Format("2006-01-02 15:04"))
+		return cache.Subscription(), nil
+	}
+
+	return sub, nil
+}
+
+// UpdateSubscription fetches the configured subscription and refreshes the cache
+func (m *Manager) UpdateSubscription() (*proxy.Subscription, error) {
+	if m.config.Proxy.SubscriptionURL == "" {
+		return nil, config.ErrNoSubscription
+	}
+
+	fmt.Println(i18n.T("Fetching subscription..."))
+	sub, err := proxy.FetchSubscription(m.config.Proxy.SubscriptionURL, m.config.Proxy.Upstream)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("failed to fetch subscription: %w"), err)
+	}
+
+	printSkippedNodes(sub)
+
+	if path, err := m.subscriptionCachePath(); err == nil {
+		err = proxy.SaveSubscriptionCache(path, sub)
+		if err != nil {
+			fmt.Printf(i18n.T("Warning: %v\n"), err)
+		}
+	}
+
+	return sub, nil
+}
+
+// printSkippedNodes lists the subscription entries that couldn't be used and why
+func printSkippedNodes(sub *proxy.Subscription) {
+	if len(sub.Skipped) == 0 {
+		return
+	}
+
+	fmt.Printf(i18n.T("⚠ Skipped %d nodes:\n"), len(sub.Skipped))
+	for _, node := range sub.Skipped {
+		fmt.Printf("  - %s: %s\n", node.Name, node.Reason)
+	}
+}
+
+// loadSubscriptionCache returns the cached subscription if it belongs to the configured URL
+func (m *Manager) loadSubscriptionCache() (*proxy.CachedSubscription, error) {
+	path, err := m.subscriptionCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := proxy.LoadSubscriptionCache(path)
+	if err != nil || cache == nil || cache.URL != m.config.Proxy.SubscriptionURL {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// subscriptionCachePath returns the path of the subscription cache file
+func (m *Manager) subscriptionCachePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "subscription.json"), nil
+}
+
+// FetchNodes returns the nodes of the configured subscription (see FetchSubscription)
+func (m *Manager) FetchNodes() ([]proxy.Node, error) {
+	sub, err := m.FetchSubscription()
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.Nodes, nil
+}
+
+// SwitchNode pins the node with the given name (or 1-based index) and
+// restarts the proxy on it
+func (m *Manager) SwitchNode(ref string) (*proxy.Node, error) {
+	nodes, err := m.FetchNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := proxy.FindNode(nodes, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.core.Download(); err != nil {
+		return nil, fmt.Errorf(i18n.T("failed to download %s: %w"), m.core.Name(), err)
+	}
+
+	m.config.Proxy.PinnedNode = node.Name
+	if err := m.restartProxy(node); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// GeoDataDue reports whether the geo data files are older than proxy.geodata_max_age,
+// or missing for a core that doesn't download them itself
+func (m *Manager) GeoDataDue() bool {
+	if !m.usesGeoData() {
+		return false
+	}
+	maxAge := m.config.Proxy.GeoDataMaxAge
+	age, ok := m.xray.GeoDataAge()
+	if !ok {
+		return m.core.Name() != proxy.CoreXray
+	}
+	return maxAge > 0 && age > maxAge
+}
+
+// usesGeoData reports whether the core routes with the geoip and geosite files;
+// sing-box keeps its own rule sets up to date
+func (m *Manager) usesGeoData() bool {
+	return m.core.Name() != proxy.CoreSingBox
+}
+
+// UpdateGeoData refreshes the geo data files and returns the names of those
+// that changed. A running proxy is restarted to load changed files.
+func (m *Manager) UpdateGeoData() ([]string, error) {
+	updated, err := m.xray.UpdateGeoData()
+	if err != nil || len(updated) == 0 || !m.usesGeoData() {
+		return updated, err
+	}
+
+	if err := m.ReloadProxy(); err != nil {
+		return updated, fmt.Errorf(i18n.T("failed to restart the proxy: %w"), err)
+	}
+	return updated, nil
+}
+
+// CheckProxy verifies that the running proxy can reach the probe URL
+func (m *Manager) CheckProxy() error {
+	return m.core.CheckHealth(m.config.Proxy.ProbeURL)
+}
+
+// Failover restarts the proxy on the best node other than the current one
+func (m *Manager) Failover() (*proxy.Node, error) {
+	sub, err := m.FetchSubscription()
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the failing node, or all balanced nodes: the balancer would have
+	// avoided a single failing one
+	failed := append([]string{m.config.Proxy.CurrentNode}, m.config.Proxy.BalancedNodes...)
+	others := []proxy.Node{}
+	for _, node := range sub.Nodes {
+		if !slices.Contains(failed, node.Name) {
+			others = append(others, node)
+		}
+	}
+	sub.Nodes = others
+
+	node, err := m.selectNode(sub)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.T("failed to select node: %w"), err)
+	}
+
+	if err := m.restartProxy(m.balanceNodes(sub, node)...); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// UnpinNode returns to automatic node selection and restarts the proxy if it is running
+func (m *Manager) UnpinNode() error {
+	m.config.Proxy.PinnedNode = ""
+	if err := m.config.Save(); err != nil {
+		return err
+	}
+
+	if !m.core.IsRunning() {
+		return nil
+	}
+	return m.RefreshProxy()
+}
+
+// RefreshProxy re-fetches the subscription and restarts the proxy on the pinned or fastest node
+func (m *Manager) RefreshProxy() error {
+	if _, err := m.UpdateSubscription(); err != nil {
+		return err
+	}
+
+	if m.core.IsRunning() {
+		if err := m.core.Stop(); err != nil {
+			return fmt.Errorf(i18n.T("failed to stop %s: %w"), m.core.Name(), err)
+		}
+	}
+
+	return m.EnableProxy()
+}
+
+// ReloadProxy restarts a running proxy on the nodes it uses, so that changed
+// settings like routing rules take effect
+func (m *Manager) ReloadProxy() error {
+	if !m.core.IsRunning() {
+		return nil
+	}
+
+	nodes, err := m.FetchNodes()
+	if err != nil {
+		return err
+	}
+
+	names := m.config.Proxy.BalancedNodes
+	if len(names) == 0 {
+		names = []string{m.config.Proxy.CurrentNode}
+	}
+	active := []*proxy.Node{}
+	for i := range nodes {
+		if slices.Contains(names, nodes[i].Name) {
+			active = append(active, &nodes[i])
+		}
+	}
+
+	// The nodes are gone from the subscription; select new ones
+	if len(active) == 0 {
+		if err := m.core.Stop(); err != nil {
+			return fmt.Errorf(i18n.T("failed to stop %s: %w"), m.core.Name(), err)
+		}
+		return m.EnableProxy()
+	}
+
+	return m.restartProxy(active...)
+}
+
+// restartProxy regenerates the core config for nodes and (re)starts the core
+func (m *Manager) restartProxy(nodes ...*proxy.Node) error {
+	if err := m.generateConfig(nodes); err != nil {
+		return fmt.Errorf(i18n.T("failed to generate %s config: %w"), m.core.Name(), err)
+	}
+
+	if m.core.IsRunning() {
+		if err := m.core.Stop(); err != nil {
+			return fmt.Errorf(i18n.T("failed to stop %s: %w"), m.core.Name(), err)
+		}
+	}
+
+	if err := m.core.Start(); err != nil {
+		return fmt.Errorf(i18n.T("failed to start %s: %w"), m.core.Name(), err)
+	}
+
+	m.config.Proxy.Enabled = true
+	m.setCurrentNodes(nodes)
+	if err := m.config.Save(); err != nil {
+		fmt.Printf(i18n.T("Warning: failed to save config: %v\n"), err)
+	}
+
+	return nil
+}
+
+// NodeStats loads the stored node test results
+func (m *Manager) NodeStats() (*proxy.NodeStats, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.LoadNodeStats(filepath.Join(dir, "nodes.json"))
+}
+
+// recordLatency stores the latency of tested nodes; failures are only warned about
+func (m *Manager) recordLatency(nodes []proxy.Node) {
+	stats, err := m.NodeStats()
+	if err == nil {
+		stats.RecordLatency(nodes)
+		err = stats.Save()
+	}
+	if err != nil {
+		fmt.Printf(i18n.T("Warning: failed to save node latency: %v\n"), err)
+	}
+}
+
+// ReadLogs returns the last n lines of the proxy core log (all lines if n <= 0)
+func (m *Manager) ReadLogs(n int) ([]string, error) {
+	file, err := os.Open(m.core.LogPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf(i18n.T("failed to open log file: %w"), err)
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(i18n.T("failed to read log file: %w"), err)
+	}
+
+	return lines, nil
+}
+
+// RotateLog rotates the proxy core log if it grew too big or old.
+// It reports whether the log was rotated.
+func (m *Manager) RotateLog() (bool, error) {
+	return m.core.RotateLog()
+}
+
+// ExpireIfDue turns acceleration off if a temporary enable has expired.
+// It reports whether acceleration was turned off.
+func (m *Manager) ExpireIfDue() (bool, error) {
+	if m.config.ExpiresAt.IsZero() || time.Now().Before(m.config.ExpiresAt) {
+		return false, nil
+	}
+
+	var errors []error
+	if err := m.DisableMirrors(); err != nil {
+		errors = append(errors, err)
+	}
+	if err := m.DisableProxy(); err != nil {
+		errors = append(errors, err)
+	}
+
+	m.config.Mirror.Enabled = false
+	m.config.Proxy.Enabled = false
+	m.config.ExpiresAt = time.Time{}
+	if err := m.config.Save(); err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return true, fmt.Errorf(i18n.T("acceleration expired but cleanup failed: %v"), errors)
+	}
+
+	return true, nil
+}
+
+// GetProxyCore returns the proxy core that runs the nodes
+func (m *Manager) GetProxyCore() proxy.ProxyCore {
+	return m.core
 }
 
 // GetXrayManager returns the Xray manager instance
@@ -341,21 +2442,21 @@ func (m *Manager) GetXrayManager() *proxy.XrayManager {
 // printDockerRestartInstructions prints instructions for restarting Docker daemon
 func (m *Manager) printDockerRestartInstructions() {
 	fmt.Println()
-	fmt.Println("⚠ Docker daemon restart required to apply changes:")
+	fmt.Println(i18n.T("⚠ Docker daemon restart required to apply changes:"))
 	fmt.Println()
 
 	// Detect OS and show appropriate restart instructions
 	if runtime.GOOS == "darwin" {
-		fmt.Println("  macOS (Docker Desktop):")
+		fmt.Println(i18n.T("  macOS (Docker Desktop):"))
 		fmt.Println("    killall Docker && open -a Docker")
 	} else if runtime.GOOS == "linux" {
-		fmt.Println("  Linux:")
+		fmt.Println(i18n.T("  Linux:"))
 		fmt.Println("    sudo systemctl restart docker")
 	} else {
 		// Windows or other
-		fmt.Println("  Restart Docker Desktop from the system tray")
+		fmt.Println(i18n.T("  Restart Docker Desktop from the system tray"))
 	}
 
 	fmt.Println()
-	fmt.Println("After restart, test with: docker pull nginx:alpine")
+	fmt.Println(i18n.T("After restart, test with: docker pull nginx:alpine"))
 }
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/config"
+)
+
+// Event types emitted on the event stream
+const (
+	EventState              = "state"
+	EventProxyUp            = "proxy-up"
+	EventProxyDown          = "proxy-down"
+	EventNodeSwitch         = "node-switch"
+	EventSubscriptionExpiry = "subscription-expiry"
+)
+
+const (
+	// statePollInterval is how often the watcher checks proxy state
+	statePollInterval = 2 * time.Second
+	// expiryCheckInterval is how often the subscription expiry is re-fetched
+	expiryCheckInterval = 6 * time.Hour
+	// expiryWarnWindow is how long before expiry subscription-expiry events start
+	expiryWarnWindow = 3 * 24 * time.Hour
+)
+
+// Event is a single message on the event stream
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// eventHub fans events out to connected subscribers
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	last        proxyState
+}
+
+// proxyState is the snapshot the watcher compares between polls
+type proxyState struct {
+	Running bool
+	Node    string
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// subscribe registers a new subscriber channel
+func (h *eventHub) subscribe() chan Event {
+	ch := make(chan Event, 16)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes and closes a subscriber channel
+func (h *eventHub) unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	delete(h.subscribers, ch)
+	h.mu.Unlock()
+	close(ch)
+}
+
+// publish sends an event to every subscriber, dropping it for slow ones
+func (h *eventHub) publish(eventType string, data map[string]interface{}) {
+	event := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// snapshot returns the current state as a "state" event
+func (h *eventHub) snapshot() Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return Event{
+		Type: EventState,
+		Time: time.Now(),
+		Data: map[string]interface{}{
+			"running": h.last.Running,
+			"node":    h.last.Node,
+		},
+	}
+}
+
+// watch polls crosh state and publishes events when it changes
+func (h *eventHub) watch() {
+	h.mu.Lock()
+	h.last = readProxyState()
+	h.mu.Unlock()
+
+	stateTicker := time.NewTicker(statePollInterval)
+	defer stateTicker.Stop()
+
+	h.checkExpiry()
+	expiryTicker := time.NewTicker(expiryCheckInterval)
+	defer expiryTicker.Stop()
+
+	for {
+		select {
+		case <-stateTicker.C:
+			h.checkState()
+		case <-expiryTicker.C:
+			h.checkExpiry()
+		}
+	}
+}
+
+// checkState compares current proxy state against the last poll
+func (h *eventHub) checkState() {
+	current := readProxyState()
+
+	h.mu.Lock()
+	previous := h.last
+	h.last = current
+	h.mu.Unlock()
+
+	switch {
+	case current.Running && !previous.Running:
+		h.publish(EventProxyUp, map[string]interface{}{"node": current.Node})
+	case !current.Running && previous.Running:
+		h.publish(EventProxyDown, nil)
+	case current.Running && current.Node != previous.Node:
+		h.publish(EventNodeSwitch, map[string]interface{}{
+			"from": previous.Node,
+			"to":   current.Node,
+		})
+	}
+}
+
+// checkExpiry fetches the subscription and warns when it is about to expire
+func (h *eventHub) checkExpiry() {
+	cfg, err := config.Load()
+	if err != nil || cfg.Proxy.SubscriptionURL == "" {
+		return
+	}
+
+	sub, err := accelerator.NewManager(cfg).FetchSubscription()
+	if err != nil || sub.Expire.IsZero() {
+		return
+	}
+
+	remaining := time.Until(sub.Expire)
+	if remaining > expiryWarnWindow {
+		return
+	}
+
+	h.publish(EventSubscriptionExpiry, map[string]interface{}{
+		"expire":    sub.Expire,
+		"days_left": int(remaining.Hours() / 24),
+		"expired":   remaining <= 0,
+	})
+}
+
+// readProxyState loads the proxy state from config and the proxy core PID file
+func readProxyState() proxyState {
+	cfg, err := config.Load()
+	if err != nil {
+		return proxyState{}
+	}
+
+	state := proxyState{Running: accelerator.NewManager(cfg).GetProxyCore().IsRunning()}
+	if state.Running {
+		state.Node = cfg.Proxy.CurrentNode
+	}
+	return state
+}
+
+// handleEvents streams events to the client as server-sent events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	writeEvent(w, s.events.snapshot())
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			writeEvent(w, event)
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single server-sent event
+func writeEvent(w http.ResponseWriter, event Event) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
@@ -0,0 +1,484 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// Server exposes crosh operations over a local HTTP API
+type Server struct {
+	addr   string
+	token  string
+	web    bool
+	mu     sync.Mutex
+	events *eventHub
+}
+
+// NewServer creates a new API server listening on addr and requiring token
+func NewServer(addr, token string) *Server {
+	return &Server{
+		addr:   addr,
+		token:  token,
+		events: newEventHub(),
+	}
+}
+
+// EnableWeb serves the web dashboard at /, alongside the API
+func (s *Server) EnableWeb() {
+	s.web = true
+}
+
+// LoadOrCreateToken returns the API token stored in the crosh config directory,
+// generating a new random token on first use
+func LoadOrCreateToken() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	tokenPath := filepath.Join(configDir, "api.token")
+	if data, err := os.ReadFile(tokenPath); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := logging.WriteFile(tokenPath, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write API token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/nodes", s.handleNodes)
+	mux.HandleFunc("/api/nodes/switch", s.handleSwitch)
+	mux.HandleFunc("/api/mirrors", s.handleMirrors)
+	mux.HandleFunc("/api/mirrors/enable", s.handleMirrorsEnable)
+	mux.HandleFunc("/api/mirrors/disable", s.handleMirrorsDisable)
+	mux.HandleFunc("/api/logs", s.handleLogs)
+	mux.HandleFunc("/api/update", s.handleUpdate)
+	mux.HandleFunc("/api/proxy/enable", s.handleProxyEnable)
+	mux.HandleFunc("/api/proxy/disable", s.handleProxyDisable)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/traffic", s.handleTraffic)
+	if !s.web {
+		return s.authenticate(mux)
+	}
+
+	// The dashboard page itself needs no token; it asks for one to call the API
+	root := http.NewServeMux()
+	root.Handle("/api/", s.authenticate(mux))
+	root.Handle("/", webHandler())
+	return root
+}
+
+// ListenAndServe starts the event watcher and serves the API
+func (s *Server) ListenAndServe() error {
+	go s.events.watch()
+	return http.ListenAndServe(s.addr, s.Handler())
+}
+
+// authenticate rejects requests that don't carry the API token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing API token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// load reads the latest config from disk and creates a manager for it.
+// The CLI may change the config between requests, so it is never cached.
+func (s *Server) load() (*config.Config, *accelerator.Manager, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, accelerator.NewManager(cfg), nil
+}
+
+// StatusResponse is returned by GET /api/status
+type StatusResponse struct {
+	Mirrors MirrorsResponse `json:"mirrors"`
+	Proxy   ProxyStatus     `json:"proxy"`
+	// ExpiresAt is when temporarily enabled acceleration turns off
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewStatusResponse describes the state of mirrors and proxy
+func NewStatusResponse(cfg *config.Config, manager *accelerator.Manager) StatusResponse {
+	status := StatusResponse{
+		Mirrors: NewMirrorsResponse(cfg, manager),
+		Proxy: ProxyStatus{
+			Configured:      cfg.Proxy.SubscriptionURL != "",
+			Enabled:         cfg.Proxy.Enabled,
+			Running:         manager.GetProxyCore().IsRunning(),
+			Core:            manager.GetProxyCore().Name(),
+			Port:            cfg.Proxy.LocalPort,
+			HTTPPort:        cfg.Proxy.HTTPPort,
+			CurrentNode:     cfg.Proxy.CurrentNode,
+			BalancedNodes:   cfg.Proxy.BalancedNodes,
+			PinnedNode:      cfg.Proxy.PinnedNode,
+			SubscriptionURL: cfg.Proxy.SubscriptionURL,
+		},
+	}
+	if !cfg.ExpiresAt.IsZero() {
+		status.ExpiresAt = &cfg.ExpiresAt
+	}
+	return status
+}
+
+// MirrorsResponse is returned by GET /api/mirrors
+type MirrorsResponse struct {
+	Enabled bool              `json:"enabled"`
+	Status  map[string]string `json:"status"`
+}
+
+// NewMirrorsResponse describes the mirror each package manager uses
+func NewMirrorsResponse(cfg *config.Config, manager *accelerator.Manager) MirrorsResponse {
+	return MirrorsResponse{
+		Enabled: cfg.Mirror.Enabled,
+		Status:  manager.GetMirrorStatus(),
+	}
+}
+
+// ProxyStatus describes the state of the proxy
+type ProxyStatus struct {
+	Configured      bool     `json:"configured"`
+	Enabled         bool     `json:"enabled"`
+	Running         bool     `json:"running"`
+	Core            string   `json:"core"`
+	Port            int      `json:"port"`
+	HTTPPort        int      `json:"http_port,omitempty"`
+	CurrentNode     string   `json:"current_node,omitempty"`
+	BalancedNodes   []string `json:"balanced_nodes,omitempty"`
+	PinnedNode      string   `json:"pinned_node,omitempty"`
+	SubscriptionURL string   `json:"subscription_url,omitempty"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewStatusResponse(cfg, manager))
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	_, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	nodes, err := manager.FetchNodes()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	// Include the latency measured last, if any
+	if stats, err := manager.NodeStats(); err == nil {
+		for i := range nodes {
+			if stat, ok := stats.Get(nodes[i].Name); ok && !stat.TestedAt.IsZero() {
+				nodes[i].Latency = stat.Latency
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func (s *Server) handleSwitch(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body must be {\"name\": \"<node name>\"}"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	node, err := manager.SwitchNode(req.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"current_node": node.Name})
+}
+
+func (s *Server) handleMirrors(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewMirrorsResponse(cfg, manager))
+}
+
+func (s *Server) handleMirrorsEnable(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	cfg.Mirror.Enabled = true
+	if _, err := manager.EnableMirrors(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := cfg.Save(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, MirrorsResponse{Enabled: true, Status: manager.GetMirrorStatus()})
+}
+
+func (s *Server) handleMirrorsDisable(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := manager.DisableMirrors(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	cfg.Mirror.Enabled = false
+	if err := cfg.Save(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, MirrorsResponse{Enabled: false, Status: manager.GetMirrorStatus()})
+}
+
+func (s *Server) handleTraffic(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	_, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	traffic, err := manager.GetProxyCore().Traffic()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, traffic)
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	lines := 100
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid lines parameter: %s", v))
+			return
+		}
+		lines = n
+	}
+
+	_, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	logs, err := manager.ReadLogs(lines)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]string{"lines": logs})
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	cfg.Proxy.Enabled = true
+	if err := manager.RefreshProxy(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"current_node": cfg.Proxy.CurrentNode})
+}
+
+func (s *Server) handleProxyEnable(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !manager.GetProxyCore().IsRunning() {
+		cfg.Proxy.Enabled = true
+		if err := manager.EnableProxy(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	// Keep failover running for proxies started from a frontend
+	if daemon.HasWork(cfg) {
+		daemon.EnsureRunning()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"running": true, "current_node": cfg.Proxy.CurrentNode})
+}
+
+func (s *Server) handleProxyDisable(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, manager, err := s.load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := manager.DisableProxy(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	cfg.Proxy.Enabled = false
+	if err := cfg.Save(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"running": false})
+}
+
+// requireMethod writes a 405 response if the request method doesn't match
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
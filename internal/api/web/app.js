@@ -0,0 +1,295 @@
+"use strict";
+
+// How many traffic samples, one a second, the graph shows
+const GRAPH_SAMPLES = 60;
+
+const $ = (id) => document.getElementById(id);
+
+let token = "";
+let status = null;
+let nodes = [];
+let samples = [];
+let lastTraffic = null;
+
+// The link crosh serve --web prints carries the token in the fragment, which
+// browsers don't send to the server; keep it for this tab and hide it
+function loadToken() {
+  const match = location.hash.match(/token=([^&]+)/);
+  if (match) {
+    sessionStorage.setItem("crosh-token", decodeURIComponent(match[1]));
+    history.replaceState(null, "", location.pathname);
+  }
+  token = sessionStorage.getItem("crosh-token") || "";
+}
+
+async function api(method, path, body) {
+  const response = await fetch(path, {
+    method,
+    headers: {
+      Authorization: "Bearer " + token,
+      "Content-Type": "application/json",
+    },
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const data = await response.json().catch(() => ({}));
+  if (response.status === 401) {
+    showLogin();
+  }
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+function showLogin() {
+  sessionStorage.removeItem("crosh-token");
+  $("login").hidden = false;
+  $("dashboard").hidden = true;
+  setConnection("not connected", "bad");
+}
+
+function setConnection(text, state) {
+  const pill = $("connection");
+  pill.textContent = text;
+  pill.className = "pill " + (state || "");
+}
+
+function setMessage(text) {
+  $("message").textContent = text;
+}
+
+// run calls action with button disabled, reporting the outcome
+async function run(button, busy, action) {
+  button.disabled = true;
+  setMessage(busy);
+  try {
+    const result = await action();
+    setMessage(result || "");
+  } catch (err) {
+    setMessage("✗ " + err.message);
+  } finally {
+    button.disabled = false;
+    refreshStatus();
+  }
+}
+
+async function refreshStatus() {
+  try {
+    status = await api("GET", "/api/status");
+  } catch (err) {
+    if ($("login").hidden) {
+      setConnection("offline", "bad");
+    }
+    return;
+  }
+  $("login").hidden = true;
+  $("dashboard").hidden = false;
+  renderStatus();
+}
+
+function renderStatus() {
+  const proxy = status.proxy;
+  setConnection(proxy.running ? "proxy running" : "proxy stopped", proxy.running ? "ok" : "");
+
+  $("proxy-state").textContent = !proxy.configured
+    ? "not configured (crosh proxy set <subscription-url>)"
+    : proxy.running ? "running" : proxy.enabled ? "enabled, not running" : "stopped";
+  $("proxy-core").textContent = proxy.core;
+  $("proxy-ports").textContent = proxy.http_port
+    ? `SOCKS ${proxy.port}, HTTP ${proxy.http_port}`
+    : `SOCKS ${proxy.port}`;
+
+  let node = proxy.current_node || "-";
+  if (proxy.balanced_nodes && proxy.balanced_nodes.length > 1) {
+    node = `balancing ${proxy.balanced_nodes.join(", ")}`;
+  }
+  if (proxy.pinned_node) {
+    node += " (pinned)";
+  }
+  $("proxy-node").textContent = proxy.running ? node : "-";
+
+  const proxyToggle = $("proxy-toggle");
+  proxyToggle.textContent = proxy.running ? "Stop" : "Start";
+  proxyToggle.hidden = !proxy.configured;
+
+  const mirrors = status.mirrors;
+  $("mirrors-toggle").textContent = mirrors.enabled ? "Disable" : "Enable";
+  const table = $("mirrors");
+  table.replaceChildren();
+  for (const name of Object.keys(mirrors.status || {}).sort()) {
+    const value = mirrors.status[name];
+    const row = table.insertRow();
+    row.insertCell().textContent = name;
+    const cell = row.insertCell();
+    cell.textContent = value;
+    if (value === "disabled") {
+      row.className = "muted";
+    }
+  }
+
+  renderNodes();
+}
+
+async function refreshNodes() {
+  try {
+    nodes = await api("GET", "/api/nodes");
+  } catch (err) {
+    setMessage("✗ " + err.message);
+    return;
+  }
+  renderNodes();
+}
+
+function latencyText(node) {
+  if (node.latency > 0) {
+    return node.latency + "ms";
+  }
+  return node.latency < 0 ? "unreachable" : "-";
+}
+
+function renderNodes() {
+  const filter = $("nodes-filter").value.toLowerCase();
+  const proxy = status ? status.proxy : {};
+  const table = $("nodes");
+  table.replaceChildren();
+
+  for (const node of nodes) {
+    if (filter && !(node.name + " " + node.type).toLowerCase().includes(filter)) {
+      continue;
+    }
+    const row = table.insertRow();
+    if (node.name === proxy.current_node && proxy.running) {
+      row.className = "current";
+    }
+    row.insertCell().textContent = node.name;
+    row.insertCell().textContent = node.type;
+    row.insertCell().textContent = latencyText(node);
+
+    const button = document.createElement("button");
+    button.textContent = node.name === proxy.pinned_node ? "Pinned" : "Use";
+    button.addEventListener("click", () =>
+      run(button, `Switching to ${node.name}…`, async () => {
+        const result = await api("POST", "/api/nodes/switch", { name: node.name });
+        return `✓ Proxy now using node: ${result.current_node}`;
+      }));
+    row.insertCell().append(button);
+  }
+}
+
+function formatBytes(n) {
+  const units = ["B", "KB", "MB", "GB", "TB"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) {
+    n /= 1024;
+    i++;
+  }
+  return (i === 0 ? Math.round(n) : n.toFixed(1)) + " " + units[i];
+}
+
+async function refreshTraffic() {
+  let traffic;
+  try {
+    traffic = await api("GET", "/api/traffic");
+  } catch (err) {
+    // Stopped proxy, or counters turned off with proxy.stats_port: 0
+    lastTraffic = null;
+    $("traffic-up").textContent = "-";
+    $("traffic-down").textContent = "-";
+    return;
+  }
+
+  const now = Date.now();
+  if (lastTraffic && traffic.up >= lastTraffic.up && traffic.down >= lastTraffic.down) {
+    const seconds = (now - lastTraffic.time) / 1000;
+    samples.push({
+      up: (traffic.up - lastTraffic.up) / seconds,
+      down: (traffic.down - lastTraffic.down) / seconds,
+    });
+    samples = samples.slice(-GRAPH_SAMPLES);
+  }
+  lastTraffic = { up: traffic.up, down: traffic.down, time: now };
+
+  const rate = samples.length ? samples[samples.length - 1] : { up: 0, down: 0 };
+  $("traffic-up").textContent = `${formatBytes(traffic.up)} (${formatBytes(rate.up)}/s)`;
+  $("traffic-down").textContent = `${formatBytes(traffic.down)} (${formatBytes(rate.down)}/s)`;
+  drawGraph();
+}
+
+function drawGraph() {
+  const canvas = $("traffic-graph");
+  const ctx = canvas.getContext("2d");
+  const { width, height } = canvas;
+  ctx.clearRect(0, 0, width, height);
+
+  const peak = Math.max(1, ...samples.map((s) => Math.max(s.up, s.down)));
+  const style = getComputedStyle(document.documentElement);
+  for (const [key, color] of [["down", "--down"], ["up", "--up"]]) {
+    ctx.beginPath();
+    ctx.strokeStyle = style.getPropertyValue(color);
+    ctx.lineWidth = 2;
+    samples.forEach((sample, i) => {
+      const x = (width * (i + GRAPH_SAMPLES - samples.length)) / (GRAPH_SAMPLES - 1);
+      const y = height - 2 - ((height - 4) * sample[key]) / peak;
+      if (i === 0) {
+        ctx.moveTo(x, y);
+      } else {
+        ctx.lineTo(x, y);
+      }
+    });
+    ctx.stroke();
+  }
+
+  ctx.fillStyle = style.getPropertyValue("--muted");
+  ctx.fillText(formatBytes(peak) + "/s", 4, 12);
+}
+
+// Refresh status as soon as the proxy starts, stops or switches nodes
+function watchEvents() {
+  const events = new EventSource("/api/events?token=" + encodeURIComponent(token));
+  for (const type of ["proxy-up", "proxy-down", "node-switch"]) {
+    events.addEventListener(type, refreshStatus);
+  }
+}
+
+function start() {
+  refreshStatus();
+  refreshNodes();
+  refreshTraffic();
+  watchEvents();
+  setInterval(refreshTraffic, 1000);
+  setInterval(refreshStatus, 10000);
+}
+
+$("login").addEventListener("submit", (event) => {
+  event.preventDefault();
+  sessionStorage.setItem("crosh-token", $("token").value.trim());
+  location.reload();
+});
+
+$("proxy-toggle").addEventListener("click", (event) => {
+  const running = status.proxy.running;
+  run(event.target, running ? "Stopping the proxy…" : "Starting the proxy…", async () => {
+    await api("POST", running ? "/api/proxy/disable" : "/api/proxy/enable");
+    return running ? "✓ Proxy disabled" : "✓ Proxy enabled";
+  });
+});
+
+$("mirrors-toggle").addEventListener("click", (event) => {
+  const enabled = status.mirrors.enabled;
+  run(event.target, enabled ? "Disabling mirrors…" : "Enabling mirrors…", async () => {
+    await api("POST", enabled ? "/api/mirrors/disable" : "/api/mirrors/enable");
+    return enabled ? "✓ Mirrors disabled" : "✓ Mirrors enabled";
+  });
+});
+
+$("nodes-refresh").addEventListener("click", (event) =>
+  run(event.target, "Loading nodes…", refreshNodes));
+
+$("nodes-filter").addEventListener("input", renderNodes);
+
+loadToken();
+if (token) {
+  start();
+} else {
+  showLogin();
+}
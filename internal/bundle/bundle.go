@@ -0,0 +1,224 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/logging"
+	"golang.org/x/crypto/pbkdf2"
+	"gopkg.in/yaml.v3"
+)
+
+// formatVersion is bumped when the bundle layout changes incompatibly
+const formatVersion = 1
+
+// pbkdf2Iterations is the key derivation work factor for encrypted subscriptions
+const pbkdf2Iterations = 200000
+
+// Bundle is a shareable snapshot of a team's crosh settings
+type Bundle struct {
+	Version      int                 `yaml:"version"`
+	CreatedAt    time.Time           `yaml:"created_at"`
+	Mirror       config.MirrorConfig `yaml:"mirror"`
+	Proxy        *ProxySettings      `yaml:"proxy,omitempty"`
+	Subscription *Subscription       `yaml:"subscription,omitempty"`
+}
+
+// ProxySettings holds the non-secret proxy settings shared in a bundle
+type ProxySettings struct {
+	LocalPort int `yaml:"local_port"`
+	HTTPPort  int `yaml:"http_port,omitempty"`
+	// Rules are the team's custom routing rules, which replace the importer's
+	Rules []config.RoutingRule `yaml:"rules,omitempty"`
+}
+
+// Subscription holds the subscription URL, either in plain text or encrypted
+type Subscription struct {
+	URL       string `yaml:"url,omitempty"`
+	Encrypted string `yaml:"encrypted,omitempty"`
+}
+
+// Options controls what goes into an exported bundle
+type Options struct {
+	// IncludeSubscription adds the subscription URL to the bundle
+	IncludeSubscription bool
+	// Passphrase encrypts the subscription URL when non-empty
+	Passphrase string
+}
+
+// New builds a bundle from the given config
+func New(cfg *config.Config, opts Options) (*Bundle, error) {
+	b := &Bundle{
+		Version:   formatVersion,
+		CreatedAt: time.Now().UTC(),
+		Mirror:    cfg.Mirror,
+		Proxy: &ProxySettings{
+			LocalPort: cfg.Proxy.LocalPort,
+			HTTPPort:  cfg.Proxy.HTTPPort,
+			Rules:     cfg.Proxy.Rules,
+		},
+	}
+	// The enabled flags and overwrite are per-machine choices, not a team preference
+	b.Mirror.Enabled = false
+	b.Mirror.Overwrite = false
+	b.Mirror.Tools = config.DefaultConfig().Mirror.Tools
+
+	if opts.IncludeSubscription && cfg.Proxy.SubscriptionURL != "" {
+		if opts.Passphrase != "" {
+			encrypted, err := encrypt([]byte(cfg.Proxy.SubscriptionURL), opts.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			b.Subscription = &Subscription{Encrypted: encrypted}
+		} else {
+			b.Subscription = &Subscription{URL: cfg.Proxy.SubscriptionURL}
+		}
+	}
+
+	return b, nil
+}
+
+// Load reads a bundle file
+func Load(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	// Mirrors added since the bundle was exported keep their defaults
+	b := &Bundle{Mirror: config.DefaultConfig().Mirror}
+	if err := yaml.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+
+	if b.Version == 0 || b.Version > formatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (this crosh supports up to %d)", b.Version, formatVersion)
+	}
+
+	return b, nil
+}
+
+// Save writes the bundle to path
+func (b *Bundle) Save(path string) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+
+	// Bundles may carry a subscription URL, so keep them private
+	if err := logging.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	return nil
+}
+
+// IsEncrypted reports whether the bundle carries an encrypted subscription
+func (b *Bundle) IsEncrypted() bool {
+	return b.Subscription != nil && b.Subscription.Encrypted != ""
+}
+
+// Apply merges the bundle into cfg. passphrase is only needed for
+// bundles with an encrypted subscription.
+func (b *Bundle) Apply(cfg *config.Config, passphrase string) error {
+	enabled, overwrite, tools := cfg.Mirror.Enabled, cfg.Mirror.Overwrite, cfg.Mirror.Tools
+	cfg.Mirror = b.Mirror
+	cfg.Mirror.Enabled, cfg.Mirror.Overwrite, cfg.Mirror.Tools = enabled, overwrite, tools
+
+	if b.Proxy != nil && b.Proxy.LocalPort != 0 {
+		cfg.Proxy.LocalPort = b.Proxy.LocalPort
+	}
+	if b.Proxy != nil && b.Proxy.HTTPPort != 0 {
+		cfg.Proxy.HTTPPort = b.Proxy.HTTPPort
+	}
+	if b.Proxy != nil && len(b.Proxy.Rules) > 0 {
+		cfg.Proxy.Rules = b.Proxy.Rules
+	}
+
+	if b.Subscription != nil {
+		subscriptionURL := b.Subscription.URL
+		if b.Subscription.Encrypted != "" {
+			plain, err := decrypt(b.Subscription.Encrypted, passphrase)
+			if err != nil {
+				return err
+			}
+			subscriptionURL = string(plain)
+		}
+		if subscriptionURL != "" {
+			cfg.Proxy.SubscriptionURL = subscriptionURL
+		}
+	}
+
+	return nil
+}
+
+// encrypt seals plaintext with AES-256-GCM using a passphrase-derived key.
+// The result is base64(salt | nonce | ciphertext).
+func encrypt(plaintext []byte, passphrase string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := append(salt, nonce...)
+	out = gcm.Seal(out, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// decrypt reverses encrypt
+func decrypt(encoded, passphrase string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted subscription: %w", err)
+	}
+
+	if len(data) < 16 {
+		return nil, fmt.Errorf("encrypted subscription is truncated")
+	}
+	salt := data[:16]
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 16+gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted subscription is truncated")
+	}
+	nonce := data[16 : 16+gcm.NonceSize()]
+
+	plain, err := gcm.Open(nil, nonce, data[16+gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted bundle")
+	}
+	return plain, nil
+}
+
+// newGCM creates an AES-256-GCM cipher keyed from passphrase and salt
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -1,37 +1,438 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/boomyao/crosh/internal/logging"
 )
 
 // Config represents the crosh configuration structure
 type Config struct {
 	Mirror MirrorConfig `yaml:"mirror"`
 	Proxy  ProxyConfig  `yaml:"proxy"`
+	API    APIConfig    `yaml:"api"`
+	// ExpiresAt is when temporarily enabled acceleration is turned off again
+	ExpiresAt time.Time `yaml:"expires_at,omitempty"`
+	// Language is the output language, "en" or "zh-CN"; empty follows LANG
+	Language string `yaml:"language,omitempty"`
 }
 
 // MirrorConfig contains mirror settings for package managers
 type MirrorConfig struct {
-	NPM     string   `yaml:"npm"`
-	Pip     string   `yaml:"pip"`
-	Apt     string   `yaml:"apt"`
-	Cargo   string   `yaml:"cargo"`
-	Go      string   `yaml:"go"`
-	Docker  []string `yaml:"docker"`
-	Enabled bool     `yaml:"enabled"`
+	NPM string `yaml:"npm"`
+	Pip string `yaml:"pip"`
+	// Pyenv is the mirror of python.org's releases pyenv builds Python from
+	Pyenv  string `yaml:"pyenv"`
+	Apt    string `yaml:"apt"`
+	Apk    string `yaml:"apk"`
+	Pacman string `yaml:"pacman"`
+	// ArchLinuxCN also adds the archlinuxcn repository from the pacman mirror
+	ArchLinuxCN bool   `yaml:"archlinuxcn"`
+	Cargo       string `yaml:"cargo"`
+	// Rustup is the mirror rustup downloads Rust toolchains from
+	Rustup string `yaml:"rustup"`
+	Go     string `yaml:"go"`
+	// GoSumDB is the checksum database Go verifies modules and toolchains from the proxy against
+	GoSumDB string `yaml:"gosumdb"`
+	// GoPrivate and GoNoSumDB are the GOPRIVATE and GONOSUMDB patterns of company
+	// modules, fetched directly and not checked against GoSumDB
+	GoPrivate string             `yaml:"goprivate"`
+	GoNoSumDB string             `yaml:"gonosumdb"`
+	Maven     string             `yaml:"maven"`
+	Gradle    GradleMirrorConfig `yaml:"gradle"`
+	Sbt       SbtMirrorConfig    `yaml:"sbt"`
+	// Sdkman is a mirror of SDKMAN's candidates API, https://api.sdkman.io/2;
+	// without one, sdkman.io is in proxy.always_proxy
+	Sdkman   string `yaml:"sdkman"`
+	Composer string `yaml:"composer"`
+	NuGet    string `yaml:"nuget"`
+	// Conan is a mirror of ConanCenter, added as Conan's first remote; without one,
+	// conan.io is in proxy.always_proxy
+	Conan string `yaml:"conan"`
+	Conda string `yaml:"conda"`
+	// HuggingFace is the endpoint huggingface_hub downloads models and datasets from
+	HuggingFace string `yaml:"huggingface"`
+	CRAN        string `yaml:"cran"`
+	// Bioconductor is the mirror R's Bioconductor packages come from, next to CRAN's
+	Bioconductor string `yaml:"bioconductor"`
+	CPAN         string `yaml:"cpan"`
+	TeXLive      string `yaml:"texlive"`
+	Hex          string `yaml:"hex"`
+	// Opam is a mirror of opam-repository, set as the default repository OCaml packages come from
+	Opam string `yaml:"opam"`
+	// LuaRocks is a mirror of luarocks.org, set first in LuaRocks' rocks_servers
+	LuaRocks string              `yaml:"luarocks"`
+	Haskell  HaskellMirrorConfig `yaml:"haskell"`
+	// Nix are mirrors of cache.nixos.org, set as the first substituters in nix.conf
+	Nix []string `yaml:"nix"`
+	// Bazel maps the upstreams Bazel fetches external dependencies from, such as
+	// github and golang, to mirrors it rewrites their URLs to
+	Bazel map[string]string `yaml:"bazel"`
+	// Node is the mirror Node.js releases come from, for version managers such as fnm and Volta
+	Node     string               `yaml:"node"`
+	Electron ElectronMirrorConfig `yaml:"electron"`
+	// Binaries maps .npmrc keys native npm packages download from, such as
+	// disturl and sass_binary_site, to their mirrors
+	Binaries map[string]string `yaml:"binaries"`
+	// Browsers maps the environment variables Puppeteer, Playwright and
+	// Cypress download from, such as PLAYWRIGHT_DOWNLOAD_HOST, to their mirrors
+	Browsers map[string]string `yaml:"browsers"`
+	// JSR is the mirror Deno takes jsr: packages from; npm: ones come from the npm mirror
+	JSR       string `yaml:"jsr"`
+	CocoaPods string `yaml:"cocoapods"`
+	// Helm maps chart repository names, such as bitnami, to their mirrors
+	Helm       map[string]string      `yaml:"helm"`
+	Kubernetes KubernetesMirrorConfig `yaml:"kubernetes"`
+	// Minikube is the --image-mirror-country minikube picks its image mirror by, such as cn
+	Minikube string `yaml:"minikube"`
+	// Terraform is the provider network mirror Terraform installs registry.terraform.io providers from
+	Terraform string `yaml:"terraform"`
+	// Winget is a mirror of the winget source, the package index winget looks packages up in on Windows
+	Winget string `yaml:"winget"`
+	// Scoop maps Scoop bucket names, such as main and extras, to mirrors of their git repositories
+	Scoop map[string]string `yaml:"scoop"`
+	// MSYS2 is the mirror host MSYS2's pacman installs MinGW toolchains from on Windows
+	MSYS2 string `yaml:"msys2"`
+	// Git maps hosts git clones from, such as github, to mirrors it clones from
+	// instead, through url.<mirror>.insteadOf in ~/.gitconfig; none are set by default
+	Git     map[string]string `yaml:"git"`
+	Docker  []string          `yaml:"docker"`
+	Enabled bool              `yaml:"enabled"`
+	// Tools turns single mirrors off for good; crosh leaves those tools' settings alone
+	Tools MirrorToolsConfig `yaml:"tools"`
+	// Overwrite replaces registries users set themselves instead of keeping them
+	Overwrite bool `yaml:"overwrite"`
+}
+
+// GradleMirrorConfig holds the mirrors of the repositories Gradle builds use
+// and of the Gradle distributions wrappers download
+type GradleMirrorConfig struct {
+	Central       string `yaml:"central"`
+	Google        string `yaml:"google"`
+	Plugins       string `yaml:"plugins"`
+	Distributions string `yaml:"distributions"`
+}
+
+// ElectronMirrorConfig holds the mirrors Electron and electron-builder
+// download their binaries from when installed
+type ElectronMirrorConfig struct {
+	Binaries        string `yaml:"binaries"`
+	BuilderBinaries string `yaml:"builder_binaries"`
+}
+
+// HaskellMirrorConfig holds the mirror of Hackage, which Stack and cabal
+// download packages from, and of Stackage, which Stack takes snapshots and
+// GHC builds from
+type HaskellMirrorConfig struct {
+	Hackage  string `yaml:"hackage"`
+	Stackage string `yaml:"stackage"`
+}
+
+// KubernetesMirrorConfig holds the pull-through mirror of registry.k8s.io
+// containerd pulls cluster images from, and the mirror of pkgs.k8s.io
+// kubeadm, kubelet and kubectl packages come from
+type KubernetesMirrorConfig struct {
+	Registry string `yaml:"registry"`
+	Packages string `yaml:"packages"`
+}
+
+// SbtMirrorConfig holds the mirrors of the Maven repository and of the Ivy
+// repository of sbt plugins that sbt resolves from
+type SbtMirrorConfig struct {
+	Maven string `yaml:"maven"`
+	Ivy   string `yaml:"ivy"`
+}
+
+// MirrorToolsConfig says which tools crosh sets mirrors for
+type MirrorToolsConfig struct {
+	NPM         bool `yaml:"npm"`
+	Yarn        bool `yaml:"yarn"`
+	Pnpm        bool `yaml:"pnpm"`
+	Pip         bool `yaml:"pip"`
+	Pyenv       bool `yaml:"pyenv"`
+	Apt         bool `yaml:"apt"`
+	Apk         bool `yaml:"apk"`
+	Pacman      bool `yaml:"pacman"`
+	Cargo       bool `yaml:"cargo"`
+	Go          bool `yaml:"go"`
+	Maven       bool `yaml:"maven"`
+	Gradle      bool `yaml:"gradle"`
+	Sbt         bool `yaml:"sbt"`
+	Sdkman      bool `yaml:"sdkman"`
+	Composer    bool `yaml:"composer"`
+	NuGet       bool `yaml:"nuget"`
+	Conan       bool `yaml:"conan"`
+	Conda       bool `yaml:"conda"`
+	HuggingFace bool `yaml:"huggingface"`
+	CRAN        bool `yaml:"cran"`
+	CPAN        bool `yaml:"cpan"`
+	TeXLive     bool `yaml:"texlive"`
+	Hex         bool `yaml:"hex"`
+	Opam        bool `yaml:"opam"`
+	LuaRocks    bool `yaml:"luarocks"`
+	Haskell     bool `yaml:"haskell"`
+	Nix         bool `yaml:"nix"`
+	Bazel       bool `yaml:"bazel"`
+	Deno        bool `yaml:"deno"`
+	Bun         bool `yaml:"bun"`
+	Node        bool `yaml:"node"`
+	Electron    bool `yaml:"electron"`
+	Binaries    bool `yaml:"binaries"`
+	Browsers    bool `yaml:"browsers"`
+	CocoaPods   bool `yaml:"cocoapods"`
+	Helm        bool `yaml:"helm"`
+	Kubernetes  bool `yaml:"kubernetes"`
+	Minikube    bool `yaml:"minikube"`
+	Terraform   bool `yaml:"terraform"`
+	Winget      bool `yaml:"winget"`
+	Scoop       bool `yaml:"scoop"`
+	MSYS2       bool `yaml:"msys2"`
+	Git         bool `yaml:"git"`
+	Docker      bool `yaml:"docker"`
+}
+
+// Enabled reports whether the tool with the given name, such as npm, is turned on
+func (t MirrorToolsConfig) Enabled(name string) bool {
+	switch name {
+	case "npm":
+		return t.NPM
+	case "yarn":
+		return t.Yarn
+	case "pnpm":
+		return t.Pnpm
+	case "pip":
+		return t.Pip
+	case "pyenv":
+		return t.Pyenv
+	case "apt":
+		return t.Apt
+	case "apk":
+		return t.Apk
+	case "pacman":
+		return t.Pacman
+	case "cargo":
+		return t.Cargo
+	case "go":
+		return t.Go
+	case "maven":
+		return t.Maven
+	case "gradle":
+		return t.Gradle
+	case "sbt":
+		return t.Sbt
+	case "sdkman":
+		return t.Sdkman
+	case "composer":
+		return t.Composer
+	case "nuget":
+		return t.NuGet
+	case "conan":
+		return t.Conan
+	case "conda":
+		return t.Conda
+	case "huggingface":
+		return t.HuggingFace
+	case "cran":
+		return t.CRAN
+	case "cpan":
+		return t.CPAN
+	case "texlive":
+		return t.TeXLive
+	case "hex":
+		return t.Hex
+	case "opam":
+		return t.Opam
+	case "luarocks":
+		return t.LuaRocks
+	case "haskell":
+		return t.Haskell
+	case "nix":
+		return t.Nix
+	case "bazel":
+		return t.Bazel
+	case "deno":
+		return t.Deno
+	case "bun":
+		return t.Bun
+	case "node":
+		return t.Node
+	case "electron":
+		return t.Electron
+	case "binaries":
+		return t.Binaries
+	case "browsers":
+		return t.Browsers
+	case "cocoapods":
+		return t.CocoaPods
+	case "helm":
+		return t.Helm
+	case "kubernetes":
+		return t.Kubernetes
+	case "minikube":
+		return t.Minikube
+	case "terraform":
+		return t.Terraform
+	case "winget":
+		return t.Winget
+	case "scoop":
+		return t.Scoop
+	case "msys2":
+		return t.MSYS2
+	case "git":
+		return t.Git
+	case "docker":
+		return t.Docker
+	}
+	return false
 }
 
 // ProxyConfig contains proxy settings
 type ProxyConfig struct {
 	SubscriptionURL string `yaml:"subscription_url"`
 	LocalPort       int    `yaml:"local_port"`
+	HTTPPort        int    `yaml:"http_port"`  // HTTP inbound next to the SOCKS one on local_port; 0 disables it
+	StatsPort       int    `yaml:"stats_port"` // loopback port the core reports traffic counters on; 0 disables them
 	Enabled         bool   `yaml:"enabled"`
+	Core            string `yaml:"core"` // proxy core that runs the nodes: xray, sing-box or mihomo
 	XrayPath        string `yaml:"xray_path"`
+	XrayVersion     string `yaml:"xray_version,omitempty"` // pins the Xray-core release, e.g. v1.8.24
 	CurrentNode     string `yaml:"current_node,omitempty"`
+	// Listen is the address the local proxy binds to; 0.0.0.0 shares it with the LAN
+	Listen string `yaml:"listen"`
+	// Auth requires a login for the local proxy when a username is set
+	Auth ProxyAuthConfig `yaml:"auth"`
+	// TUN routes all system traffic through the proxy; it needs root privileges
+	TUN TUNConfig `yaml:"tun"`
+	// BalancedNodes are the nodes Xray balances across, if balancing is active
+	BalancedNodes []string `yaml:"balanced_nodes,omitempty"`
+	// PinnedNode is a node chosen by the user; it is used instead of the fastest node
+	PinnedNode string `yaml:"pinned_node,omitempty"`
+	// Selection is how nodes are picked automatically: "latency" (default), "url-test" or "bandwidth"
+	Selection string `yaml:"selection,omitempty"`
+	// ProbeURL is requested through nodes by url-test selection
+	ProbeURL string `yaml:"probe_url,omitempty"`
+	// SpeedTestURL is downloaded through nodes to measure bandwidth
+	SpeedTestURL string `yaml:"speed_test_url,omitempty"`
+	// Failover lets the background daemon switch nodes when the active one stops
+	// responding; off by default, as it keeps the daemon running with the proxy
+	Failover bool `yaml:"failover"`
+	// SubscriptionMaxAge is how long the cached subscription is used before it is refreshed
+	SubscriptionMaxAge time.Duration `yaml:"subscription_max_age"`
+	// GeoDataMaxAge is how old the geoip and geosite files may get before they
+	// are refreshed when the proxy starts or by the daemon; 0 disables refreshes
+	GeoDataMaxAge time.Duration `yaml:"geodata_max_age"`
+	// GeoData is where the geoip and geosite files are downloaded from
+	GeoData GeoDataConfig `yaml:"geodata"`
+	// Filter limits which nodes automatic selection considers
+	Filter NodeFilterConfig `yaml:"filter"`
+	// BalanceNodes, when above 1, makes Xray balance traffic across this many of
+	// the fastest nodes instead of using only the fastest one
+	BalanceNodes int `yaml:"balance_nodes,omitempty"`
+	// Upstream is a proxy (http://, https:// or socks5://) that nodes, subscription
+	// fetches and downloads go through, e.g. a mandatory company proxy
+	Upstream string `yaml:"upstream,omitempty"`
+	// Rules route matching connections before the built-in rules that send
+	// private and Chinese destinations direct; the first matching rule wins
+	Rules []RoutingRule `yaml:"rules,omitempty"`
+	// AlwaysProxy are domains (with their subdomains) that always go through the
+	// proxy, even if stale geo data would send them direct
+	AlwaysProxy []string `yaml:"always_proxy"`
+	// Services are services and tools, such as ollama, snapd, Scoop and
+	// Chocolatey, that ignore the shell's proxy variables and get the proxy in
+	// their own configuration while it runs
+	Services []string `yaml:"services"`
+	// DNS is how Xray resolves domains; foreign ones are resolved through the proxy
+	DNS DNSConfig `yaml:"dns"`
+	// Sniffing reads domains from connections made to IP addresses, so domain rules still match
+	Sniffing SniffingConfig `yaml:"sniffing"`
+	// ClashRules is a Clash config whose rules and rule providers the mihomo core
+	// uses instead of the built-in rules sending Chinese destinations direct
+	ClashRules string `yaml:"clash_rules,omitempty"`
+	// Log sets the proxy core log level and when crosh rotates the log
+	Log LogConfig `yaml:"log"`
+}
+
+// LogConfig configures the proxy core log
+type LogConfig struct {
+	Level     string        `yaml:"level"`       // debug, info, warning, error or none
+	MaxSizeMB int           `yaml:"max_size_mb"` // rotate once the log is bigger; 0 disables
+	MaxAge    time.Duration `yaml:"max_age"`     // rotate once the log is older; 0 disables
+	MaxFiles  int           `yaml:"max_files"`   // rotated logs kept next to the log
+}
+
+// GeoDataConfig selects the geo data rule set and mirrors for its files
+type GeoDataConfig struct {
+	Variant     string   `yaml:"variant"`      // loyalsoldier, lite or v2fly
+	GeoIPURLs   []string `yaml:"geoip_urls"`   // geoip.dat mirrors tried instead of the variant's sources
+	GeoSiteURLs []string `yaml:"geosite_urls"` // geosite.dat mirrors tried instead of the variant's sources
+}
+
+// SniffingConfig configures domain sniffing on the local proxy
+type SniffingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	FakeDNS bool `yaml:"fake_dns"` // answer DNS queries in TUN mode with fake addresses
+}
+
+// DNSConfig configures the DNS servers of the Xray config
+type DNSConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Servers  []string `yaml:"servers"`  // DoH URLs or addresses for foreign domains
+	Domestic []string `yaml:"domestic"` // servers for geosite:cn domains
+}
+
+// RoutingRule sends connections to any of its domains or IPs, optionally
+// limited to some ports, to an outbound: "direct", "proxy" or "block"
+type RoutingRule struct {
+	Outbound string   `yaml:"outbound"`
+	Domains  []string `yaml:"domains,omitempty"` // e.g. example.com, geosite:google
+	IPs      []string `yaml:"ips,omitempty"`     // CIDRs or geoip:xx
+	Ports    string   `yaml:"ports,omitempty"`   // e.g. "22" or "1000-2000,3000"
+}
+
+// ProxyAuthConfig is the login for the local SOCKS and HTTP proxy
+type ProxyAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// TUNConfig configures the TUN interface of TUN mode
+type TUNConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Name    string `yaml:"name"` // interface name; crosh0 on Linux, utun9 on macOS if empty
+	MTU     int    `yaml:"mtu"`
+}
+
+// NodeFilterConfig limits which subscription nodes are used. Empty lists don't restrict anything.
+type NodeFilterConfig struct {
+	Include []string `yaml:"include"` // name regexes; a node must match one of them
+	Exclude []string `yaml:"exclude"` // name regexes; matching nodes are skipped
+	Types   []string `yaml:"types"`   // node types, e.g. trojan, vless
+	Regions []string `yaml:"regions"` // region codes detected from node names, e.g. HK, JP
+	// Prefer and Avoid are soft region preferences: selection tests only nodes in
+	// preferred regions and skips avoided ones, unless that leaves no nodes
+	Prefer []string `yaml:"prefer"`
+	Avoid  []string `yaml:"avoid"`
+}
+
+// Node selection strategies
+const (
+	SelectionLatency   = "latency"
+	SelectionURLTest   = "url-test"
+	SelectionBandwidth = "bandwidth"
+)
+
+// APIConfig contains settings for the local HTTP API server
+type APIConfig struct {
+	Listen string `yaml:"listen"`
+	Token  string `yaml:"token,omitempty"`
+	// Web serves the web dashboard from crosh serve
+	Web bool `yaml:"web,omitempty"`
 }
 
 // DefaultConfig returns a configuration with default values
@@ -39,28 +440,209 @@ func DefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 	return &Config{
 		Mirror: MirrorConfig{
-			NPM:   "https://registry.npmmirror.com",
-			Pip:   "https://mirrors.aliyun.com/pypi/simple/",
-			Apt:   "mirrors.aliyun.com",
-			Cargo: "https://mirrors.ustc.edu.cn/crates.io-index",
-			Go:    "https://goproxy.cn,direct",
+			NPM:     "https://registry.npmmirror.com",
+			Pip:     "https://mirrors.aliyun.com/pypi/simple/",
+			Pyenv:   "https://npmmirror.com/mirrors/python",
+			Apt:     "mirrors.aliyun.com",
+			Apk:     "mirrors.aliyun.com",
+			Pacman:  "mirrors.aliyun.com",
+			Cargo:   "https://mirrors.ustc.edu.cn/crates.io-index",
+			Rustup:  "https://mirrors.ustc.edu.cn/rust-static",
+			Go:      "https://goproxy.cn,direct",
+			GoSumDB: "sum.golang.google.cn",
+			Maven:   "https://maven.aliyun.com/repository/public",
+			Gradle: GradleMirrorConfig{
+				Central:       "https://maven.aliyun.com/repository/central",
+				Google:        "https://maven.aliyun.com/repository/google",
+				Plugins:       "https://maven.aliyun.com/repository/gradle-plugin",
+				Distributions: "https://mirrors.cloud.tencent.com/gradle/",
+			},
+			Sbt: SbtMirrorConfig{
+				Maven: "https://maven.aliyun.com/repository/public",
+				Ivy:   "https://repo.huaweicloud.com/repository/ivy/",
+			},
+			Composer:     "https://mirrors.aliyun.com/composer/",
+			NuGet:        "https://repo.huaweicloud.com/repository/nuget/v3/index.json",
+			Conda:        "https://mirrors.tuna.tsinghua.edu.cn/anaconda",
+			HuggingFace:  "https://hf-mirror.com",
+			CRAN:         "https://mirrors.tuna.tsinghua.edu.cn/CRAN/",
+			Bioconductor: "https://mirrors.tuna.tsinghua.edu.cn/bioconductor",
+			CPAN:         "https://mirrors.tuna.tsinghua.edu.cn/CPAN/",
+			TeXLive:      "https://mirrors.tuna.tsinghua.edu.cn/CTAN/systems/texlive/tlnet",
+			Hex:          "https://hexpm.upyun.com",
+			Opam:         "https://mirrors.tuna.tsinghua.edu.cn/git/opam-repository.git",
+			LuaRocks:     "https://luarocks.cn",
+			Haskell: HaskellMirrorConfig{
+				Hackage:  "https://mirrors.tuna.tsinghua.edu.cn/hackage/",
+				Stackage: "https://mirrors.tuna.tsinghua.edu.cn/stackage/",
+			},
+			Nix: []string{
+				"https://mirrors.tuna.tsinghua.edu.cn/nix-channels/store",
+				"https://mirrors.ustc.edu.cn/nix-channels/store",
+			},
+			Bazel: map[string]string{
+				"github": "https://ghfast.top/https://github.com",
+				"golang": "https://mirrors.aliyun.com/golang",
+				"maven":  "https://maven.aliyun.com/repository/central",
+				"npm":    "https://registry.npmmirror.com",
+			},
+			Node: "https://npmmirror.com/mirrors/node",
+			Electron: ElectronMirrorConfig{
+				Binaries:        "https://npmmirror.com/mirrors/electron/",
+				BuilderBinaries: "https://npmmirror.com/mirrors/electron-builder-binaries/",
+			},
+			Binaries: map[string]string{
+				"disturl":                   "https://npmmirror.com/mirrors/node/",
+				"sass_binary_site":          "https://npmmirror.com/mirrors/node-sass/",
+				"sharp_binary_host":         "https://npmmirror.com/mirrors/sharp",
+				"sharp_libvips_binary_host": "https://npmmirror.com/mirrors/sharp-libvips",
+				"canvas_binary_host_mirror": "https://npmmirror.com/mirrors/canvas",
+			},
+			Browsers: map[string]string{
+				"PUPPETEER_DOWNLOAD_BASE_URL": "https://cdn.npmmirror.com/binaries/chrome-for-testing",
+				"PLAYWRIGHT_DOWNLOAD_HOST":    "https://cdn.npmmirror.com/binaries/playwright",
+				"CYPRESS_DOWNLOAD_MIRROR":     "https://cdn.npmmirror.com/binaries/cypress",
+			},
+			CocoaPods: "https://mirrors.tuna.tsinghua.edu.cn/git/CocoaPods/Specs.git",
+			Helm: map[string]string{
+				"stable":  "https://kubernetes.oss-cn-hangzhou.aliyuncs.com/charts",
+				"bitnami": "https://helm-charts.itboon.top/bitnami",
+			},
+			Kubernetes: KubernetesMirrorConfig{
+				Registry: "k8s.m.daocloud.io",
+				Packages: "https://mirrors.aliyun.com/kubernetes-new",
+			},
+			Minikube:  "cn",
+			Terraform: "https://mirrors.tencent.com/terraform/",
+			Winget:    "https://mirrors.ustc.edu.cn/winget-source",
+			Scoop: map[string]string{
+				"main":   "https://gitee.com/scoop-installer/Main",
+				"extras": "https://gitee.com/scoop-installer/Extras",
+			},
+			MSYS2: "mirrors.tuna.tsinghua.edu.cn",
 			Docker: []string{
 				"docker.1ms.run",
 				"docker.m.daocloud.io",
 			},
 			Enabled: false,
+			Tools: MirrorToolsConfig{
+				NPM:         true,
+				Yarn:        true,
+				Pnpm:        true,
+				Pip:         true,
+				Pyenv:       true,
+				Apt:         true,
+				Apk:         true,
+				Pacman:      true,
+				Cargo:       true,
+				Go:          true,
+				Maven:       true,
+				Gradle:      true,
+				Sbt:         true,
+				Sdkman:      true,
+				Composer:    true,
+				NuGet:       true,
+				Conan:       true,
+				Conda:       true,
+				HuggingFace: true,
+				CRAN:        true,
+				CPAN:        true,
+				TeXLive:     true,
+				Hex:         true,
+				Opam:        true,
+				LuaRocks:    true,
+				Haskell:     true,
+				Nix:         true,
+				Bazel:       true,
+				Deno:        true,
+				Bun:         true,
+				Node:        true,
+				Electron:    true,
+				Binaries:    true,
+				Browsers:    true,
+				CocoaPods:   true,
+				Helm:        true,
+				Kubernetes:  true,
+				Minikube:    true,
+				Terraform:   true,
+				Winget:      true,
+				Scoop:       true,
+				MSYS2:       true,
+				Git:         true,
+				Docker:      true,
+			},
 		},
 		Proxy: ProxyConfig{
-			SubscriptionURL: "",
-			LocalPort:       7676,
-			Enabled:         false,
-			XrayPath:        filepath.Join(homeDir, ".crosh", "xray-core"),
+			SubscriptionURL:    "",
+			LocalPort:          7676,
+			HTTPPort:           7677,
+			StatsPort:          7678,
+			Listen:             "127.0.0.1",
+			Enabled:            false,
+			Core:               "xray",
+			XrayPath:           filepath.Join(homeDir, ".crosh", "xray-core"),
+			Failover:           false,
+			SubscriptionMaxAge: 12 * time.Hour,
+			GeoDataMaxAge:      7 * 24 * time.Hour,
+			GeoData: GeoDataConfig{
+				Variant: "loyalsoldier",
+			},
+			TUN: TUNConfig{
+				MTU: 1500,
+			},
+			DNS: DNSConfig{
+				Enabled:  true,
+				Servers:  []string{"https://1.1.1.1/dns-query", "https://8.8.8.8/dns-query"},
+				Domestic: []string{"223.5.5.5", "119.29.29.29"},
+			},
+			Sniffing: SniffingConfig{
+				Enabled: true,
+			},
+			Log: LogConfig{
+				Level:     "warning",
+				MaxSizeMB: 10,
+				MaxAge:    7 * 24 * time.Hour,
+				MaxFiles:  3,
+			},
+			// Developer services that are slow or blocked without the proxy
+			AlwaysProxy: []string{
+				"github.com", "githubusercontent.com", "githubassets.com", "ghcr.io",
+				"npmjs.org", "npmjs.com", "pypi.org", "pythonhosted.org",
+				"golang.org", "go.dev", "docker.io", "docker.com",
+				"huggingface.co", "hf.co", "sdkman.io",
+				"vagrantcloud.com", "vagrantup.com", "gems.hashicorp.com", "rubygems.org", "conan.io",
+				"chocolatey.org",
+			},
+			Services: []string{"chocolatey", "ollama", "scoop", "snapd"},
+			Filter: NodeFilterConfig{
+				// Providers list traffic and expiry info as fake nodes
+				Exclude: []string{"剩余", "到期", "过期", "官网", "流量", "套餐", "重置"},
+			},
+		},
+		API: APIConfig{
+			Listen: "127.0.0.1:7680",
 		},
 	}
 }
 
-// GetConfigPath returns the path to the config file
-func GetConfigPath() (string, error) {
+// ErrNoSubscription is returned by operations that need a subscription when none is configured
+var ErrNoSubscription = errors.New("no subscription URL configured")
+
+// Error is returned when the config file can't be read or a setting is invalid
+type Error struct {
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GetConfigDir returns the crosh config directory, creating it if needed
+func GetConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user home directory: %w", err)
@@ -71,6 +653,16 @@ func GetConfigPath() (string, error) {
 		return "", fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	return configDir, nil
+}
+
+// GetConfigPath returns the path to the config file
+func GetConfigPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
 	return filepath.Join(configDir, "config.yaml"), nil
 }
 
@@ -88,12 +680,13 @@ func Load() (*Config, error) {
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, &Error{Err: fmt.Errorf("failed to read config file: %w", err)}
 	}
 
-	config := &Config{}
+	// Start from defaults so sections missing from older config files are filled in
+	config := DefaultConfig()
 	if err := yaml.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, &Error{Err: fmt.Errorf("failed to parse config file: %w", err)}
 	}
 
 	return config, nil
@@ -111,9 +704,13 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	// The config holds the subscription URL and proxy password, keep it private
+	if err := logging.WriteFile(configPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	if err := os.Chmod(configPath, 0600); err != nil {
+		return fmt.Errorf("failed to set config file permissions: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,275 @@
+package config
+
+// MirrorPreset is a set of mirrors run by one provider. Tools the provider
+// has no mirror for are empty.
+type MirrorPreset struct {
+	Name      string
+	Title     string
+	NPM       string
+	Pip       string
+	Pyenv     string
+	Apt       string
+	Apk       string
+	Pacman    string
+	Cargo     string
+	Rustup    string
+	Go        string
+	Maven     string
+	Composer  string
+	NuGet     string
+	Conda     string
+	CRAN      string
+	CPAN      string
+	TeXLive   string
+	Node      string
+	CocoaPods string
+}
+
+// PresetTools are the tools mirror presets cover; Docker mirrors aren't run
+// by these providers for the public
+var PresetTools = []string{"npm", "pip", "pyenv", "apt", "apk", "pacman", "cargo", "rustup", "go", "maven", "composer", "nuget", "conda", "cran", "cpan", "texlive", "node", "cocoapods"}
+
+// MirrorPresets are the providers crosh mirrors preset switches between
+var MirrorPresets = []MirrorPreset{
+	{
+		Name:      "tsinghua",
+		Title:     "Tsinghua University TUNA",
+		Pip:       "https://pypi.tuna.tsinghua.edu.cn/simple",
+		Apt:       "mirrors.tuna.tsinghua.edu.cn",
+		Apk:       "mirrors.tuna.tsinghua.edu.cn",
+		Pacman:    "mirrors.tuna.tsinghua.edu.cn",
+		Cargo:     "https://mirrors.tuna.tsinghua.edu.cn/git/crates.io-index.git",
+		Rustup:    "https://mirrors.tuna.tsinghua.edu.cn/rustup",
+		Conda:     "https://mirrors.tuna.tsinghua.edu.cn/anaconda",
+		CRAN:      "https://mirrors.tuna.tsinghua.edu.cn/CRAN/",
+		CPAN:      "https://mirrors.tuna.tsinghua.edu.cn/CPAN/",
+		TeXLive:   "https://mirrors.tuna.tsinghua.edu.cn/CTAN/systems/texlive/tlnet",
+		Node:      "https://mirrors.tuna.tsinghua.edu.cn/nodejs-release",
+		CocoaPods: "https://mirrors.tuna.tsinghua.edu.cn/git/CocoaPods/Specs.git",
+	},
+	{
+		Name:     "aliyun",
+		Title:    "Alibaba Cloud",
+		NPM:      "https://registry.npmmirror.com",
+		Pip:      "https://mirrors.aliyun.com/pypi/simple/",
+		Apt:      "mirrors.aliyun.com",
+		Apk:      "mirrors.aliyun.com",
+		Pacman:   "mirrors.aliyun.com",
+		Cargo:    "sparse+https://mirrors.aliyun.com/crates.io-index/",
+		Rustup:   "https://mirrors.aliyun.com/rustup",
+		Go:       "https://mirrors.aliyun.com/goproxy/,direct",
+		Maven:    "https://maven.aliyun.com/repository/public",
+		Composer: "https://mirrors.aliyun.com/composer/",
+		Conda:    "https://mirrors.aliyun.com/anaconda",
+		CRAN:     "https://mirrors.aliyun.com/CRAN/",
+		CPAN:     "https://mirrors.aliyun.com/CPAN/",
+		TeXLive:  "https://mirrors.aliyun.com/CTAN/systems/texlive/tlnet",
+		Node:     "https://mirrors.aliyun.com/nodejs-release",
+	},
+	{
+		Name:      "ustc",
+		Title:     "USTC",
+		Pip:       "https://mirrors.ustc.edu.cn/pypi/simple",
+		Apt:       "mirrors.ustc.edu.cn",
+		Apk:       "mirrors.ustc.edu.cn",
+		Pacman:    "mirrors.ustc.edu.cn",
+		Cargo:     "https://mirrors.ustc.edu.cn/crates.io-index",
+		Rustup:    "https://mirrors.ustc.edu.cn/rust-static",
+		Conda:     "https://mirrors.ustc.edu.cn/anaconda",
+		CRAN:      "https://mirrors.ustc.edu.cn/CRAN/",
+		CPAN:      "https://mirrors.ustc.edu.cn/CPAN/",
+		TeXLive:   "https://mirrors.ustc.edu.cn/CTAN/systems/texlive/tlnet",
+		Node:      "https://mirrors.ustc.edu.cn/node",
+		CocoaPods: "https://mirrors.ustc.edu.cn/repo/CocoaPods/Specs.git",
+	},
+	{
+		Name:     "tencent",
+		Title:    "Tencent Cloud",
+		NPM:      "https://mirrors.cloud.tencent.com/npm/",
+		Pip:      "https://mirrors.cloud.tencent.com/pypi/simple",
+		Apt:      "mirrors.cloud.tencent.com",
+		Apk:      "mirrors.cloud.tencent.com",
+		Pacman:   "mirrors.cloud.tencent.com",
+		Go:       "https://mirrors.cloud.tencent.com/go/,direct",
+		Maven:    "https://mirrors.cloud.tencent.com/nexus/repository/maven-public/",
+		Composer: "https://mirrors.cloud.tencent.com/composer/",
+		Conda:    "https://mirrors.cloud.tencent.com/anaconda",
+		CRAN:     "https://mirrors.cloud.tencent.com/CRAN/",
+		CPAN:     "https://mirrors.cloud.tencent.com/CPAN/",
+		TeXLive:  "https://mirrors.cloud.tencent.com/CTAN/systems/texlive/tlnet",
+		Node:     "https://mirrors.cloud.tencent.com/nodejs-release",
+	},
+	{
+		Name:     "huawei",
+		Title:    "Huawei Cloud",
+		NPM:      "https://repo.huaweicloud.com/repository/npm/",
+		Pip:      "https://repo.huaweicloud.com/repository/pypi/simple",
+		Pyenv:    "https://repo.huaweicloud.com/python",
+		Apt:      "repo.huaweicloud.com",
+		Apk:      "repo.huaweicloud.com",
+		Pacman:   "repo.huaweicloud.com",
+		Go:       "https://mirrors.huaweicloud.com/goproxy/,direct",
+		Maven:    "https://repo.huaweicloud.com/repository/maven/",
+		Composer: "https://repo.huaweicloud.com/repository/php/",
+		NuGet:    "https://repo.huaweicloud.com/repository/nuget/v3/index.json",
+		Node:     "https://repo.huaweicloud.com/nodejs",
+	},
+}
+
+// FindMirrorPreset returns the preset with the given name
+func FindMirrorPreset(name string) (MirrorPreset, bool) {
+	for _, preset := range MirrorPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return MirrorPreset{}, false
+}
+
+// URL returns the preset's mirror for the tool with the given name, such as
+// npm, or "" if it has none
+func (p MirrorPreset) URL(tool string) string {
+	switch tool {
+	case "npm":
+		return p.NPM
+	case "pip":
+		return p.Pip
+	case "pyenv":
+		return p.Pyenv
+	case "apt":
+		return p.Apt
+	case "apk":
+		return p.Apk
+	case "pacman":
+		return p.Pacman
+	case "cargo":
+		return p.Cargo
+	case "rustup":
+		return p.Rustup
+	case "go":
+		return p.Go
+	case "maven":
+		return p.Maven
+	case "composer":
+		return p.Composer
+	case "nuget":
+		return p.NuGet
+	case "conda":
+		return p.Conda
+	case "cran":
+		return p.CRAN
+	case "cpan":
+		return p.CPAN
+	case "texlive":
+		return p.TeXLive
+	case "node":
+		return p.Node
+	case "cocoapods":
+		return p.CocoaPods
+	}
+	return ""
+}
+
+// Tools returns the names of the tools the preset has mirrors for
+func (p MirrorPreset) Tools() []string {
+	tools := []string{}
+	for _, tool := range PresetTools {
+		if p.URL(tool) != "" {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// URL returns the mirror of the tool with the given name, such as npm
+func (m *MirrorConfig) URL(tool string) string {
+	switch tool {
+	case "npm":
+		return m.NPM
+	case "pip":
+		return m.Pip
+	case "pyenv":
+		return m.Pyenv
+	case "apt":
+		return m.Apt
+	case "apk":
+		return m.Apk
+	case "pacman":
+		return m.Pacman
+	case "cargo":
+		return m.Cargo
+	case "rustup":
+		return m.Rustup
+	case "go":
+		return m.Go
+	case "maven":
+		return m.Maven
+	case "composer":
+		return m.Composer
+	case "nuget":
+		return m.NuGet
+	case "conda":
+		return m.Conda
+	case "cran":
+		return m.CRAN
+	case "cpan":
+		return m.CPAN
+	case "texlive":
+		return m.TeXLive
+	case "hex":
+		return m.Hex
+	case "node":
+		return m.Node
+	case "cocoapods":
+		return m.CocoaPods
+	case "winget":
+		return m.Winget
+	}
+	return ""
+}
+
+// SetURL sets the mirror of the tool with the given name, such as npm
+func (m *MirrorConfig) SetURL(tool, url string) {
+	switch tool {
+	case "npm":
+		m.NPM = url
+	case "pip":
+		m.Pip = url
+	case "pyenv":
+		m.Pyenv = url
+	case "apt":
+		m.Apt = url
+	case "apk":
+		m.Apk = url
+	case "pacman":
+		m.Pacman = url
+	case "cargo":
+		m.Cargo = url
+	case "rustup":
+		m.Rustup = url
+	case "go":
+		m.Go = url
+	case "maven":
+		m.Maven = url
+	case "composer":
+		m.Composer = url
+	case "nuget":
+		m.NuGet = url
+	case "conda":
+		m.Conda = url
+	case "cran":
+		m.CRAN = url
+	case "cpan":
+		m.CPAN = url
+	case "texlive":
+		m.TeXLive = url
+	case "hex":
+		m.Hex = url
+	case "node":
+		m.Node = url
+	case "cocoapods":
+		m.CocoaPods = url
+	case "winget":
+		m.Winget = url
+	}
+}
@@ -0,0 +1,296 @@
+package daemon
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/accelerator"
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/logging"
+	"github.com/boomyao/crosh/internal/process"
+)
+
+// tickInterval is how often the daemon re-reads config and runs its tasks
+const tickInterval = 15 * time.Second
+
+// failoverThreshold is how many health checks in a row must fail before switching nodes
+const failoverThreshold = 3
+
+// geoDataRetryInterval is how long to wait before retrying a failed geo data refresh
+const geoDataRetryInterval = time.Hour
+
+// proxyRetryInterval is how long a service daemon waits before retrying to start the proxy
+const proxyRetryInterval = time.Minute
+
+// Daemon runs crosh's scheduled background tasks
+type Daemon struct {
+	logger *log.Logger
+	// service is set for a daemon run by the service manager, which keeps
+	// running without work and starts the proxy if it is enabled
+	service bool
+
+	// failures counts consecutive failed proxy health checks
+	failures int
+	// geoDataAttempt is when geo data was last refreshed or tried to
+	geoDataAttempt time.Time
+	// proxyAttempt is when a service daemon last tried to start the proxy
+	proxyAttempt time.Time
+}
+
+// New creates a daemon logging to stdout
+func New() *Daemon {
+	return &Daemon{
+		logger: log.New(os.Stdout, "crosh-daemon: ", log.LstdFlags),
+	}
+}
+
+// NewService creates a daemon for the service manager to run, see crosh service
+func NewService() *Daemon {
+	d := New()
+	d.service = true
+	return d
+}
+
+// pidPath returns the path of the daemon PID file
+func pidPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "daemon.pid"), nil
+}
+
+// LogPath returns the path of the daemon log file
+func LogPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "daemon.log"), nil
+}
+
+// readPID returns the PID in the daemon PID file, or 0
+func readPID() int {
+	path, err := pidPath()
+	if err != nil {
+		return 0
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	var pid int
+	fmt.Sscanf(string(data), "%d", &pid)
+	return pid
+}
+
+// IsRunning checks whether a daemon process is alive
+func IsRunning() bool {
+	return process.Alive(readPID())
+}
+
+// EnsureRunning starts the daemon in the background unless it is already running
+func EnsureRunning() error {
+	if IsRunning() {
+		return nil
+	}
+	return Start()
+}
+
+// Start starts a daemon in the background with the given daemon flags
+func Start(args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate crosh executable: %w", err)
+	}
+
+	logPath, err := LogPath()
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create daemon log file: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := logging.Command(exe, append([]string{"daemon"}, args...)...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+
+	// The daemon writes its own PID file; don't wait for it to exit
+	return cmd.Process.Release()
+}
+
+// Stop asks the running daemon to exit by removing its PID file; it exits
+// within tickInterval
+func Stop() error {
+	path, err := pidPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove PID file: %w", err)
+	}
+	return nil
+}
+
+// Run executes the daemon loop until there is no work left, or another
+// daemon, such as one started by the service manager, takes over
+func (d *Daemon) Run() error {
+	path, err := pidPath()
+	if err != nil {
+		return err
+	}
+	pid := os.Getpid()
+	if err := logging.WriteFile(path, []byte(fmt.Sprintf("%d", pid)), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	defer func() {
+		if readPID() == pid {
+			os.Remove(path)
+		}
+	}()
+
+	d.logger.Printf("started (PID %d)", pid)
+
+	for {
+		if other := readPID(); other != pid {
+			if other == 0 {
+				d.logger.Println("asked to stop, exiting")
+			} else {
+				d.logger.Printf("daemon %d took over, exiting", other)
+			}
+			return nil
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			d.logger.Printf("failed to load config: %v", err)
+		} else {
+			if !HasWork(cfg) && !d.service {
+				d.logger.Println("nothing scheduled, exiting")
+				return nil
+			}
+			d.tick(cfg)
+		}
+
+		time.Sleep(tickInterval)
+	}
+}
+
+// HasWork reports whether cfg needs the daemon: a scheduled expiry or proxy failover
+func HasWork(cfg *config.Config) bool {
+	return !cfg.ExpiresAt.IsZero() || failoverActive(cfg)
+}
+
+// failoverActive reports whether the proxy should be health-checked. Proxies
+// loaded from a local YAML file have no subscription to fail over to.
+func failoverActive(cfg *config.Config) bool {
+	return cfg.Proxy.Enabled && cfg.Proxy.Failover && cfg.Proxy.SubscriptionURL != ""
+}
+
+// tick runs one round of scheduled tasks
+func (d *Daemon) tick(cfg *config.Config) {
+	manager := accelerator.NewManager(cfg)
+
+	expired, err := manager.ExpireIfDue()
+	if err != nil {
+		d.logger.Printf("%v", err)
+	} else if expired {
+		d.logger.Println("temporary acceleration expired, acceleration disabled")
+	}
+
+	// A proxy that was just started gets a tick to come up before its first health check
+	started := false
+	if d.service && cfg.Proxy.Enabled && time.Since(d.proxyAttempt) > proxyRetryInterval {
+		started = d.startProxy(manager)
+	}
+
+	if failoverActive(cfg) && !started {
+		d.checkProxy(cfg, manager)
+	}
+
+	if cfg.Proxy.Enabled {
+		if rotated, err := manager.RotateLog(); err != nil {
+			d.logger.Printf("%v", err)
+		} else if rotated {
+			d.logger.Println("rotated the proxy log")
+		}
+	}
+
+	if cfg.Proxy.Enabled && manager.GeoDataDue() && time.Since(d.geoDataAttempt) > geoDataRetryInterval {
+		d.refreshGeoData(manager)
+	}
+}
+
+// startProxy starts the proxy if it is enabled but not running, such as
+// after a reboot. It reports whether it started the proxy.
+func (d *Daemon) startProxy(manager *accelerator.Manager) bool {
+	if manager.GetProxyCore().IsRunning() {
+		return false
+	}
+
+	d.proxyAttempt = time.Now()
+	if err := manager.EnableProxy(); err != nil {
+		d.logger.Printf("failed to start the proxy: %v", err)
+		return false
+	}
+	d.logger.Println("started the proxy")
+	return true
+}
+
+// refreshGeoData updates outdated geo data, restarting the proxy if it changed
+func (d *Daemon) refreshGeoData(manager *accelerator.Manager) {
+	d.geoDataAttempt = time.Now()
+	updated, err := manager.UpdateGeoData()
+	if err != nil {
+		d.logger.Printf("geo data refresh failed: %v", err)
+		return
+	}
+	if len(updated) == 0 {
+		d.logger.Println("geo data is up to date")
+		return
+	}
+	d.logger.Printf("updated %s", strings.Join(updated, ", "))
+}
+
+// checkProxy probes the active node and fails over to another node
+// after failoverThreshold consecutive failures
+func (d *Daemon) checkProxy(cfg *config.Config, manager *accelerator.Manager) {
+	err := manager.CheckProxy()
+	if err == nil {
+		d.failures = 0
+		return
+	}
+
+	d.failures++
+	d.logger.Printf("node %s: %v (%d/%d)", cfg.Proxy.CurrentNode, err, d.failures, failoverThreshold)
+	if d.failures < failoverThreshold {
+		return
+	}
+	d.failures = 0
+
+	if cfg.Proxy.PinnedNode != "" {
+		d.logger.Printf("node %s is pinned, not failing over (run 'crosh nodes auto' to allow it)", cfg.Proxy.PinnedNode)
+		return
+	}
+
+	node, err := manager.Failover()
+	if err != nil {
+		d.logger.Printf("failover failed: %v", err)
+		return
+	}
+	d.logger.Printf("failed over to node %s", node.Name)
+}
@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	// English is the language crosh is written in
+	English = "en"
+	// Chinese is Simplified Chinese
+	Chinese = "zh-CN"
+)
+
+// catalogs maps a language to its translations, keyed by the English text
+var catalogs = map[string]map[string]string{
+	Chinese: zhCN,
+}
+
+var catalog map[string]string
+
+// SetLanguage selects the output language, e.g. "zh-CN" or "zh_CN.UTF-8".
+// An empty language (or "auto") follows the LC_ALL, LC_MESSAGES and LANG
+// environment variables.
+func SetLanguage(lang string) {
+	if lang == "" || lang == "auto" {
+		lang = locale()
+	}
+	if strings.HasPrefix(strings.ToLower(lang), "zh") {
+		lang = Chinese
+	}
+	catalog = catalogs[lang]
+}
+
+// locale returns the locale set in the environment; as in gettext, the first
+// variable set wins
+func locale() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return English
+}
+
+// T returns the translation of msg, or msg itself if it has none.
+// msg may be a format string; the translation keeps its verbs.
+func T(msg string) string {
+	if translated, ok := catalog[msg]; ok {
+		return translated
+	}
+	return msg
+}
@@ -0,0 +1,746 @@
+package i18n
+
+// zhCN holds the Simplified Chinese translations. Keys are the exact English
+// text, including format verbs and surrounding newlines.
+var zhCN = map[string]string{
+	// Help
+	"Network acceleration for Chinese developers": "为中国开发者提供的网络加速工具",
+	"USAGE:":                        "用法：",
+	"COMMANDS:":                     "命令：",
+	"SHORTHANDS:":                   "简写：",
+	"GLOBAL FLAGS:":                 "全局参数：",
+	"EXAMPLES:":                     "示例：",
+	"Enable acceleration (default)": "开启加速（默认）",
+	"Run 'crosh help <command>' for more about a command group.":          "运行 'crosh help <命令>' 查看命令组的详细说明。",
+	"For more information, visit:":                                        "更多信息请访问：",
+	"\nRun '%s <command> -h' for command flags.\n":                        "\n运行 '%s <命令> -h' 查看命令参数。\n",
+	"%s - %s\n\nRun '%s -h' for flags.\n":                                 "%s - %s\n\n运行 '%s -h' 查看参数。\n",
+	"Usage: %s [flags]\n":                                                 "用法：%s [参数]\n",
+	"\nFlags:":                                                            "\n参数：",
+	"Unknown command: %s\n":                                               "未知命令：%s\n",
+	"Unknown command: %s\n\n":                                             "未知命令：%s\n\n",
+	"Unknown command: %s %s\n\n":                                          "未知命令：%s %s\n\n",
+	"crosh version %s\n":                                                  "crosh 版本 %s\n",
+	"Same as: crosh proxy set <subscription-url>":                         "等同于：crosh proxy set <订阅地址>",
+	"Same as: crosh proxy load <config.yaml>":                             "等同于：crosh proxy load <config.yaml>",
+	"Print JSON from status, proxy status, nodes list and mirrors status": "让 status、proxy status、nodes list 和 mirrors status 输出 JSON",
+	"Print errors only":                                                   "只输出错误",
+	"Also print HTTP requests, files written and commands run":            "同时输出 HTTP 请求、写入的文件和执行的命令",
+	"Enable acceleration":                                                 "开启加速",
+	"Enable acceleration for the next two hours only":                     "只在接下来两小时内开启加速",
+	"Disable acceleration":                                                "关闭加速",
+	"Configure proxy subscription (auto-starts proxy and mirrors)":        "配置代理订阅（自动启动代理和镜像）",
+	"Use local YAML file (one-time use, not saved)":                       "使用本地 YAML 文件（仅本次使用，不保存）",
+	"Check status":                                                        "查看状态",
+	"Change the local proxy port":                                         "修改本地代理端口",
+	"Share team settings (subscription encrypted with a passphrase)":      "共享团队配置（订阅地址用口令加密）",
+	"Accelerate a cloud dev server, using this machine's proxy over a reverse tunnel": "加速云端开发机，通过反向隧道使用本机代理",
+	"Serve the local API (token is stored in ~/.crosh/api.token)":                     "启动本地 API（令牌保存在 ~/.crosh/api.token）",
+
+	// Command summaries
+	"Show current status":                                               "查看当前状态",
+	"Show live proxy, traffic, mirror and log status":                   "实时查看代理、流量、镜像和日志状态",
+	"Print shell commands that set the proxy variables, for eval":       "输出设置代理环境变量的 shell 命令，供 eval 使用",
+	"Print a shell hook that keeps the proxy variables in sync":         "输出让代理环境变量保持同步的 shell 钩子",
+	"Run a command through the proxy, starting it if needed":            "通过代理运行命令，必要时先启动代理",
+	"Diagnose common problems and suggest fixes":                        "诊断常见问题并给出修复建议",
+	"Compare download times direct, from mirrors and through the proxy": "比较直连、镜像和代理的下载耗时",
+	"Show the proxy core log (--follow to stream it)":                   "查看代理内核日志（--follow 持续输出）",
+	"Manage the proxy": "管理代理",
+	"Configure proxy subscription and auto-start":  "配置代理订阅并自动启动",
+	"Use local YAML file (one-time configuration)": "使用本地 YAML 文件（一次性配置）",
+	"Start the proxy":               "启动代理",
+	"Stop the proxy":                "停止代理",
+	"Show proxy status":             "查看代理状态",
+	"Manage the proxy subscription": "管理代理订阅",
+	"Re-fetch the subscription and refresh the local cache":                              "重新获取订阅并刷新本地缓存",
+	"Inspect and select subscription nodes":                                              "查看和选择订阅节点",
+	"List nodes in the subscription":                                                     "列出订阅中的节点",
+	"Show or set which nodes automatic selection may use":                                "查看或设置自动选择可以使用的节点",
+	"Pick a node from a live, filterable list and pin it":                                "从实时测速、可筛选的列表中选择并固定节点",
+	"Pin a node and restart the proxy on it":                                             "固定一个节点并用它重启代理",
+	"Unpin the node and select the fastest one again":                                    "取消固定节点，重新选择最快的节点",
+	"Measure download speed through each node":                                           "测量每个节点的下载速度",
+	"Manage routing rules":                                                               "管理路由规则",
+	"List routing rules":                                                                 "列出路由规则",
+	"Send domains or IPs direct, through the proxy or nowhere":                           "让域名或 IP 直连、走代理或屏蔽",
+	"Remove rules, or domains and IPs from them":                                         "删除规则，或删除规则中的域名和 IP",
+	"Manage the Xray-core binary":                                                        "管理 Xray-core 程序",
+	"Show the installed and latest Xray-core versions":                                   "查看已安装和最新的 Xray-core 版本",
+	"Install the latest (or given) Xray-core and restart the proxy":                      "安装最新（或指定）版本的 Xray-core 并重启代理",
+	"Manage the geoip and geosite data used by routing rules":                            "管理路由规则使用的 geoip 和 geosite 数据",
+	"Download the latest geo data and restart the proxy if it changed":                   "下载最新的地理数据，有变化时重启代理",
+	"Manage package manager mirrors":                                                     "管理包管理器镜像",
+	"Enable mirrors":                                                                     "开启镜像",
+	"Disable mirrors":                                                                    "关闭镜像",
+	"Switch mirrors to a provider such as tsinghua, or list the providers":               "将镜像切换到某个提供方（如 tsinghua），或列出所有提供方",
+	"Download Gradle from the mirror in the project in dir (default: current directory)": "让 dir 中的项目（默认当前目录）从镜像下载 Gradle",
+	"Measure the candidate mirrors of each tool from this network":                       "从当前网络测量各工具的候选镜像",
+	"Show mirror status":                                                                 "查看镜像状态",
+	"View and edit crosh configuration":                                                  "查看和编辑 crosh 配置",
+	"Print the configuration":                                                            "输出配置",
+	"Print the configuration file path":                                                  "输出配置文件路径",
+	"Print a value (e.g. proxy.local_port)":                                              "输出一个配置项（如 proxy.local_port）",
+	"Set a value (e.g. mirror.npm https://...)":                                          "设置一个配置项（如 mirror.npm https://...）",
+	"Configure remote machines over SSH":                                                 "通过 SSH 配置远程机器",
+	"Apply mirrors (and optionally proxy) to a remote Linux machine":                     "为远程 Linux 机器配置镜像（可选代理）",
+	"Export settings":                                                                    "导出配置",
+	"Export team settings to a shareable bundle file":                                    "将团队配置导出为可共享的配置包",
+	"Import settings":                                                                    "导入配置",
+	"Import a team bundle and enable acceleration":                                       "导入团队配置包并开启加速",
+	"Start the local HTTP API server (for GUI frontends)":                                "启动本地 HTTP API 服务（供图形界面使用）",
+	"Start crosh with your session, so acceleration survives reboots":                    "随登录会话启动 crosh，重启后加速依然有效",
+	"Register the daemon with the system service manager and start it":                   "将守护进程注册到系统服务管理器并启动",
+	"Stop the daemon from starting with your session":                                    "不再随登录会话启动守护进程",
+	"Show whether the service is installed and the daemon running":                       "查看服务是否已安装、守护进程是否在运行",
+	"Put back the tool config files crosh changed as they were before":                   "将 crosh 修改过的工具配置文件恢复原样",
+	"Turn crosh off, undo its changes and delete its files":                              "关闭 crosh，撤销它所做的修改并删除它的文件",
+	"Run the background scheduler (started automatically)":                               "运行后台调度程序（自动启动）",
+	"Show version": "查看版本",
+	"Show help":    "查看帮助",
+
+	// Flags
+	"keep running without work and start the proxy if it is enabled (used by crosh service)":            "没有任务时也保持运行，并在代理开启时启动代理（供 crosh service 使用）",
+	"start the daemon in the background and return":                                                     "在后台启动守护进程后返回",
+	"turn acceleration off automatically after this long (e.g. 2h)":                                     "在这段时间后自动关闭加速（如 2h）",
+	"share the proxy with other devices by listening on 0.0.0.0 (saved; --allow-lan=false undoes it)":   "监听 0.0.0.0，与其他设备共享代理（会保存；--allow-lan=false 撤销）",
+	"route all system traffic through the proxy; needs root (saved; --tun=false undoes it)":             "让系统所有流量走代理，需要 root 权限（会保存；--tun=false 撤销）",
+	"proxy core to run the nodes with: xray, sing-box or mihomo (saved)":                                "运行节点的代理内核：xray、sing-box 或 mihomo（会保存）",
+	"keep printing new lines as the proxy writes them":                                                  "持续输出代理写入的新日志",
+	"don't ask for confirmation":                                                                        "不再询问确认",
+	"print the files, variables and processes crosh on would change, without changing anything":         "列出 crosh on 会修改的文件、环境变量和启动的进程，但不做任何修改",
+	"print the files crosh off would change and the processes it would stop, without changing anything": "列出 crosh off 会修改的文件和停止的进程，但不做任何修改",
+	"delete the originals of config files crosh couldn't put back, too":                                 "同时删除 crosh 未能恢复的配置文件原始版本",
+	"shorthand for --yes":                                                                         "--yes 的简写",
+	"shorthand for --follow":                                                                      "--follow 的简写",
+	"number of lines to show first (0 shows the whole log)":                                       "先显示的行数（0 显示全部日志）",
+	"print commands that remove the proxy variables instead":                                      "改为输出删除代理环境变量的命令",
+	"also list nodes excluded by the node filter":                                                 "同时列出被节点筛选排除的节点",
+	"give up on a fetch after this long":                                                          "下载超过这段时间则放弃",
+	"only use nodes whose name matches one of these regexes (comma-separated, repeatable)":        "只使用名称匹配这些正则表达式之一的节点（逗号分隔，可重复）",
+	"skip nodes whose name matches one of these regexes":                                          "跳过名称匹配这些正则表达式之一的节点",
+	"only use these node types, e.g. trojan,vless":                                                "只使用这些类型的节点，如 trojan,vless",
+	"only use nodes in these regions, e.g. HK,JP,SG":                                              "只使用这些地区的节点，如 HK,JP,SG",
+	"prefer nodes in these regions, using others only if none is available":                       "优先使用这些地区的节点，没有可用节点时才用其他节点",
+	"avoid nodes in these regions unless nothing else is available, e.g. US":                      "除非没有其他可用节点，否则避开这些地区的节点，如 US",
+	"remove all filters, including the default exclude list":                                      "删除所有筛选条件，包括默认的排除列表",
+	"URL to download through each node (default: proxy.speed_test_url or a Cloudflare test file)": "通过每个节点下载的 URL（默认：proxy.speed_test_url 或 Cloudflare 测试文件）",
+	"connect to these domains or IPs directly (comma-separated, repeatable)":                      "直连这些域名或 IP（逗号分隔，可重复）",
+	"connect to these domains or IPs through the proxy":                                           "通过代理连接这些域名或 IP",
+	"block connections to these domains or IPs":                                                   "阻止连接这些域名或 IP",
+	"only match these ports, e.g. \"22\" or \"8000-9000,443\"":                                    "只匹配这些端口，如 \"22\" 或 \"8000-9000,443\"",
+	"proxy deployment: none, tunnel (use this machine's proxy) or node (run Xray on the remote)":  "代理部署方式：none、tunnel（使用本机代理）或 node（在远程机器上运行 Xray）",
+	"SSH port":                     "SSH 端口",
+	"SSH identity file":            "SSH 私钥文件",
+	"output file":                  "输出文件",
+	"include the subscription URL": "包含订阅链接",
+	"encrypt the subscription URL with a passphrase":                            "用口令加密订阅链接",
+	"only update config, don't enable acceleration":                             "只更新配置，不开启加速",
+	"address to listen on":                                                      "监听地址",
+	"also serve a web dashboard (api.web in the config turns it on by default)": "同时提供网页控制台（配置中的 api.web 可默认开启）",
+	"pin this version in proxy.xray_version, so new downloads use it too":       "将此版本固定到 proxy.xray_version，之后下载也使用该版本",
+	"only show lines at this level or above: %s":                                "只显示该级别及以上的日志：%s",
+	"switch to another rule set: %s (saved)":                                    "切换到另一套规则数据：%s（会保存）",
+
+	// On, off and status
+	"Enabling acceleration...":                     "正在开启加速...",
+	"Disabling acceleration...":                    "正在关闭加速...",
+	"\n✓ Acceleration enabled":                     "\n✓ 加速已开启",
+	"\n✓ Acceleration disabled":                    "\n✓ 加速已关闭",
+	"✓ Mirrors disabled":                           "✓ 镜像已关闭",
+	"○ %s mirror skipped: kept your %s in %s\n":    "○ 已跳过 %s 镜像：保留了你在 %[3]s 中的 %[2]s\n",
+	"  Kept your %s next to it\n":                  "  同时保留了你的 %s\n",
+	"  Replaced your %s; crosh off puts it back\n": "  已替换你的 %s；crosh off 会将其恢复\n",
+	"\nTo use crosh's mirrors in place of your own settings: crosh config set mirror.overwrite true": "\n如需用 crosh 的镜像替换你自己的设置：crosh config set mirror.overwrite true",
+	"○ %s mirror is turned off in config, skipped (crosh config set mirror.tools.%s true)\n":         "○ %s 镜像已在配置中关闭，已跳过（crosh config set mirror.tools.%s true）\n",
+	"✓ Mirrors enabled (%s)\n":                             "✓ 镜像已开启（%s）\n",
+	"✓ Mirrors disabled (%s)\n":                            "✓ 镜像已关闭（%s）\n",
+	"✗ Unknown component: %s (use proxy, mirrors or %s)\n": "✗ 未知组件：%s（可用 proxy、mirrors 或 %s）\n",
+	"\n✓ Mirrors enabled":                                  "\n✓ 镜像已开启",
+	"\n✓ Mirrors disabled":                                 "\n✓ 镜像已关闭",
+	"✓ Proxy enabled":                                      "✓ 代理已开启",
+	"✓ %s uses the proxy\n":                                "✓ %s 已使用代理\n",
+	"⚠ %s doesn't use the proxy: %v\n":                     "⚠ %s 未使用代理：%v\n",
+	"✓ %s no longer uses the proxy\n":                      "✓ %s 已不再使用代理\n",
+	"⚠ %s still uses the stopped proxy: %v\n":              "⚠ %s 仍在使用已停止的代理：%v\n",
+	"✓ Proxy disabled":                                     "✓ 代理已关闭",
+	"Warning: Failed to enable mirrors: %v\n":              "警告：开启镜像失败：%v\n",
+	"Warning: Failed to disable mirrors: %v\n":             "警告：关闭镜像失败：%v\n",
+	"Warning: Failed to disable proxy: %v\n":               "警告：关闭代理失败：%v\n",
+	"✗ Proxy failed: %v\n":                                 "✗ 代理启动失败：%v\n",
+	"\nTrying to download %s...\n":                         "\n正在尝试下载 %s...\n",
+	"✗ Failed to download %s: %v\n":                        "✗ 下载 %s 失败：%v\n",
+	"\nProxy acceleration is unavailable.":                 "\n代理加速不可用。",
+	"Mirrors are still enabled and working.":               "镜像仍已开启并正常工作。",
+	"✗ Proxy still failed: %v\n":                           "✗ 代理仍然启动失败：%v\n",
+	"✗ --for turns everything off when it ends, so it can't be combined with components": "✗ --for 到期时会关闭全部加速，因此不能与组件一起使用",
+	"✗ --for must be a positive duration":                                                "✗ --for 必须是正的时长",
+	"Error saving config: %v\n":                                                          "保存配置出错：%v\n",
+	"Error loading config: %v\n":                                                         "加载配置出错：%v\n",
+	"Warning: %v\n":                                                                      "警告：%v\n",
+	"⚠ Failed to start background daemon: %v\n":                                          "⚠ 启动后台守护进程失败：%v\n",
+	"  Acceleration will be turned off the next time crosh runs after expiry.":           "  到期后下次运行 crosh 时将关闭加速。",
+	"⏱ Acceleration will turn off automatically at %s\n":                                 "⏱ 加速将在 %s 自动关闭\n",
+	"⏱ Temporary acceleration expired, acceleration disabled":                            "⏱ 临时加速已到期，加速已关闭",
+	"Current Status":                                        "当前状态",
+	"\n⏱ Auto-off in %s (at %s)\n":                          "\n⏱ %s 后自动关闭（%s）\n",
+	"✓ Mirrors: enabled":                                    "✓ 镜像：已开启",
+	"✗ Mirrors: disabled":                                   "✗ 镜像：已关闭",
+	"✓ Proxy: enabled (%s)\n":                               "✓ 代理：已开启（%s）\n",
+	"✗ Proxy: disabled":                                     "✗ 代理：已关闭",
+	"○ Proxy: not configured":                               "○ 代理：未配置",
+	"  Pinned node: %s\n":                                   "  固定节点：%s\n",
+	"  ⚠ Shared with your network (listening on %s)\n":      "  ⚠ 已共享给局域网（监听 %s）\n",
+	"  TUN mode: all system traffic goes through the proxy": "  TUN 模式：系统所有流量都走代理",
+	"  Services using the proxy: %s\n":                      "  使用代理的服务：%s\n",
+	"  Subscription: %s\n":                                  "  订阅：%s\n",
+	"  Upstream proxy: %s\n":                                "  上游代理：%s\n",
+	"\n  To configure proxy, run:":                          "\n  配置代理请运行：",
+	"Dry run, nothing was changed. crosh on would:":         "演练模式，未做任何修改。crosh on 将会：",
+	"Dry run, nothing was changed. crosh off would:":        "演练模式，未做任何修改。crosh off 将会：",
+	"\nChange files:":                                       "\n修改文件：",
+	"\nNo files to change":                                  "\n无需修改文件",
+	"%s (new)":                                              "%s（新建）",
+	"%s (removed)":                                          "%s（删除）",
+	"\nStart:":                                              "\n启动：",
+	"\nRun:":                                                "\n运行：",
+	"\nStop:":                                               "\n停止：",
+	"\nKeep %s running\n":                                   "\n保持 %s 运行\n",
+	"download %s to %s":                                     "下载 %s 到 %s",
+	"fetch the subscription, pick a node and write %s":      "获取订阅，选择节点并写入 %s",
+	"\nSet, in shells that run crosh env or crosh init:":    "\n在运行 crosh env 或 crosh init 的 shell 中设置：",
+
+	// Shell integration
+	"✗ Unknown shell %q, use one of: %s\n":                                                 "✗ 未知的 shell %q，可选：%s\n",
+	"⚠ Proxy is not running, removing the proxy variables (start it with: crosh proxy on)": "⚠ 代理未运行，将删除代理环境变量（启动代理：crosh proxy on）",
+	"\nTo use the proxy in this shell, run: %s\n":                                          "\n要在当前 shell 中使用代理，请运行：%s\n",
+	"✗ Proxy is not configured, run: crosh proxy set <subscription-url>":                   "✗ 代理未配置，请运行：crosh proxy set <订阅地址>",
+	"✗ Unsupported shell %q, use bash, zsh or fish\n":                                      "✗ 不支持的 shell %q，请使用 bash、zsh 或 fish\n",
+
+	// Proxy commands
+	"✗ Not an http(s) subscription URL: %s\n":             "✗ 不是 http(s) 订阅地址：%s\n",
+	"✗ Cannot read %s: %v\n":                              "✗ 无法读取 %s：%v\n",
+	"✓ Proxy already running (%s)\n":                      "✓ 代理已在运行（%s）\n",
+	"✓ TUN mode disabled":                                 "✓ TUN 模式已关闭",
+	"✗ Unknown proxy core: %s (expected %s)\n":            "✗ 未知的代理内核：%s（可选 %s）\n",
+	"✓ Using the %s core\n":                               "✓ 使用 %s 内核\n",
+	"Restarting the proxy with the new settings...":       "正在用新设置重启代理...",
+	"✗ Failed to stop proxy: %v\n":                        "✗ 停止代理失败：%v\n",
+	"✗ Failed to disable proxy: %v\n":                     "✗ 关闭代理失败：%v\n",
+	"✓ Subscription URL saved: %s\n":                      "✓ 订阅地址已保存：%s\n",
+	"\nYou can try again later with: crosh on":            "\n稍后可以重试：crosh on",
+	"\n✓ Proxy configured successfully":                   "\n✓ 代理配置成功",
+	"\nEnabling mirrors...":                               "\n正在开启镜像...",
+	"\nStarting proxy...":                                 "\n正在启动代理...",
+	"✗ Failed to start proxy: %v\n":                       "✗ 启动代理失败：%v\n",
+	"\nYou can try again with: crosh on":                  "\n可以重试：crosh on",
+	"\nProxy is running in background.":                   "\n代理正在后台运行。",
+	"\nPlease try again later.":                           "\n请稍后重试。",
+	"\nParsing YAML file...":                              "\n正在解析 YAML 文件...",
+	"✗ Failed to load YAML file: %v\n":                    "✗ 加载 YAML 文件失败：%v\n",
+	"\nPlease check your YAML file format and try again.": "\n请检查 YAML 文件格式后重试。",
+	"✓ Found %d nodes in YAML file\n":                     "✓ YAML 文件中有 %d 个节点\n",
+	"\nTesting node latency...":                           "\n正在测试节点延迟...",
+	"✗ Failed to select node: %v\n":                       "✗ 选择节点失败：%v\n",
+	"✓ Selected node: %s (latency: %dms)\n":               "✓ 已选择节点：%s（延迟：%dms）\n",
+	"✗ Failed to generate %s config: %v\n":                "✗ 生成 %s 配置失败：%v\n",
+	"\n✓ Proxy configured successfully (one-time use)":    "\n✓ 代理配置成功（仅本次使用）",
+	"\nNote: This is a one-time configuration. To use this YAML file again, run: crosh proxy load ": "\n注意：这是一次性配置。要再次使用此 YAML 文件，请运行：crosh proxy load ",
+
+	// Mirrors
+	"give up on a mirror after this long":    "镜像超过这段时间未完成则放弃",
+	"use the fastest mirror of each tool":    "为每个工具使用最快的镜像",
+	"Measuring mirrors from this network...": "正在从当前网络测量镜像...",
+	"SPEED":                                  "速度",
+	"MIRROR":                                 "镜像",
+	" (current)":                             "（当前）",
+	"failed":                                 "失败",
+	"  ✗ No mirror answered":                 "  ✗ 没有镜像响应",
+	"  → Fastest: %s\n":                      "  → 最快：%s\n",
+	"\n✓ The configured mirrors are the fastest":                                    "\n✓ 当前配置的镜像已是最快",
+	"\nFaster mirrors found for %s; run 'crosh mirrors bench --save' to use them\n": "\n%s 有更快的镜像；运行 'crosh mirrors bench --save' 以使用它们\n",
+	"Mirror presets:": "镜像预设：",
+	"\nRun: crosh mirrors preset <name> [tool...]": "\n运行：crosh mirrors preset <名称> [工具...]",
+	"✗ Unknown mirror preset: %s\n\n":              "✗ 未知镜像预设：%s\n\n",
+	"✗ Unknown tool: %s (use %s)\n":                "✗ 未知工具：%s（可用 %s）\n",
+	"○ %s has no %s mirror, keeping %s\n":          "○ %s 没有 %s 镜像，保留 %s\n",
+	"\nRun 'crosh on' to use them":                 "\n运行 'crosh on' 以使用它们",
+	"Yarn mirror: %w":                              "Yarn 镜像：%w",
+	"pnpm mirror: %w":                              "pnpm 镜像：%w",
+	"NPM mirror: %w":                               "NPM 镜像：%w",
+	"pyenv mirror: %w":                             "pyenv 镜像：%w",
+	"Pip mirror: %w":                               "Pip 镜像：%w",
+	"CocoaPods mirror: %w":                         "CocoaPods 镜像：%w",
+	"CRAN mirror: %w":                              "CRAN 镜像：%w",
+	"CPAN mirror: %w":                              "CPAN 镜像：%w",
+	"TeX Live mirror: %w":                          "TeX Live 镜像：%w",
+	"Hex mirror: %w":                               "Hex 镜像：%w",
+	"opam mirror: %w":                              "opam 镜像：%w",
+	"LuaRocks mirror: %w":                          "LuaRocks 镜像：%w",
+	"Haskell mirror: %w":                           "Haskell 镜像：%w",
+	"Nix mirror: %w":                               "Nix 镜像：%w",
+	"Bazel mirror: %w":                             "Bazel 镜像：%w",
+	"Browser mirrors: %w":                          "浏览器镜像：%w",
+	"Binary mirrors: %w":                           "二进制镜像：%w",
+	"Electron mirror: %w":                          "Electron 镜像：%w",
+	"Node.js mirror: %w":                           "Node.js 镜像：%w",
+	"Bun mirror: %w":                               "Bun 镜像：%w",
+	"Deno mirror: %w":                              "Deno 镜像：%w",
+	"Helm mirror: %w":                              "Helm 镜像：%w",
+	"Kubernetes mirror: %w":                        "Kubernetes 镜像：%w",
+	"minikube mirror: %w":                          "minikube 镜像：%w",
+	"Terraform mirror: %w":                         "Terraform 镜像：%w",
+	"winget mirror: %w":                            "winget 镜像：%w",
+	"Scoop mirror: %w":                             "Scoop 镜像：%w",
+	"MSYS2 mirror: %w":                             "MSYS2 镜像：%w",
+	"Git mirror: %w":                               "Git 镜像：%w",
+	"Conda mirror: %w":                             "Conda 镜像：%w",
+	"Hugging Face mirror: %w":                      "Hugging Face 镜像：%w",
+	"NuGet mirror: %w":                             "NuGet 镜像：%w",
+	"Conan mirror: %w":                             "Conan 镜像：%w",
+	"Composer mirror: %w":                          "Composer 镜像：%w",
+	"Gradle mirror: %w":                            "Gradle 镜像：%w",
+	"sbt mirror: %w":                               "sbt 镜像：%w",
+	"SDKMAN mirror: %w":                            "SDKMAN 镜像：%w",
+	"Maven mirror: %w":                             "Maven 镜像：%w",
+	"⚠ Apk mirror skipped: %v\n":                   "⚠ 已跳过 Apk 镜像：%v\n",
+	"⚠ Pacman mirror skipped: %v\n":                "⚠ 已跳过 Pacman 镜像：%v\n",
+	"Cargo mirror: %w":                             "Cargo 镜像：%w",
+	"Go proxy: %w":                                 "Go 代理：%w",
+	"Docker mirror: %w":                            "Docker 镜像：%w",
+	"✓ Yarn mirror enabled:":                       "✓ Yarn 镜像已开启：",
+	"✓ pnpm mirror enabled:":                       "✓ pnpm 镜像已开启：",
+	"✓ NPM mirror enabled:":                        "✓ NPM 镜像已开启：",
+	"✓ pyenv mirror enabled:":                      "✓ pyenv 镜像已开启：",
+	"✓ Pip mirror enabled:":                        "✓ Pip 镜像已开启：",
+	"✓ Apt mirror enabled:":                        "✓ Apt 镜像已开启：",
+	"✓ CocoaPods mirror enabled:":                  "✓ CocoaPods 镜像已开启：",
+	"✓ CRAN mirror enabled:":                       "✓ CRAN 镜像已开启：",
+	"✓ CPAN mirror enabled:":                       "✓ CPAN 镜像已开启：",
+	"✓ TeX Live mirror enabled:":                   "✓ TeX Live 镜像已开启：",
+	"✓ Hex mirror enabled:":                        "✓ Hex 镜像已开启：",
+	"✓ opam mirror enabled:":                       "✓ opam 镜像已开启：",
+	"✓ LuaRocks mirror enabled:":                   "✓ LuaRocks 镜像已开启：",
+	"✓ Haskell mirror enabled:":                    "✓ Haskell 镜像已开启：",
+	"✓ Nix mirror enabled:":                        "✓ Nix 镜像已开启：",
+	"✓ Bazel mirror enabled:":                      "✓ Bazel 镜像已开启：",
+	"  Run 'opam update' to fetch the packages from the mirror":             "  运行 'opam update' 从镜像获取软件包",
+	"✓ Browser mirrors enabled:":                                            "✓ 浏览器镜像已开启：",
+	"✓ Binary mirrors enabled:":                                             "✓ 二进制镜像已开启：",
+	"✓ Electron mirror enabled:":                                            "✓ Electron 镜像已开启：",
+	"✓ Node.js mirror enabled:":                                             "✓ Node.js 镜像已开启：",
+	"✓ Bun mirror enabled:":                                                 "✓ Bun 镜像已开启：",
+	"✓ Deno mirror enabled:":                                                "✓ Deno 镜像已开启：",
+	"  Open a new terminal for %s to use it\n":                              "  打开新终端后 %s 才会使用它\n",
+	"  Put this first in your Podfile: source '%s'\n":                       "  请将此行放在 Podfile 开头：source '%s'\n",
+	"✓ Helm mirror enabled:":                                                "✓ Helm 镜像已开启：",
+	"  Run 'helm repo update' to fetch the charts from the mirrors":         "  运行 'helm repo update' 从镜像获取 chart",
+	"✓ Kubernetes mirror enabled:":                                          "✓ Kubernetes 镜像已开启：",
+	"✓ minikube mirror enabled:":                                            "✓ minikube 镜像已开启：",
+	"✓ Terraform mirror enabled:":                                           "✓ Terraform 镜像已开启：",
+	"✓ winget mirror enabled:":                                              "✓ winget 镜像已开启：",
+	"✓ Scoop mirror enabled:":                                               "✓ Scoop 镜像已开启：",
+	"✓ MSYS2 mirror enabled:":                                               "✓ MSYS2 镜像已开启：",
+	"✓ Git mirror enabled:":                                                 "✓ Git 镜像已开启：",
+	"  Run 'scoop update' to fetch the buckets from the mirrors":            "  运行 'scoop update' 从镜像获取 bucket",
+	"  kubeadm pulls through it with: kubeadm init --image-repository %s\n": "  kubeadm 通过它拉取镜像：kubeadm init --image-repository %s\n",
+	"✓ Conda mirror enabled:":                                               "✓ Conda 镜像已开启：",
+	"✓ Hugging Face mirror enabled:":                                        "✓ Hugging Face 镜像已开启：",
+	"✓ NuGet mirror enabled:":                                               "✓ NuGet 镜像已开启：",
+	"✓ Conan mirror enabled:":                                               "✓ Conan 镜像已开启：",
+	"✓ Composer mirror enabled:":                                            "✓ Composer 镜像已开启：",
+	"✓ Gradle mirror enabled:":                                              "✓ Gradle 镜像已开启：",
+	"✓ sbt mirror enabled:":                                                 "✓ sbt 镜像已开启：",
+	"✓ SDKMAN mirror enabled:":                                              "✓ SDKMAN 镜像已开启：",
+	"  Builds with their own resolvers use it with: sbt -Dsbt.override.build.repos=true":       "  自带 resolver 的构建需这样使用：sbt -Dsbt.override.build.repos=true",
+	"  Run 'crosh mirrors gradle-wrapper' in a project to download Gradle from the mirror too": "  在项目中运行 'crosh mirrors gradle-wrapper'，让 Gradle 本身也从镜像下载",
+	"✓ Maven mirror enabled:": "✓ Maven 镜像已开启：",
+	"✓ Apk mirror enabled:":   "✓ Apk 镜像已开启：",
+	"  With archlinuxcn; its packages need its keys: sudo pacman -Sy archlinuxcn-keyring": "  已包含 archlinuxcn；其软件包需要它的密钥：sudo pacman -Sy archlinuxcn-keyring",
+	"✓ Pacman mirror enabled:":                               "✓ Pacman 镜像已开启：",
+	"✓ Cargo mirror enabled:":                                "✓ Cargo 镜像已开启：",
+	"✓ Go proxy enabled:":                                    "✓ Go 代理已开启：",
+	"✓ Docker mirror enabled: %s\n":                          "✓ Docker 镜像已开启：%s\n",
+	"⚠ Apt mirror skipped: %v\n":                             "⚠ 已跳过 Apt 镜像：%v\n",
+	"  Additional: %s\n":                                     "  其他：%s\n",
+	"\n%d errors occurred:\n":                                "\n出现 %d 个错误：\n",
+	"✓ Yarn mirror disabled":                                 "✓ Yarn 镜像已关闭",
+	"✓ pnpm mirror disabled":                                 "✓ pnpm 镜像已关闭",
+	"✓ NPM mirror disabled":                                  "✓ NPM 镜像已关闭",
+	"✓ pyenv mirror disabled":                                "✓ pyenv 镜像已关闭",
+	"✓ Pip mirror disabled":                                  "✓ Pip 镜像已关闭",
+	"✓ Apt mirror disabled":                                  "✓ Apt 镜像已关闭",
+	"✓ Gradle wrapper downloads %s\n":                        "✓ Gradle wrapper 将从 %s 下载\n",
+	"  crosh off gradle puts the original download URL back": "  crosh off gradle 会恢复原来的下载地址",
+	"✓ CocoaPods mirror disabled":                            "✓ CocoaPods 镜像已关闭",
+	"✓ CRAN mirror disabled":                                 "✓ CRAN 镜像已关闭",
+	"✓ CPAN mirror disabled":                                 "✓ CPAN 镜像已关闭",
+	"✓ TeX Live mirror disabled":                             "✓ TeX Live 镜像已关闭",
+	"✓ Hex mirror disabled":                                  "✓ Hex 镜像已关闭",
+	"✓ opam mirror disabled":                                 "✓ opam 镜像已关闭",
+	"✓ LuaRocks mirror disabled":                             "✓ LuaRocks 镜像已关闭",
+	"✓ Haskell mirror disabled":                              "✓ Haskell 镜像已关闭",
+	"✓ Nix mirror disabled":                                  "✓ Nix 镜像已关闭",
+	"✓ Bazel mirror disabled":                                "✓ Bazel 镜像已关闭",
+	"✓ Browser mirrors disabled":                             "✓ 浏览器镜像已关闭",
+	"✓ Binary mirrors disabled":                              "✓ 二进制镜像已关闭",
+	"✓ Electron mirror disabled":                             "✓ Electron 镜像已关闭",
+	"✓ Node.js mirror disabled":                              "✓ Node.js 镜像已关闭",
+	"✓ Bun mirror disabled":                                  "✓ Bun 镜像已关闭",
+	"✓ Deno mirror disabled":                                 "✓ Deno 镜像已关闭",
+	"✓ Helm mirror disabled":                                 "✓ Helm 镜像已关闭",
+	"✓ Kubernetes mirror disabled":                           "✓ Kubernetes 镜像已关闭",
+	"✓ minikube mirror disabled":                             "✓ minikube 镜像已关闭",
+	"✓ Terraform mirror disabled":                            "✓ Terraform 镜像已关闭",
+	"✓ winget mirror disabled":                               "✓ winget 镜像已关闭",
+	"✓ Scoop mirror disabled":                                "✓ Scoop 镜像已关闭",
+	"✓ MSYS2 mirror disabled":                                "✓ MSYS2 镜像已关闭",
+	"✓ Git mirror disabled":                                  "✓ Git 镜像已关闭",
+	"✓ Conda mirror disabled":                                "✓ Conda 镜像已关闭",
+	"✓ Hugging Face mirror disabled":                         "✓ Hugging Face 镜像已关闭",
+	"✓ NuGet mirror disabled":                                "✓ NuGet 镜像已关闭",
+	"✓ Conan mirror disabled":                                "✓ Conan 镜像已关闭",
+	"✓ Composer mirror disabled":                             "✓ Composer 镜像已关闭",
+	"✓ Gradle mirror disabled":                               "✓ Gradle 镜像已关闭",
+	"✓ sbt mirror disabled":                                  "✓ sbt 镜像已关闭",
+	"✓ SDKMAN mirror disabled":                               "✓ SDKMAN 镜像已关闭",
+	"✓ Maven mirror disabled":                                "✓ Maven 镜像已关闭",
+	"✓ Apk mirror disabled":                                  "✓ Apk 镜像已关闭",
+	"✓ Pacman mirror disabled":                               "✓ Pacman 镜像已关闭",
+	"✓ Cargo mirror disabled":                                "✓ Cargo 镜像已关闭",
+	"✓ Go proxy disabled":                                    "✓ Go 代理已关闭",
+	"✓ Docker mirror disabled":                               "✓ Docker 镜像已关闭",
+	"⚠ Docker daemon restart required to apply changes:":     "⚠ 需要重启 Docker 守护进程以应用更改：",
+	"  macOS (Docker Desktop):":                              "  macOS（Docker Desktop）：",
+	"  Linux:":                                               "  Linux：",
+	"  Restart Docker Desktop from the system tray":          "  从系统托盘重启 Docker Desktop",
+	"After restart, test with: docker pull nginx:alpine":     "重启后测试：docker pull nginx:alpine",
+
+	// Node selection
+	"Warning: %v, using xray\n":                                                    "警告：%v，改用 xray\n",
+	"Refreshing geoip and geosite data files...":                                   "正在刷新 geoip 和 geosite 数据文件...",
+	"Warning: failed to refresh geo data: %v\n":                                    "警告：刷新地理数据失败：%v\n",
+	"Found %d nodes in subscription\n":                                             "订阅中有 %d 个节点\n",
+	"Warning: failed to save config: %v\n":                                         "警告：保存配置失败：%v\n",
+	"Using pinned node: %s\n":                                                      "使用固定节点：%s\n",
+	"⚠ Pinned node %s is no longer in the subscription, selecting automatically\n": "⚠ 固定节点 %s 已不在订阅中，改为自动选择\n",
+	"Skipping %d nodes excluded by the node filter\n":                              "跳过 %d 个被节点筛选排除的节点\n",
+	"Skipping %d nodes %s can't run (use sing-box for hysteria2 and tuic nodes)\n": "跳过 %d 个 %s 无法运行的节点（hysteria2 和 tuic 节点请使用 sing-box）\n",
+	"Testing %d of %d nodes by region preference\n":                                "按地区偏好测试 %d/%d 个节点\n",
+	"Testing nodes through the proxy (url-test)...":                                "正在通过代理测试节点（url-test）...",
+	"Testing node latency...":                                                      "正在测试节点延迟...",
+	"Selected node: %s (%.1f MB/s)\n":                                              "已选择节点：%s（%.1f MB/s）\n",
+	"⚠ Speed tests failed, falling back to latency":                                "⚠ 测速失败，改为按延迟选择",
+	"Selected node: %s (latency: %dms)\n":                                          "已选择节点：%s（延迟：%dms）\n",
+	"Balancing across %d nodes\n":                                                  "在 %d 个节点间负载均衡\n",
+	"Testing bandwidth of %d nodes...\n":                                           "正在测试 %d 个节点的带宽...\n",
+	"Using cached subscription from %s\n":                                          "使用 %s 缓存的订阅\n",
+	"  Using cached subscription from %s\n":                                        "  使用 %s 缓存的订阅\n",
+	"Fetching subscription...":                                                     "正在获取订阅...",
+	"⚠ Skipped %d nodes:\n":                                                        "⚠ 跳过了 %d 个节点：\n",
+	"Warning: failed to save node latency: %v\n":                                   "警告：保存节点延迟失败：%v\n",
+	"No node answered over TCP, testing through the proxy (url-test)...":           "没有节点响应 TCP 连接，改为通过代理测试（url-test）...",
+
+	// Node picker
+	"✗ crosh nodes pick needs a terminal, use: crosh nodes use <name|index>": "✗ crosh nodes pick 需要在终端中运行，请使用：crosh nodes use <名称|序号>",
+	"No nodes to pick from (see: crosh nodes filter)":                        "没有可选的节点（参见：crosh nodes filter）",
+	"No node picked": "未选择节点",
+	"Pick a node: ↑/↓ move, type to filter, enter pins, ctrl-r retests, esc cancels": "选择节点：↑/↓ 移动，输入文字筛选，回车固定，ctrl-r 重新测速，esc 取消",
+	"%d of %d nodes shown, %d reachable":                                             "显示 %d/%d 个节点，%d 个可达",
+	", testing %d...":                                                                "，正在测试 %d 个...",
+	"NAME":                                                                           "名称",
+	"TYPE":                                                                           "类型",
+	"REGION":                                                                         "地区",
+	"LATENCY":                                                                        "延迟",
+	"active":                                                                         "使用中",
+	"pinned":                                                                         "已固定",
+	"unreachable":                                                                    "不可达",
+
+	// Dashboard
+	"✗ %s needs a terminal\n":               "✗ %s 需要在终端中运行\n",
+	"Switching the proxy...":                "正在切换代理...",
+	"Switching mirrors...":                  "正在切换镜像...",
+	"Restoring automatic node selection...": "正在恢复自动选择节点...",
+	"Fetching nodes...":                     "正在获取节点...",
+	"Switching to %s...":                    "正在切换到 %s...",
+	"✗ Failed to switch node: %v\n":         "✗ 切换节点失败：%v\n",
+	"✓ Proxy now using node: %s\n":          "✓ 代理已改用节点：%s\n",
+	"✓ Node selection is already automatic": "✓ 节点已经是自动选择",
+	"✓ Automatic node selection restored":   "✓ 已恢复自动选择节点",
+	"Proxy":                                 "代理",
+	"Node":                                  "节点",
+	"Traffic":                               "流量",
+	"Mirrors":                               "镜像",
+	"○ not configured":                      "○ 未配置",
+	"○ stopped":                             "○ 已停止",
+	"unavailable":                           "不可用",
+	"✓ enabled":                             "✓ 已开启",
+	"✗ disabled":                            "✗ 已关闭",
+	"disabled":                              "已关闭",
+	"Recent log":                            "最近日志",
+	"balancing %d nodes: %s":                "在 %d 个节点间负载均衡：%s",
+	"(pinned)":                              "（已固定）",
+	" p proxy on/off  m mirrors on/off  n pick node  a auto node  q quit": " p 开关代理  m 开关镜像  n 选择节点  a 自动选择节点  q 退出",
+
+	// Service
+	"✗ Failed to install the service: %v\n":                                       "✗ 安装服务失败：%v\n",
+	"✓ crosh service installed (%s: %s)\n":                                        "✓ 已安装 crosh 服务（%s：%s）\n",
+	"  The daemon now starts the proxy again after a reboot if it was on":         "  重启后，守护进程会重新启动之前开启的代理",
+	"It starts when you log in; to start it at boot, run: loginctl enable-linger": "登录时启动；要在开机时启动，请运行：loginctl enable-linger",
+	"It starts when you log in":                                                   "登录时启动",
+	"○ crosh service is not installed":                                            "○ crosh 服务未安装",
+	"✗ Failed to uninstall the service: %v\n":                                     "✗ 卸载服务失败：%v\n",
+	"✓ crosh service uninstalled":                                                 "✓ 已卸载 crosh 服务",
+	"  Acceleration stays as it is; after a reboot, run: crosh on":                "  加速状态保持不变；重启后请运行：crosh on",
+	"✓ Service: installed (%s: %s)\n":                                             "✓ 服务：已安装（%s：%s）\n",
+	"○ Service: not installed (run: crosh service install)":                       "○ 服务：未安装（运行：crosh service install）",
+	"✓ Daemon: running":                                                           "✓ 守护进程：运行中",
+	"○ Daemon: stopped":                                                           "○ 守护进程：已停止",
+	"systemd user unit":                                                           "systemd 用户单元",
+	"launchd agent":                                                               "launchd 代理",
+	"logon Run key":                                                               "登录 Run 注册表项",
+
+	// Restore
+	"also put back files changed since crosh edited them, losing those changes":             "同时恢复在 crosh 修改后又被改动的文件，这些改动会丢失",
+	"Nothing to restore: crosh hasn't changed any tool config files":                        "无需恢复：crosh 未修改任何工具配置文件",
+	"⚠ %s changed since crosh edited it, skipped (--force puts the original back anyway)\n": "⚠ %s 在 crosh 修改后又被改动，已跳过（使用 --force 仍可恢复原文件）\n",
+	"✓ Restored %s\n":                                                "✓ 已恢复 %s\n",
+	"✓ Removed %s, which crosh created\n":                            "✓ 已删除 crosh 创建的 %s\n",
+	"⚠ %s changed since crosh edited it; its original stays in %s\n": "⚠ %s 在 crosh 修改后又被改动，原文件保留在 %s\n",
+	"⚠ %s changed since crosh edited it; removing only crosh's setting (crosh restore --force puts the original back)\n": "⚠ %s 在 crosh 修改后又被改动，只删除 crosh 的设置（crosh restore --force 可恢复原文件）\n",
+
+	// Uninstall
+	"This turns acceleration off, undoes the package manager settings crosh changed and removes:": "此操作会关闭加速，撤销 crosh 对包管理器配置的修改，并删除：",
+	"  • %s (config, subscription cache, proxy cores, geo data and logs)\n":                       "  • %s（配置、订阅缓存、代理内核、地理数据和日志）\n",
+	"  • the crosh service (%s)\n": "  • crosh 服务（%s）\n",
+	"  • crosh lines in %s\n":      "  • %s 中的 crosh 相关行\n",
+	"Continue? [y/N] ":             "是否继续？[y/N] ",
+	"⚠ crosh still holds the originals of these files, as they changed since crosh edited them:": "⚠ 以下文件在 crosh 修改后又有变动，crosh 仍保存着它们的原始版本：",
+	"  Put them back with: crosh restore --force, or delete them with: crosh uninstall --force":  "  恢复：crosh restore --force，或删除：crosh uninstall --force",
+	"Nothing was removed":                           "未删除任何内容",
+	"⚠ Failed to uninstall the service: %v\n":       "⚠ 卸载服务失败：%v\n",
+	"✓ Proxy stopped":                               "✓ 代理已停止",
+	"⚠ Failed to remove crosh lines from %s: %v\n":  "⚠ 从 %s 删除 crosh 相关行失败：%v\n",
+	"✓ Removed crosh lines from %s\n":               "✓ 已从 %s 删除 crosh 相关行\n",
+	"⚠ Remove these crosh lines from %s by hand:\n": "⚠ 请手动从 %s 删除以下 crosh 相关行：\n",
+	"✗ Failed to remove %s: %v\n":                   "✗ 删除 %s 失败：%v\n",
+	"✓ Removed %s\n":                                "✓ 已删除 %s\n",
+	"  Left %s in place\n":                          "  保留了 %s\n",
+	"\n✓ crosh uninstalled":                         "\n✓ crosh 已卸载",
+	"  Shells that are still open keep the proxy variables; to remove them, run:": "  已打开的 shell 仍保留代理环境变量；要删除它们，请运行：",
+	"  To remove crosh itself, delete: %s\n":                                      "  要删除 crosh 本身，请删除：%s\n",
+
+	// Errors
+	"failed to download %s: %w":                                             "下载 %s 失败：%w",
+	"failed to select node: %w":                                             "选择节点失败：%w",
+	"failed to generate %s config: %w":                                      "生成 %s 配置失败：%w",
+	"failed to start %s: %w":                                                "启动 %s 失败：%w",
+	"failed to stop %s: %w":                                                 "停止 %s 失败：%w",
+	"failed to fetch subscription: %w":                                      "获取订阅失败：%w",
+	"failed to restart the proxy: %w":                                       "重启代理失败：%w",
+	"failed to open log file: %w":                                           "打开日志文件失败：%w",
+	"failed to read log file: %w":                                           "读取日志文件失败：%w",
+	"acceleration expired but cleanup failed: %v":                           "加速已到期，但清理失败：%v",
+	"none of the %d nodes pass the node filter (see: crosh nodes filter)":   "%d 个节点都未通过节点筛选（参见：crosh nodes filter）",
+	"%s can't run any of the %d nodes, try: crosh proxy on --core sing-box": "%s 无法运行这 %d 个节点中的任何一个，请尝试：crosh proxy on --core sing-box",
+
+	// Proxy cores
+	"%s started on port %d (SOCKS) and %d (HTTP) (PID: %d)\n":         "%s 已启动，端口 %d（SOCKS）和 %d（HTTP）（PID：%d）\n",
+	"%s started on port %d (PID: %d)\n":                               "%s 已启动，端口 %d（PID：%d）\n",
+	"%s stopped\n":                                                    "%s 已停止\n",
+	"Logs: %s\n":                                                      "日志：%s\n",
+	"Note: Process %d may have already stopped\n":                     "注意：进程 %d 可能已经停止\n",
+	"⚠ Proxy is listening on %s: anyone on your network can use it\n": "⚠ 代理正在监听 %s：局域网内任何人都可以使用\n",
+	"  Require a login with: crosh config set proxy.auth.username <name> (and proxy.auth.password)": "  要求登录：crosh config set proxy.auth.username <用户名>（以及 proxy.auth.password）",
+	"⚠ Proxy is listening on %s: devices on your network can use it with the proxy login\n":         "⚠ 代理正在监听 %s：局域网内的设备可以凭代理账号使用\n",
+	"  Other devices can use socks5://%s:%d":                                                        "  其他设备可以使用 socks5://%s:%d",
+	" or http://%s:%d":                                                                              " 或 http://%s:%d",
+	"✓ TUN mode: all traffic goes through %s\n":                                                     "✓ TUN 模式：所有流量经过 %s\n",
+	"Xray-core already exists, skipping download":                                                   "Xray-core 已存在，跳过下载",
+	"sing-box already exists, skipping download":                                                    "sing-box 已存在，跳过下载",
+	"mihomo already exists, skipping download":                                                      "mihomo 已存在，跳过下载",
+	"Downloading Xray-core...":                                                                      "正在下载 Xray-core...",
+	"Downloading sing-box...":                                                                       "正在下载 sing-box...",
+	"Downloading mihomo...":                                                                         "正在下载 mihomo...",
+	"Downloading Xray-core version %s...\n":                                                         "正在下载 Xray-core %s...\n",
+	"Downloading sing-box version %s...\n":                                                          "正在下载 sing-box %s...\n",
+	"Downloading mihomo version %s...\n":                                                            "正在下载 mihomo %s...\n",
+	"✓ Xray-core downloaded successfully":                                                           "✓ Xray-core 下载成功",
+	"✓ sing-box downloaded successfully":                                                            "✓ sing-box 下载成功",
+	"✓ mihomo downloaded successfully":                                                              "✓ mihomo 下载成功",
+	"Warning: failed to get latest release info: %v\n":                                              "警告：获取最新版本信息失败：%v\n",
+	"Falling back to default version %s\n":                                                          "改用默认版本 %s\n",
+	"Falling back to default version v1.8.4":                                                        "改用默认版本 v1.8.4",
+	"Downloading geoip and geosite data files...":                                                   "正在下载 geoip 和 geosite 数据文件...",
+	"Warning: failed to download geo data: %v\n":                                                    "警告：下载地理数据失败：%v\n",
+	"Routing rules may not work properly without geo data files":                                    "缺少地理数据文件，路由规则可能无法正常工作",
+	"Trying source %d/%d: %s\n":                                                                     "正在尝试下载源 %d/%d：%s\n",
+	"✗ Failed: %v\n":                                                                                "✗ 失败：%v\n",
+	"✓ %s already exists\n":                                                                         "✓ %s 已存在\n",
+	"Downloading %s...\n":                                                                           "正在下载 %s...\n",
+	"✓ %s downloaded successfully\n":                                                                "✓ %s 下载成功\n",
+	"✓ %s is up to date\n":                                                                          "✓ %s 已是最新\n",
+	"✓ %s updated\n":                                                                                "✓ %s 已更新\n",
+	"  Trying source %d/%d...\n":                                                                    "  正在尝试下载源 %d/%d...\n",
+	"  ✗ Failed: %v\n":                                                                              "  ✗ 失败：%v\n",
+
+	// Doctor
+	"No problems found":    "未发现问题",
+	"1 problem found":      "发现 1 个问题",
+	"%d problems found\n":  "发现 %d 个问题\n",
+	"Config directory: %v": "配置目录：%v",
+	"Config: %v":           "配置：%v",
+	"Config: %s":           "配置：%s",
+	"Config: not created yet, using the defaults":                     "配置：尚未创建，使用默认值",
+	"Fix the YAML in %s, or move it away to start from the defaults":  "修正 %s 中的 YAML，或将其移走以使用默认配置",
+	"Make sure your home directory exists and is writable":            "请确认主目录存在且可写",
+	"%s: not installed (%s)":                                          "%s：未安装（%s）",
+	"Run: crosh proxy on (downloads it)":                              "运行：crosh proxy on（会自动下载）",
+	"Check the permissions of %s":                                     "请检查 %s 的权限",
+	"%s: %s is not executable":                                        "%s：%s 不可执行",
+	"xray: %v":                                                        "xray：%v",
+	"xray: %s (%s)":                                                   "xray：%s（%s）",
+	"Reinstall it with: crosh xray upgrade":                           "重新安装：crosh xray upgrade",
+	"Geo data: geoip.dat or geosite.dat is missing":                   "地理数据：缺少 geoip.dat 或 geosite.dat",
+	"Geo data: %s old":                                                "地理数据：已有 %s 未更新",
+	"Geo data: updated %s ago":                                        "地理数据：%s 前更新",
+	"Run: crosh geodata update":                                       "运行：crosh geodata update",
+	"Port %d is in use by another program":                            "端口 %d 已被其他程序占用",
+	"Stop that program, or pick another port: crosh config set %s %d": "请停止该程序，或换一个端口：crosh config set %s %d",
+	" and ":                 " 和 ",
+	"Ports: %s free":        "端口：%s 可用",
+	"Proxy: not configured": "代理：未配置",
+	"Run: crosh proxy set <subscription-url>": "运行：crosh proxy set <订阅地址>",
+	"Proxy: disabled":                         "代理：已关闭",
+	"Proxy: enabled but %s is not running":    "代理：已开启，但 %s 未运行",
+	"Run: crosh proxy on (if it keeps stopping, see: crosh logs --level warning)": "运行：crosh proxy on（如果反复停止，请查看：crosh logs --level warning）",
+	"Proxy: node %s does not respond: %v":                                         "代理：节点 %s 无响应：%v",
+	"Switch to the fastest working node: crosh nodes auto":                        "切换到最快的可用节点：crosh nodes auto",
+	"Proxy: %s":              "代理：%s",
+	"Subscription: %v":       "订阅：%v",
+	"Subscription: %d nodes": "订阅：%d 个节点",
+	"Check the subscription URL with your provider, then run: crosh proxy set <subscription-url>": "请向服务商确认订阅地址，然后运行：crosh proxy set <订阅地址>",
+	"\n  → Behind a company proxy, set it with: crosh config set proxy.upstream http://host:port": "\n  → 如果在公司代理后面，请设置：crosh config set proxy.upstream http://host:port",
+	"DNS: cannot resolve github.com: %v":                                               "DNS：无法解析 github.com：%v",
+	"Check your network connection and DNS servers (e.g. set 223.5.5.5 as DNS server)": "请检查网络连接和 DNS 服务器（如将 DNS 服务器设为 223.5.5.5）",
+	"DNS: github.com resolves to %s":                                                   "DNS：github.com 解析为 %s",
+	"Mirrors: disabled":                                                                "镜像：已关闭",
+	"Run: crosh mirrors on":                                                            "运行：crosh mirrors on",
+	"Mirrors: %s is not using a mirror":                                                "镜像：%s 未使用镜像",
+	"Mirrors: %s uses %s":                                                              "镜像：%s 使用 %s",
+
+	// Node commands
+	"Found %d nodes":                                    "共 %d 个节点",
+	", %d excluded by the node filter":                  "，%d 个被节点筛选排除",
+	" (show them with --all)":                           "（用 --all 显示）",
+	"\n* balanced nodes: %s\n":                          "\n* 负载均衡节点：%s\n",
+	"\n* active node: %s\n":                             "\n* 当前节点：%s\n",
+	"  pinned node: %s (undo with: crosh nodes auto)\n": "  固定节点：%s（撤销：crosh nodes auto）\n",
+	"✓ Node filter saved":                               "✓ 节点筛选已保存",
+	"Node filter:":                                      "节点筛选：",
+	"  include: %s\n":                                   "  包含：%s\n",
+	"  exclude: %s\n":                                   "  排除：%s\n",
+	"  types:   %s\n":                                   "  类型：%s\n",
+	"  regions: %s\n":                                   "  地区：%s\n",
+	"  prefer:  %s\n":                                   "  优先：%s\n",
+	"  avoid:   %s\n":                                   "  避开：%s\n",
+	"(any)":                                             "（任意）",
+	"(none)":                                            "（无）",
+	"\nThe filter applies the next time a node is selected, e.g.: crosh proxy off && crosh proxy on": "\n筛选条件将在下次选择节点时生效，如：crosh proxy off && crosh proxy on",
+	"  This node stays selected until you run: crosh nodes auto":                                     "  该节点会一直保持选中，直到运行：crosh nodes auto",
+	"Testing download speed of %d nodes (up to %s each)...\n\n":                                      "正在测试 %d 个节点的下载速度（每个最多 %s）...\n\n",
+	"✓ %s: %.1f MB/s\n":                                          "✓ %s：%.1f MB/s\n",
+	"\n✗ No node completed the speed test":                       "\n✗ 没有节点完成测速",
+	"\nFastest download: %s (%.1f MB/s)\n":                       "\n下载最快：%s（%.1f MB/s）\n",
+	"  To prefer bandwidth over ping when selecting nodes, run:": "  要在选择节点时优先考虑带宽而非延迟，请运行：",
+
+	// Routing rules
+	"No routing rules, add one with: crosh rules add --direct internal.corp.com":     "没有路由规则，添加一条：crosh rules add --direct internal.corp.com",
+	"\nRules apply in order before %d always-proxied domains (proxy.always_proxy)\n": "\n规则按顺序生效，先于 %d 个始终走代理的域名（proxy.always_proxy）\n",
+	"and the built-in rules that send private and Chinese addresses direct.":         "以及让私有地址和中国地址直连的内置规则。",
+	"✓ Added %d routing rule(s)\n":                                                   "✓ 已添加 %d 条路由规则\n",
+	"✗ No routing rule matches %s\n":                                                 "✗ 没有匹配 %s 的路由规则\n",
+	"✓ Removed %s from the routing rules\n":                                          "✓ 已从路由规则中删除 %s\n",
+	"Reloading the proxy with the new rules...":                                      "正在用新规则重新加载代理...",
+	"✗ Failed to reload proxy: %v\n":                                                 "✗ 重新加载代理失败：%v\n",
+
+	// Remote machines
+	"Applying mirrors on %s...\n\n":                                                                "正在为 %s 配置镜像...\n\n",
+	"✗ Failed to apply mirrors: %v\n":                                                              "✗ 配置镜像失败：%v\n",
+	"✗ Local proxy is not running, start it first with: crosh on":                                  "✗ 本机代理未运行，请先启动：crosh on",
+	"\nConfiguring remote proxy environment...":                                                    "\n正在配置远程代理环境...",
+	"✗ Failed to configure remote proxy: %v\n":                                                     "✗ 配置远程代理失败：%v\n",
+	"\nOpening reverse tunnel (remote 127.0.0.1 ports %s → local proxy). Press Ctrl+C to close.\n": "\n正在打开反向隧道（远程 127.0.0.1 端口 %s → 本机代理）。按 Ctrl+C 关闭。\n",
+	"✗ Tunnel closed: %v\n":                                                                        "✗ 隧道已关闭：%v\n",
+	"\nDeploying proxy to remote...":                                                               "\n正在将代理部署到远程机器...",
+	"✗ Xray-core on the remote can't run %s node %s, pick another with: crosh nodes use\n":         "✗ 远程的 Xray-core 无法运行 %s 节点 %s，请换一个：crosh nodes use\n",
+	"✗ Failed to generate the remote's Xray config: %v\n":                                          "✗ 生成远程的 Xray 配置失败：%v\n",
+	"✗ Failed to deploy proxy: %v\n":                                                               "✗ 部署代理失败：%v\n",
+	"Unknown proxy mode: %s (expected none, tunnel or node)\n":                                     "未知的代理方式：%s（应为 none、tunnel 或 node）\n",
+	"\n✓ Remote %s configured\n":                                                                   "\n✓ 远程机器 %s 已配置\n",
+	"no node selected yet, start the proxy first with: crosh on":                                   "尚未选择节点，请先启动代理：crosh on",
+	"node %s is no longer in the subscription, pick another with: crosh nodes use":                 "节点 %s 已不在订阅中，请换一个：crosh nodes use",
+
+	// Bundles
+	"Passphrase for subscription: ":                                      "订阅口令：",
+	"Bundle passphrase: ":                                                "配置包口令：",
+	"✗ Passphrase must not be empty":                                     "✗ 口令不能为空",
+	"✗ Failed to create bundle: %v\n":                                    "✗ 创建配置包失败：%v\n",
+	"✓ Bundle written to %s\n":                                           "✓ 配置包已写入 %s\n",
+	"  Subscription not included (use --with-subscription or --encrypt)": "  未包含订阅（使用 --with-subscription 或 --encrypt）",
+	"  Subscription is encrypted, share the passphrase separately":       "  订阅已加密，请另行分享口令",
+	"  ⚠ Subscription URL is stored in plain text":                       "  ⚠ 订阅链接以明文保存",
+	"\nOnboard a teammate with: crosh import bundle %s\n":                "\n队友可以这样导入：crosh import bundle %s\n",
+	"✗ Failed to import bundle: %v\n":                                    "✗ 导入配置包失败：%v\n",
+	"✓ Imported bundle %s\n":                                             "✓ 已导入配置包 %s\n",
+	"✓ Subscription configured":                                          "✓ 订阅已配置",
+	"\nRun 'crosh on' to enable acceleration":                            "\n运行 'crosh on' 开启加速",
+
+	// Config
+	"✗ Failed to marshal config: %v\n": "✗ 序列化配置失败：%v\n",
+	"✗ Invalid value for %s: %v\n":     "✗ %s 的值无效：%v\n",
+	"failed to encode config: %w":      "编码配置失败：%w",
+	"unknown config key: %s":           "未知的配置项：%s",
+
+	// API server
+	"api.token in ~/.crosh/config.yaml":                                      "~/.crosh/config.yaml 中的 api.token",
+	"✗ Failed to load API token: %v\n":                                       "✗ 加载 API 令牌失败：%v\n",
+	"⚠ API is listening on %s, which may be reachable from other machines\n": "⚠ API 正在监听 %s，其他机器可能可以访问\n",
+	"✓ crosh API listening on http://%s\n":                                   "✓ crosh API 正在监听 http://%s\n",
+	"  Authenticate with: Authorization: Bearer <token from %s>\n":           "  认证方式：Authorization: Bearer <%s 中的令牌>\n",
+	"✓ Web dashboard: %s\n":                                                  "✓ 网页控制台：%s\n",
+	"✗ API server stopped: %v\n":                                             "✗ API 服务已停止：%v\n",
+	"✗ Daemon failed: %v\n":                                                  "✗ 守护进程出错：%v\n",
+
+	// Xray-core
+	"unknown (%v)":                          "未知（%v）",
+	"not installed":                         "未安装",
+	"Installed: %s\n":                       "已安装：%s\n",
+	"Pinned:    %s (proxy.xray_version)\n":  "已固定：%s（proxy.xray_version）\n",
+	"Latest:    %s\n":                       "最新：%s\n",
+	"\nUpgrade with: crosh xray upgrade":    "\n升级：crosh xray upgrade",
+	"✓ Xray-core %s is already installed\n": "✓ Xray-core %s 已经安装\n",
+	"✗ Failed to upgrade Xray-core: %v\n":   "✗ 升级 Xray-core 失败：%v\n",
+	"✓ Xray-core %s installed\n":            "✓ Xray-core %s 已安装\n",
+
+	// Logs, geo data and subscription
+	"No %s log yet, it is written once the proxy starts: %s\n":                            "还没有 %s 日志，代理启动后会写入：%s\n",
+	"✗ Failed to open log: %v\n":                                                          "✗ 打开日志失败：%v\n",
+	"✗ Unknown log level %q, use one of: %s\n":                                            "✗ 未知的日志级别 %q，请使用：%s\n",
+	"✗ Unknown geo data variant %q, use one of: %s\n":                                     "✗ 未知的地理数据版本 %q，请使用：%s\n",
+	"✗ The %s geo data has no %s, which your routing rules use (see: crosh rules list)\n": "✗ %s 地理数据中没有你的路由规则使用的 %s（参见：crosh rules list）\n",
+	"✓ Geo data is up to date":                                                            "✓ 地理数据已是最新",
+	"✓ Updated %s\n":                                                                      "✓ 已更新 %s\n",
+	"✓ Subscription updated: %d nodes\n":                                                  "✓ 订阅已更新：%d 个节点\n",
+	"  Expires: %s (%d days left)\n":                                                      "  到期：%s（剩余 %d 天）\n",
+	"  Cached for %s (proxy.subscription_max_age)\n":                                      "  缓存 %s（proxy.subscription_max_age）\n",
+
+	// Benchmark
+	"⚠ Proxy is not running, only testing direct and mirror fetches (start it with: crosh proxy on)": "⚠ 代理未运行，只测试直连和镜像下载（启动：crosh proxy on）",
+	"Fetching %d endpoints directly, from mirrors and through the proxy...\n\n":                      "正在分别直连、通过镜像和通过代理下载 %d 个地址...\n\n",
+	"✗ unreachable":               "✗ 无法访问",
+	"✗ only reachable directly":   "✗ 只能直连访问",
+	"✓ only reachable with crosh": "✓ 只能通过 crosh 访问",
+	"✓ %.1fx faster":              "✓ 快 %.1f 倍",
+	"○ no faster than direct":     "○ 不比直连快",
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Level is how much crosh prints
+type Level int
+
+const (
+	// Quiet prints errors only
+	Quiet Level = iota
+	// Normal prints progress and results
+	Normal
+	// Verbose also prints HTTP requests, files written and commands run
+	Verbose
+)
+
+var level = Normal
+
+// SetLevel sets how much crosh prints
+func SetLevel(l Level) {
+	level = l
+}
+
+// GetLevel returns how much crosh prints
+func GetLevel() Level {
+	return level
+}
+
+// Debugf prints a detail in verbose mode. Details go to stderr so they don't
+// mix with results, such as JSON or crosh env output.
+func Debugf(format string, args ...interface{}) {
+	if level < Verbose {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  » "+format+"\n", args...)
+}
+
+// Change is a file change, or a command, that DryRun recorded instead of
+// making or running
+type Change struct {
+	Path    string
+	Data    []byte   // the new content, nil if the file is removed
+	Command []string // the command line instead of a file change, see Run
+}
+
+// recorded collects the changes while DryRun runs
+var recorded *[]Change
+
+// DryRun runs fn with WriteFile, Remove, MkdirAll and Run recording changes
+// instead of making them, and returns the last change made to each file and
+// the commands in order
+func DryRun(fn func()) []Change {
+	changes := []Change{}
+	recorded = &changes
+	defer func() { recorded = nil }()
+	fn()
+	return changes
+}
+
+// record adds a change to those DryRun collects, replacing an earlier change to the same file
+func record(change Change) {
+	for i := range *recorded {
+		if change.Command == nil && (*recorded)[i].Path == change.Path {
+			(*recorded)[i] = change
+			return
+		}
+	}
+	*recorded = append(*recorded, change)
+}
+
+// WriteFile is os.WriteFile, logging the path in verbose mode
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if recorded != nil {
+		record(Change{Path: path, Data: data})
+		return nil
+	}
+	Debugf("write %s", path)
+	return os.WriteFile(path, data, perm)
+}
+
+// Remove is os.Remove, logging the path in verbose mode
+func Remove(path string) error {
+	if recorded != nil {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		record(Change{Path: path})
+		return nil
+	}
+	Debugf("remove %s", path)
+	return os.Remove(path)
+}
+
+// MkdirAll is os.MkdirAll; directories are not created during DryRun
+func MkdirAll(path string, perm os.FileMode) error {
+	if recorded != nil {
+		return nil
+	}
+	return os.MkdirAll(path, perm)
+}
+
+// Command is exec.Command, logging the command line in verbose mode
+func Command(name string, args ...string) *exec.Cmd {
+	Debugf("run %s", strings.Join(append([]string{name}, args...), " "))
+	return exec.Command(name, args...)
+}
+
+// Run runs a command that changes something, such as a tool's setting, and
+// returns its combined output. During DryRun it's recorded instead.
+func Run(name string, args ...string) ([]byte, error) {
+	if recorded != nil {
+		record(Change{Command: append([]string{name}, args...)})
+		return nil, nil
+	}
+	return Command(name, args...).CombinedOutput()
+}
+
+// Transport wraps next to log requests and their outcome in verbose mode
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return loggingTransport{next: next}
+}
+
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if level < Verbose {
+		return t.next.RoundTrip(req)
+	}
+
+	// Leave out the query, which often holds subscription tokens
+	target := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		Debugf("%s %s: %v", req.Method, target, err)
+		return nil, err
+	}
+	Debugf("%s %s: %s in %dms", req.Method, target, resp.Status, time.Since(start).Milliseconds())
+	return resp, nil
+}
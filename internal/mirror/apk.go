@@ -0,0 +1,121 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	apkRepositoriesPath = "/etc/apk/repositories"
+	apkDefaultHost      = "dl-cdn.alpinelinux.org"
+	apkHeader           = "# Mirror set by crosh; crosh off puts the original back"
+)
+
+// apkRepository matches a repository line up to the host, keeping an
+// optional @tag, the scheme and the path after it
+var apkRepository = regexp.MustCompile(`(?m)^(\s*(?:@\S+\s+)?https?://)([^/\s]+)(/alpine/)`)
+
+// ApkMirror handles Alpine apk repository configuration
+type ApkMirror struct {
+	mirrorURL string
+}
+
+// NewApkMirror creates a new apk mirror handler
+func NewApkMirror(mirrorURL string) *ApkMirror {
+	return &ApkMirror{
+		mirrorURL: mirrorURL,
+	}
+}
+
+// IsAlpine reports whether this is Alpine Linux, which has apk repositories
+func IsAlpine() bool {
+	_, err := os.Stat(apkRepositoriesPath)
+	return err == nil
+}
+
+// Enable points the Alpine repositories in /etc/apk/repositories at the
+// mirror, keeping their versions and tags
+func (a *ApkMirror) Enable() error {
+	// Only works on Linux
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("apk mirror only works on Alpine Linux")
+	}
+
+	existing, err := os.ReadFile(apkRepositoriesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("not Alpine Linux (no %s)", apkRepositoriesPath)
+		}
+		return fmt.Errorf("failed to read %s: %w", apkRepositoriesPath, err)
+	}
+	if !apkRepository.Match(existing) {
+		return fmt.Errorf("no Alpine repositories in %s", apkRepositoriesPath)
+	}
+
+	ours := strings.HasPrefix(string(existing), apkHeader)
+	content := strings.TrimPrefix(string(existing), apkHeader+"\n")
+	content = apkHeader + "\n" + apkRepository.ReplaceAllString(content, "${1}"+a.mirrorURL+"${3}")
+
+	// Write new repositories (requires root)
+	if err := writeConfig(apkRepositoriesPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s (try running as root): %w", apkRepositoriesPath, err)
+	}
+
+	return nil
+}
+
+// Disable restores the original apk repositories
+func (a *ApkMirror) Disable() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("apk mirror only works on Alpine Linux")
+	}
+
+	if restored, err := restoreOriginal(apkRepositoriesPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(apkRepositoriesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", apkRepositoriesPath, err)
+	}
+	if !strings.HasPrefix(string(data), apkHeader) {
+		return nil
+	}
+
+	// Point the repositories back at Alpine's CDN
+	content := strings.TrimPrefix(string(data), apkHeader+"\n")
+	content = apkRepository.ReplaceAllString(content, "${1}"+apkDefaultHost+"${3}")
+	if err := logging.WriteFile(apkRepositoriesPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", apkRepositoriesPath, err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (a *ApkMirror) Status() (bool, string, error) {
+	if runtime.GOOS != "linux" {
+		return false, "", fmt.Errorf("apk mirror only works on Alpine Linux")
+	}
+
+	data, err := os.ReadFile(apkRepositoriesPath)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read %s: %w", apkRepositoriesPath, err)
+	}
+
+	if strings.HasPrefix(string(data), apkHeader) {
+		if match := apkRepository.FindSubmatch(data); match != nil {
+			return true, string(match[2]), nil
+		}
+	}
+
+	return false, "default repositories", nil
+}
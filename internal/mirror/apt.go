@@ -3,11 +3,16 @@ package mirror
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
 )
 
+// legacyAptBackupPath is where crosh kept the original sources.list before
+// it saved originals in its own directory
+const legacyAptBackupPath = "/etc/apt/sources.list.crosh.backup"
+
 // AptMirror handles apt sources configuration
 type AptMirror struct {
 	mirrorURL string
@@ -39,7 +44,7 @@ func detectUbuntuVersion() (string, error) {
 	}
 
 	// Fallback: try lsb_release command
-	cmd := exec.Command("lsb_release", "-cs")
+	cmd := logging.Command("lsb_release", "-cs")
 	output, err := cmd.Output()
 	if err == nil {
 		return strings.TrimSpace(string(output)), nil
@@ -62,19 +67,15 @@ func (a *AptMirror) Enable() error {
 	}
 
 	sourcesPath := "/etc/apt/sources.list"
-	backupPath := "/etc/apt/sources.list.crosh.backup"
-
-	// Backup original sources.list if not already backed up
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-		data, err := os.ReadFile(sourcesPath)
-		if err != nil {
-			return fmt.Errorf("failed to read sources.list: %w", err)
-		}
-		if err := os.WriteFile(backupPath, data, 0644); err != nil {
-			return fmt.Errorf("failed to backup sources.list: %w", err)
-		}
+	existing, err := os.ReadFile(sourcesPath)
+	if err != nil {
+		return fmt.Errorf("failed to read sources.list: %w", err)
 	}
 
+	// Sources crosh generated before it saved originals have theirs in the old backup
+	_, legacyErr := os.Stat(legacyAptBackupPath)
+	ours := strings.Contains(string(existing), "Generated by crosh") || legacyErr == nil
+
 	// Generate new sources.list content
 	content := fmt.Sprintf(`# Generated by crosh - Chinese mirror acceleration
 deb http://%s/ubuntu/ %s main restricted universe multiverse
@@ -84,7 +85,7 @@ deb http://%s/ubuntu/ %s-security main restricted universe multiverse
 `, a.mirrorURL, codename, a.mirrorURL, codename, a.mirrorURL, codename, a.mirrorURL, codename)
 
 	// Write new sources.list (requires sudo)
-	if err := os.WriteFile(sourcesPath, []byte(content), 0644); err != nil {
+	if err := writeConfig(sourcesPath, []byte(content), 0644, ours); err != nil {
 		return fmt.Errorf("failed to write sources.list (try running with sudo): %w", err)
 	}
 
@@ -98,9 +99,12 @@ func (a *AptMirror) Disable() error {
 	}
 
 	sourcesPath := "/etc/apt/sources.list"
-	backupPath := "/etc/apt/sources.list.crosh.backup"
+	if restored, err := restoreOriginal(sourcesPath); restored || err != nil {
+		return err
+	}
 
-	// Restore from backup
+	// Restore from the backup older crosh versions kept next to sources.list
+	backupPath := legacyAptBackupPath
 	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
 		return fmt.Errorf("no backup found to restore")
 	}
@@ -110,12 +114,12 @@ func (a *AptMirror) Disable() error {
 		return fmt.Errorf("failed to read backup: %w", err)
 	}
 
-	if err := os.WriteFile(sourcesPath, data, 0644); err != nil {
+	if err := logging.WriteFile(sourcesPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to restore sources.list: %w", err)
 	}
 
 	// Remove backup file
-	os.Remove(backupPath)
+	logging.Remove(backupPath)
 
 	return nil
 }
@@ -0,0 +1,355 @@
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// ErrChanged is returned by Restore when a file changed since crosh last wrote it
+var ErrChanged = errors.New("changed since crosh edited it")
+
+// Backup is the original of a tool config file, saved before crosh first changed it
+type Backup struct {
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`          // false if crosh created the file
+	File    string      `json:"file,omitempty"`   // copy of the original in the backup directory
+	Mode    os.FileMode `json:"mode,omitempty"`   // permissions of the original
+	SHA256  string      `json:"sha256,omitempty"` // checksum of the original
+	Written string      `json:"written"`          // checksum of what crosh last wrote
+	SavedAt time.Time   `json:"saved_at"`
+}
+
+// BackupDir returns the directory the originals are saved in
+func BackupDir() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "backups"), nil
+}
+
+// Backups returns the saved originals, ordered by path
+func Backups() ([]Backup, error) {
+	manifest, err := loadManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Backup, 0, len(manifest))
+	for _, backup := range manifest {
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Path < backups[j].Path })
+	return backups, nil
+}
+
+// Restore puts back the original of path saved before crosh first changed it,
+// removing the file if crosh created it. It reports false if no original was
+// saved. If the file changed since crosh wrote it, Restore returns ErrChanged
+// and keeps the original, unless force is set.
+func Restore(path string, force bool) (bool, error) {
+	manifest, err := loadManifest()
+	if err != nil {
+		return false, err
+	}
+	backup, ok := manifest[path]
+	if !ok {
+		return false, nil
+	}
+	dir, err := BackupDir()
+	if err != nil {
+		return false, err
+	}
+
+	if !force && fileSum(path) != backup.Written {
+		return false, ErrChanged
+	}
+
+	if backup.Existed {
+		data, err := os.ReadFile(filepath.Join(dir, backup.File))
+		if err != nil {
+			return false, fmt.Errorf("failed to read backup of %s: %w", path, err)
+		}
+		if sum(data) != backup.SHA256 {
+			return false, fmt.Errorf("backup of %s is corrupted (checksum mismatch)", path)
+		}
+		if err := logging.WriteFile(path, data, backup.Mode); err != nil {
+			return false, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		logging.Remove(filepath.Join(dir, backup.File))
+	} else if err := logging.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	delete(manifest, path)
+	return true, saveManifest(manifest)
+}
+
+// writeConfig writes a tool config file. The first time crosh changes the
+// file the original is saved, so Disable can put it back exactly, unless
+// ours reports that the file already carries crosh's setting, e.g. from a
+// crosh version that didn't save originals.
+func writeConfig(path string, data []byte, perm os.FileMode, ours bool) error {
+	manifest, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	dir, err := BackupDir()
+	if err != nil {
+		return err
+	}
+
+	backup, ok := manifest[path]
+	stale := ok && fileSum(path) != backup.Written
+	if stale {
+		// Edited since crosh wrote it, so the original no longer tells what to go back to
+		if backup.Existed {
+			fmt.Fprintf(os.Stderr, i18n.T("⚠ %s changed since crosh edited it; its original stays in %s\n"), path, filepath.Join(dir, backup.File))
+		}
+		delete(manifest, path)
+		ok, ours = false, true
+	}
+
+	if !ok && !ours {
+		backup = Backup{Path: path, SavedAt: time.Now()}
+		if info, err := os.Stat(path); err == nil {
+			original, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+			backup.Existed = true
+			backup.Mode = info.Mode().Perm()
+			backup.SHA256 = sum(original)
+			backup.File = fmt.Sprintf("%s-%s", sum([]byte(path))[:12], filepath.Base(path))
+			if err := logging.MkdirAll(dir, 0700); err != nil {
+				return fmt.Errorf("failed to create backup directory: %w", err)
+			}
+			if err := logging.WriteFile(filepath.Join(dir, backup.File), original, 0600); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+		}
+		ok = true
+	}
+
+	if err := logging.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	if !ok {
+		if stale {
+			return saveManifest(manifest)
+		}
+		return nil
+	}
+	backup.Written = sum(data)
+	manifest[path] = backup
+	return saveManifest(manifest)
+}
+
+// tracked reports whether crosh saved the original of path, and so wrote it since
+func tracked(path string) bool {
+	manifest, err := loadManifest()
+	if err != nil {
+		return false
+	}
+	_, ok := manifest[path]
+	return ok
+}
+
+// restoreOriginal is what Disable tries first: putting back the original of
+// path. If the file changed since, Disable removes only crosh's setting, and
+// crosh restore --force can still put the original back.
+func restoreOriginal(path string) (bool, error) {
+	restored, err := Restore(path, false)
+	if errors.Is(err, ErrChanged) {
+		fmt.Fprintf(os.Stderr, i18n.T("⚠ %s changed since crosh edited it; removing only crosh's setting (crosh restore --force puts the original back)\n"), path)
+		return false, nil
+	}
+	return restored, err
+}
+
+// restoreOriginalKeepingBlocks is restoreOriginal for files other tools put
+// their settings in as blocks between markers, such as Electron's in .npmrc:
+// the original comes back with those blocks, and stays saved until the last
+// of them is removed
+func restoreOriginalKeepingBlocks(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return restoreOriginal(path)
+	}
+	blocks := anyEnvBlock.FindAllString(string(data), -1)
+	original, ok := savedOriginal(path)
+	if len(blocks) == 0 || !ok || fileSum(path) != writtenSum(path) {
+		return restoreOriginal(path)
+	}
+
+	content := string(original)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(blocks, "")
+	if err := writeConfig(path, []byte(content), 0644, true); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// removeBlock removes the block of tool's settings from the file at path,
+// putting the original back once the file is as it was
+func removeBlock(path, tool string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	content := envBlock(tool).ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	if original, ok := savedOriginal(path); ok && content == string(original) {
+		if restored, err := restoreOriginal(path); restored || err != nil {
+			return err
+		}
+	} else if tracked(path) {
+		// Until then the original stays saved, and what crosh wrote is noted
+		if err := writeConfig(path, []byte(content), 0644, true); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := logging.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// savedOriginal returns the original of path crosh saved, empty if crosh
+// created the file, and whether there is one
+func savedOriginal(path string) ([]byte, bool) {
+	manifest, err := loadManifest()
+	if err != nil {
+		return nil, false
+	}
+	backup, ok := manifest[path]
+	if !ok {
+		return nil, false
+	}
+	if !backup.Existed {
+		return nil, true
+	}
+	dir, err := BackupDir()
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, backup.File))
+	if err != nil || sum(data) != backup.SHA256 {
+		return nil, false
+	}
+	return data, true
+}
+
+// writtenSum returns the checksum of what crosh last wrote to path, or "" if
+// it isn't tracked
+func writtenSum(path string) string {
+	manifest, err := loadManifest()
+	if err != nil {
+		return ""
+	}
+	return manifest[path].Written
+}
+
+// manifestPath returns the path of the file listing the saved originals
+func manifestPath() (string, error) {
+	dir, err := BackupDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "manifest.json"), nil
+}
+
+// loadManifest reads the saved originals, keyed by path
+func loadManifest() (map[string]Backup, error) {
+	path, err := manifestPath()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := map[string]Backup{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// saveManifest writes the saved originals, removing the manifest and directory once empty
+func saveManifest(manifest map[string]Backup) error {
+	path, err := manifestPath()
+	if err != nil {
+		return err
+	}
+
+	if len(manifest) == 0 {
+		if err := logging.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove backup manifest: %w", err)
+		}
+		// Fails, harmlessly, if an original kept for a changed file is left
+		logging.Remove(filepath.Dir(path))
+		return nil
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := logging.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := logging.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return nil
+}
+
+// fileSum returns the checksum of the file at path, or "" if it doesn't exist
+func fileSum(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return sum(data)
+}
+
+// sum returns the hex SHA-256 checksum of data
+func sum(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,238 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// bazelUpstreams are the URL prefixes, without the scheme, that the mirrors
+// of common Bazel external dependencies replace, by the names mirror.bazel takes
+var bazelUpstreams = map[string]string{
+	"github": "github.com",
+	"golang": "dl.google.com/go",
+	"maven":  "repo1.maven.org/maven2",
+	"npm":    "registry.npmjs.org",
+}
+
+var (
+	// bazelDownloaderConfig matches a line of a bazelrc setting the downloader config
+	bazelDownloaderConfig = regexp.MustCompile(`(?m)^[^#\n]*--(?:experimental_)?downloader_config[= ]\S+`)
+	// bazelRewrite matches a rewrite rule of a downloader config, e.g.
+	// "rewrite github.com/(.*) ghfast.top/https://github.com/$1"
+	bazelRewrite = regexp.MustCompile(`(?m)^rewrite\s+(\S+?)/\(\.\*\)\s`)
+)
+
+// bazelNames returns the names mirror.bazel takes, in order
+func bazelNames() []string {
+	names := make([]string, 0, len(bazelUpstreams))
+	for name := range bazelUpstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BazelMirror handles the downloader config that rewrites the URLs Bazel
+// fetches external dependencies from to their mirrors
+type BazelMirror struct {
+	conflicts
+	mirrors map[string]string // name in bazelUpstreams to mirror, without the scheme
+}
+
+// NewBazelMirror creates a new Bazel mirror handler for the upstreams in
+// mirrors, e.g. github, each with its mirror
+func NewBazelMirror(mirrors map[string]string) *BazelMirror {
+	return &BazelMirror{
+		mirrors: mirrors,
+	}
+}
+
+// HasBazel reports whether Bazel or Bazelisk is installed
+func HasBazel() bool {
+	for _, name := range []string{"bazel", "bazelisk"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// getBazelrcPath returns the path of the user's ~/.bazelrc
+func getBazelrcPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".bazelrc"), nil
+}
+
+// getBazelDownloaderConfigPath returns the path of the downloader config
+// crosh writes, in its own config directory
+func getBazelDownloaderConfigPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bazel_downloader.cfg"), nil
+}
+
+// Upstreams returns the upstreams that have a mirror, in order
+func (b *BazelMirror) Upstreams() []string {
+	var upstreams []string
+	for _, name := range bazelNames() {
+		if b.mirrors[name] != "" {
+			upstreams = append(upstreams, bazelUpstreams[name])
+		}
+	}
+	return upstreams
+}
+
+// rules returns the rewrite rules of the downloader config, in the order of Upstreams.
+// Bazel matches URLs without their scheme and keeps the scheme on the rewritten one.
+func (b *BazelMirror) rules() []string {
+	var rules []string
+	for _, name := range bazelNames() {
+		mirror := b.mirrors[name]
+		if mirror == "" {
+			continue
+		}
+		mirror = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(mirror, "/"), "https://"), "http://")
+		rules = append(rules, fmt.Sprintf("rewrite %s/(.*) %s/$1", regexp.QuoteMeta(bazelUpstreams[name]), mirror))
+	}
+	return rules
+}
+
+// Enable writes the downloader config and points ~/.bazelrc at it, between
+// markers. Rewritten URLs are all Bazel tries, so a dependency missing from
+// its mirror fails to download until the mirror is turned off.
+func (b *BazelMirror) Enable() error {
+	rules := b.rules()
+	if len(rules) == 0 {
+		return fmt.Errorf("no mirror for %s", strings.Join(bazelNames(), ", "))
+	}
+	rcPath, err := getBazelrcPath()
+	if err != nil {
+		return err
+	}
+	cfgPath, err := getBazelDownloaderConfigPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing bazelrc if it exists
+	var existingContent string
+	if data, err := os.ReadFile(rcPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin)
+	content := strings.TrimRight(hashMarkedBlock.ReplaceAllString(existingContent, ""), "\n")
+
+	// A downloader config the user set, e.g. a company one, stays unless
+	// Overwrite is set; crosh's lines come last, so they win over theirs otherwise
+	if line := bazelDownloaderConfig.FindString(content); line != "" && b.resolve(rcPath, strings.TrimSpace(line)) {
+		return nil
+	}
+
+	if err := logging.WriteFile(cfgPath, []byte("# Generated by crosh: Bazel's downloads from mirrors, removed by crosh off\n"+strings.Join(rules, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", cfgPath, err)
+	}
+
+	if content != "" {
+		content += "\n\n"
+	}
+	content += hashMarkerBegin + "\n"
+	// fetch and query don't take build's options
+	for _, command := range []string{"build", "fetch", "query"} {
+		content += fmt.Sprintf("%s --experimental_downloader_config=%s\n", command, cfgPath)
+	}
+	content += hashMarkerEnd + "\n"
+
+	if err := writeConfig(rcPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcPath, err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (b *BazelMirror) Disable() error {
+	rcPath, err := getBazelrcPath()
+	if err != nil {
+		return err
+	}
+	cfgPath, err := getBazelDownloaderConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := logging.Remove(cfgPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", cfgPath, err)
+	}
+	if restored, err := restoreOriginal(rcPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(rcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", rcPath, err)
+	}
+
+	// Remove the block between crosh's markers
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(rcPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", rcPath, err)
+		}
+		return nil
+	}
+
+	if err := logging.WriteFile(rcPath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcPath, err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled, listing the upstreams
+// that are rewritten to mirrors
+func (b *BazelMirror) Status() (bool, string, error) {
+	rcPath, err := getBazelrcPath()
+	if err != nil {
+		return false, "", err
+	}
+	cfgPath, err := getBazelDownloaderConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(rcPath)
+	if err != nil || !strings.Contains(hashMarkedBlock.FindString(string(data)), cfgPath) {
+		return false, "upstream URLs", nil
+	}
+	rules, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return false, "upstream URLs", nil
+	}
+
+	var upstreams []string
+	for _, match := range bazelRewrite.FindAllStringSubmatch(string(rules), -1) {
+		upstreams = append(upstreams, strings.ReplaceAll(match[1], `\.`, "."))
+	}
+	return true, strings.Join(upstreams, ", "), nil
+}
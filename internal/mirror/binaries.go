@@ -0,0 +1,72 @@
+package mirror
+
+import (
+	"sort"
+)
+
+// BinaryMirror handles where native npm packages, such as node-sass, sharp
+// and canvas, download prebuilt binaries and node-gyp downloads Node.js
+// headers from, which the npm registry mirror doesn't cover
+type BinaryMirror struct {
+	conflicts
+	hosts map[string]string
+}
+
+// NewBinaryMirror creates a new binary mirror handler from hosts, which maps
+// .npmrc keys, such as disturl and sass_binary_site, to their mirrors
+func NewBinaryMirror(hosts map[string]string) *BinaryMirror {
+	return &BinaryMirror{
+		hosts: hosts,
+	}
+}
+
+// Keys returns the .npmrc keys with a mirror, in order
+func (b *BinaryMirror) Keys() []string {
+	keys := make([]string, 0, len(b.hosts))
+	for key, url := range b.hosts {
+		if url != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Enable sets the mirrors in ~/.npmrc, between "# crosh:begin binaries" and
+// "# crosh:end binaries"
+func (b *BinaryMirror) Enable() error {
+	// Hosts the user set, e.g. a company one, stay unless Overwrite is set
+	for _, key := range b.Keys() {
+		npmrcPath, line, value, err := userNpmrcKey(key)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != b.hosts[key] && b.resolve(npmrcPath, line) {
+			return nil
+		}
+	}
+
+	var keys []npmrcKey
+	for _, key := range b.Keys() {
+		keys = append(keys, npmrcKey{key, b.hosts[key]})
+	}
+	return setNpmrcBlock("binaries", keys)
+}
+
+// Disable removes the mirror configuration
+func (b *BinaryMirror) Disable() error {
+	return unsetNpmrcBlock("binaries")
+}
+
+// Status checks if the mirror is currently enabled
+func (b *BinaryMirror) Status() (bool, string, error) {
+	if url, ok := getNpmrcKey("binaries", "disturl"); ok {
+		return true, url, nil
+	}
+	for _, key := range b.Keys() {
+		if url, ok := getNpmrcKey("binaries", key); ok {
+			return true, url, nil
+		}
+	}
+	return false, "default downloads", nil
+}
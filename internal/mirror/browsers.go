@@ -0,0 +1,68 @@
+package mirror
+
+import (
+	"sort"
+)
+
+// BrowserMirror handles where Puppeteer, Playwright and Cypress download
+// their browsers and binaries from in postinstall scripts, which the npm
+// registry mirror doesn't cover
+type BrowserMirror struct {
+	conflicts
+	hosts map[string]string
+}
+
+// NewBrowserMirror creates a new browser mirror handler from hosts, which
+// maps environment variables, such as PLAYWRIGHT_DOWNLOAD_HOST, to their mirrors
+func NewBrowserMirror(hosts map[string]string) *BrowserMirror {
+	return &BrowserMirror{
+		hosts: hosts,
+	}
+}
+
+// Names returns the environment variables with a mirror, in order
+func (b *BrowserMirror) Names() []string {
+	names := make([]string, 0, len(b.hosts))
+	for name, url := range b.hosts {
+		if url != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enable sets the mirrors in the shell rc file
+func (b *BrowserMirror) Enable() error {
+	// Hosts the user set, e.g. a company one, stay unless Overwrite is set
+	for _, name := range b.Names() {
+		rcFile, line, value, err := userEnv(name)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != b.hosts[name] && b.resolve(rcFile, line) {
+			return nil
+		}
+	}
+
+	var vars []envVar
+	for _, name := range b.Names() {
+		vars = append(vars, envVar{name, b.hosts[name]})
+	}
+	return setEnv("browsers", vars)
+}
+
+// Disable removes the mirror configuration
+func (b *BrowserMirror) Disable() error {
+	return unsetEnv("browsers")
+}
+
+// Status checks if the mirror is currently enabled
+func (b *BrowserMirror) Status() (bool, string, error) {
+	for _, name := range b.Names() {
+		if url, ok := getEnv("browsers", name); ok {
+			return true, url, nil
+		}
+	}
+	return false, "default downloads", nil
+}
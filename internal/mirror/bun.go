@@ -0,0 +1,174 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+var bunRegistry = regexp.MustCompile(`(?m)^registry\s*=\s*"([^"]*)"`)
+
+// BunMirror handles the npm registry of Bun, which ignores .npmrc in some
+// configurations
+type BunMirror struct {
+	conflicts
+	registryURL string
+}
+
+// NewBunMirror creates a new Bun mirror handler
+func NewBunMirror(registryURL string) *BunMirror {
+	return &BunMirror{
+		registryURL: registryURL,
+	}
+}
+
+// HasBun reports whether Bun is installed
+func HasBun() bool {
+	_, err := exec.LookPath("bun")
+	return err == nil
+}
+
+// getBunfigPath returns the path of Bun's global bunfig.toml
+func getBunfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, ".bunfig.toml"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".bunfig.toml"), nil
+}
+
+// Enable sets install.registry in the global bunfig.toml, between markers
+// in the [install] table if it has one
+func (b *BunMirror) Enable() error {
+	bunfigPath, err := getBunfigPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing bunfig.toml if it exists
+	var existingContent string
+	if data, err := os.ReadFile(bunfigPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin)
+	content := strings.TrimRight(hashMarkedBlock.ReplaceAllString(existingContent, ""), "\n")
+
+	// A registry the user set, e.g. a company one, stays unless Overwrite is
+	// set; TOML allows a key only once, so with Overwrite it goes
+	lines := strings.Split(content, "\n")
+	table := ""
+	install := -1
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "[") {
+			table = strings.TrimSpace(strings.Trim(trimmed, "[]"))
+			if table == "install" && install < 0 {
+				install = i
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !(table == "install" && key == "registry" || table == "" && key == "install.registry") {
+			continue
+		}
+		registry := strings.Trim(strings.TrimSpace(value), `"'`)
+		if registry != b.registryURL && ownSetting("npm", bunfigPath, registry) && b.resolve(bunfigPath, trimmed) {
+			return nil
+		}
+		lines = slices.Delete(lines, i, i+1)
+		i--
+	}
+
+	registryLine := fmt.Sprintf("registry = %q", b.registryURL)
+	if install >= 0 {
+		block := []string{hashMarkerBegin, registryLine, hashMarkerEnd}
+		lines = slices.Insert(lines, install+1, block...)
+		content = strings.Join(lines, "\n") + "\n"
+	} else {
+		content = strings.Join(lines, "\n")
+		if content != "" {
+			content += "\n\n"
+		}
+		content += fmt.Sprintf("%s\n[install]\n%s\n%s\n", hashMarkerBegin, registryLine, hashMarkerEnd)
+	}
+
+	if err := writeConfig(bunfigPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write bunfig.toml: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (b *BunMirror) Disable() error {
+	bunfigPath, err := getBunfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(bunfigPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(bunfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read bunfig.toml: %w", err)
+	}
+
+	// Remove the block between crosh's markers
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(bunfigPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove bunfig.toml: %w", err)
+		}
+		return nil
+	}
+
+	if err := logging.WriteFile(bunfigPath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write bunfig.toml: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (b *BunMirror) Status() (bool, string, error) {
+	bunfigPath, err := getBunfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(bunfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default registry", nil
+		}
+		return false, "", fmt.Errorf("failed to read bunfig.toml: %w", err)
+	}
+
+	if block := hashMarkedBlock.FindString(string(data)); block != "" {
+		if match := bunRegistry.FindStringSubmatch(block); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	return false, "default registry", nil
+}
@@ -5,17 +5,24 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
 )
 
-// CargoMirror handles Rust cargo registry configuration
+// CargoMirror handles Rust cargo registry configuration, and where rustup
+// downloads toolchains from
 type CargoMirror struct {
+	conflicts
 	registryURL string
+	rustupURL   string
 }
 
-// NewCargoMirror creates a new Cargo mirror handler
-func NewCargoMirror(registryURL string) *CargoMirror {
+// NewCargoMirror creates a new Cargo mirror handler for crates, from
+// registryURL, and toolchains, from rustupURL if it's set
+func NewCargoMirror(registryURL, rustupURL string) *CargoMirror {
 	return &CargoMirror{
 		registryURL: registryURL,
+		rustupURL:   strings.TrimSuffix(rustupURL, "/"),
 	}
 }
 
@@ -28,7 +35,7 @@ func getCargoConfigPath() (string, error) {
 
 	// ~/.cargo/config.toml
 	cargoDir := filepath.Join(homeDir, ".cargo")
-	if err := os.MkdirAll(cargoDir, 0755); err != nil {
+	if err := logging.MkdirAll(cargoDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create cargo directory: %w", err)
 	}
 
@@ -50,11 +57,29 @@ func (c *CargoMirror) Enable() error {
 
 	// Check if source section exists
 	lines := strings.Split(existingContent, "\n")
+
+	// crates.io replaced with a source of the user's stays unless Overwrite is set
+	inCratesIO := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inCratesIO = trimmed == "[source.crates-io]"
+			continue
+		}
+		_, source, ok := strings.Cut(trimmed, "=")
+		if inCratesIO && strings.HasPrefix(trimmed, "replace-with") && ok &&
+			strings.Trim(strings.TrimSpace(source), `'"`) != "ustc" && !tracked(cargoConfigPath) &&
+			c.resolve(cargoConfigPath, trimmed) {
+			return nil
+		}
+	}
+
 	hasSourceSection := false
 	hasCratesIOSection := false
 	newLines := []string{}
 
 	inCratesIOSection := false
+	inUstcSection := false
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
@@ -69,6 +94,7 @@ func (c *CargoMirror) Enable() error {
 
 		if strings.HasPrefix(trimmed, "[source.") && trimmed != "[source.crates-io]" {
 			inCratesIOSection = false
+			inUstcSection = trimmed == "[source.ustc]"
 			newLines = append(newLines, line)
 			continue
 		}
@@ -79,10 +105,17 @@ func (c *CargoMirror) Enable() error {
 				newLines = append(newLines, fmt.Sprintf("replace-with = 'ustc'"))
 			}
 			inCratesIOSection = false
+			inUstcSection = false
 			newLines = append(newLines, line)
 			continue
 		}
 
+		// Point the mirror at the current registry, which may have changed
+		if inUstcSection && strings.HasPrefix(trimmed, "registry") {
+			newLines = append(newLines, fmt.Sprintf("registry = \"%s\"", c.registryURL))
+			continue
+		}
+
 		if inCratesIOSection && strings.HasPrefix(trimmed, "replace-with") {
 			// Replace existing replace-with
 			newLines = append(newLines, "replace-with = 'ustc'")
@@ -112,19 +145,52 @@ func (c *CargoMirror) Enable() error {
 
 	// Write back
 	content := strings.Join(newLines, "\n") + "\n"
-	if err := os.WriteFile(cargoConfigPath, []byte(content), 0644); err != nil {
+	ours := strings.Contains(existingContent, "[source.ustc]")
+	if err := writeConfig(cargoConfigPath, []byte(content), 0644, ours); err != nil {
 		return fmt.Errorf("failed to write cargo config: %w", err)
 	}
 
-	return nil
+	return c.enableRustup()
+}
+
+// enableRustup sets RUSTUP_DIST_SERVER and RUSTUP_UPDATE_ROOT in the shell rc file
+func (c *CargoMirror) enableRustup() error {
+	if c.rustupURL == "" {
+		return nil
+	}
+
+	// A dist server the user set stays next to the crates mirror unless Overwrite is set
+	rcFile, line, value, err := userEnv("RUSTUP_DIST_SERVER")
+	if err != nil {
+		return err
+	}
+	if line != "" && strings.TrimSuffix(value, "/") != c.rustupURL && ownSetting("rustup", rcFile, value) {
+		if !c.Overwrite {
+			c.merge(rcFile, line)
+			return nil
+		}
+		c.resolve(rcFile, line)
+	}
+
+	return setEnv("rustup", []envVar{
+		{"RUSTUP_DIST_SERVER", c.rustupURL},
+		{"RUSTUP_UPDATE_ROOT", c.rustupURL + "/rustup"},
+	})
 }
 
 // Disable removes the mirror configuration
 func (c *CargoMirror) Disable() error {
+	if err := unsetEnv("rustup"); err != nil {
+		return err
+	}
+
 	cargoConfigPath, err := getCargoConfigPath()
 	if err != nil {
 		return err
 	}
+	if restored, err := restoreOriginal(cargoConfigPath); restored || err != nil {
+		return err
+	}
 
 	data, err := os.ReadFile(cargoConfigPath)
 	if err != nil {
@@ -159,11 +225,11 @@ func (c *CargoMirror) Disable() error {
 	// Write back or remove file if empty
 	if len(newLines) > 0 {
 		content := strings.Join(newLines, "\n") + "\n"
-		if err := os.WriteFile(cargoConfigPath, []byte(content), 0644); err != nil {
+		if err := logging.WriteFile(cargoConfigPath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("failed to write cargo config: %w", err)
 		}
 	} else {
-		os.Remove(cargoConfigPath)
+		logging.Remove(cargoConfigPath)
 	}
 
 	return nil
@@ -201,3 +267,11 @@ func (c *CargoMirror) Status() (bool, string, error) {
 
 	return false, "default registry", nil
 }
+
+// RustupStatus checks if the toolchain mirror is currently enabled
+func (c *CargoMirror) RustupStatus() (bool, string, error) {
+	if url, ok := getEnv("rustup", "RUSTUP_DIST_SERVER"); ok {
+		return true, url, nil
+	}
+	return false, "static.rust-lang.org", nil
+}
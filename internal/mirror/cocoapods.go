@@ -0,0 +1,174 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// cocoaPodsSpecsURL is where the git-based CocoaPods specs repo comes from by default
+const cocoaPodsSpecsURL = "https://github.com/CocoaPods/Specs.git"
+
+// CocoaPodsMirror handles CocoaPods specs repo configuration
+type CocoaPodsMirror struct {
+	specsURL string
+}
+
+// NewCocoaPodsMirror creates a new CocoaPods mirror handler
+func NewCocoaPodsMirror(specsURL string) *CocoaPodsMirror {
+	return &CocoaPodsMirror{
+		specsURL: specsURL,
+	}
+}
+
+// getCocoaPodsDir returns CocoaPods' directory, ~/.cocoapods unless CP_HOME_DIR is set
+func getCocoaPodsDir() (string, error) {
+	if dir := os.Getenv("CP_HOME_DIR"); dir != "" {
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cocoapods"), nil
+}
+
+// getCocoaPodsSpecsConfigPath returns the git config of the master specs repo
+func getCocoaPodsSpecsConfigPath() (string, error) {
+	dir, err := getCocoaPodsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "repos", "master", ".git", "config"), nil
+}
+
+// HasCocoaPods reports whether CocoaPods has been set up for this user
+func HasCocoaPods() bool {
+	dir, err := getCocoaPodsDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(dir)
+	return err == nil
+}
+
+// originURLLine returns the index of the url line of the origin remote in
+// the lines of a git config, or -1
+func originURLLine(lines []string) int {
+	inOrigin := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inOrigin = trimmed == `[remote "origin"]`
+			continue
+		}
+		if key, _, ok := strings.Cut(trimmed, "="); inOrigin && ok && strings.TrimSpace(key) == "url" {
+			return i
+		}
+	}
+	return -1
+}
+
+// originURL returns the URL of the origin remote in a git config, or ""
+func originURL(config string) string {
+	lines := strings.Split(config, "\n")
+	i := originURLLine(lines)
+	if i < 0 {
+		return ""
+	}
+	_, url, _ := strings.Cut(lines[i], "=")
+	return strings.TrimSpace(url)
+}
+
+// setOriginURL returns the git config with the URL of the origin remote set to url
+func setOriginURL(config, url string) (string, bool) {
+	lines := strings.Split(config, "\n")
+	i := originURLLine(lines)
+	if i < 0 {
+		return config, false
+	}
+	lines[i] = "\turl = " + url
+	return strings.Join(lines, "\n"), true
+}
+
+// Enable points the git-based master specs repo, if there is one, at the
+// mirror, so pod repo update fetches from it. Projects using the CDN need
+// the mirror as a source in their Podfile.
+func (c *CocoaPodsMirror) Enable() error {
+	configPath, err := getCocoaPodsSpecsConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Only the CDN is used, which the Podfile source replaces
+		}
+		return fmt.Errorf("failed to read specs repo config: %w", err)
+	}
+
+	content, ok := setOriginURL(string(data), c.specsURL)
+	if !ok {
+		return fmt.Errorf("no origin remote in %s", configPath)
+	}
+	ours := originURL(string(data)) == c.specsURL
+	if err := writeConfig(configPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write specs repo config: %w", err)
+	}
+
+	return nil
+}
+
+// Disable points the master specs repo back at GitHub
+func (c *CocoaPodsMirror) Disable() error {
+	configPath, err := getCocoaPodsSpecsConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read specs repo config: %w", err)
+	}
+	if originURL(string(data)) == cocoaPodsSpecsURL {
+		return nil
+	}
+
+	content, _ := setOriginURL(string(data), cocoaPodsSpecsURL)
+	if err := logging.WriteFile(configPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write specs repo config: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (c *CocoaPodsMirror) Status() (bool, string, error) {
+	configPath, err := getCocoaPodsSpecsConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "CDN", nil
+		}
+		return false, "", fmt.Errorf("failed to read specs repo config: %w", err)
+	}
+
+	if url := originURL(string(data)); url != "" && url != cocoaPodsSpecsURL {
+		return true, url, nil
+	}
+	return false, "default specs repo", nil
+}
@@ -0,0 +1,185 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// ComposerMirror handles Composer (Packagist) repository configuration
+type ComposerMirror struct {
+	conflicts
+	repositoryURL string
+}
+
+// NewComposerMirror creates a new Composer mirror handler
+func NewComposerMirror(repositoryURL string) *ComposerMirror {
+	return &ComposerMirror{
+		repositoryURL: repositoryURL,
+	}
+}
+
+// getComposerConfigPath returns the path of Composer's global config.json,
+// where composer config -g writes
+func getComposerConfigPath() (string, error) {
+	if home := os.Getenv("COMPOSER_HOME"); home != "" {
+		return filepath.Join(home, "config.json"), nil
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "Composer", "config.json"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	legacy := filepath.Join(homeDir, ".composer")
+	if _, err := os.Stat(legacy); err == nil || runtime.GOOS == "darwin" {
+		return filepath.Join(legacy, "config.json"), nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "composer", "config.json"), nil
+}
+
+// readComposerConfig reads config.json, or returns an empty config if it doesn't exist
+func readComposerConfig(path string) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, fmt.Errorf("failed to read composer config.json: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse composer config.json: %w", err)
+	}
+	return config, nil
+}
+
+// packagistURL returns the URL the packagist repository points at in config, if set
+func packagistURL(config map[string]interface{}) (string, bool) {
+	repositories, _ := config["repositories"].(map[string]interface{})
+	packagist, _ := repositories["packagist"].(map[string]interface{})
+	url, ok := packagist["url"].(string)
+	return url, ok
+}
+
+// Enable configures Composer to fetch packages through the Packagist mirror,
+// like composer config -g repo.packagist composer <url>
+func (c *ComposerMirror) Enable() error {
+	configPath, err := getComposerConfigPath()
+	if err != nil {
+		return err
+	}
+
+	config, err := readComposerConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	// A Packagist mirror the user set stays unless Overwrite is set
+	url, ok := packagistURL(config)
+	if ok && url != c.repositoryURL && ownSetting("composer", configPath, url) && c.resolve(configPath, "repo.packagist "+url) {
+		return nil
+	}
+
+	repositories, ok := config["repositories"].(map[string]interface{})
+	if !ok {
+		if _, set := config["repositories"]; set {
+			return fmt.Errorf("repositories in %s is a list; run: composer config -g repo.packagist composer %s", configPath, c.repositoryURL)
+		}
+		repositories = make(map[string]interface{})
+	}
+	repositories["packagist"] = map[string]interface{}{
+		"type": "composer",
+		"url":  c.repositoryURL,
+	}
+	config["repositories"] = repositories
+
+	jsonData, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal composer config.json: %w", err)
+	}
+
+	if err := logging.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create composer config directory: %w", err)
+	}
+	ours := url == c.repositoryURL
+	if err := writeConfig(configPath, append(jsonData, '\n'), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write composer config.json: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration, so Composer uses packagist.org again
+func (c *ComposerMirror) Disable() error {
+	configPath, err := getComposerConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil // Nothing to disable
+	}
+	config, err := readComposerConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	repositories, ok := config["repositories"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	delete(repositories, "packagist")
+	if len(repositories) == 0 {
+		delete(config, "repositories")
+	}
+
+	// If config is now empty, remove the file
+	if len(config) == 0 {
+		if err := logging.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove composer config.json: %w", err)
+		}
+		return nil
+	}
+
+	jsonData, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal composer config.json: %w", err)
+	}
+	if err := logging.WriteFile(configPath, append(jsonData, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write composer config.json: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (c *ComposerMirror) Status() (bool, string, error) {
+	configPath, err := getComposerConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	config, err := readComposerConfig(configPath)
+	if err != nil {
+		return false, "", err
+	}
+	if url, ok := packagistURL(config); ok {
+		return true, url, nil
+	}
+
+	return false, "default repository", nil
+}
@@ -0,0 +1,124 @@
+package mirror
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// conanRemoteName is the name of the remote crosh adds for the mirror
+const conanRemoteName = "crosh"
+
+var (
+	// conanRemote matches a remote in the output of conan remote list, e.g.
+	// "conancenter: https://center2.conan.io [Verify SSL: True, Enabled: True]"
+	conanRemote = regexp.MustCompile(`(?m)^(\S+):\s+(\S+)`)
+	// conanVersion matches the major version in the output of conan --version
+	conanVersion = regexp.MustCompile(`version\s+(\d+)\.`)
+)
+
+// ConanMirror handles the remote Conan resolves C and C++ packages from first
+type ConanMirror struct {
+	conflicts
+	mirrorURL string
+}
+
+// NewConanMirror creates a new Conan mirror handler for a mirror of ConanCenter
+func NewConanMirror(mirrorURL string) *ConanMirror {
+	return &ConanMirror{
+		mirrorURL: strings.TrimSuffix(mirrorURL, "/"),
+	}
+}
+
+// HasConan reports whether Conan is installed
+func HasConan() bool {
+	_, err := exec.LookPath("conan")
+	return err == nil
+}
+
+// conanRemotes returns Conan's remotes in the order it resolves from, as name and URL pairs
+func conanRemotes() ([][2]string, error) {
+	output, err := logging.Command("conan", "remote", "list").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("conan remote list: %s", strings.TrimSpace(string(output)))
+	}
+	var remotes [][2]string
+	for _, match := range conanRemote.FindAllStringSubmatch(string(output), -1) {
+		remotes = append(remotes, [2]string{match[1], strings.TrimSuffix(match[2], "/")})
+	}
+	return remotes, nil
+}
+
+// isConanCenter reports whether url is ConanCenter's, which Conan comes with
+func isConanCenter(url string) bool {
+	return strings.Contains(url, "center.conan.io") || strings.Contains(url, "center2.conan.io")
+}
+
+// Enable adds the mirror as the first remote, named crosh, so Conan
+// resolves from it before ConanCenter, which stays as the fallback
+func (c *ConanMirror) Enable() error {
+	remotes, err := conanRemotes()
+	if err != nil {
+		return err
+	}
+	if len(remotes) > 0 && remotes[0] == [2]string{conanRemoteName, c.mirrorURL} {
+		return nil
+	}
+
+	// A remote the user put first, e.g. a company Artifactory, stays first unless Overwrite is set
+	for _, remote := range remotes {
+		if remote[0] == conanRemoteName {
+			continue
+		}
+		if !isConanCenter(remote[1]) && ownSetting("conan", "conan", remote[1]) && c.resolve("conan", fmt.Sprintf("remote %s: %s", remote[0], remote[1])) {
+			return nil
+		}
+		break
+	}
+
+	// Conan 1 takes the position as --insert, Conan 2 as --index
+	position := "--index"
+	if output, err := logging.Command("conan", "--version").CombinedOutput(); err == nil {
+		if match := conanVersion.FindSubmatch(output); match != nil && string(match[1]) == "1" {
+			position = "--insert"
+		}
+	}
+	if output, err := logging.Run("conan", "remote", "add", conanRemoteName, c.mirrorURL, position, "0", "--force"); err != nil {
+		return fmt.Errorf("conan remote add %s: %s", conanRemoteName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Disable removes the remote crosh added, leaving the user's as they were
+func (c *ConanMirror) Disable() error {
+	remotes, err := conanRemotes()
+	if err != nil {
+		return err
+	}
+	for _, remote := range remotes {
+		if remote[0] != conanRemoteName {
+			continue
+		}
+		if output, err := logging.Run("conan", "remote", "remove", conanRemoteName); err != nil {
+			return fmt.Errorf("conan remote remove %s: %s", conanRemoteName, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (c *ConanMirror) Status() (bool, string, error) {
+	remotes, err := conanRemotes()
+	if err != nil {
+		return false, "", err
+	}
+	for _, remote := range remotes {
+		if remote[0] == conanRemoteName {
+			return true, remote[1], nil
+		}
+	}
+	return false, "ConanCenter", nil
+}
@@ -0,0 +1,148 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// CondaMirror handles conda channel configuration
+type CondaMirror struct {
+	conflicts
+	mirrorURL string // e.g. https://mirrors.tuna.tsinghua.edu.cn/anaconda
+}
+
+// NewCondaMirror creates a new conda mirror handler
+func NewCondaMirror(mirrorURL string) *CondaMirror {
+	return &CondaMirror{
+		mirrorURL: strings.TrimSuffix(mirrorURL, "/"),
+	}
+}
+
+// getCondarcPath returns the path of the user's .condarc
+func getCondarcPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".condarc"), nil
+}
+
+// Enable points conda's defaults channels (main, r, msys2) and named channels
+// such as conda-forge and pytorch at the mirror. The user's channels list
+// stays as it is.
+func (c *CondaMirror) Enable() error {
+	condarcPath, err := getCondarcPath()
+	if err != nil {
+		return err
+	}
+
+	mapping, err := readYAMLMapping(condarcPath)
+	if err != nil {
+		return err
+	}
+
+	// Channels the user pointed elsewhere, e.g. at a company mirror, stay unless Overwrite is set
+	ours := false
+	for _, key := range []string{"channel_alias", "default_channels"} {
+		existing := mappingKey(mapping, key)
+		if existing == nil {
+			continue
+		}
+		value := existing.Value
+		if existing.Kind == yaml.SequenceNode && len(existing.Content) > 0 {
+			value = existing.Content[0].Value
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(value, "/cloud"), "/pkgs/main")
+		if base == c.mirrorURL {
+			ours = true
+			continue
+		}
+		if ownSetting("conda", condarcPath, base) && c.resolve(condarcPath, key+": "+value) {
+			return nil
+		}
+	}
+
+	// Named channels resolve under channel_alias
+	setMappingKey(mapping, "channel_alias", &yaml.Node{Kind: yaml.ScalarNode, Value: c.mirrorURL + "/cloud"})
+	defaults := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, channel := range []string{"main", "r", "msys2"} {
+		defaults.Content = append(defaults.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: c.mirrorURL + "/pkgs/" + channel})
+	}
+	setMappingKey(mapping, "default_channels", defaults)
+
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(condarcPath, data, 0644, ours); err != nil {
+		return fmt.Errorf("failed to write .condarc: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (c *CondaMirror) Disable() error {
+	condarcPath, err := getCondarcPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(condarcPath); restored || err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(condarcPath); os.IsNotExist(err) {
+		return nil // Nothing to disable
+	}
+	mapping, err := readYAMLMapping(condarcPath)
+	if err != nil {
+		return err
+	}
+	if mappingKey(mapping, "channel_alias") == nil {
+		return nil
+	}
+	deleteMappingKey(mapping, "channel_alias")
+	deleteMappingKey(mapping, "default_channels")
+
+	// Remove file if empty
+	if len(mapping.Content) == 0 {
+		if err := logging.Remove(condarcPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove .condarc: %w", err)
+		}
+		return nil
+	}
+
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return err
+	}
+	if err := logging.WriteFile(condarcPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write .condarc: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (c *CondaMirror) Status() (bool, string, error) {
+	condarcPath, err := getCondarcPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	mapping, err := readYAMLMapping(condarcPath)
+	if err != nil {
+		return false, "", err
+	}
+	if alias := mappingKey(mapping, "channel_alias"); alias != nil {
+		return true, strings.TrimSuffix(alias.Value, "/cloud"), nil
+	}
+
+	return false, "default channels", nil
+}
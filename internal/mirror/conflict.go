@@ -0,0 +1,66 @@
+package mirror
+
+import (
+	"github.com/boomyao/crosh/internal/config"
+)
+
+// What Enable did about a setting the user had made themselves
+const (
+	ConflictKept     = "kept"     // the user's setting stays and crosh's mirror isn't set
+	ConflictMerged   = "merged"   // the user's setting stays next to crosh's mirror
+	ConflictReplaced = "replaced" // crosh's mirror replaced it; Disable puts it back
+)
+
+// Conflict is a setting the user made in a tool's config, such as a company
+// npm registry, that Enable found where it would set crosh's mirror
+type Conflict struct {
+	Path    string
+	Setting string // e.g. registry=https://npm.example.com/
+	Action  string // ConflictKept, ConflictMerged or ConflictReplaced
+}
+
+// conflicts collects what a handler's Enable found
+type conflicts struct {
+	// Overwrite replaces settings the user made instead of keeping them
+	Overwrite bool
+	found     []Conflict
+}
+
+// Conflicts returns the settings of the user's the last Enable found
+func (c *conflicts) Conflicts() []Conflict {
+	return c.found
+}
+
+// resolve records a setting of the user's at path and reports whether Enable
+// should keep it instead of setting crosh's mirror
+func (c *conflicts) resolve(path, setting string) bool {
+	action := ConflictKept
+	if c.Overwrite {
+		action = ConflictReplaced
+	}
+	c.found = append(c.found, Conflict{Path: path, Setting: setting, Action: action})
+	return !c.Overwrite
+}
+
+// merge records a setting of the user's at path kept next to crosh's mirror
+func (c *conflicts) merge(path, setting string) {
+	c.found = append(c.found, Conflict{Path: path, Setting: setting, Action: ConflictMerged})
+}
+
+// ownSetting reports whether value, found for tool in the file at path, was
+// set by the user rather than by crosh: it isn't one of crosh's mirrors and
+// crosh hasn't written the file
+func ownSetting(tool, path, value string) bool {
+	if tracked(path) {
+		return false
+	}
+	if config.DefaultConfig().Mirror.URL(tool) == value {
+		return false
+	}
+	for _, preset := range config.MirrorPresets {
+		if preset.URL(tool) == value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// cpanDefaultURL is where CPAN downloads from by default
+const cpanDefaultURL = "http://www.cpan.org/"
+
+var (
+	cpanURLList    = regexp.MustCompile(`('urllist'\s*=>\s*\[)((?:\s*q\[[^\]]*\]\s*,?)*\s*)(\])`)
+	cpanQuotedURL  = regexp.MustCompile(`q\[([^\]]*)\]`)
+	cpanmOptMirror = regexp.MustCompile(`--mirror\s+(\S+)`)
+)
+
+// CPANMirror handles CPAN mirror configuration for cpanm and cpan
+type CPANMirror struct {
+	conflicts
+	mirrorURL string
+}
+
+// NewCPANMirror creates a new CPAN mirror handler
+func NewCPANMirror(mirrorURL string) *CPANMirror {
+	return &CPANMirror{
+		mirrorURL: mirrorURL,
+	}
+}
+
+// getCPANConfigPath returns the path of the cpan client's per-user MyConfig.pm
+func getCPANConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cpan", "CPAN", "MyConfig.pm"), nil
+}
+
+// HasCPAN reports whether cpanm or cpan is installed
+func HasCPAN() bool {
+	for _, name := range []string{"cpanm", "cpan"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Enable points cpanm at the mirror through PERL_CPANM_OPT in the shell rc
+// file, and the cpan client through the urllist in its MyConfig.pm if it has
+// been set up
+func (c *CPANMirror) Enable() error {
+	// A mirror the user gave cpanm, e.g. a company DarkPAN, stays unless Overwrite is set
+	rcFile, line, value, err := userEnv("PERL_CPANM_OPT")
+	if err != nil {
+		return err
+	}
+	if match := cpanmOptMirror.FindStringSubmatch(value); match != nil {
+		if ownSetting("cpan", rcFile, match[1]) && c.resolve(rcFile, line) {
+			return nil
+		}
+	}
+
+	// So is a mirror the user gave the cpan client
+	configPath, err := getCPANConfigPath()
+	if err != nil {
+		return err
+	}
+	for _, url := range cpanURLs(configPath) {
+		if url != c.mirrorURL && url != cpanDefaultURL && ownSetting("cpan", configPath, url) && c.resolve(configPath, "urllist "+url) {
+			return nil
+		}
+	}
+
+	// Options the user set before stay in front of the mirror
+	if err := setEnv("cpan", []envVar{{"PERL_CPANM_OPT", "$PERL_CPANM_OPT --mirror " + c.mirrorURL + " --mirror-only"}}); err != nil {
+		return err
+	}
+
+	return c.enableURLList(configPath)
+}
+
+// cpanURLs returns the urllist in the cpan client's MyConfig.pm at path
+func cpanURLs(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	match := cpanURLList.FindSubmatch(data)
+	if match == nil {
+		return nil
+	}
+	var urls []string
+	for _, url := range cpanQuotedURL.FindAllSubmatch(match[2], -1) {
+		urls = append(urls, string(url[1]))
+	}
+	return urls
+}
+
+// enableURLList puts the mirror in the urllist of the cpan client's MyConfig.pm
+func (c *CPANMirror) enableURLList(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // The cpan client hasn't been set up
+		}
+		return fmt.Errorf("failed to read MyConfig.pm: %w", err)
+	}
+
+	if !cpanURLList.Match(data) {
+		return nil // cpan asks for mirrors when it first needs them
+	}
+	ours := slices.Contains(cpanURLs(configPath), c.mirrorURL)
+
+	content := cpanURLList.ReplaceAll(data, []byte("${1}q["+c.mirrorURL+"]${3}"))
+	if err := writeConfig(configPath, content, 0644, ours); err != nil {
+		return fmt.Errorf("failed to write MyConfig.pm: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (c *CPANMirror) Disable() error {
+	if err := unsetEnv("cpan"); err != nil {
+		return err
+	}
+	return c.disableURLList()
+}
+
+// disableURLList points the urllist of the cpan client back at www.cpan.org
+// if it has the mirror
+func (c *CPANMirror) disableURLList() error {
+	configPath, err := getCPANConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read MyConfig.pm: %w", err)
+	}
+
+	// A urllist without the mirror is the user's
+	if c.mirrorURL == "" || !slices.Contains(cpanURLs(configPath), c.mirrorURL) {
+		return nil
+	}
+
+	content := cpanURLList.ReplaceAll(data, []byte("${1}q["+cpanDefaultURL+"]${3}"))
+	if err := logging.WriteFile(configPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write MyConfig.pm: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (c *CPANMirror) Status() (bool, string, error) {
+	if opt, ok := getEnv("cpan", "PERL_CPANM_OPT"); ok {
+		if match := cpanmOptMirror.FindStringSubmatch(opt); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	configPath, err := getCPANConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+	if urls := cpanURLs(configPath); len(urls) > 0 && urls[0] != cpanDefaultURL {
+		return true, urls[0], nil
+	}
+
+	return false, "default mirror", nil
+}
@@ -0,0 +1,174 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+var (
+	// rRepos matches a line of R code setting the repos option, e.g.
+	// options(repos = c(CRAN = "https://cloud.r-project.org"))
+	rRepos    = regexp.MustCompile(`(?m)^[^#\n]*options\s*\([^\n]*\brepos\s*=[^\n]*`)
+	rQuoted   = regexp.MustCompile(`["'](https?://[^"']+)["']`)
+	rCRANRepo = regexp.MustCompile(`CRAN\s*=\s*"([^"]+)"`)
+)
+
+// CRANMirror handles R package repository configuration
+type CRANMirror struct {
+	conflicts
+	cranURL         string
+	bioconductorURL string
+}
+
+// NewCRANMirror creates a new CRAN mirror handler. With bioconductorURL set,
+// Bioconductor packages come from that mirror too.
+func NewCRANMirror(cranURL, bioconductorURL string) *CRANMirror {
+	return &CRANMirror{
+		cranURL:         cranURL,
+		bioconductorURL: bioconductorURL,
+	}
+}
+
+// getRprofilePath returns the path of the user's .Rprofile, following
+// R_PROFILE_USER and R_USER like R does
+func getRprofilePath() (string, error) {
+	if path := os.Getenv("R_PROFILE_USER"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("R_USER"); dir != "" {
+		return filepath.Join(dir, ".Rprofile"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".Rprofile"), nil
+}
+
+// HasR reports whether R is installed
+func HasR() bool {
+	if _, err := exec.LookPath("Rscript"); err == nil {
+		return true
+	}
+	path, err := getRprofilePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Enable sets the repos option, and Bioconductor's mirror, between markers at
+// the end of .Rprofile, so it comes after anything else the file sets
+func (c *CRANMirror) Enable() error {
+	rprofilePath, err := getRprofilePath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing .Rprofile if it exists
+	var existingContent string
+	if data, err := os.ReadFile(rprofilePath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin)
+	content := hashMarkedBlock.ReplaceAllString(existingContent, "")
+
+	// Repositories the user set, e.g. an internal CRAN-like repository, stay unless Overwrite is set
+	if line := rRepos.FindString(content); line != "" {
+		url := ""
+		if match := rQuoted.FindStringSubmatch(line); match != nil {
+			url = match[1]
+		}
+		if ownSetting("cran", rprofilePath, url) && c.resolve(rprofilePath, strings.TrimSpace(line)) {
+			return nil
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(hashMarkerBegin + "\n")
+	fmt.Fprintf(&b, "options(repos = c(CRAN = %q))\n", c.cranURL)
+	if c.bioconductorURL != "" {
+		fmt.Fprintf(&b, "options(BioC_mirror = %q)\n", c.bioconductorURL)
+	}
+	b.WriteString(hashMarkerEnd + "\n")
+
+	if content = strings.TrimRight(content, "\n"); content != "" {
+		content += "\n\n"
+	}
+	if err := writeConfig(rprofilePath, []byte(content+b.String()), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write .Rprofile: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (c *CRANMirror) Disable() error {
+	rprofilePath, err := getRprofilePath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(rprofilePath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(rprofilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read .Rprofile: %w", err)
+	}
+
+	// Remove the block between crosh's markers
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(rprofilePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove .Rprofile: %w", err)
+		}
+		return nil
+	}
+
+	if err := logging.WriteFile(rprofilePath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write .Rprofile: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (c *CRANMirror) Status() (bool, string, error) {
+	rprofilePath, err := getRprofilePath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(rprofilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default repository", nil
+		}
+		return false, "", fmt.Errorf("failed to read .Rprofile: %w", err)
+	}
+
+	if block := hashMarkedBlock.FindString(string(data)); block != "" {
+		if match := rCRANRepo.FindStringSubmatch(block); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	return false, "default repository", nil
+}
@@ -0,0 +1,101 @@
+package mirror
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DenoMirror handles the npm registry and JSR mirrors of Deno
+type DenoMirror struct {
+	conflicts
+	npmURL string
+	jsrURL string
+}
+
+// NewDenoMirror creates a new Deno mirror handler for npm: specifiers, from
+// npmURL, and jsr: specifiers, from jsrURL if it's set
+func NewDenoMirror(npmURL, jsrURL string) *DenoMirror {
+	return &DenoMirror{
+		npmURL: npmURL,
+		jsrURL: jsrURL,
+	}
+}
+
+// HasDeno reports whether Deno is installed
+func HasDeno() bool {
+	_, err := exec.LookPath("deno")
+	return err == nil
+}
+
+// npmrcRegistry returns the path of ~/.npmrc and its registry line, or "" if it has none
+func npmrcRegistry() (string, string) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", ""
+	}
+	npmrcPath := filepath.Join(homeDir, ".npmrc")
+	data, err := os.ReadFile(npmrcPath)
+	if err != nil {
+		return npmrcPath, ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "registry=") {
+			return npmrcPath, trimmed
+		}
+	}
+	return npmrcPath, ""
+}
+
+// Enable sets NPM_CONFIG_REGISTRY and JSR_URL, which Deno downloads npm and
+// JSR packages from, in the shell rc file
+func (d *DenoMirror) Enable() error {
+	// Registries the user set, e.g. a company one, stay unless Overwrite is set
+	for _, name := range []string{"NPM_CONFIG_REGISTRY", "JSR_URL"} {
+		rcFile, line, value, err := userEnv(name)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != d.npmURL && value != d.jsrURL && ownSetting("npm", rcFile, value) && d.resolve(rcFile, line) {
+			return nil
+		}
+	}
+
+	var vars []envVar
+	// The variable would win over a registry of the user's in ~/.npmrc, which npm keeps
+	npmrcPath, line := npmrcRegistry()
+	registry := strings.TrimPrefix(line, "registry=")
+	if line != "" && registry != d.npmURL && ownSetting("npm", npmrcPath, registry) {
+		d.merge(npmrcPath, line)
+	} else {
+		vars = append(vars, envVar{"NPM_CONFIG_REGISTRY", d.npmURL})
+	}
+	if d.jsrURL != "" {
+		vars = append(vars, envVar{"JSR_URL", d.jsrURL})
+	}
+	if len(vars) == 0 {
+		return nil
+	}
+
+	return setEnv("deno", vars)
+}
+
+// Disable removes the mirror configuration
+func (d *DenoMirror) Disable() error {
+	return unsetEnv("deno")
+}
+
+// Status checks if the mirror is currently enabled
+func (d *DenoMirror) Status() (bool, string, error) {
+	var mirrors []string
+	for _, name := range []string{"NPM_CONFIG_REGISTRY", "JSR_URL"} {
+		if url, ok := getEnv("deno", name); ok {
+			mirrors = append(mirrors, url)
+		}
+	}
+	if len(mirrors) > 0 {
+		return true, strings.Join(mirrors, ", "), nil
+	}
+	return false, "default registries", nil
+}
@@ -6,11 +6,16 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/logging"
 )
 
 // DockerMirror handles Docker registry mirror configuration
 type DockerMirror struct {
+	conflicts
 	registries []string
 }
 
@@ -44,6 +49,16 @@ func (d *DockerMirror) getDockerConfigPath() (string, error) {
 	return filepath.Join(homeDir, ".docker", "daemon.json"), nil
 }
 
+// isDefault reports whether reg is one of crosh's default registry mirrors
+func (d *DockerMirror) isDefault(reg string) bool {
+	for _, mirror := range config.DefaultConfig().Mirror.Docker {
+		if strings.TrimPrefix(reg, "https://") == strings.TrimPrefix(mirror, "https://") {
+			return true
+		}
+	}
+	return false
+}
+
 // isDockerDesktop checks if Docker Desktop is being used
 func (d *DockerMirror) isDockerDesktop() bool {
 	if runtime.GOOS == "darwin" {
@@ -105,7 +120,7 @@ func (d *DockerMirror) Enable() error {
 
 	// Ensure .docker directory exists
 	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := logging.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create .docker directory: %w", err)
 	}
 
@@ -124,12 +139,15 @@ func (d *DockerMirror) Enable() error {
 		if err := json.Unmarshal(data, &config); err != nil {
 			// Backup corrupted file
 			backupPath := configPath + ".backup"
-			os.WriteFile(backupPath, data, 0644)
+			logging.WriteFile(backupPath, data, 0644)
 			fmt.Printf("Warning: existing daemon.json is invalid, backed up to %s\n", backupPath)
 			config = make(map[string]interface{})
 		}
 	}
 
+	// The same mirrors already set are crosh's, from before it saved originals
+	ours := false
+
 	// Format registry URLs (ensure they have https:// prefix)
 	if len(d.registries) > 0 {
 		formattedRegistries := make([]string, len(d.registries))
@@ -140,6 +158,24 @@ func (d *DockerMirror) Enable() error {
 				formattedRegistries[i] = reg
 			}
 		}
+		ours = fmt.Sprint(config["registry-mirrors"]) == fmt.Sprint(formattedRegistries)
+
+		// Mirrors the user added stay after crosh's, unless Overwrite is set
+		if existing, ok := config["registry-mirrors"].([]interface{}); ok && !ours && !tracked(configPath) {
+			for _, entry := range existing {
+				reg, ok := entry.(string)
+				if !ok || slices.Contains(formattedRegistries, reg) || d.isDefault(reg) {
+					continue
+				}
+				if d.Overwrite {
+					d.resolve(configPath, "registry-mirrors: "+reg)
+				} else {
+					d.merge(configPath, "registry-mirrors: "+reg)
+					formattedRegistries = append(formattedRegistries, reg)
+				}
+			}
+		}
+
 		// Set registry-mirrors
 		config["registry-mirrors"] = formattedRegistries
 	}
@@ -150,7 +186,7 @@ func (d *DockerMirror) Enable() error {
 		return fmt.Errorf("failed to marshal daemon.json: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, jsonData, 0644); err != nil {
+	if err := writeConfig(configPath, jsonData, 0644, ours); err != nil {
 		return fmt.Errorf("failed to write daemon.json: %w", err)
 	}
 
@@ -174,6 +210,9 @@ func (d *DockerMirror) Disable() error {
 	if err != nil {
 		return err
 	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
 
 	// Read existing config
 	data, err := os.ReadFile(configPath)
@@ -195,7 +234,7 @@ func (d *DockerMirror) Disable() error {
 
 	// If config is now empty, remove the file
 	if len(config) == 0 {
-		if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+		if err := logging.Remove(configPath); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("failed to remove daemon.json: %w", err)
 		}
 		return nil
@@ -207,7 +246,7 @@ func (d *DockerMirror) Disable() error {
 		return fmt.Errorf("failed to marshal daemon.json: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, jsonData, 0644); err != nil {
+	if err := logging.WriteFile(configPath, jsonData, 0644); err != nil {
 		return fmt.Errorf("failed to write daemon.json: %w", err)
 	}
 
@@ -0,0 +1,89 @@
+package mirror
+
+// ElectronMirror handles where Electron and electron-builder download their
+// binaries from in postinstall scripts, which the npm registry mirror doesn't cover
+type ElectronMirror struct {
+	conflicts
+	electronURL string
+	builderURL  string
+}
+
+// NewElectronMirror creates a new Electron mirror handler for Electron's
+// releases, from electronURL, and electron-builder's tools, from builderURL
+func NewElectronMirror(electronURL, builderURL string) *ElectronMirror {
+	return &ElectronMirror{
+		electronURL: electronURL,
+		builderURL:  builderURL,
+	}
+}
+
+// electronSettings are the .npmrc keys and environment variables of the mirrors
+var electronSettings = []struct{ key, env string }{
+	{"electron_mirror", "ELECTRON_MIRROR"},
+	{"electron_builder_binaries_mirror", "ELECTRON_BUILDER_BINARIES_MIRROR"},
+}
+
+// urls returns the mirrors in the order of electronSettings
+func (e *ElectronMirror) urls() []string {
+	return []string{e.electronURL, e.builderURL}
+}
+
+// Enable sets the mirrors as keys in ~/.npmrc, between
+// "# crosh:begin electron" and "# crosh:end electron", and as environment
+// variables in the shell rc file for other package managers
+func (e *ElectronMirror) Enable() error {
+	// Mirrors the user set, e.g. a company one, stay unless Overwrite is set
+	for i, setting := range electronSettings {
+		if e.urls()[i] == "" {
+			continue
+		}
+		npmrcPath, line, value, err := userNpmrcKey(setting.key)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != e.urls()[i] && e.resolve(npmrcPath, line) {
+			return nil
+		}
+		rcFile, line, value, err := userEnv(setting.env)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != e.urls()[i] && ownSetting("electron", rcFile, value) && e.resolve(rcFile, line) {
+			return nil
+		}
+	}
+
+	var keys []npmrcKey
+	var vars []envVar
+	for i, setting := range electronSettings {
+		if e.urls()[i] == "" {
+			continue
+		}
+		keys = append(keys, npmrcKey{setting.key, e.urls()[i]})
+		vars = append(vars, envVar{setting.env, e.urls()[i]})
+	}
+	if err := setNpmrcBlock("electron", keys); err != nil {
+		return err
+	}
+
+	return setEnv("electron", vars)
+}
+
+// Disable removes the mirror configuration
+func (e *ElectronMirror) Disable() error {
+	if err := unsetNpmrcBlock("electron"); err != nil {
+		return err
+	}
+	return unsetEnv("electron")
+}
+
+// Status checks if the mirror is currently enabled
+func (e *ElectronMirror) Status() (bool, string, error) {
+	if url, ok := getNpmrcKey("electron", "electron_mirror"); ok {
+		return true, url, nil
+	}
+	if url, ok := getEnv("electron", "ELECTRON_MIRROR"); ok {
+		return true, url, nil
+	}
+	return false, "default downloads", nil
+}
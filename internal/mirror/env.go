@@ -0,0 +1,148 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// Environment variables a tool's mirror needs go in the shell rc file, in a
+// block per tool between "# crosh:begin <tool>" and "# crosh:end <tool>"
+
+// anyEnvBlock matches the block of any tool
+var anyEnvBlock = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(hashMarkerBegin) + `[^\n]*\n.*?` + regexp.QuoteMeta(hashMarkerEnd) + `[^\n]*\n?`)
+
+// envVar is an environment variable crosh sets in the shell rc file
+type envVar struct {
+	name  string
+	value string // may use other variables, e.g. $PERL_CPANM_OPT
+}
+
+// envBlock matches the block of tool's variables
+func envBlock(tool string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)` + regexp.QuoteMeta(hashMarkerBegin+" "+tool) + `\n.*?` + regexp.QuoteMeta(hashMarkerEnd+" "+tool) + `\n?`)
+}
+
+// exportLine matches a line of a shell rc file exporting name
+func exportLine(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^\s*export\s+` + regexp.QuoteMeta(name) + `=(.*)$`)
+}
+
+// croshSettings reports whether content of the shell rc file has settings
+// of crosh's, such as another tool's block or the Go proxy
+func croshSettings(content string) bool {
+	return strings.Contains(content, hashMarkerBegin) || strings.Contains(content, "# Added by crosh")
+}
+
+// setEnv puts tool's variables in a block at the end of the shell rc file,
+// replacing the one set before
+func setEnv(tool string, vars []envVar) error {
+	rcFile, err := getShellRCPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing rc file
+	var existingContent string
+	if data, err := os.ReadFile(rcFile); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin+" "+tool+"\n")
+	content := envBlock(tool).ReplaceAllString(existingContent, "")
+
+	var b strings.Builder
+	b.WriteString(hashMarkerBegin + " " + tool + "\n")
+	for _, v := range vars {
+		fmt.Fprintf(&b, "export %s=\"%s\"\n", v.name, v.value)
+	}
+	b.WriteString(hashMarkerEnd + " " + tool + "\n")
+
+	if content = strings.TrimRight(content, "\n"); content != "" {
+		content += "\n\n"
+	}
+	if err := writeConfig(rcFile, []byte(content+b.String()), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcFile, err)
+	}
+
+	return nil
+}
+
+// unsetEnv removes the block of tool's variables from the shell rc file. The
+// original comes back once no setting of crosh's is left in it.
+func unsetEnv(tool string) error {
+	rcFile, err := getShellRCPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(rcFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", rcFile, err)
+	}
+	content := envBlock(tool).ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+	content = strings.TrimRight(content, "\n") + "\n"
+
+	// Until then the original stays saved, and what crosh wrote is noted
+	if croshSettings(content) {
+		if err := writeConfig(rcFile, []byte(content), 0644, true); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rcFile, err)
+		}
+		return nil
+	}
+
+	if restored, err := restoreOriginal(rcFile); restored || err != nil {
+		return err
+	}
+	if err := logging.WriteFile(rcFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcFile, err)
+	}
+
+	return nil
+}
+
+// getEnv returns the value the block of tool's variables sets name to
+func getEnv(tool, name string) (string, bool) {
+	rcFile, err := getShellRCPath()
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(rcFile)
+	if err != nil {
+		return "", false
+	}
+
+	match := exportLine(name).FindStringSubmatch(envBlock(tool).FindString(string(data)))
+	if match == nil {
+		return "", false
+	}
+	return strings.Trim(match[1], `"'`), true
+}
+
+// userEnv returns the line of the shell rc file, outside crosh's blocks, that
+// exports name, and the value it sets, or "" if there is none
+func userEnv(name string) (rcFile, line, value string, err error) {
+	rcFile, err = getShellRCPath()
+	if err != nil {
+		return "", "", "", err
+	}
+	data, err := os.ReadFile(rcFile)
+	if err != nil {
+		return rcFile, "", "", nil
+	}
+
+	match := exportLine(name).FindStringSubmatch(anyEnvBlock.ReplaceAllString(string(data), ""))
+	if match == nil {
+		return rcFile, "", "", nil
+	}
+	return rcFile, strings.TrimSpace(match[0]), strings.Trim(match[1], `"'`), nil
+}
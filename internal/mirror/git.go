@@ -0,0 +1,250 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// gitHosts are the URL prefixes git clones from that mirror.git rewrites,
+// by the names it takes
+var gitHosts = map[string]string{
+	"github": "https://github.com/",
+	"gist":   "https://gist.github.com/",
+	"gitlab": "https://gitlab.com/",
+}
+
+var (
+	// gitSection matches a section header of a git config, e.g. `[url "https://ghfast.top/https://github.com/"]`
+	gitSection = regexp.MustCompile(`^\s*\[\s*([^\s\]"]+)(?:\s+"([^"]*)")?\s*\]`)
+	// gitInsteadOf matches an insteadOf line of a url section, which git takes in any case
+	gitInsteadOf = regexp.MustCompile(`(?i)^\s*insteadof\s*=\s*(\S+)`)
+)
+
+// GitMirror handles the url.<mirror>.insteadOf rewrites that make git clone
+// from a mirror, such as a GitHub proxy, instead of the host
+type GitMirror struct {
+	conflicts
+	mirrors map[string]string // name in gitHosts to the URL prefix of its mirror
+}
+
+// NewGitMirror creates a new git mirror handler for the hosts in mirrors,
+// e.g. github, each with the URL its repositories are under on the mirror
+func NewGitMirror(mirrors map[string]string) *GitMirror {
+	return &GitMirror{
+		mirrors: mirrors,
+	}
+}
+
+// HasGit reports whether git is installed
+func HasGit() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// getGitConfigPath returns the path of the user's ~/.gitconfig
+func getGitConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".gitconfig"), nil
+}
+
+// gitNames returns the names mirror.git takes, in order
+func gitNames() []string {
+	names := make([]string, 0, len(gitHosts))
+	for name := range gitHosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Hosts returns the hosts that have a mirror, in order
+func (g *GitMirror) Hosts() []string {
+	var hosts []string
+	for _, name := range gitNames() {
+		if g.mirrors[name] != "" {
+			hosts = append(hosts, strings.TrimSuffix(strings.TrimPrefix(gitHosts[name], "https://"), "/"))
+		}
+	}
+	return hosts
+}
+
+// rewrites returns the URL prefixes of the hosts that have a mirror, in the
+// order of Hosts, each with the prefix of its mirror
+func (g *GitMirror) rewrites() [][2]string {
+	var rewrites [][2]string
+	for _, name := range gitNames() {
+		if mirror := g.mirrors[name]; mirror != "" {
+			rewrites = append(rewrites, [2]string{gitHosts[name], strings.TrimSuffix(mirror, "/") + "/"})
+		}
+	}
+	return rewrites
+}
+
+// userInsteadOf returns the lines of a git config outside crosh's block that
+// rewrite prefix, by their index, each with the url section it is in
+func userInsteadOf(lines []string, prefix string) map[int]string {
+	found := make(map[int]string)
+	section := ""
+	for i, line := range lines {
+		if match := gitSection.FindStringSubmatch(line); match != nil {
+			section = ""
+			if strings.EqualFold(match[1], "url") {
+				section = match[2]
+			}
+			continue
+		}
+		if match := gitInsteadOf.FindStringSubmatch(line); match != nil && section != "" && match[1] == prefix {
+			found[i] = section
+		}
+	}
+	return found
+}
+
+// Enable adds url sections rewriting the hosts to their mirrors to
+// ~/.gitconfig, between markers. Pushes still go to the hosts themselves,
+// as mirrors only serve clones and fetches.
+func (g *GitMirror) Enable() error {
+	rewrites := g.rewrites()
+	if len(rewrites) == 0 {
+		return fmt.Errorf("no mirror for %s", strings.Join(gitNames(), ", "))
+	}
+	configPath, err := getGitConfigPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing git config if it exists
+	var existingContent string
+	if data, err := os.ReadFile(configPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin)
+	lines := strings.Split(strings.TrimRight(hashMarkedBlock.ReplaceAllString(existingContent, ""), "\n"), "\n")
+
+	// A rewrite the user set, e.g. to a company mirror, stays unless Overwrite
+	// is set, and then is taken out until Disable puts the original back
+	block := hashMarkerBegin + "\n"
+	mirrored := 0
+	replaced := make(map[int]bool)
+	for _, rewrite := range rewrites {
+		found := userInsteadOf(lines, rewrite[0])
+		kept := false
+		for i, section := range found {
+			if section == rewrite[1] {
+				continue
+			}
+			if g.resolve(configPath, fmt.Sprintf("url.%s.insteadOf = %s", section, rewrite[0])) {
+				kept = true
+			} else {
+				replaced[i] = true
+			}
+		}
+		if kept {
+			continue
+		}
+		block += fmt.Sprintf("[url %q]\n\tinsteadOf = %s\n", rewrite[1], rewrite[0])
+		// Matching itself, this keeps insteadOf from applying to pushes
+		block += fmt.Sprintf("[url %q]\n\tpushInsteadOf = %s\n", rewrite[0], rewrite[0])
+		mirrored++
+	}
+	if mirrored == 0 {
+		return nil
+	}
+	block += hashMarkerEnd + "\n"
+
+	var kept []string
+	for i, line := range lines {
+		if !replaced[i] && (line != "" || len(kept) > 0) {
+			kept = append(kept, line)
+		}
+	}
+	content := strings.Join(kept, "\n")
+	if content != "" {
+		content += "\n\n"
+	}
+	content += block
+
+	if err := writeConfig(configPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (g *GitMirror) Disable() error {
+	configPath, err := getGitConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	// Remove the block between crosh's markers
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", configPath, err)
+		}
+		return nil
+	}
+
+	if err := logging.WriteFile(configPath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled, listing the mirrors git
+// clones from
+func (g *GitMirror) Status() (bool, string, error) {
+	configPath, err := getGitConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return false, "hosts", nil
+	}
+
+	var mirrors []string
+	section := ""
+	for _, line := range strings.Split(hashMarkedBlock.FindString(string(data)), "\n") {
+		if match := gitSection.FindStringSubmatch(line); match != nil {
+			section = match[2]
+		} else if gitInsteadOf.MatchString(line) {
+			mirrors = append(mirrors, section)
+		}
+	}
+	if len(mirrors) == 0 {
+		return false, "hosts", nil
+	}
+	return true, strings.Join(mirrors, ", "), nil
+}
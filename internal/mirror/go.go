@@ -3,47 +3,57 @@ package mirror
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
 )
 
-// GoMirror handles Go module proxy configuration
+// GoMirror handles Go module proxy configuration, and the checksum database
+// and private modules that go with it
 type GoMirror struct {
+	conflicts
 	proxyURL string
+	sumDB    string
+	private  string
+	noSumDB  string
 }
 
-// NewGoMirror creates a new Go mirror handler
-func NewGoMirror(proxyURL string) *GoMirror {
+// NewGoMirror creates a new Go mirror handler for proxyURL. sumDB, if set,
+// is the checksum database that modules and toolchains, which also come
+// through the proxy, are verified against; private and noSumDB, if set, are
+// the GOPRIVATE modules fetched directly and the GONOSUMDB modules not checked.
+func NewGoMirror(proxyURL, sumDB, private, noSumDB string) *GoMirror {
 	return &GoMirror{
 		proxyURL: proxyURL,
+		sumDB:    sumDB,
+		private:  private,
+		noSumDB:  noSumDB,
 	}
 }
 
-// Enable configures Go to use the mirror proxy
-// This is done via environment variable GOPROXY
-func (g *GoMirror) Enable() error {
-	// For Go, we typically set environment variables
-	// This will output the command to set the environment variable
-	fmt.Printf("# Run the following command to enable Go proxy:\n")
-	fmt.Printf("export GOPROXY=%s\n", g.proxyURL)
-	fmt.Printf("# To make it permanent, add it to your ~/.bashrc or ~/.zshrc\n")
-
-	// We can also try to append to shell rc files
+// getShellRCPath returns the rc file of the user's shell, which crosh adds
+// environment variables such as GOPROXY to
+func getShellRCPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
 
-	// Try to detect shell
-	shell := os.Getenv("SHELL")
-	var rcFile string
+	// Default to bashrc
+	if strings.Contains(os.Getenv("SHELL"), "zsh") {
+		return fmt.Sprintf("%s/.zshrc", homeDir), nil
+	}
+	return fmt.Sprintf("%s/.bashrc", homeDir), nil
+}
 
-	if strings.Contains(shell, "zsh") {
-		rcFile = fmt.Sprintf("%s/.zshrc", homeDir)
-	} else if strings.Contains(shell, "bash") {
-		rcFile = fmt.Sprintf("%s/.bashrc", homeDir)
-	} else {
-		// Default to bashrc
-		rcFile = fmt.Sprintf("%s/.bashrc", homeDir)
+// Enable configures Go to use the mirror proxy
+// This is done via environment variable GOPROXY
+func (g *GoMirror) Enable() error {
+	// We can also try to append to shell rc files
+	rcFile, err := getShellRCPath()
+	if err != nil {
+		return err
 	}
 
 	// Read existing rc file
@@ -52,6 +62,22 @@ func (g *GoMirror) Enable() error {
 		existingContent = string(data)
 	}
 
+	// A GOPROXY crosh didn't add is the user's, which stays unless Overwrite is set
+	ours := strings.Contains(existingContent, "# Added by crosh")
+	for _, line := range strings.Split(existingContent, "\n") {
+		trimmed := strings.TrimSpace(line)
+		goproxy, ok := strings.CutPrefix(trimmed, "export GOPROXY=")
+		if ok && !ours && goproxy != g.proxyURL && ownSetting("go", rcFile, goproxy) && g.resolve(rcFile, trimmed) {
+			return nil
+		}
+	}
+
+	// For Go, we typically set environment variables
+	// This will output the command to set the environment variable
+	fmt.Printf("# Run the following command to enable Go proxy:\n")
+	fmt.Printf("export GOPROXY=%s\n", g.proxyURL)
+	fmt.Printf("# To make it permanent, add it to your ~/.bashrc or ~/.zshrc\n")
+
 	// Check if GOPROXY is already set
 	exportLine := fmt.Sprintf("export GOPROXY=%s", g.proxyURL)
 	if strings.Contains(existingContent, "export GOPROXY=") {
@@ -75,35 +101,104 @@ func (g *GoMirror) Enable() error {
 	}
 
 	// Write back
-	if err := os.WriteFile(rcFile, []byte(existingContent), 0644); err != nil {
+	if err := writeConfig(rcFile, []byte(existingContent), 0644, ours); err != nil {
 		return fmt.Errorf("failed to write %s: %w", rcFile, err)
 	}
 
 	// Set for current session
 	os.Setenv("GOPROXY", g.proxyURL)
 
-	return nil
+	return g.enableGoEnv()
 }
 
-// Disable removes the Go proxy configuration
-func (g *GoMirror) Disable() error {
-	homeDir, err := os.UserHomeDir()
+// getGoEnvPath returns the path of the file go env -w writes to
+func getGoEnvPath() (string, error) {
+	if path := os.Getenv("GOENV"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
 	}
+	return filepath.Join(dir, "go", "env"), nil
+}
 
-	shell := os.Getenv("SHELL")
-	var rcFile string
+// userGoEnv returns where the user set name, in the shell rc file or with
+// go env -w, the setting and its value, or "" if they didn't
+func userGoEnv(name string) (path, setting, value string, err error) {
+	path, setting, value, err = userEnv(name)
+	if err != nil || setting != "" {
+		return path, setting, value, err
+	}
 
-	if strings.Contains(shell, "zsh") {
-		rcFile = fmt.Sprintf("%s/.zshrc", homeDir)
-	} else if strings.Contains(shell, "bash") {
-		rcFile = fmt.Sprintf("%s/.bashrc", homeDir)
-	} else {
-		rcFile = fmt.Sprintf("%s/.bashrc", homeDir)
+	path, err = getGoEnvPath()
+	if err != nil {
+		return "", "", "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path, "", "", nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), name+"="); ok {
+			return path, strings.TrimSpace(line), value, nil
+		}
+	}
+	return path, "", "", nil
+}
+
+// enableGoEnv sets GOSUMDB, GOPRIVATE and GONOSUMDB in the shell rc file,
+// where they win over go env -w, so go env agrees with the proxy
+func (g *GoMirror) enableGoEnv() error {
+	var vars []envVar
+	for _, v := range []envVar{{"GOSUMDB", g.sumDB}, {"GOPRIVATE", g.private}, {"GONOSUMDB", g.noSumDB}} {
+		if v.value == "" {
+			continue
+		}
+		// A value the user set, e.g. company modules in GOPRIVATE, stays next to the proxy unless Overwrite is set
+		path, setting, value, err := userGoEnv(v.name)
+		if err != nil {
+			return err
+		}
+		if setting != "" && value != v.value {
+			if !g.Overwrite {
+				g.merge(path, setting)
+				continue
+			}
+			g.resolve(path, setting)
+		}
+		vars = append(vars, v)
+	}
+
+	if len(vars) == 0 {
+		return unsetEnv("go")
+	}
+	return setEnv("go", vars)
+}
+
+// Disable removes the Go proxy configuration
+func (g *GoMirror) Disable() error {
+	if err := unsetEnv("go"); err != nil {
+		return err
 	}
 
+	rcFile, err := getShellRCPath()
+	if err != nil {
+		return err
+	}
+
+	// While other tools' variables are set there the original would drop
+	// them too, so only GOPROXY goes and the original stays for when they do
 	data, err := os.ReadFile(rcFile)
+	shared := err == nil && strings.Contains(string(data), hashMarkerBegin)
+	if !shared {
+		if restored, err := restoreOriginal(rcFile); restored || err != nil {
+			os.Unsetenv("GOPROXY")
+			return err
+		}
+	}
+
+	data, err = os.ReadFile(rcFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -132,7 +227,12 @@ func (g *GoMirror) Disable() error {
 
 	// Write back
 	content := strings.Join(newLines, "\n")
-	if err := os.WriteFile(rcFile, []byte(content), 0644); err != nil {
+	if shared {
+		err = writeConfig(rcFile, []byte(content), 0644, true)
+	} else {
+		err = logging.WriteFile(rcFile, []byte(content), 0644)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to write %s: %w", rcFile, err)
 	}
 
@@ -152,6 +252,14 @@ func (g *GoMirror) Status() (bool, string, error) {
 	return false, "default proxy", nil
 }
 
+// SumDBStatus checks if the checksum database is currently set
+func (g *GoMirror) SumDBStatus() (bool, string, error) {
+	if sumDB, ok := getEnv("go", "GOSUMDB"); ok {
+		return true, sumDB, nil
+	}
+	return false, "sum.golang.org", nil
+}
+
 // GetEnvCommand returns the command to set environment variable for current session
 func (g *GoMirror) GetEnvCommand() string {
 	return fmt.Sprintf("export GOPROXY=%s", g.proxyURL)
@@ -0,0 +1,228 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// gradleDistributions is where Gradle wrappers download Gradle from by default
+const gradleDistributions = `https\://services.gradle.org/distributions/`
+
+var (
+	gradleCentral         = regexp.MustCompile(`'https://repo\.maven\.apache\.org/maven2'\s*:\s*'([^']*)'`)
+	gradleDistributionURL = regexp.MustCompile(`(?m)^(\s*distributionUrl\s*=\s*)(.*?)([^/]+\.zip)\s*$`)
+)
+
+// GradleMirror handles Gradle repository and distribution mirror configuration
+type GradleMirror struct {
+	central       string
+	google        string
+	plugins       string
+	distributions string
+}
+
+// NewGradleMirror creates a new Gradle mirror handler
+func NewGradleMirror(central, google, plugins, distributions string) *GradleMirror {
+	return &GradleMirror{
+		central:       central,
+		google:        google,
+		plugins:       plugins,
+		distributions: distributions,
+	}
+}
+
+// getGradleInitScriptPath returns the path of the init script crosh adds,
+// which Gradle runs before every build
+func getGradleInitScriptPath() (string, error) {
+	gradleHome := os.Getenv("GRADLE_USER_HOME")
+	if gradleHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		gradleHome = filepath.Join(homeDir, ".gradle")
+	}
+	return filepath.Join(gradleHome, "init.d", "crosh-mirrors.gradle"), nil
+}
+
+// Enable adds an init script that points mavenCentral(), google() and
+// gradlePluginPortal() at the mirrors in every build
+func (g *GradleMirror) Enable() error {
+	scriptPath, err := getGradleInitScriptPath()
+	if err != nil {
+		return err
+	}
+
+	// Repositories are matched by URL, so ones declared with maven { url ... } are covered too
+	mirrors := []string{}
+	for _, m := range []struct{ from, to string }{
+		{"https://repo.maven.apache.org/maven2", g.central},
+		{"https://repo1.maven.org/maven2", g.central},
+		{"https://dl.google.com/dl/android/maven2", g.google},
+		{"https://maven.google.com", g.google},
+		{"https://plugins.gradle.org/m2", g.plugins},
+	} {
+		if m.to != "" {
+			mirrors = append(mirrors, fmt.Sprintf("    '%s': '%s',", m.from, m.to))
+		}
+	}
+	if len(mirrors) == 0 {
+		return fmt.Errorf("no Gradle repository mirrors configured")
+	}
+
+	script := fmt.Sprintf(`// Generated by crosh: points Gradle's public repositories at mirrors.
+// crosh off removes this file.
+def croshMirrors = [
+%s
+]
+
+def croshRedirect = { RepositoryHandler repositories ->
+    repositories.withType(MavenArtifactRepository).configureEach { repo ->
+        def url = repo.url.toString().replaceAll('/$', '')
+        def mirror = croshMirrors[url]
+        if (mirror != null) {
+            repo.url = mirror
+        }
+    }
+}
+
+beforeSettings { settings ->
+    croshRedirect(settings.pluginManagement.repositories)
+    if (settings.hasProperty('dependencyResolutionManagement')) {
+        croshRedirect(settings.dependencyResolutionManagement.repositories)
+    }
+}
+
+allprojects {
+    croshRedirect(buildscript.repositories)
+    croshRedirect(repositories)
+}
+`, strings.Join(mirrors, "\n"))
+
+	// Builds that don't list plugin repositories use the Plugin Portal implicitly
+	if g.plugins != "" {
+		script += fmt.Sprintf(`
+settingsEvaluated { settings ->
+    if (settings.pluginManagement.repositories.isEmpty()) {
+        settings.pluginManagement.repositories.maven { url '%s' }
+    }
+}
+`, g.plugins)
+	}
+
+	if err := logging.MkdirAll(filepath.Dir(scriptPath), 0755); err != nil {
+		return fmt.Errorf("failed to create Gradle init directory: %w", err)
+	}
+	_, err = os.Stat(scriptPath)
+	if err := writeConfig(scriptPath, []byte(script), 0644, err == nil); err != nil {
+		return fmt.Errorf("failed to write Gradle init script: %w", err)
+	}
+
+	return nil
+}
+
+// EnableWrapper points the Gradle wrapper of the project in dir at the
+// distribution mirror and returns the new download URL. Wrappers download
+// Gradle before any init script runs, so this is done per project.
+func (g *GradleMirror) EnableWrapper(dir string) (string, error) {
+	if g.distributions == "" {
+		return "", fmt.Errorf("no Gradle distribution mirror configured")
+	}
+	propertiesPath := filepath.Join(dir, "gradle", "wrapper", "gradle-wrapper.properties")
+
+	data, err := os.ReadFile(propertiesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no Gradle wrapper in %s (missing %s)", dir, filepath.Join("gradle", "wrapper", "gradle-wrapper.properties"))
+		}
+		return "", fmt.Errorf("failed to read gradle-wrapper.properties: %w", err)
+	}
+
+	match := gradleDistributionURL.FindStringSubmatch(string(data))
+	if match == nil {
+		return "", fmt.Errorf("no distributionUrl in %s", propertiesPath)
+	}
+
+	// Properties files escape the colon
+	mirror := strings.Replace(strings.TrimSuffix(g.distributions, "/")+"/", ":", `\:`, 1)
+	content := gradleDistributionURL.ReplaceAllString(string(data), "${1}"+mirror+"${3}")
+	if err := writeConfig(propertiesPath, []byte(content), 0644, match[2] == mirror); err != nil {
+		return "", fmt.Errorf("failed to write gradle-wrapper.properties: %w", err)
+	}
+
+	return strings.ReplaceAll(mirror, `\:`, ":") + match[3], nil
+}
+
+// Disable removes the init script and points the wrappers crosh changed back
+// at services.gradle.org
+func (g *GradleMirror) Disable() error {
+	scriptPath, err := getGradleInitScriptPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(scriptPath); err != nil {
+		return err
+	} else if !restored {
+		if err := logging.Remove(scriptPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove Gradle init script: %w", err)
+		}
+	}
+
+	backups, err := Backups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if filepath.Base(backup.Path) != "gradle-wrapper.properties" {
+			continue
+		}
+		if _, err := os.Stat(backup.Path); os.IsNotExist(err) {
+			continue // The project is gone
+		}
+		restored, err := restoreOriginal(backup.Path)
+		if err != nil {
+			return err
+		}
+		if restored {
+			continue
+		}
+
+		// Changed since, so only the download URL goes back
+		data, err := os.ReadFile(backup.Path)
+		if err != nil {
+			continue
+		}
+		content := gradleDistributionURL.ReplaceAllString(string(data), "${1}"+gradleDistributions+"${3}")
+		if err := logging.WriteFile(backup.Path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write gradle-wrapper.properties: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (g *GradleMirror) Status() (bool, string, error) {
+	scriptPath, err := getGradleInitScriptPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default repositories", nil
+		}
+		return false, "", fmt.Errorf("failed to read Gradle init script: %w", err)
+	}
+
+	if match := gradleCentral.FindStringSubmatch(string(data)); match != nil {
+		return true, match[1], nil
+	}
+	return true, "custom init script", nil
+}
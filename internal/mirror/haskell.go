@@ -0,0 +1,329 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	// hackageURL is where Stack and cabal download the package index and packages from by default
+	hackageURL = "http://hackage.haskell.org/"
+	// stackPackageIndex is Stack's package-index for a Hackage mirror, which
+	// is checked against Hackage's own root keys
+	stackPackageIndex = `download-prefix: %s
+hackage-security:
+  keyids:
+    - 0a5c7ea47cd1b15f01f5f51a33adda7e655bc0f0b0615baa8e271f4c3351e21d
+    - 1ea9ba32c526d1cc91ab5e5bd364ec5e9e8cb67179a471872f6e26f0ae773d42
+    - 280b10153a522681163658cb49f632cde3f38d768b736ddbc901d99a1a772833
+    - 2a96b1889dc221c17296fcc2bb34b908ca9734376f0f361660200935916ef201
+    - 2c6c3627bd6c982990239487f1abd02e08a02e6cf16edb105a8012d444d870c3
+    - 51f0161b906011b52c6613376b1ae937670da69322113a246a09f807c62f6921
+    - 772e9f4c7db33d251d5c6e357199c819e569d130857dc225549b40845ff0890d
+    - aa315286e6ad281ad61182235533c41e806e5a787e0b6d1e7eef3f09d137d2e9
+    - fe331502606802feac15e514d9b9ea83fee8b6ffef71335479a2e68d84adc6b0
+  key-threshold: 3
+  ignore-expiry: true
+`
+)
+
+// stackMirrorKeys are the keys of Stack's config.yaml crosh sets
+var stackMirrorKeys = []string{"package-index", "setup-info-locations", "snapshot-location-base", "urls"}
+
+// HaskellMirror handles the Hackage index Stack and cabal download packages
+// from, and the Stackage snapshots and GHC builds Stack uses
+type HaskellMirror struct {
+	conflicts
+	hackageURL  string
+	stackageURL string
+}
+
+// NewHaskellMirror creates a new Haskell mirror handler for mirrors of
+// Hackage and Stackage
+func NewHaskellMirror(hackageURL, stackageURL string) *HaskellMirror {
+	return &HaskellMirror{
+		hackageURL:  strings.TrimSuffix(hackageURL, "/") + "/",
+		stackageURL: strings.TrimSuffix(stackageURL, "/") + "/",
+	}
+}
+
+// getStackConfigPath returns the path of Stack's global config.yaml, under
+// $STACK_ROOT or Stack's default root
+func getStackConfigPath() (string, error) {
+	root := os.Getenv("STACK_ROOT")
+	if root == "" && runtime.GOOS == "windows" {
+		root = filepath.Join(os.Getenv("APPDATA"), "stack")
+	}
+	if root == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		root = filepath.Join(homeDir, ".stack")
+	}
+	return filepath.Join(root, "config.yaml"), nil
+}
+
+// getCabalConfigPath returns the path of cabal's config, following
+// CABAL_CONFIG and CABAL_DIR like cabal does, and its XDG location when
+// there is no ~/.cabal
+func getCabalConfigPath() (string, error) {
+	if path := os.Getenv("CABAL_CONFIG"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("CABAL_DIR"); dir != "" {
+		return filepath.Join(dir, "config"), nil
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "cabal", "config"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, ".cabal")); err == nil {
+		return filepath.Join(homeDir, ".cabal", "config"), nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "cabal", "config"), nil
+}
+
+// HasHaskell reports whether Stack or cabal is installed
+func HasHaskell() bool {
+	for _, name := range []string{"stack", "cabal"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// stackDownloadPrefix returns where the Stack config in mapping downloads
+// the package index from, or ""
+func stackDownloadPrefix(mapping *yaml.Node) string {
+	index := mappingKey(mapping, "package-index")
+	if indices := mappingKey(mapping, "package-indices"); index == nil && indices != nil && len(indices.Content) > 0 {
+		// Stack before 2.9 took a list of indices
+		index = indices.Content[0]
+	}
+	if index == nil || index.Kind != yaml.MappingNode {
+		return ""
+	}
+	if prefix := mappingKey(index, "download-prefix"); prefix != nil {
+		return prefix.Value
+	}
+	return ""
+}
+
+// cabalHackageURLLine returns the index of the url line of the
+// hackage.haskell.org repository in the lines of a cabal config, or -1
+func cabalHackageURLLine(lines []string) int {
+	inHackage := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if line != "" && line[0] != ' ' && line[0] != '\t' {
+			inHackage = strings.HasPrefix(trimmed, "repository hackage.haskell.org")
+			continue
+		}
+		if key, _, ok := strings.Cut(trimmed, ":"); inHackage && ok && key == "url" {
+			return i
+		}
+	}
+	return -1
+}
+
+// cabalHackageURL returns the url of the hackage.haskell.org repository in
+// the lines of a cabal config, or ""
+func cabalHackageURL(lines []string) string {
+	i := cabalHackageURLLine(lines)
+	if i < 0 {
+		return ""
+	}
+	_, url, _ := strings.Cut(lines[i], ":")
+	return strings.TrimSpace(url)
+}
+
+// setCabalHackageURL sets the url line at i in the lines of a cabal config
+// to url, keeping its indentation
+func setCabalHackageURL(lines []string, i int, url string) {
+	indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+	lines[i] = indent + "url: " + url
+}
+
+// isHackage reports whether url is Hackage's own
+func isHackage(url string) bool {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"), "/") == "hackage.haskell.org"
+}
+
+// Enable points Stack's global config.yaml at the mirrors, if Stack is set
+// up, and the hackage.haskell.org repository in cabal's config, if there is
+// one, at the Hackage mirror
+func (h *HaskellMirror) Enable() error {
+	stackPath, err := getStackConfigPath()
+	if err != nil {
+		return err
+	}
+	cabalPath, err := getCabalConfigPath()
+	if err != nil {
+		return err
+	}
+
+	var stack *yaml.Node
+	if _, err := os.Stat(filepath.Dir(stackPath)); err == nil {
+		if stack, err = readYAMLMapping(stackPath); err != nil {
+			return err
+		}
+		// An index the user set, e.g. a company one, stays unless Overwrite is set
+		if prefix := stackDownloadPrefix(stack); prefix != "" && prefix != h.hackageURL && !isHackage(prefix) && ownSetting("haskell", stackPath, prefix) && h.resolve(stackPath, "package-index "+prefix) {
+			return nil
+		}
+	}
+
+	var cabal []string
+	if data, err := os.ReadFile(cabalPath); err == nil {
+		cabal = strings.Split(string(data), "\n")
+		if url := cabalHackageURL(cabal); url != "" && url != h.hackageURL && !isHackage(url) && ownSetting("haskell", cabalPath, url) && h.resolve(cabalPath, "url: "+url) {
+			return nil
+		}
+	}
+
+	if stack != nil {
+		if err := h.enableStack(stackPath, stack); err != nil {
+			return err
+		}
+	}
+	if i := cabalHackageURLLine(cabal); i >= 0 {
+		ours := cabalHackageURL(cabal) == h.hackageURL
+		setCabalHackageURL(cabal, i, h.hackageURL)
+		if err := writeConfig(cabalPath, []byte(strings.Join(cabal, "\n")), 0644, ours); err != nil {
+			return fmt.Errorf("failed to write cabal config: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// enableStack sets the mirrors in Stack's config.yaml
+func (h *HaskellMirror) enableStack(stackPath string, stack *yaml.Node) error {
+	ours := stackDownloadPrefix(stack) == h.hackageURL
+
+	var index yaml.Node
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(stackPackageIndex, h.hackageURL)), &index); err != nil {
+		return fmt.Errorf("failed to build package-index: %w", err)
+	}
+	deleteMappingKey(stack, "package-indices")
+	setMappingKey(stack, "package-index", index.Content[0])
+	setMappingKey(stack, "setup-info-locations", &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: h.stackageURL + "stack-setup.yaml"},
+	}})
+	setMappingKey(stack, "snapshot-location-base", &yaml.Node{Kind: yaml.ScalarNode, Value: h.stackageURL + "stackage-snapshots/"})
+	urls := mappingKey(stack, "urls")
+	if urls == nil || urls.Kind != yaml.MappingNode {
+		urls = &yaml.Node{Kind: yaml.MappingNode}
+		setMappingKey(stack, "urls", urls)
+	}
+	setMappingKey(urls, "latest-snapshot", &yaml.Node{Kind: yaml.ScalarNode, Value: h.stackageURL + "snapshots.json"})
+
+	data, err := marshalYAML(stack)
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(stackPath, data, 0644, ours); err != nil {
+		return fmt.Errorf("failed to write Stack config.yaml: %w", err)
+	}
+	return nil
+}
+
+// Disable puts back the original configs, or removes the mirrors from them
+// if they changed since
+func (h *HaskellMirror) Disable() error {
+	stackPath, err := getStackConfigPath()
+	if err != nil {
+		return err
+	}
+	cabalPath, err := getCabalConfigPath()
+	if err != nil {
+		return err
+	}
+
+	restored, err := restoreOriginal(stackPath)
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(stackPath); !restored && statErr == nil {
+		stack, err := readYAMLMapping(stackPath)
+		if err != nil {
+			return err
+		}
+		if prefix := stackDownloadPrefix(stack); prefix != "" && (prefix == h.hackageURL || !ownSetting("haskell", stackPath, prefix)) {
+			for _, key := range stackMirrorKeys {
+				deleteMappingKey(stack, key)
+			}
+			data, err := marshalYAML(stack)
+			if err != nil {
+				return err
+			}
+			if err := logging.WriteFile(stackPath, data, 0644); err != nil {
+				return fmt.Errorf("failed to write Stack config.yaml: %w", err)
+			}
+		}
+	}
+
+	if restored, err := restoreOriginal(cabalPath); restored || err != nil {
+		return err
+	}
+	data, err := os.ReadFile(cabalPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read cabal config: %w", err)
+	}
+	cabal := strings.Split(string(data), "\n")
+	i := cabalHackageURLLine(cabal)
+	if url := cabalHackageURL(cabal); i < 0 || isHackage(url) || url != h.hackageURL && ownSetting("haskell", cabalPath, url) {
+		return nil
+	}
+	setCabalHackageURL(cabal, i, hackageURL)
+	if err := logging.WriteFile(cabalPath, []byte(strings.Join(cabal, "\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write cabal config: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (h *HaskellMirror) Status() (bool, string, error) {
+	stackPath, err := getStackConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+	cabalPath, err := getCabalConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	if stack, err := readYAMLMapping(stackPath); err == nil {
+		if prefix := stackDownloadPrefix(stack); prefix != "" && !isHackage(prefix) {
+			return true, prefix, nil
+		}
+	}
+	if data, err := os.ReadFile(cabalPath); err == nil {
+		if url := cabalHackageURL(strings.Split(string(data), "\n")); url != "" && !isHackage(url) {
+			return true, url, nil
+		}
+	}
+
+	return false, "hackage.haskell.org", nil
+}
@@ -0,0 +1,252 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// helmOfficialRepos are where the common chart repositories come from by default
+var helmOfficialRepos = map[string]string{
+	"stable":  "https://charts.helm.sh/stable",
+	"bitnami": "https://charts.bitnami.com/bitnami",
+}
+
+// HelmMirror handles Helm chart repository configuration
+type HelmMirror struct {
+	conflicts
+	repos    map[string]string // repository name to mirror URL
+	mirrored []string
+}
+
+// NewHelmMirror creates a new Helm mirror handler for the chart repositories
+// in repos, e.g. bitnami, each with the URL of its mirror
+func NewHelmMirror(repos map[string]string) *HelmMirror {
+	return &HelmMirror{
+		repos: repos,
+	}
+}
+
+// getHelmRepositoryConfigPath returns the path of Helm's repositories.yaml,
+// following HELM_REPOSITORY_CONFIG and HELM_CONFIG_HOME like Helm does
+func getHelmRepositoryConfigPath() (string, error) {
+	if path := os.Getenv("HELM_REPOSITORY_CONFIG"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("HELM_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "repositories.yaml"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "helm", "repositories.yaml"), nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "helm", "repositories.yaml"), nil
+	case "darwin":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		return filepath.Join(homeDir, "Library", "Preferences", "helm", "repositories.yaml"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "helm", "repositories.yaml"), nil
+}
+
+// HasHelm reports whether Helm has chart repositories set up for this user
+func HasHelm() bool {
+	path, err := getHelmRepositoryConfigPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// helmRepo returns the entry of the repository called name, or nil
+func helmRepo(repositories *yaml.Node, name string) *yaml.Node {
+	for _, entry := range repositories.Content {
+		if value := mappingKey(entry, "name"); value != nil && value.Value == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+// names returns the names of the repositories to mirror in a stable order
+func (h *HelmMirror) names() []string {
+	names := make([]string, 0, len(h.repos))
+	for name, url := range h.repos {
+		if url != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mirrored returns the names of the repositories the last Enable pointed at their mirrors
+func (h *HelmMirror) Mirrored() []string {
+	return h.mirrored
+}
+
+// Enable points the chart repositories at their mirrors in repositories.yaml,
+// adding the ones that aren't there yet. Run helm repo update afterwards to
+// fetch their indexes.
+func (h *HelmMirror) Enable() error {
+	configPath, err := getHelmRepositoryConfigPath()
+	if err != nil {
+		return err
+	}
+
+	mapping, err := readYAMLMapping(configPath)
+	if err != nil {
+		return err
+	}
+	repositories := mappingKey(mapping, "repositories")
+	if repositories == nil || repositories.Kind != yaml.SequenceNode {
+		repositories = &yaml.Node{Kind: yaml.SequenceNode}
+		if mappingKey(mapping, "apiVersion") == nil {
+			setMappingKey(mapping, "apiVersion", &yaml.Node{Kind: yaml.ScalarNode, Value: "", Style: yaml.DoubleQuotedStyle})
+		}
+		setMappingKey(mapping, "repositories", repositories)
+	}
+
+	ours := false
+	h.mirrored = nil
+	for _, name := range h.names() {
+		mirrorURL := h.repos[name]
+		entry := helmRepo(repositories, name)
+		if entry == nil {
+			entry = &yaml.Node{Kind: yaml.MappingNode}
+			setMappingKey(entry, "name", &yaml.Node{Kind: yaml.ScalarNode, Value: name})
+			repositories.Content = append(repositories.Content, entry)
+		} else if url := mappingKey(entry, "url"); url != nil {
+			switch {
+			case url.Value == mirrorURL:
+				ours = true
+			case url.Value == helmOfficialRepos[name]:
+				// The official repository is what the mirror replaces
+			case ownSetting("helm", configPath, url.Value) && h.resolve(configPath, fmt.Sprintf("%s repo %s", name, url.Value)):
+				// A repository the user pointed elsewhere, e.g. at a company proxy, stays unless Overwrite is set
+				continue
+			}
+		}
+		setMappingKey(entry, "url", &yaml.Node{Kind: yaml.ScalarNode, Value: mirrorURL})
+		h.mirrored = append(h.mirrored, name)
+	}
+	if len(h.mirrored) == 0 {
+		return nil
+	}
+
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return err
+	}
+	if err := logging.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create Helm config directory: %w", err)
+	}
+	if err := writeConfig(configPath, data, 0644, ours); err != nil {
+		return fmt.Errorf("failed to write repositories.yaml: %w", err)
+	}
+
+	return nil
+}
+
+// Disable points the chart repositories back at their official URLs, and
+// removes mirrored ones that have none
+func (h *HelmMirror) Disable() error {
+	configPath, err := getHelmRepositoryConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil // Nothing to disable
+	}
+	mapping, err := readYAMLMapping(configPath)
+	if err != nil {
+		return err
+	}
+	repositories := mappingKey(mapping, "repositories")
+	if repositories == nil {
+		return nil
+	}
+
+	changed := false
+	kept := repositories.Content[:0]
+	for _, entry := range repositories.Content {
+		name, url := mappingKey(entry, "name"), mappingKey(entry, "url")
+		if name == nil || url == nil || h.repos[name.Value] == "" || url.Value != h.repos[name.Value] {
+			kept = append(kept, entry)
+			continue
+		}
+		changed = true
+		if official := helmOfficialRepos[name.Value]; official != "" {
+			url.Value = official
+			kept = append(kept, entry)
+		}
+	}
+	if !changed {
+		return nil
+	}
+	repositories.Content = kept
+
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return err
+	}
+	if err := logging.WriteFile(configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write repositories.yaml: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled, listing the repositories
+// that use their mirrors
+func (h *HelmMirror) Status() (bool, string, error) {
+	configPath, err := getHelmRepositoryConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	mapping, err := readYAMLMapping(configPath)
+	if err != nil {
+		return false, "", err
+	}
+	repositories := mappingKey(mapping, "repositories")
+	if repositories == nil {
+		return false, "no chart repositories", nil
+	}
+
+	var mirrored []string
+	for _, name := range h.names() {
+		entry := helmRepo(repositories, name)
+		if entry == nil {
+			continue
+		}
+		if url := mappingKey(entry, "url"); url != nil && url.Value == h.repos[name] {
+			mirrored = append(mirrored, name)
+		}
+	}
+	if len(mirrored) > 0 {
+		return true, strings.Join(mirrored, ", "), nil
+	}
+
+	return false, "default chart repositories", nil
+}
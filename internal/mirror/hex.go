@@ -0,0 +1,57 @@
+package mirror
+
+import (
+	"os/exec"
+)
+
+// HexMirror handles the Hex package mirror Mix uses for Elixir and Erlang
+type HexMirror struct {
+	conflicts
+	mirrorURL string
+}
+
+// NewHexMirror creates a new Hex mirror handler
+func NewHexMirror(mirrorURL string) *HexMirror {
+	return &HexMirror{
+		mirrorURL: mirrorURL,
+	}
+}
+
+// HasHex reports whether Mix is installed
+func HasHex() bool {
+	_, err := exec.LookPath("mix")
+	return err == nil
+}
+
+// Enable sets HEX_MIRROR, which the package index and tarballs come from,
+// and HEX_CDN in the shell rc file
+func (h *HexMirror) Enable() error {
+	// A mirror the user set, e.g. a company Hex repository, stays unless Overwrite is set
+	for _, name := range []string{"HEX_MIRROR", "HEX_CDN"} {
+		rcFile, line, value, err := userEnv(name)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != h.mirrorURL && ownSetting("hex", rcFile, value) && h.resolve(rcFile, line) {
+			return nil
+		}
+	}
+
+	return setEnv("hex", []envVar{
+		{"HEX_MIRROR", h.mirrorURL},
+		{"HEX_CDN", h.mirrorURL},
+	})
+}
+
+// Disable removes the mirror configuration
+func (h *HexMirror) Disable() error {
+	return unsetEnv("hex")
+}
+
+// Status checks if the mirror is currently enabled
+func (h *HexMirror) Status() (bool, string, error) {
+	if url, ok := getEnv("hex", "HEX_MIRROR"); ok {
+		return true, url, nil
+	}
+	return false, "default repository", nil
+}
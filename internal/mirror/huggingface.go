@@ -0,0 +1,73 @@
+package mirror
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HuggingFaceMirror handles the endpoint huggingface_hub, and the libraries
+// built on it such as transformers and datasets, download models from
+type HuggingFaceMirror struct {
+	conflicts
+	endpointURL string
+}
+
+// NewHuggingFaceMirror creates a new Hugging Face mirror handler for a
+// mirror of https://huggingface.co
+func NewHuggingFaceMirror(endpointURL string) *HuggingFaceMirror {
+	return &HuggingFaceMirror{
+		endpointURL: strings.TrimSuffix(endpointURL, "/"),
+	}
+}
+
+// HasHuggingFace reports whether the Hugging Face CLI is installed or models
+// have been downloaded into the Hugging Face cache
+func HasHuggingFace() bool {
+	for _, name := range []string{"hf", "huggingface-cli"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	home := os.Getenv("HF_HOME")
+	if home == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		home = filepath.Join(homeDir, ".cache", "huggingface")
+	}
+	_, err := os.Stat(home)
+	return err == nil
+}
+
+// Enable sets HF_ENDPOINT in the shell rc file. huggingface_hub has no
+// config file for the endpoint, so that is where Python and the CLI see it.
+func (h *HuggingFaceMirror) Enable() error {
+	// An endpoint the user set, e.g. a company hub, stays unless Overwrite is set
+	rcFile, line, value, err := userEnv("HF_ENDPOINT")
+	if err != nil {
+		return err
+	}
+	if line != "" && strings.TrimSuffix(value, "/") != h.endpointURL && ownSetting("huggingface", rcFile, value) && h.resolve(rcFile, line) {
+		return nil
+	}
+
+	return setEnv("huggingface", []envVar{
+		{"HF_ENDPOINT", h.endpointURL},
+	})
+}
+
+// Disable removes the mirror configuration
+func (h *HuggingFaceMirror) Disable() error {
+	return unsetEnv("huggingface")
+}
+
+// Status checks if the mirror is currently enabled
+func (h *HuggingFaceMirror) Status() (bool, string, error) {
+	if url, ok := getEnv("huggingface", "HF_ENDPOINT"); ok {
+		return true, url, nil
+	}
+	return false, "huggingface.co", nil
+}
@@ -0,0 +1,180 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// k8sHostsPath is containerd's registry host config for registry.k8s.io, which
+// containerd reads when config_path in its config.toml is /etc/containerd/certs.d
+const k8sHostsPath = "/etc/containerd/certs.d/registry.k8s.io/hosts.toml"
+
+// k8sPackagesURL is where the Kubernetes apt and yum repositories of
+// kubeadm, kubelet and kubectl are published
+const k8sPackagesURL = "https://pkgs.k8s.io/core:/stable:/"
+
+// k8sMirroredURL matches the packages mirror enablePackages put in place of k8sPackagesURL
+var k8sMirroredURL = regexp.MustCompile(`https?://[^\s"']+/core/stable/`)
+
+// k8sRepoPaths are the repository files the Kubernetes install guide adds
+var k8sRepoPaths = []string{
+	"/etc/apt/sources.list.d/kubernetes.list",
+	"/etc/yum.repos.d/kubernetes.repo",
+}
+
+// KubernetesMirror handles where cluster images from registry.k8s.io and
+// kubeadm, kubelet and kubectl packages from pkgs.k8s.io come from
+type KubernetesMirror struct {
+	conflicts
+	registryURL string
+	packagesURL string
+}
+
+// NewKubernetesMirror creates a new Kubernetes mirror handler for a
+// pull-through mirror of registry.k8s.io and a mirror of pkgs.k8s.io laid
+// out like https://mirrors.aliyun.com/kubernetes-new
+func NewKubernetesMirror(registryURL, packagesURL string) *KubernetesMirror {
+	return &KubernetesMirror{
+		registryURL: strings.TrimSuffix(registryURL, "/"),
+		packagesURL: strings.TrimSuffix(packagesURL, "/"),
+	}
+}
+
+// HasKubernetes reports whether there is something to set up: containerd,
+// or the Kubernetes package repository
+func HasKubernetes() bool {
+	for _, path := range append([]string{"/etc/containerd"}, k8sRepoPaths...) {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Enable makes containerd pull registry.k8s.io images through the registry
+// mirror and points the Kubernetes package repository at the packages mirror
+func (k *KubernetesMirror) Enable() error {
+	if k.registryURL != "" {
+		if err := k.enableRegistry(); err != nil {
+			return err
+		}
+	}
+	if k.packagesURL != "" {
+		return k.enablePackages()
+	}
+	return nil
+}
+
+// enableRegistry writes containerd's hosts.toml for registry.k8s.io, with the
+// registry itself as the fallback
+func (k *KubernetesMirror) enableRegistry() error {
+	if _, err := os.Stat("/etc/containerd"); err != nil {
+		return nil
+	}
+
+	// Hosts the user set up, e.g. a company registry, stay unless Overwrite is set
+	existing, err := os.ReadFile(k8sHostsPath)
+	ours := strings.Contains(string(existing), "# Generated by crosh")
+	if err == nil && !ours && !tracked(k8sHostsPath) && k.resolve(k8sHostsPath, "hosts.toml") {
+		return nil
+	}
+
+	registryURL := k.registryURL
+	if !strings.HasPrefix(registryURL, "http://") && !strings.HasPrefix(registryURL, "https://") {
+		registryURL = "https://" + registryURL
+	}
+	content := fmt.Sprintf(`# Generated by crosh - Chinese mirror acceleration
+server = "https://registry.k8s.io"
+
+[host.%q]
+  capabilities = ["pull", "resolve"]
+`, registryURL)
+
+	if err := logging.MkdirAll(filepath.Dir(k8sHostsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s (try running with sudo): %w", filepath.Dir(k8sHostsPath), err)
+	}
+	if err := writeConfig(k8sHostsPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s (try running with sudo): %w", k8sHostsPath, err)
+	}
+	return nil
+}
+
+// enablePackages rewrites pkgs.k8s.io in the repository files that exist to
+// the packages mirror, keeping the version and signing key they name
+func (k *KubernetesMirror) enablePackages() error {
+	mirrorURL := k.packagesURL + "/core/stable/"
+	for _, path := range k8sRepoPaths {
+		data, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(data), k8sPackagesURL) {
+			continue
+		}
+		content := strings.ReplaceAll(string(data), k8sPackagesURL, mirrorURL)
+		if err := writeConfig(path, []byte(content), 0644, false); err != nil {
+			return fmt.Errorf("failed to write %s (try running with sudo): %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Disable puts back the repository files and removes crosh's hosts.toml
+func (k *KubernetesMirror) Disable() error {
+	for _, path := range k8sRepoPaths {
+		restored, err := restoreOriginal(path)
+		if err != nil {
+			return err
+		}
+		if restored {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || !k8sMirroredURL.Match(data) {
+			continue
+		}
+		content := k8sMirroredURL.ReplaceAllString(string(data), k8sPackagesURL)
+		if err := logging.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s (try running with sudo): %w", path, err)
+		}
+	}
+
+	restored, err := restoreOriginal(k8sHostsPath)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		data, err := os.ReadFile(k8sHostsPath)
+		if err != nil || !strings.Contains(string(data), "# Generated by crosh") {
+			return nil
+		}
+		if err := logging.Remove(k8sHostsPath); err != nil {
+			return fmt.Errorf("failed to remove %s (try running with sudo): %w", k8sHostsPath, err)
+		}
+	}
+	// The directories are crosh's too once they're empty
+	if os.Remove(filepath.Dir(k8sHostsPath)) == nil {
+		os.Remove(filepath.Dir(filepath.Dir(k8sHostsPath)))
+	}
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (k *KubernetesMirror) Status() (bool, string, error) {
+	data, err := os.ReadFile(k8sHostsPath)
+	if err == nil && strings.Contains(string(data), "# Generated by crosh") {
+		for _, line := range strings.Split(string(data), "\n") {
+			if host, ok := strings.CutPrefix(strings.TrimSpace(line), "[host."); ok {
+				return true, strings.Trim(strings.TrimSuffix(host, "]"), `"`), nil
+			}
+		}
+	}
+	for _, path := range k8sRepoPaths {
+		if tracked(path) {
+			return true, k.packagesURL, nil
+		}
+	}
+	return false, "registry.k8s.io", nil
+}
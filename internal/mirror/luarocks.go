@@ -0,0 +1,183 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// luaRocksDefaultServer is where LuaRocks installs rocks from by default
+const luaRocksDefaultServer = "https://luarocks.org"
+
+// Markers around what crosh adds to Lua config files, which comment with --
+const (
+	luaMarkerBegin = "-- crosh:begin"
+	luaMarkerEnd   = "-- crosh:end"
+)
+
+var (
+	luaMarkedBlock = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(luaMarkerBegin) + `\n.*?` + regexp.QuoteMeta(luaMarkerEnd) + `\n?`)
+	// luaRocksServers matches a line setting rocks_servers, e.g.
+	// rocks_servers = { "https://luarocks.org" }
+	luaRocksServers = regexp.MustCompile(`(?m)^\s*rocks_servers\s*=[^\n]*`)
+	luaQuoted       = regexp.MustCompile(`["'](https?://[^"']+)["']`)
+	// luaRocksUserConfig matches the user config in the output of luarocks, e.g.
+	// "User  : /home/me/.luarocks/config-5.4.lua (not found)"
+	luaRocksUserConfig = regexp.MustCompile(`(?m)^\s*User\s*:\s*(.+?)\s+\((?:ok|not found)\)`)
+)
+
+// LuaRocksMirror handles the servers LuaRocks installs Lua modules from
+type LuaRocksMirror struct {
+	conflicts
+	serverURL string
+}
+
+// NewLuaRocksMirror creates a new LuaRocks mirror handler for a mirror of
+// https://luarocks.org
+func NewLuaRocksMirror(serverURL string) *LuaRocksMirror {
+	return &LuaRocksMirror{
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+	}
+}
+
+// HasLuaRocks reports whether LuaRocks is installed
+func HasLuaRocks() bool {
+	_, err := exec.LookPath("luarocks")
+	return err == nil
+}
+
+// getLuaRocksConfigPath returns the path of the user's LuaRocks config,
+// $LUAROCKS_CONFIG or the one luarocks reports, which is named after the Lua
+// version, e.g. ~/.luarocks/config-5.4.lua
+func getLuaRocksConfigPath() (string, error) {
+	if path := os.Getenv("LUAROCKS_CONFIG"); path != "" {
+		return path, nil
+	}
+	if output, err := logging.Command("luarocks").Output(); err == nil {
+		if match := luaRocksUserConfig.FindSubmatch(output); match != nil {
+			return string(match[1]), nil
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "luarocks", "config.lua"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".luarocks", "config.lua"), nil
+}
+
+// Enable sets rocks_servers at the end of the user config, between markers,
+// with luarocks.org after the mirror as a fallback
+func (l *LuaRocksMirror) Enable() error {
+	configPath, err := getLuaRocksConfigPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing config if it exists
+	var existingContent string
+	if data, err := os.ReadFile(configPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, luaMarkerBegin)
+	content := strings.TrimRight(luaMarkedBlock.ReplaceAllString(existingContent, ""), "\n")
+
+	// Servers the user set, e.g. a company one, stay unless Overwrite is set;
+	// crosh's block comes last, so it wins over theirs otherwise
+	if line := luaRocksServers.FindString(content); line != "" {
+		server := strings.TrimSpace(line)
+		if match := luaQuoted.FindStringSubmatch(line); match != nil {
+			server = match[1]
+		}
+		if server != l.serverURL && ownSetting("luarocks", configPath, server) && l.resolve(configPath, strings.TrimSpace(line)) {
+			return nil
+		}
+	}
+
+	if content != "" {
+		content += "\n\n"
+	}
+	content += fmt.Sprintf("%s\nrocks_servers = {\n   %q,\n   %q,\n}\n%s\n", luaMarkerBegin, l.serverURL, luaRocksDefaultServer, luaMarkerEnd)
+
+	if err := logging.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create LuaRocks config directory: %w", err)
+	}
+	if err := writeConfig(configPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (l *LuaRocksMirror) Disable() error {
+	configPath, err := getLuaRocksConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	// Remove the block between crosh's markers
+	content := luaMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", configPath, err)
+		}
+		return nil
+	}
+
+	if err := logging.WriteFile(configPath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (l *LuaRocksMirror) Status() (bool, string, error) {
+	configPath, err := getLuaRocksConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "luarocks.org", nil
+		}
+		return false, "", fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	if block := luaMarkedBlock.FindString(string(data)); block != "" {
+		if match := luaQuoted.FindStringSubmatch(block); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	return false, "luarocks.org", nil
+}
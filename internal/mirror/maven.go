@@ -0,0 +1,184 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// Markers around what crosh adds to XML config files such as settings.xml
+const (
+	markerBegin = "<!-- crosh:begin -->"
+	markerEnd   = "<!-- crosh:end -->"
+)
+
+var (
+	markedBlock   = regexp.MustCompile(`(?s)[ \t]*` + regexp.QuoteMeta(markerBegin) + `.*?` + regexp.QuoteMeta(markerEnd) + `\n?`)
+	mavenMirror   = regexp.MustCompile(`(?s)<mirror>(.*?)</mirror>`)
+	mavenMirrorOf = regexp.MustCompile(`<mirrorOf>\s*(.*?)\s*</mirrorOf>`)
+	mavenURL      = regexp.MustCompile(`<url>\s*(.*?)\s*</url>`)
+)
+
+// MavenMirror handles Maven repository mirror configuration
+type MavenMirror struct {
+	conflicts
+	repositoryURL string
+}
+
+// NewMavenMirror creates a new Maven mirror handler
+func NewMavenMirror(repositoryURL string) *MavenMirror {
+	return &MavenMirror{
+		repositoryURL: repositoryURL,
+	}
+}
+
+// getMavenSettingsPath returns the path to the user's Maven settings
+func getMavenSettingsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".m2", "settings.xml"), nil
+}
+
+// Enable configures Maven to fetch Maven Central through the mirror. The
+// mirror goes between markers in ~/.m2/settings.xml; the rest of the file
+// stays as it is.
+func (m *MavenMirror) Enable() error {
+	settingsPath, err := getMavenSettingsPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing settings.xml if it exists
+	var existingContent string
+	if data, err := os.ReadFile(settingsPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, markerBegin)
+	content := markedBlock.ReplaceAllString(existingContent, "")
+
+	// A mirror of Central the user set, e.g. a company Nexus, stays unless Overwrite is set
+	for _, match := range mavenMirror.FindAllStringSubmatch(content, -1) {
+		mirrorOf := mavenMirrorOf.FindStringSubmatch(match[1])
+		url := mavenURL.FindStringSubmatch(match[1])
+		if mirrorOf == nil || url == nil || !mirrorsCentral(mirrorOf[1]) {
+			continue
+		}
+		if url[1] != m.repositoryURL && ownSetting("maven", settingsPath, url[1]) && m.resolve(settingsPath, "mirror "+url[1]) {
+			return nil
+		}
+	}
+
+	block := fmt.Sprintf(`    %s
+    <mirror>
+      <id>crosh</id>
+      <mirrorOf>central</mirrorOf>
+      <name>Maven Central mirror set by crosh</name>
+      <url>%s</url>
+    </mirror>
+    %s
+`, markerBegin, m.repositoryURL, markerEnd)
+
+	// Maven uses the first mirror of Central it finds, so crosh's goes first
+	switch {
+	case strings.Contains(content, "<mirrors>"):
+		content = strings.Replace(content, "<mirrors>", "<mirrors>\n"+strings.TrimSuffix(block, "\n"), 1)
+	case strings.Contains(content, "</settings>"):
+		content = strings.Replace(content, "</settings>", "  <mirrors>\n"+block+"  </mirrors>\n</settings>", 1)
+	case strings.TrimSpace(content) == "":
+		content = `<?xml version="1.0" encoding="UTF-8"?>
+<settings xmlns="http://maven.apache.org/SETTINGS/1.0.0"
+          xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+          xsi:schemaLocation="http://maven.apache.org/SETTINGS/1.0.0 https://maven.apache.org/xsd/settings-1.0.0.xsd">
+  <mirrors>
+` + block + `  </mirrors>
+</settings>
+`
+	default:
+		return fmt.Errorf("failed to understand %s: no <settings> element", settingsPath)
+	}
+
+	if err := logging.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create .m2 directory: %w", err)
+	}
+	if err := writeConfig(settingsPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write settings.xml: %w", err)
+	}
+
+	return nil
+}
+
+// mirrorsCentral reports whether a mirrorOf value, such as "*,!internal",
+// covers Maven Central
+func mirrorsCentral(mirrorOf string) bool {
+	covered := false
+	for _, id := range strings.Split(mirrorOf, ",") {
+		switch strings.TrimSpace(id) {
+		case "central", "*", "external:*":
+			covered = true
+		case "!central":
+			return false
+		}
+	}
+	return covered
+}
+
+// Disable removes the mirror configuration
+func (m *MavenMirror) Disable() error {
+	settingsPath, err := getMavenSettingsPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(settingsPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read settings.xml: %w", err)
+	}
+
+	// Remove the block between crosh's markers
+	content := markedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+	if err := logging.WriteFile(settingsPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write settings.xml: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (m *MavenMirror) Status() (bool, string, error) {
+	settingsPath, err := getMavenSettingsPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default repository", nil
+		}
+		return false, "", fmt.Errorf("failed to read settings.xml: %w", err)
+	}
+
+	if block := markedBlock.FindString(string(data)); block != "" {
+		if url := mavenURL.FindStringSubmatch(block); url != nil {
+			return true, url[1], nil
+		}
+	}
+
+	return false, "default repository", nil
+}
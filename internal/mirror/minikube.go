@@ -0,0 +1,57 @@
+package mirror
+
+import (
+	"os/exec"
+)
+
+// MinikubeMirror handles the image mirror minikube pulls its base image and
+// Kubernetes images from
+type MinikubeMirror struct {
+	conflicts
+	country string
+}
+
+// NewMinikubeMirror creates a new minikube mirror handler for the country
+// minikube picks its image mirror by, such as cn
+func NewMinikubeMirror(country string) *MinikubeMirror {
+	return &MinikubeMirror{
+		country: country,
+	}
+}
+
+// HasMinikube reports whether minikube is installed
+func HasMinikube() bool {
+	_, err := exec.LookPath("minikube")
+	return err == nil
+}
+
+// Enable sets MINIKUBE_IMAGE_MIRROR_COUNTRY in the shell rc file, which
+// minikube start takes as --image-mirror-country; --image-repository still
+// wins over it
+func (m *MinikubeMirror) Enable() error {
+	// A country the user set stays unless Overwrite is set
+	rcFile, line, value, err := userEnv("MINIKUBE_IMAGE_MIRROR_COUNTRY")
+	if err != nil {
+		return err
+	}
+	if line != "" && value != m.country && m.resolve(rcFile, line) {
+		return nil
+	}
+
+	return setEnv("minikube", []envVar{
+		{"MINIKUBE_IMAGE_MIRROR_COUNTRY", m.country},
+	})
+}
+
+// Disable removes the mirror configuration
+func (m *MinikubeMirror) Disable() error {
+	return unsetEnv("minikube")
+}
+
+// Status checks if the mirror is currently enabled
+func (m *MinikubeMirror) Status() (bool, string, error) {
+	if country, ok := getEnv("minikube", "MINIKUBE_IMAGE_MIRROR_COUNTRY"); ok {
+		return true, "image mirror for " + country, nil
+	}
+	return false, "default registries", nil
+}
@@ -0,0 +1,166 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// msys2Repos maps the names of MSYS2's mirrorlists, mirrorlist.<name>, to
+// where their repositories are on a mirror. Current installs have one
+// mirrorlist for all MinGW repositories, older ones one each.
+var msys2Repos = map[string]string{
+	"msys":       "msys/$arch",
+	"mingw":      "mingw/$repo",
+	"mingw32":    "mingw/i686",
+	"mingw64":    "mingw/x86_64",
+	"ucrt64":     "mingw/ucrt64",
+	"clang32":    "mingw/clang32",
+	"clang64":    "mingw/clang64",
+	"clangarm64": "mingw/clangarm64",
+}
+
+// MSYS2Mirror handles the pacman mirrorlists of MSYS2 on Windows
+type MSYS2Mirror struct {
+	mirrorURL string
+}
+
+// NewMSYS2Mirror creates a new MSYS2 mirror handler for the mirror host,
+// e.g. mirrors.tuna.tsinghua.edu.cn
+func NewMSYS2Mirror(mirrorURL string) *MSYS2Mirror {
+	return &MSYS2Mirror{
+		mirrorURL: mirrorURL,
+	}
+}
+
+// getMSYS2Root returns where MSYS2 is installed, found from its pacman on
+// the PATH or at C:\msys64, or "" if it isn't
+func getMSYS2Root() string {
+	if runtime.GOOS != "windows" {
+		return ""
+	}
+	var candidates []string
+	if pacman, err := exec.LookPath("pacman"); err == nil {
+		// pacman.exe is in usr\bin under the root
+		candidates = append(candidates, filepath.Dir(filepath.Dir(filepath.Dir(pacman))))
+	}
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = "C:"
+	}
+	candidates = append(candidates, drive+`\msys64`)
+
+	for _, root := range candidates {
+		if _, err := os.Stat(filepath.Join(root, "etc", "pacman.d", "mirrorlist.msys")); err == nil {
+			return root
+		}
+	}
+	return ""
+}
+
+// HasMSYS2 reports whether MSYS2 is installed
+func HasMSYS2() bool {
+	return getMSYS2Root() != ""
+}
+
+// msys2Mirrorlists returns the paths of the mirrorlists this MSYS2 install
+// has, by name
+func msys2Mirrorlists() (map[string]string, error) {
+	root := getMSYS2Root()
+	if root == "" {
+		return nil, fmt.Errorf("MSYS2 not found")
+	}
+	paths := make(map[string]string)
+	for name := range msys2Repos {
+		path := filepath.Join(root, "etc", "pacman.d", "mirrorlist."+name)
+		if _, err := os.Stat(path); err == nil {
+			paths[name] = path
+		}
+	}
+	return paths, nil
+}
+
+// Enable puts the mirror first in each of MSYS2's mirrorlists, between
+// markers so the default mirrors stay after it as fallbacks
+func (m *MSYS2Mirror) Enable() error {
+	paths, err := msys2Mirrorlists()
+	if err != nil {
+		return err
+	}
+
+	for name, path := range paths {
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		ours := strings.Contains(string(existing), hashMarkerBegin)
+		content := fmt.Sprintf("%s\nServer = https://%s/msys2/%s/\n%s\n", hashMarkerBegin, m.mirrorURL, msys2Repos[name], hashMarkerEnd) +
+			hashMarkedBlock.ReplaceAllString(string(existing), "")
+		if content == string(existing) {
+			continue
+		}
+		if err := writeConfig(path, []byte(content), 0644, ours); err != nil {
+			return fmt.Errorf("failed to write %s (try running as administrator): %w", filepath.Base(path), err)
+		}
+	}
+
+	return nil
+}
+
+// Disable removes the mirror from MSYS2's mirrorlists
+func (m *MSYS2Mirror) Disable() error {
+	paths, err := msys2Mirrorlists()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		restored, err := restoreOriginal(path)
+		if err != nil {
+			return err
+		}
+		if restored {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		// Remove the block between crosh's markers
+		content := hashMarkedBlock.ReplaceAllString(string(data), "")
+		if content == string(data) {
+			continue
+		}
+		if err := logging.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (m *MSYS2Mirror) Status() (bool, string, error) {
+	root := getMSYS2Root()
+	if root == "" {
+		return false, "", fmt.Errorf("MSYS2 not found")
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "etc", "pacman.d", "mirrorlist.msys"))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read mirrorlist.msys: %w", err)
+	}
+	if block := hashMarkedBlock.FindString(string(data)); block != "" {
+		if match := pacmanServer.FindStringSubmatch(block); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	return false, "default mirrors", nil
+}
@@ -0,0 +1,217 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	// nixCacheURL is the binary cache Nix substitutes from by default
+	nixCacheURL = "https://cache.nixos.org/"
+	// nixCacheKey is the key cache.nixos.org signs with, which the mirrors'
+	// store paths carry too
+	nixCacheKey = "cache.nixos.org-1:6NCHdD59X431o0gWypbMrAURkbJ16ZPMQFGspcDShjY="
+)
+
+// nixSubstituters matches a line setting substituters in nix.conf
+var nixSubstituters = regexp.MustCompile(`(?m)^\s*substituters\s*=([^\n]*)`)
+
+// NixMirror handles the binary caches Nix substitutes store paths from
+type NixMirror struct {
+	conflicts
+	substituters []string
+}
+
+// NewNixMirror creates a new Nix mirror handler for mirrors of
+// cache.nixos.org, tried in order
+func NewNixMirror(substituters []string) *NixMirror {
+	return &NixMirror{
+		substituters: substituters,
+	}
+}
+
+// getNixConfPath returns the nix.conf crosh sets the mirrors in: the
+// system-wide one the daemon of a multi-user install reads, under
+// $NIX_CONF_DIR or /etc/nix, or else the user's
+func getNixConfPath() (string, error) {
+	dir := os.Getenv("NIX_CONF_DIR")
+	if dir == "" {
+		dir = "/etc/nix"
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nix.conf")); err == nil {
+		return filepath.Join(dir, "nix.conf"), nil
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "nix", "nix.conf"), nil
+}
+
+// HasNix reports whether Nix is installed
+func HasNix() bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	if _, err := exec.LookPath("nix"); err == nil {
+		return true
+	}
+	_, err := os.Stat("/nix/store")
+	return err == nil
+}
+
+// restartNixDaemon restarts the daemon of a multi-user install, if it runs,
+// so it reads nix.conf again
+func restartNixDaemon(confPath string) error {
+	if !strings.HasPrefix(confPath, "/etc/") {
+		return nil
+	}
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("systemctl"); err != nil {
+			return nil
+		}
+		if output, err := logging.Run("systemctl", "try-restart", "nix-daemon.service"); err != nil {
+			return fmt.Errorf("systemctl try-restart nix-daemon: %s", strings.TrimSpace(string(output)))
+		}
+	case "darwin":
+		// Nothing to restart if the daemon isn't loaded
+		if err := logging.Command("launchctl", "print", "system/org.nixos.nix-daemon").Run(); err != nil {
+			return nil
+		}
+		if output, err := logging.Run("launchctl", "kickstart", "-k", "system/org.nixos.nix-daemon"); err != nil {
+			return fmt.Errorf("launchctl kickstart nix-daemon: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// Enable sets the mirrors as the first substituters at the end of nix.conf,
+// between markers, so they take over from the substituters set above. The
+// user's own substituters, or cache.nixos.org, stay after them as fallbacks.
+func (n *NixMirror) Enable() error {
+	confPath, err := getNixConfPath()
+	if err != nil {
+		return err
+	}
+	// NixOS generates nix.conf into the store from configuration.nix
+	if target, err := filepath.EvalSymlinks(confPath); err == nil && strings.HasPrefix(target, "/nix/store/") {
+		return fmt.Errorf("%s is managed by NixOS; set nix.settings.substituters in configuration.nix", confPath)
+	}
+
+	// Read existing config if it exists
+	var existingContent string
+	if data, err := os.ReadFile(confPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin)
+	content := strings.TrimRight(hashMarkedBlock.ReplaceAllString(existingContent, ""), "\n")
+
+	substituters := slices.Clone(n.substituters)
+	fallbacks := []string{nixCacheURL}
+	if match := nixSubstituters.FindStringSubmatch(content); match != nil {
+		// Substituters the user set, e.g. a company cache, stay after the mirrors
+		fallbacks = strings.Fields(match[1])
+		n.merge(confPath, strings.TrimSpace(match[0]))
+	}
+	for _, fallback := range fallbacks {
+		if !slices.Contains(substituters, fallback) {
+			substituters = append(substituters, fallback)
+		}
+	}
+
+	if content != "" {
+		content += "\n\n"
+	}
+	content += fmt.Sprintf("%s\nsubstituters = %s\nextra-trusted-public-keys = %s\n%s\n",
+		hashMarkerBegin, strings.Join(substituters, " "), nixCacheKey, hashMarkerEnd)
+
+	if err := logging.MkdirAll(filepath.Dir(confPath), 0755); err != nil {
+		return fmt.Errorf("failed to create Nix config directory: %w", err)
+	}
+	if err := writeConfig(confPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s (try running with sudo): %w", confPath, err)
+	}
+
+	return restartNixDaemon(confPath)
+}
+
+// Disable removes the mirror configuration
+func (n *NixMirror) Disable() error {
+	confPath, err := getNixConfPath()
+	if err != nil {
+		return err
+	}
+	restored, err := restoreOriginal(confPath)
+	if err != nil {
+		return err
+	}
+	if restored {
+		return restartNixDaemon(confPath)
+	}
+
+	data, err := os.ReadFile(confPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", confPath, err)
+	}
+
+	// Remove the block between crosh's markers
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(confPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s (try running with sudo): %w", confPath, err)
+		}
+	} else if err := logging.WriteFile(confPath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s (try running with sudo): %w", confPath, err)
+	}
+
+	return restartNixDaemon(confPath)
+}
+
+// Status checks if the mirror is currently enabled
+func (n *NixMirror) Status() (bool, string, error) {
+	confPath, err := getNixConfPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(confPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "cache.nixos.org", nil
+		}
+		return false, "", fmt.Errorf("failed to read %s: %w", confPath, err)
+	}
+
+	if block := hashMarkedBlock.FindString(string(data)); block != "" {
+		if match := nixSubstituters.FindStringSubmatch(block); match != nil {
+			if fields := strings.Fields(match[1]); len(fields) > 0 {
+				return true, fields[0], nil
+			}
+		}
+	}
+
+	return false, "cache.nixos.org", nil
+}
@@ -0,0 +1,306 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// NodeMirror handles where Corepack fetches package managers from and where
+// Node.js version managers (fnm, nvm, nvm-windows, n, Volta) download Node.js releases
+type NodeMirror struct {
+	conflicts
+	registryURL string
+	distURL     string
+}
+
+// NewNodeMirror creates a new Node.js mirror handler for Corepack, from the
+// npm mirror registryURL, and Node.js releases, from distURL
+func NewNodeMirror(registryURL, distURL string) *NodeMirror {
+	return &NodeMirror{
+		registryURL: registryURL,
+		distURL:     strings.TrimSuffix(distURL, "/"),
+	}
+}
+
+// HasNode reports whether Corepack or a Node.js version manager is installed
+func HasNode() bool {
+	for _, name := range []string{"corepack", "fnm", "volta", "n"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	if getNvmWindowsSettingsPath() != "" {
+		return true
+	}
+	_, err := os.Stat(getNvmDir())
+	return err == nil
+}
+
+// getNvmDir returns where nvm, a shell function rather than a command, is installed
+func getNvmDir() string {
+	if dir := os.Getenv("NVM_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".nvm")
+}
+
+// getNvmWindowsSettingsPath returns the path of nvm-windows' settings.txt,
+// or "" if nvm-windows isn't installed
+func getNvmWindowsSettingsPath() string {
+	if runtime.GOOS != "windows" || os.Getenv("NVM_HOME") == "" {
+		return ""
+	}
+	return filepath.Join(os.Getenv("NVM_HOME"), "settings.txt")
+}
+
+// nvmWindowsMirror returns the node_mirror line of nvm-windows' settings.txt
+// and the mirror it sets, or "" if it has none
+func nvmWindowsMirror(content string) (string, string) {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(trimmed, "node_mirror:"); ok {
+			return trimmed, strings.TrimSuffix(strings.TrimSpace(value), "/")
+		}
+	}
+	return "", ""
+}
+
+// getVoltaHooksPath returns the path of Volta's hooks.json, or "" if Volta
+// isn't set up
+func getVoltaHooksPath() string {
+	dir := os.Getenv("VOLTA_HOME")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(homeDir, ".volta")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "hooks.json")
+}
+
+// readVoltaHooks reads hooks.json, or returns empty hooks if it doesn't exist
+func readVoltaHooks(path string) (map[string]interface{}, error) {
+	hooks := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return hooks, nil
+		}
+		return nil, fmt.Errorf("failed to read Volta hooks.json: %w", err)
+	}
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse Volta hooks.json: %w", err)
+	}
+	return hooks, nil
+}
+
+// voltaIndexURL returns the template of the Node.js index Volta's hooks
+// point at, if set
+func voltaIndexURL(hooks map[string]interface{}) (string, bool) {
+	node, _ := hooks["node"].(map[string]interface{})
+	index, _ := node["index"].(map[string]interface{})
+	template, ok := index["template"].(string)
+	return template, ok
+}
+
+// Enable sets COREPACK_NPM_REGISTRY and the release mirrors of fnm and nvm in
+// the shell rc file, and points Volta's node hooks at the mirror
+func (n *NodeMirror) Enable() error {
+	// Mirrors the user set, e.g. a company one, stay unless Overwrite is set
+	for _, name := range []string{"COREPACK_NPM_REGISTRY", "FNM_NODE_DIST_MIRROR", "NVM_NODEJS_ORG_MIRROR", "N_NODE_MIRROR"} {
+		rcFile, line, value, err := userEnv(name)
+		if err != nil {
+			return err
+		}
+		if line != "" && value != n.registryURL && value != n.distURL && ownSetting("node", rcFile, value) && ownSetting("npm", rcFile, value) && n.resolve(rcFile, line) {
+			return nil
+		}
+	}
+
+	settingsPath := getNvmWindowsSettingsPath()
+	var settings string
+	if settingsPath != "" {
+		if data, err := os.ReadFile(settingsPath); err == nil {
+			settings = string(data)
+		}
+		line, mirror := nvmWindowsMirror(settings)
+		if mirror != "" && mirror != n.distURL && ownSetting("node", settingsPath, mirror) && n.resolve(settingsPath, line) {
+			return nil
+		}
+	}
+
+	hooksPath := getVoltaHooksPath()
+	var hooks map[string]interface{}
+	indexURL := n.distURL + "/index.json"
+	if hooksPath != "" {
+		var err error
+		if hooks, err = readVoltaHooks(hooksPath); err != nil {
+			return err
+		}
+		template, ok := voltaIndexURL(hooks)
+		if ok && template != indexURL && ownSetting("node", hooksPath, strings.TrimSuffix(template, "/index.json")) && n.resolve(hooksPath, "node.index "+template) {
+			return nil
+		}
+	}
+
+	if err := setEnv("node", []envVar{
+		{"COREPACK_NPM_REGISTRY", n.registryURL},
+		{"FNM_NODE_DIST_MIRROR", n.distURL},
+		{"NVM_NODEJS_ORG_MIRROR", n.distURL},
+		{"N_NODE_MIRROR", n.distURL},
+	}); err != nil {
+		return err
+	}
+	if settingsPath != "" {
+		if err := n.enableNvmWindows(settingsPath, settings); err != nil {
+			return err
+		}
+	}
+	if hooksPath == "" {
+		return nil
+	}
+
+	template, _ := voltaIndexURL(hooks)
+	hooks["node"] = map[string]interface{}{
+		"index":  map[string]interface{}{"template": indexURL},
+		"distro": map[string]interface{}{"template": n.distURL + "/v{{version}}/{{filename}}"},
+	}
+	jsonData, err := json.MarshalIndent(hooks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Volta hooks.json: %w", err)
+	}
+	if err := writeConfig(hooksPath, append(jsonData, '\n'), 0644, template == indexURL); err != nil {
+		return fmt.Errorf("failed to write Volta hooks.json: %w", err)
+	}
+
+	return nil
+}
+
+// enableNvmWindows sets node_mirror in nvm-windows' settings.txt, which
+// doesn't read environment variables
+func (n *NodeMirror) enableNvmWindows(settingsPath, settings string) error {
+	line, mirror := nvmWindowsMirror(settings)
+	mirrorLine := "node_mirror: " + n.distURL + "/"
+
+	newLines := []string{}
+	for _, l := range strings.Split(strings.TrimRight(settings, "\r\n"), "\n") {
+		if line != "" && strings.TrimSpace(l) == line {
+			newLines = append(newLines, mirrorLine)
+		} else if strings.TrimSpace(l) != "" {
+			newLines = append(newLines, strings.TrimRight(l, "\r"))
+		}
+	}
+	if line == "" {
+		newLines = append(newLines, mirrorLine)
+	}
+
+	content := strings.Join(newLines, "\r\n") + "\r\n"
+	if err := writeConfig(settingsPath, []byte(content), 0644, mirror == n.distURL); err != nil {
+		return fmt.Errorf("failed to write nvm-windows settings.txt: %w", err)
+	}
+	return nil
+}
+
+// disableNvmWindows empties node_mirror in nvm-windows' settings.txt
+func disableNvmWindows(settingsPath string) error {
+	if restored, err := restoreOriginal(settingsPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read nvm-windows settings.txt: %w", err)
+	}
+	// Without a saved original only a mirror of crosh's is removed
+	line, mirror := nvmWindowsMirror(string(data))
+	if line == "" || ownSetting("node", "", mirror) {
+		return nil
+	}
+
+	content := strings.Replace(string(data), line, "node_mirror:", 1)
+	if err := logging.WriteFile(settingsPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write nvm-windows settings.txt: %w", err)
+	}
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (n *NodeMirror) Disable() error {
+	if err := unsetEnv("node"); err != nil {
+		return err
+	}
+	if settingsPath := getNvmWindowsSettingsPath(); settingsPath != "" {
+		if err := disableNvmWindows(settingsPath); err != nil {
+			return err
+		}
+	}
+
+	hooksPath := getVoltaHooksPath()
+	if hooksPath == "" {
+		return nil
+	}
+	if restored, err := restoreOriginal(hooksPath); restored || err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(hooksPath); os.IsNotExist(err) {
+		return nil // Nothing to disable
+	}
+	hooks, err := readVoltaHooks(hooksPath)
+	if err != nil {
+		return err
+	}
+	// Without a saved original only hooks of crosh's are removed
+	template, ok := voltaIndexURL(hooks)
+	if !ok || ownSetting("node", "", strings.TrimSuffix(template, "/index.json")) {
+		return nil
+	}
+	delete(hooks, "node")
+
+	// If hooks are now empty, remove the file
+	if len(hooks) == 0 {
+		if err := logging.Remove(hooksPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove Volta hooks.json: %w", err)
+		}
+		return nil
+	}
+
+	jsonData, err := json.MarshalIndent(hooks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Volta hooks.json: %w", err)
+	}
+	if err := logging.WriteFile(hooksPath, append(jsonData, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write Volta hooks.json: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (n *NodeMirror) Status() (bool, string, error) {
+	var mirrors []string
+	for _, name := range []string{"FNM_NODE_DIST_MIRROR", "COREPACK_NPM_REGISTRY"} {
+		if url, ok := getEnv("node", name); ok {
+			mirrors = append(mirrors, url)
+		}
+	}
+	if len(mirrors) > 0 {
+		return true, strings.Join(mirrors, ", "), nil
+	}
+	return false, "nodejs.org", nil
+}
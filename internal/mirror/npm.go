@@ -5,10 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
 )
 
 // NPMMirror handles npm registry configuration
 type NPMMirror struct {
+	conflicts
 	registryURL string
 }
 
@@ -37,6 +40,16 @@ func (n *NPMMirror) Enable() error {
 	// Check if registry is already configured
 	lines := strings.Split(existingContent, "\n")
 	registryLine := fmt.Sprintf("registry=%s", n.registryURL)
+
+	// A registry the user set, e.g. a company one, stays unless Overwrite is set
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		registry, ok := strings.CutPrefix(trimmed, "registry=")
+		if ok && registry != n.registryURL && ownSetting("npm", npmrcPath, registry) && n.resolve(npmrcPath, trimmed) {
+			return nil
+		}
+	}
+
 	hasRegistry := false
 	newLines := []string{}
 
@@ -57,7 +70,8 @@ func (n *NPMMirror) Enable() error {
 
 	// Write back to .npmrc
 	content := strings.Join(newLines, "\n") + "\n"
-	if err := os.WriteFile(npmrcPath, []byte(content), 0644); err != nil {
+	ours := strings.Contains(existingContent, registryLine)
+	if err := writeConfig(npmrcPath, []byte(content), 0644, ours); err != nil {
 		return fmt.Errorf("failed to write .npmrc: %w", err)
 	}
 
@@ -72,6 +86,9 @@ func (n *NPMMirror) Disable() error {
 	}
 
 	npmrcPath := filepath.Join(homeDir, ".npmrc")
+	if restored, err := restoreOriginalKeepingBlocks(npmrcPath); restored || err != nil {
+		return err
+	}
 
 	// Read existing .npmrc file
 	data, err := os.ReadFile(npmrcPath)
@@ -96,12 +113,12 @@ func (n *NPMMirror) Disable() error {
 	// Write back
 	if len(newLines) > 0 {
 		content := strings.Join(newLines, "\n") + "\n"
-		if err := os.WriteFile(npmrcPath, []byte(content), 0644); err != nil {
+		if err := logging.WriteFile(npmrcPath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("failed to write .npmrc: %w", err)
 		}
 	} else {
 		// Remove file if empty
-		os.Remove(npmrcPath)
+		logging.Remove(npmrcPath)
 	}
 
 	return nil
@@ -0,0 +1,117 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Settings other than the registry that tools take from ~/.npmrc go in a
+// block per tool, between "# crosh:begin <tool>" and "# crosh:end <tool>",
+// which npm hands to install scripts as npm_config_ variables
+
+// npmrcKey is a key crosh sets in ~/.npmrc
+type npmrcKey struct {
+	name  string
+	value string
+}
+
+// HasNPM reports whether npm is installed
+func HasNPM() bool {
+	_, err := exec.LookPath("npm")
+	return err == nil
+}
+
+// getNpmrcPath returns the path of the user's .npmrc
+func getNpmrcPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".npmrc"), nil
+}
+
+// setNpmrcBlock puts tool's keys in a block at the end of ~/.npmrc, replacing
+// the one set before. npm takes the last of keys set twice, so keys the user
+// set stay above the block when Overwrite is set.
+func setNpmrcBlock(tool string, keys []npmrcKey) error {
+	npmrcPath, err := getNpmrcPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing .npmrc file if it exists
+	var existingContent string
+	if data, err := os.ReadFile(npmrcPath); err == nil {
+		existingContent = string(data)
+	}
+	ours := strings.Contains(existingContent, hashMarkerBegin+" "+tool+"\n")
+	content := envBlock(tool).ReplaceAllString(existingContent, "")
+
+	var b strings.Builder
+	b.WriteString(hashMarkerBegin + " " + tool + "\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", key.name, key.value)
+	}
+	b.WriteString(hashMarkerEnd + " " + tool + "\n")
+
+	if content = strings.TrimRight(content, "\n"); content != "" {
+		content += "\n"
+	}
+	if err := writeConfig(npmrcPath, []byte(content+b.String()), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write .npmrc: %w", err)
+	}
+
+	return nil
+}
+
+// unsetNpmrcBlock removes the block of tool's keys from ~/.npmrc
+func unsetNpmrcBlock(tool string) error {
+	npmrcPath, err := getNpmrcPath()
+	if err != nil {
+		return err
+	}
+	return removeBlock(npmrcPath, tool)
+}
+
+// getNpmrcKey returns the value the block of tool's keys sets name to
+func getNpmrcKey(tool, name string) (string, bool) {
+	npmrcPath, err := getNpmrcPath()
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(npmrcPath)
+	if err != nil {
+		return "", false
+	}
+
+	for _, line := range strings.Split(envBlock(tool).FindString(string(data)), "\n") {
+		if value, ok := strings.CutPrefix(line, name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// userNpmrcKey returns the line of ~/.npmrc, outside crosh's blocks, that
+// sets name, and its value, or "" if there is none
+func userNpmrcKey(name string) (npmrcPath, line, value string, err error) {
+	npmrcPath, err = getNpmrcPath()
+	if err != nil {
+		return "", "", "", err
+	}
+	data, err := os.ReadFile(npmrcPath)
+	if err != nil {
+		return npmrcPath, "", "", nil
+	}
+
+	for _, l := range strings.Split(anyEnvBlock.ReplaceAllString(string(data), ""), "\n") {
+		trimmed := strings.TrimSpace(l)
+		if value, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			return npmrcPath, trimmed, value, nil
+		}
+	}
+	return npmrcPath, "", "", nil
+}
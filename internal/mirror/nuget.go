@@ -0,0 +1,167 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+var (
+	nugetSources      = regexp.MustCompile(`(?s)<packageSources>(.*?)</packageSources>`)
+	nugetSource       = regexp.MustCompile(`<add\s+key="([^"]*)"\s+value="([^"]*)"`)
+	nugetSelfClosing  = regexp.MustCompile(`<(packageSources|disabledPackageSources)\s*/>`)
+	nugetDisabledOrgs = regexp.MustCompile(`(?s)<disabledPackageSources>.*?<add\s+key="nuget\.org"\s+value="true"`)
+)
+
+// NuGetMirror handles NuGet package source configuration
+type NuGetMirror struct {
+	conflicts
+	sourceURL string
+}
+
+// NewNuGetMirror creates a new NuGet mirror handler
+func NewNuGetMirror(sourceURL string) *NuGetMirror {
+	return &NuGetMirror{
+		sourceURL: sourceURL,
+	}
+}
+
+// getNuGetConfigPath returns the path of the per-user NuGet.Config
+func getNuGetConfigPath() (string, error) {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "NuGet", "NuGet.Config"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".nuget", "NuGet", "NuGet.Config"), nil
+}
+
+// Enable adds the mirror as a package source and disables nuget.org, which
+// restores would otherwise still query. Both go between markers in
+// NuGet.Config; the user's own sources stay.
+func (n *NuGetMirror) Enable() error {
+	configPath, err := getNuGetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing NuGet.Config if it exists
+	var existingContent string
+	if data, err := os.ReadFile(configPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, markerBegin)
+	content := markedBlock.ReplaceAllString(existingContent, "")
+	content = nugetSelfClosing.ReplaceAllString(content, "<$1>\n  </$1>")
+
+	// Sources the user added, e.g. a company feed, stay next to the mirror
+	if !tracked(configPath) {
+		for _, section := range nugetSources.FindAllStringSubmatch(content, -1) {
+			for _, match := range nugetSource.FindAllStringSubmatch(section[1], -1) {
+				if match[1] != "nuget.org" && match[2] != n.sourceURL {
+					n.merge(configPath, fmt.Sprintf("package source %s (%s)", match[1], match[2]))
+				}
+			}
+		}
+	}
+
+	source := fmt.Sprintf(`    %s
+    <add key="crosh" value="%s" protocolVersion="3" />
+    %s
+`, markerBegin, n.sourceURL, markerEnd)
+	disabled := fmt.Sprintf(`    %s
+    <add key="nuget.org" value="true" />
+    %s
+`, markerBegin, markerEnd)
+
+	switch {
+	case strings.Contains(content, "<packageSources>"):
+		content = strings.Replace(content, "<packageSources>", "<packageSources>\n"+strings.TrimSuffix(source, "\n"), 1)
+	case strings.Contains(content, "<configuration>"):
+		content = strings.Replace(content, "<configuration>", "<configuration>\n  <packageSources>\n"+source+"  </packageSources>", 1)
+	case strings.TrimSpace(content) == "":
+		content = "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<configuration>\n  <packageSources>\n" + source + "  </packageSources>\n</configuration>\n"
+	default:
+		return fmt.Errorf("failed to understand %s: no <configuration> element", configPath)
+	}
+
+	// nuget.org may be disabled already
+	if !nugetDisabledOrgs.MatchString(content) {
+		if strings.Contains(content, "<disabledPackageSources>") {
+			content = strings.Replace(content, "<disabledPackageSources>", "<disabledPackageSources>\n"+strings.TrimSuffix(disabled, "\n"), 1)
+		} else {
+			content = strings.Replace(content, "</configuration>", "  <disabledPackageSources>\n"+disabled+"  </disabledPackageSources>\n</configuration>", 1)
+		}
+	}
+
+	if err := logging.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create NuGet config directory: %w", err)
+	}
+	if err := writeConfig(configPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write NuGet.Config: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (n *NuGetMirror) Disable() error {
+	configPath, err := getNuGetConfigPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(configPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read NuGet.Config: %w", err)
+	}
+
+	// Remove the blocks between crosh's markers
+	content := markedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+	if err := logging.WriteFile(configPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write NuGet.Config: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (n *NuGetMirror) Status() (bool, string, error) {
+	configPath, err := getNuGetConfigPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default source", nil
+		}
+		return false, "", fmt.Errorf("failed to read NuGet.Config: %w", err)
+	}
+
+	for _, block := range markedBlock.FindAllString(string(data), -1) {
+		if match := nugetSource.FindStringSubmatch(block); match != nil && match[1] == "crosh" {
+			return true, match[2], nil
+		}
+	}
+
+	return false, "default source", nil
+}
@@ -0,0 +1,102 @@
+package mirror
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// opamDefaultURL is where opam's default repository comes from by default
+const opamDefaultURL = "https://opam.ocaml.org"
+
+// opamRepository matches the default repository in the output of opam
+// repository list --all, e.g. "default  https://opam.ocaml.org  <default>"
+var opamRepository = regexp.MustCompile(`(?m)^default\s+(\S+)`)
+
+// OpamMirror handles the default repository opam installs OCaml packages from
+type OpamMirror struct {
+	conflicts
+	repositoryURL string
+}
+
+// NewOpamMirror creates a new opam mirror handler for a mirror of
+// opam-repository
+func NewOpamMirror(repositoryURL string) *OpamMirror {
+	return &OpamMirror{
+		repositoryURL: strings.TrimSuffix(repositoryURL, "/"),
+	}
+}
+
+// HasOpam reports whether opam is installed
+func HasOpam() bool {
+	_, err := exec.LookPath("opam")
+	return err == nil
+}
+
+// opamRepositoryURL returns the URL of opam's default repository
+func opamRepositoryURL() (string, error) {
+	output, err := logging.Command("opam", "repository", "list", "--all").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("opam repository list: %s", strings.TrimSpace(string(output)))
+	}
+	if match := opamRepository.FindSubmatch(output); match != nil {
+		return strings.TrimSuffix(string(match[1]), "/"), nil
+	}
+	return "", nil
+}
+
+// setOpamRepositoryURL points the default repository at url in every switch
+func setOpamRepositoryURL(url string) error {
+	if output, err := logging.Run("opam", "repository", "set-url", "default", url, "--all-switches", "--set-default"); err != nil {
+		return fmt.Errorf("opam repository set-url default: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Enable points opam's default repository at the mirror. Run opam update
+// afterwards to fetch the package index from it.
+func (o *OpamMirror) Enable() error {
+	current, err := opamRepositoryURL()
+	if err != nil {
+		return err
+	}
+	if current == o.repositoryURL {
+		return nil
+	}
+
+	// A repository the user set, e.g. a company one, stays unless Overwrite is set
+	if current != "" && current != opamDefaultURL && ownSetting("opam", "opam", current) && o.resolve("opam", "repository default: "+current) {
+		return nil
+	}
+
+	return setOpamRepositoryURL(o.repositoryURL)
+}
+
+// Disable points the default repository back at opam.ocaml.org if it is
+// the mirror or one of crosh's, leaving a repository the user set alone
+func (o *OpamMirror) Disable() error {
+	current, err := opamRepositoryURL()
+	if err != nil {
+		return err
+	}
+	if current == "" || current == opamDefaultURL || current != o.repositoryURL && ownSetting("opam", "opam", current) {
+		return nil
+	}
+
+	return setOpamRepositoryURL(opamDefaultURL)
+}
+
+// Status checks if the mirror is currently enabled
+func (o *OpamMirror) Status() (bool, string, error) {
+	current, err := opamRepositoryURL()
+	if err != nil {
+		return false, "", err
+	}
+	if current != "" && current != opamDefaultURL {
+		return true, current, nil
+	}
+	return false, "opam.ocaml.org", nil
+}
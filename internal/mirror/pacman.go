@@ -0,0 +1,159 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	pacmanMirrorlistPath = "/etc/pacman.d/mirrorlist"
+	pacmanConfPath       = "/etc/pacman.conf"
+)
+
+// Markers around what crosh adds to config files with # comments, such as pacman.conf
+const (
+	hashMarkerBegin = "# crosh:begin"
+	hashMarkerEnd   = "# crosh:end"
+)
+
+var (
+	hashMarkedBlock = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(hashMarkerBegin) + `\n.*?` + regexp.QuoteMeta(hashMarkerEnd) + `\n?`)
+	pacmanServer    = regexp.MustCompile(`(?m)^Server\s*=\s*(\S+)`)
+)
+
+// PacmanMirror handles Arch Linux pacman mirror configuration
+type PacmanMirror struct {
+	mirrorURL   string
+	archlinuxcn bool
+}
+
+// NewPacmanMirror creates a new pacman mirror handler. With archlinuxcn set,
+// the archlinuxcn repository is added from the same mirror.
+func NewPacmanMirror(mirrorURL string, archlinuxcn bool) *PacmanMirror {
+	return &PacmanMirror{
+		mirrorURL:   mirrorURL,
+		archlinuxcn: archlinuxcn,
+	}
+}
+
+// IsArch reports whether this is Arch Linux or a derivative, which has a pacman mirrorlist
+func IsArch() bool {
+	_, err := os.Stat(pacmanMirrorlistPath)
+	return err == nil
+}
+
+// Enable puts the mirror first in the pacman mirrorlist, between markers so
+// the user's mirrors stay after it as fallbacks
+func (p *PacmanMirror) Enable() error {
+	// Only works on Linux
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("pacman mirror only works on Arch Linux")
+	}
+
+	existing, err := os.ReadFile(pacmanMirrorlistPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("not Arch Linux (no %s)", pacmanMirrorlistPath)
+		}
+		return fmt.Errorf("failed to read mirrorlist: %w", err)
+	}
+
+	ours := strings.Contains(string(existing), hashMarkerBegin)
+	content := fmt.Sprintf("%s\nServer = https://%s/archlinux/$repo/os/$arch\n%s\n", hashMarkerBegin, p.mirrorURL, hashMarkerEnd) +
+		hashMarkedBlock.ReplaceAllString(string(existing), "")
+
+	// Write new mirrorlist (requires root)
+	if err := writeConfig(pacmanMirrorlistPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write mirrorlist (try running with sudo): %w", err)
+	}
+
+	return p.enableArchLinuxCN()
+}
+
+// enableArchLinuxCN adds the archlinuxcn repository to pacman.conf, or
+// removes the one crosh added if archlinuxcn isn't wanted anymore
+func (p *PacmanMirror) enableArchLinuxCN() error {
+	data, err := os.ReadFile(pacmanConfPath)
+	if err != nil {
+		return fmt.Errorf("failed to read pacman.conf: %w", err)
+	}
+
+	ours := strings.Contains(string(data), hashMarkerBegin)
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if p.archlinuxcn {
+		// A repository section the user added stays as it is
+		if strings.Contains(content, "[archlinuxcn]") {
+			return nil
+		}
+		content = strings.TrimRight(content, "\n") + fmt.Sprintf("\n\n%s\n[archlinuxcn]\nServer = https://%s/archlinuxcn/$arch\n%s\n", hashMarkerBegin, p.mirrorURL, hashMarkerEnd)
+	} else if !ours {
+		return nil
+	}
+
+	if err := writeConfig(pacmanConfPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write pacman.conf (try running with sudo): %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror, and archlinuxcn if crosh added it
+func (p *PacmanMirror) Disable() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("pacman mirror only works on Arch Linux")
+	}
+
+	for _, path := range []string{pacmanMirrorlistPath, pacmanConfPath} {
+		restored, err := restoreOriginal(path)
+		if err != nil {
+			return err
+		}
+		if restored {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // Nothing to disable
+			}
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		// Remove the block between crosh's markers
+		content := hashMarkedBlock.ReplaceAllString(string(data), "")
+		if content == string(data) {
+			continue
+		}
+		if err := logging.WriteFile(path, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (p *PacmanMirror) Status() (bool, string, error) {
+	if runtime.GOOS != "linux" {
+		return false, "", fmt.Errorf("pacman mirror only works on Arch Linux")
+	}
+
+	data, err := os.ReadFile(pacmanMirrorlistPath)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read mirrorlist: %w", err)
+	}
+
+	if block := hashMarkedBlock.FindString(string(data)); block != "" {
+		if match := pacmanServer.FindStringSubmatch(block); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	return false, "default mirrors", nil
+}
@@ -5,10 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
 )
 
 // PipMirror handles pip index configuration
 type PipMirror struct {
+	conflicts
 	indexURL string
 }
 
@@ -28,7 +31,7 @@ func getPipConfigPath() (string, error) {
 
 	// Linux/macOS: ~/.config/pip/pip.conf
 	configDir := filepath.Join(homeDir, ".config", "pip")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := logging.MkdirAll(configDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create pip config directory: %w", err)
 	}
 
@@ -50,6 +53,28 @@ func (p *PipMirror) Enable() error {
 
 	// Parse or create [global] section
 	lines := strings.Split(existingContent, "\n")
+
+	// An index the user set stays unless Overwrite is set; extra indexes stay next to the mirror
+	var extraIndexes []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		key, value, _ := strings.Cut(trimmed, "=")
+		switch strings.TrimSpace(key) {
+		case "index-url":
+			value = strings.TrimSpace(value)
+			if value != p.indexURL && ownSetting("pip", pipConfigPath, value) && p.resolve(pipConfigPath, trimmed) {
+				return nil
+			}
+		case "extra-index-url":
+			extraIndexes = append(extraIndexes, trimmed)
+		}
+	}
+	if !tracked(pipConfigPath) {
+		for _, extra := range extraIndexes {
+			p.merge(pipConfigPath, extra)
+		}
+	}
+
 	hasGlobalSection := false
 	hasIndexURL := false
 	newLines := []string{}
@@ -100,7 +125,8 @@ func (p *PipMirror) Enable() error {
 
 	// Write back
 	content := strings.Join(newLines, "\n") + "\n"
-	if err := os.WriteFile(pipConfigPath, []byte(content), 0644); err != nil {
+	ours := strings.Contains(existingContent, fmt.Sprintf("index-url = %s", p.indexURL))
+	if err := writeConfig(pipConfigPath, []byte(content), 0644, ours); err != nil {
 		return fmt.Errorf("failed to write pip config: %w", err)
 	}
 
@@ -113,6 +139,9 @@ func (p *PipMirror) Disable() error {
 	if err != nil {
 		return err
 	}
+	if restored, err := restoreOriginal(pipConfigPath); restored || err != nil {
+		return err
+	}
 
 	data, err := os.ReadFile(pipConfigPath)
 	if err != nil {
@@ -136,11 +165,11 @@ func (p *PipMirror) Disable() error {
 	// Write back or remove file if empty
 	if len(newLines) > 0 {
 		content := strings.Join(newLines, "\n") + "\n"
-		if err := os.WriteFile(pipConfigPath, []byte(content), 0644); err != nil {
+		if err := logging.WriteFile(pipConfigPath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("failed to write pip config: %w", err)
 		}
 	} else {
-		os.Remove(pipConfigPath)
+		logging.Remove(pipConfigPath)
 	}
 
 	return nil
@@ -0,0 +1,169 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// PnpmMirror handles the registry in pnpm's global rc file, which wins over
+// ~/.npmrc
+type PnpmMirror struct {
+	conflicts
+	registryURL string
+}
+
+// NewPnpmMirror creates a new pnpm mirror handler
+func NewPnpmMirror(registryURL string) *PnpmMirror {
+	return &PnpmMirror{
+		registryURL: registryURL,
+	}
+}
+
+// HasPnpm reports whether pnpm is installed
+func HasPnpm() bool {
+	_, err := exec.LookPath("pnpm")
+	return err == nil
+}
+
+// getPnpmrcPath returns the path of pnpm's global rc file, where
+// pnpm config set --global writes
+func getPnpmrcPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pnpm", "rc"), nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "pnpm", "config", "rc"), nil
+	case "darwin":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		return filepath.Join(homeDir, "Library", "Preferences", "pnpm", "rc"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "pnpm", "rc"), nil
+}
+
+// Enable configures pnpm to use the mirror registry
+func (p *PnpmMirror) Enable() error {
+	pnpmrcPath, err := getPnpmrcPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing rc file if it exists
+	var existingContent string
+	if data, err := os.ReadFile(pnpmrcPath); err == nil {
+		existingContent = string(data)
+	}
+
+	lines := strings.Split(existingContent, "\n")
+	registryLine := fmt.Sprintf("registry=%s", p.registryURL)
+
+	// A registry the user set, e.g. a company one, stays unless Overwrite is set
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		registry, ok := strings.CutPrefix(trimmed, "registry=")
+		if ok && registry != p.registryURL && ownSetting("npm", pnpmrcPath, registry) && p.resolve(pnpmrcPath, trimmed) {
+			return nil
+		}
+	}
+
+	newLines := []string{}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "registry=") && trimmed != "" {
+			newLines = append(newLines, line)
+		}
+	}
+	newLines = append(newLines, registryLine)
+
+	if err := logging.MkdirAll(filepath.Dir(pnpmrcPath), 0755); err != nil {
+		return fmt.Errorf("failed to create pnpm config directory: %w", err)
+	}
+	content := strings.Join(newLines, "\n") + "\n"
+	ours := strings.Contains(existingContent, registryLine)
+	if err := writeConfig(pnpmrcPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write pnpm rc: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (p *PnpmMirror) Disable() error {
+	pnpmrcPath, err := getPnpmrcPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(pnpmrcPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(pnpmrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read pnpm rc: %w", err)
+	}
+
+	// Remove registry line
+	newLines := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "registry=") && trimmed != "" {
+			newLines = append(newLines, line)
+		}
+	}
+
+	// Remove file if empty
+	if len(newLines) == 0 {
+		if err := logging.Remove(pnpmrcPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pnpm rc: %w", err)
+		}
+		return nil
+	}
+
+	content := strings.Join(newLines, "\n") + "\n"
+	if err := logging.WriteFile(pnpmrcPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write pnpm rc: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (p *PnpmMirror) Status() (bool, string, error) {
+	pnpmrcPath, err := getPnpmrcPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(pnpmrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default registry", nil
+		}
+		return false, "", fmt.Errorf("failed to read pnpm rc: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if registry, ok := strings.CutPrefix(strings.TrimSpace(line), "registry="); ok {
+			return true, registry, nil
+		}
+	}
+
+	return false, "default registry", nil
+}
@@ -0,0 +1,72 @@
+package mirror
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PyenvMirror handles where pyenv's python-build downloads CPython sources from
+type PyenvMirror struct {
+	conflicts
+	mirrorURL string
+}
+
+// NewPyenvMirror creates a new pyenv mirror handler for a mirror laid out like
+// https://www.python.org/ftp/python
+func NewPyenvMirror(mirrorURL string) *PyenvMirror {
+	return &PyenvMirror{
+		mirrorURL: strings.TrimSuffix(mirrorURL, "/"),
+	}
+}
+
+// HasPyenv reports whether pyenv is installed
+func HasPyenv() bool {
+	if _, err := exec.LookPath("pyenv"); err == nil {
+		return true
+	}
+	root := os.Getenv("PYENV_ROOT")
+	if root == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		root = filepath.Join(homeDir, ".pyenv")
+	}
+	_, err := os.Stat(root)
+	return err == nil
+}
+
+// Enable sets PYTHON_BUILD_MIRROR_URL in the shell rc file. Mirrors like
+// npmmirror's don't serve python-build's checksum-named files, so
+// PYTHON_BUILD_MIRROR_URL_SKIP_CHECKSUM makes it fetch by the python.org path;
+// python-build still verifies the checksums.
+func (p *PyenvMirror) Enable() error {
+	// A mirror the user set, e.g. a company one, stays unless Overwrite is set
+	rcFile, line, value, err := userEnv("PYTHON_BUILD_MIRROR_URL")
+	if err != nil {
+		return err
+	}
+	if line != "" && strings.TrimSuffix(value, "/") != p.mirrorURL && ownSetting("pyenv", rcFile, value) && p.resolve(rcFile, line) {
+		return nil
+	}
+
+	return setEnv("pyenv", []envVar{
+		{"PYTHON_BUILD_MIRROR_URL", p.mirrorURL},
+		{"PYTHON_BUILD_MIRROR_URL_SKIP_CHECKSUM", "1"},
+	})
+}
+
+// Disable removes the mirror configuration
+func (p *PyenvMirror) Disable() error {
+	return unsetEnv("pyenv")
+}
+
+// Status checks if the mirror is currently enabled
+func (p *PyenvMirror) Status() (bool, string, error) {
+	if url, ok := getEnv("pyenv", "PYTHON_BUILD_MIRROR_URL"); ok {
+		return true, url, nil
+	}
+	return false, "python.org", nil
+}
@@ -0,0 +1,157 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	sbtHeader = "# Mirrors set by crosh; crosh off puts the original back"
+	// sbtIvyPattern is the layout of sbt plugins in Ivy repositories
+	sbtIvyPattern = "[organization]/[module]/(scala_[scalaVersion]/)(sbt_[sbtVersion]/)[revision]/[type]s/[artifact](-[classifier]).[ext]"
+)
+
+var sbtMavenRepo = regexp.MustCompile(`(?m)^\s*crosh-maven:\s*(\S+)`)
+
+// SbtMirror handles sbt resolver configuration
+type SbtMirror struct {
+	conflicts
+	mavenURL string
+	ivyURL   string
+}
+
+// NewSbtMirror creates a new sbt mirror handler for a Maven repository and
+// an Ivy repository of sbt plugins
+func NewSbtMirror(mavenURL, ivyURL string) *SbtMirror {
+	return &SbtMirror{
+		mavenURL: mavenURL,
+		ivyURL:   ivyURL,
+	}
+}
+
+// getSbtDir returns sbt's per-user directory
+func getSbtDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".sbt"), nil
+}
+
+// getSbtRepositoriesPath returns the path of sbt's repositories file
+func getSbtRepositoriesPath() (string, error) {
+	dir, err := getSbtDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "repositories"), nil
+}
+
+// HasSbt reports whether sbt has been run by this user
+func HasSbt() bool {
+	dir, err := getSbtDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(dir)
+	return err == nil
+}
+
+// Enable writes ~/.sbt/repositories with the mirrors first and the official
+// repositories after them as fallbacks. sbt reads it for its own launcher;
+// builds only use it instead of their resolvers with
+// -Dsbt.override.build.repos=true.
+func (s *SbtMirror) Enable() error {
+	repositoriesPath, err := getSbtRepositoriesPath()
+	if err != nil {
+		return err
+	}
+
+	// A repositories file the user wrote, e.g. for a company Nexus, stays unless Overwrite is set
+	existing, err := os.ReadFile(repositoriesPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read sbt repositories: %w", err)
+	}
+	ours := strings.HasPrefix(string(existing), sbtHeader)
+	if err == nil && !ours && !tracked(repositoriesPath) && s.resolve(repositoriesPath, "[repositories]") {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(sbtHeader + "\n")
+	b.WriteString("[repositories]\n")
+	b.WriteString("  local\n")
+	fmt.Fprintf(&b, "  crosh-maven: %s\n", s.mavenURL)
+	if s.ivyURL != "" {
+		fmt.Fprintf(&b, "  crosh-ivy: %s, %s\n", s.ivyURL, sbtIvyPattern)
+	}
+	b.WriteString("  maven-central\n")
+	fmt.Fprintf(&b, "  sbt-plugin-releases: https://repo.scala-sbt.org/scalasbt/sbt-plugin-releases/, %s\n", sbtIvyPattern)
+
+	if err := logging.MkdirAll(filepath.Dir(repositoriesPath), 0755); err != nil {
+		return fmt.Errorf("failed to create sbt directory: %w", err)
+	}
+	if err := writeConfig(repositoriesPath, []byte(b.String()), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write sbt repositories: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (s *SbtMirror) Disable() error {
+	repositoriesPath, err := getSbtRepositoriesPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(repositoriesPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(repositoriesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read sbt repositories: %w", err)
+	}
+	if !strings.HasPrefix(string(data), sbtHeader) {
+		return nil
+	}
+
+	// The whole file is crosh's
+	if err := logging.Remove(repositoriesPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sbt repositories: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (s *SbtMirror) Status() (bool, string, error) {
+	repositoriesPath, err := getSbtRepositoriesPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(repositoriesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "default repositories", nil
+		}
+		return false, "", fmt.Errorf("failed to read sbt repositories: %w", err)
+	}
+
+	if strings.HasPrefix(string(data), sbtHeader) {
+		if match := sbtMavenRepo.FindSubmatch(data); match != nil {
+			return true, string(match[1]), nil
+		}
+	}
+
+	return false, "default repositories", nil
+}
@@ -0,0 +1,182 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// scoopOfficialBuckets are where Scoop's known buckets come from by default
+var scoopOfficialBuckets = map[string]string{
+	"main":     "https://github.com/ScoopInstaller/Main",
+	"extras":   "https://github.com/ScoopInstaller/Extras",
+	"versions": "https://github.com/ScoopInstaller/Versions",
+	"java":     "https://github.com/ScoopInstaller/Java",
+}
+
+// ScoopMirror handles the git repositories of Scoop's buckets on Windows
+type ScoopMirror struct {
+	conflicts
+	buckets  map[string]string // bucket name to mirror URL
+	mirrored []string
+}
+
+// NewScoopMirror creates a new Scoop mirror handler for the buckets in
+// buckets, e.g. main, each with the URL of its mirror
+func NewScoopMirror(buckets map[string]string) *ScoopMirror {
+	return &ScoopMirror{
+		buckets: buckets,
+	}
+}
+
+// getScoopBucketsDir returns the directory Scoop keeps its buckets in,
+// under $SCOOP or ~/scoop
+func getScoopBucketsDir() (string, error) {
+	dir := os.Getenv("SCOOP")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		dir = filepath.Join(homeDir, "scoop")
+	}
+	return filepath.Join(dir, "buckets"), nil
+}
+
+// HasScoop reports whether Scoop has buckets set up for this user
+func HasScoop() bool {
+	dir, err := getScoopBucketsDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(dir)
+	return err == nil
+}
+
+// names returns the names of the buckets to mirror in a stable order
+func (s *ScoopMirror) names() []string {
+	names := make([]string, 0, len(s.buckets))
+	for name, url := range s.buckets {
+		if url != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mirrored returns the names of the buckets the last Enable pointed at their mirrors
+func (s *ScoopMirror) Mirrored() []string {
+	return s.mirrored
+}
+
+// Enable points the git repositories of the added buckets at their mirrors,
+// so scoop update fetches manifests from them. Buckets that aren't added are
+// left out, as scoop bucket add takes the mirror itself.
+func (s *ScoopMirror) Enable() error {
+	dir, err := getScoopBucketsDir()
+	if err != nil {
+		return err
+	}
+
+	s.mirrored = nil
+	for _, name := range s.names() {
+		mirrorURL := s.buckets[name]
+		configPath := filepath.Join(dir, name, ".git", "config")
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read %s bucket config: %w", name, err)
+		}
+
+		current := originURL(string(data))
+		switch {
+		case current == "":
+			continue
+		case current == mirrorURL:
+			s.mirrored = append(s.mirrored, name)
+			continue
+		case strings.TrimSuffix(current, ".git") == scoopOfficialBuckets[name]:
+			// The official bucket is what the mirror replaces
+		case ownSetting("scoop", configPath, current) && s.resolve(configPath, fmt.Sprintf("%s bucket %s", name, current)):
+			// A bucket the user pointed elsewhere, e.g. at a fork, stays unless Overwrite is set
+			continue
+		}
+
+		content, ok := setOriginURL(string(data), mirrorURL)
+		if !ok {
+			return fmt.Errorf("no origin remote in %s", configPath)
+		}
+		if err := writeConfig(configPath, []byte(content), 0644, false); err != nil {
+			return fmt.Errorf("failed to write %s bucket config: %w", name, err)
+		}
+		s.mirrored = append(s.mirrored, name)
+	}
+
+	return nil
+}
+
+// Disable points the mirrored buckets back at their official repositories
+func (s *ScoopMirror) Disable() error {
+	dir, err := getScoopBucketsDir()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range s.names() {
+		configPath := filepath.Join(dir, name, ".git", "config")
+		restored, err := restoreOriginal(configPath)
+		if err != nil {
+			return err
+		}
+		if restored {
+			continue
+		}
+
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // Nothing to disable
+			}
+			return fmt.Errorf("failed to read %s bucket config: %w", name, err)
+		}
+		official := scoopOfficialBuckets[name]
+		if official == "" || originURL(string(data)) != s.buckets[name] {
+			continue
+		}
+
+		content, _ := setOriginURL(string(data), official)
+		if err := logging.WriteFile(configPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s bucket config: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (s *ScoopMirror) Status() (bool, string, error) {
+	dir, err := getScoopBucketsDir()
+	if err != nil {
+		return false, "", err
+	}
+
+	var mirrored []string
+	for _, name := range s.names() {
+		data, err := os.ReadFile(filepath.Join(dir, name, ".git", "config"))
+		if err == nil && originURL(string(data)) == s.buckets[name] {
+			mirrored = append(mirrored, name)
+		}
+	}
+	if len(mirrored) > 0 {
+		return true, strings.Join(mirrored, ", "), nil
+	}
+
+	return false, "default buckets", nil
+}
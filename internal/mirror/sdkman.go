@@ -0,0 +1,68 @@
+package mirror
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SdkmanMirror handles the candidates API SDKMAN lists and downloads JDKs,
+// Gradle, Maven and other JVM tools from
+type SdkmanMirror struct {
+	conflicts
+	apiURL string
+}
+
+// NewSdkmanMirror creates a new SDKMAN mirror handler for a mirror of
+// https://api.sdkman.io/2
+func NewSdkmanMirror(apiURL string) *SdkmanMirror {
+	return &SdkmanMirror{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+	}
+}
+
+// HasSdkman reports whether SDKMAN is installed. sdk is a shell function,
+// so this looks for its directory instead of the PATH.
+func HasSdkman() bool {
+	dir := os.Getenv("SDKMAN_DIR")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		dir = filepath.Join(homeDir, ".sdkman")
+	}
+	_, err := os.Stat(dir)
+	return err == nil
+}
+
+// Enable sets SDKMAN_CANDIDATES_API in the shell rc file. sdk reads it on
+// every call, so it wins over the default sdkman-init.sh exports even though
+// SDKMAN wants to be sourced at the end of the file.
+func (s *SdkmanMirror) Enable() error {
+	// An API the user set, e.g. a company one, stays unless Overwrite is set
+	rcFile, line, value, err := userEnv("SDKMAN_CANDIDATES_API")
+	if err != nil {
+		return err
+	}
+	if line != "" && strings.TrimSuffix(value, "/") != s.apiURL && ownSetting("sdkman", rcFile, value) && s.resolve(rcFile, line) {
+		return nil
+	}
+
+	return setEnv("sdkman", []envVar{
+		{"SDKMAN_CANDIDATES_API", s.apiURL},
+	})
+}
+
+// Disable removes the mirror configuration
+func (s *SdkmanMirror) Disable() error {
+	return unsetEnv("sdkman")
+}
+
+// Status checks if the mirror is currently enabled
+func (s *SdkmanMirror) Status() (bool, string, error) {
+	if url, ok := getEnv("sdkman", "SDKMAN_CANDIDATES_API"); ok {
+		return true, url, nil
+	}
+	return false, "api.sdkman.io", nil
+}
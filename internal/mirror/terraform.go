@@ -0,0 +1,185 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+var terraformMirrorURL = regexp.MustCompile(`(?m)^\s*url\s*=\s*"([^"]*)"`)
+
+// TerraformMirror handles the network mirror Terraform installs providers from
+type TerraformMirror struct {
+	conflicts
+	mirrorURL string
+}
+
+// NewTerraformMirror creates a new Terraform mirror handler for a provider
+// network mirror, which serves the providers of registry.terraform.io
+func NewTerraformMirror(mirrorURL string) *TerraformMirror {
+	if mirrorURL != "" && !strings.HasSuffix(mirrorURL, "/") {
+		mirrorURL += "/"
+	}
+	return &TerraformMirror{
+		mirrorURL: mirrorURL,
+	}
+}
+
+// HasTerraform reports whether Terraform is installed
+func HasTerraform() bool {
+	_, err := exec.LookPath("terraform")
+	return err == nil
+}
+
+// getTerraformRCPath returns the path of Terraform's CLI configuration file
+func getTerraformRCPath() (string, error) {
+	if path := os.Getenv("TF_CLI_CONFIG_FILE"); path != "" {
+		return path, nil
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "terraform.rc"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".terraformrc"), nil
+}
+
+// providerInstallation returns the lines of the provider_installation block
+// in lines, from its first line to its closing brace, or -1 if there is none
+func providerInstallation(lines []string) (int, int) {
+	start, depth := -1, 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 {
+			if !strings.HasPrefix(trimmed, "provider_installation") {
+				continue
+			}
+			start = i
+		}
+		depth += strings.Count(trimmed, "{") - strings.Count(trimmed, "}")
+		if depth <= 0 && strings.Contains(trimmed, "}") {
+			return start, i
+		}
+	}
+	return start, len(lines) - 1
+}
+
+// Enable adds a provider_installation block with the network mirror to the
+// CLI configuration file, between markers
+func (t *TerraformMirror) Enable() error {
+	rcPath, err := getTerraformRCPath()
+	if err != nil {
+		return err
+	}
+
+	// Read existing CLI configuration if it exists
+	var existingContent string
+	if data, err := os.ReadFile(rcPath); err == nil {
+		existingContent = string(data)
+	}
+
+	ours := strings.Contains(existingContent, hashMarkerBegin)
+	content := strings.TrimRight(hashMarkedBlock.ReplaceAllString(existingContent, ""), "\n")
+
+	// Installation methods the user set up, e.g. a company mirror, stay unless
+	// Overwrite is set; Terraform takes only one provider_installation block,
+	// so with Overwrite theirs goes
+	lines := strings.Split(content, "\n")
+	if start, end := providerInstallation(lines); start >= 0 {
+		if t.resolve(rcPath, strings.TrimSpace(lines[start])+" …") {
+			return nil
+		}
+		lines = slices.Delete(lines, start, end+1)
+	}
+	content = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+
+	if content != "" {
+		content += "\n\n"
+	}
+	content += fmt.Sprintf(`%s
+provider_installation {
+  network_mirror {
+    url = %q
+  }
+}
+%s
+`, hashMarkerBegin, t.mirrorURL, hashMarkerEnd)
+
+	if err := writeConfig(rcPath, []byte(content), 0644, ours); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcPath, err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (t *TerraformMirror) Disable() error {
+	rcPath, err := getTerraformRCPath()
+	if err != nil {
+		return err
+	}
+	if restored, err := restoreOriginal(rcPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(rcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read %s: %w", rcPath, err)
+	}
+
+	// Remove the block between crosh's markers
+	content := hashMarkedBlock.ReplaceAllString(string(data), "")
+	if content == string(data) {
+		return nil
+	}
+
+	// Remove file if empty
+	if strings.TrimSpace(content) == "" {
+		if err := logging.Remove(rcPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", rcPath, err)
+		}
+		return nil
+	}
+
+	if err := logging.WriteFile(rcPath, []byte(strings.TrimRight(content, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcPath, err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (t *TerraformMirror) Status() (bool, string, error) {
+	rcPath, err := getTerraformRCPath()
+	if err != nil {
+		return false, "", err
+	}
+
+	data, err := os.ReadFile(rcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, "registry.terraform.io", nil
+		}
+		return false, "", fmt.Errorf("failed to read %s: %w", rcPath, err)
+	}
+
+	if block := hashMarkedBlock.FindString(string(data)); block != "" {
+		if match := terraformMirrorURL.FindStringSubmatch(block); match != nil {
+			return true, match[1], nil
+		}
+	}
+
+	return false, "registry.terraform.io", nil
+}
@@ -0,0 +1,147 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// texLiveDefaultURL is the CTAN redirector tlmgr updates from by default
+const texLiveDefaultURL = "https://mirror.ctan.org/systems/texlive/tlnet"
+
+// tlmgrRepository matches the repository in the output of tlmgr option repository
+var tlmgrRepository = regexp.MustCompile(`\(repository\):\s*(\S+)`)
+
+// TeXLiveMirror handles the package repository of TeX Live's tlmgr
+type TeXLiveMirror struct {
+	conflicts
+	mirrorURL string
+}
+
+// NewTeXLiveMirror creates a new TeX Live mirror handler
+func NewTeXLiveMirror(mirrorURL string) *TeXLiveMirror {
+	return &TeXLiveMirror{
+		mirrorURL: strings.TrimSuffix(mirrorURL, "/"),
+	}
+}
+
+// HasTeXLive reports whether TeX Live's tlmgr is installed
+func HasTeXLive() bool {
+	_, err := exec.LookPath("tlmgr")
+	return err == nil
+}
+
+// getTlmgrOriginalPath returns the file the repository tlmgr used before
+// crosh set it is saved in, next to the originals of config files
+func getTlmgrOriginalPath() (string, error) {
+	dir, err := BackupDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tlmgr-repository"), nil
+}
+
+// tlmgrRepositoryURL returns the repository tlmgr updates from
+func tlmgrRepositoryURL() (string, error) {
+	output, err := logging.Command("tlmgr", "option", "repository").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("tlmgr option repository: %s", strings.TrimSpace(string(output)))
+	}
+	match := tlmgrRepository.FindSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("failed to read the repository from tlmgr")
+	}
+	return strings.TrimSuffix(string(match[1]), "/"), nil
+}
+
+// setTlmgrRepository makes tlmgr update from url
+func setTlmgrRepository(url string) error {
+	if output, err := logging.Run("tlmgr", "option", "repository", url); err != nil {
+		return fmt.Errorf("tlmgr option repository %s (try running with sudo): %s", url, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Enable sets tlmgr's repository to the mirror with tlmgr option repository,
+// saving the repository it used before so Disable can set it back
+func (t *TeXLiveMirror) Enable() error {
+	current, err := tlmgrRepositoryURL()
+	if err != nil {
+		return err
+	}
+	if current == t.mirrorURL {
+		return nil
+	}
+
+	originalPath, err := getTlmgrOriginalPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(originalPath); os.IsNotExist(err) {
+		// A repository the user chose, e.g. a local copy of tlnet, stays unless Overwrite is set
+		if current != texLiveDefaultURL && current != "ctan" && ownSetting("texlive", "tlmgr", current) && t.resolve("tlmgr", "repository "+current) {
+			return nil
+		}
+		if err := logging.MkdirAll(filepath.Dir(originalPath), 0700); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		if err := logging.WriteFile(originalPath, []byte(current+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to save tlmgr's repository: %w", err)
+		}
+	}
+
+	return setTlmgrRepository(t.mirrorURL)
+}
+
+// Disable sets tlmgr's repository back to the one it used before, or to the
+// CTAN redirector
+func (t *TeXLiveMirror) Disable() error {
+	originalPath, err := getTlmgrOriginalPath()
+	if err != nil {
+		return err
+	}
+
+	original := texLiveDefaultURL
+	data, err := os.ReadFile(originalPath)
+	if err == nil {
+		original = strings.TrimSpace(string(data))
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read tlmgr's saved repository: %w", err)
+	}
+
+	current, err := tlmgrRepositoryURL()
+	if err != nil {
+		return err
+	}
+	// Without a saved repository only a mirror of crosh's is set back
+	if current != original && (data != nil || !ownSetting("texlive", "", current)) {
+		if err := setTlmgrRepository(original); err != nil {
+			return err
+		}
+	}
+
+	if data != nil {
+		if err := logging.Remove(originalPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove tlmgr's saved repository: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (t *TeXLiveMirror) Status() (bool, string, error) {
+	current, err := tlmgrRepositoryURL()
+	if err != nil {
+		return false, "", err
+	}
+	if current != texLiveDefaultURL && current != "ctan" {
+		return true, current, nil
+	}
+	return false, "CTAN", nil
+}
@@ -0,0 +1,110 @@
+package mirror
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// wingetDefaultURL is where the winget source comes from by default
+const wingetDefaultURL = "https://cdn.winget.microsoft.com/cache"
+
+// wingetSource matches the winget source in the output of winget source list, e.g.
+// "winget  https://cdn.winget.microsoft.com/cache  false"
+var wingetSource = regexp.MustCompile(`(?m)^winget\s+(\S+)`)
+
+// WingetMirror handles the winget source, the package index winget looks
+// packages up in, on Windows
+type WingetMirror struct {
+	conflicts
+	sourceURL string
+}
+
+// NewWingetMirror creates a new winget mirror handler for a mirror of the
+// winget source
+func NewWingetMirror(sourceURL string) *WingetMirror {
+	return &WingetMirror{
+		sourceURL: strings.TrimSuffix(sourceURL, "/"),
+	}
+}
+
+// HasWinget reports whether winget is installed, which it only is on Windows
+func HasWinget() bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	_, err := exec.LookPath("winget")
+	return err == nil
+}
+
+// wingetSourceURL returns the URL of the winget source, or "" if it was removed
+func wingetSourceURL() (string, error) {
+	output, err := logging.Command("winget", "source", "list").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("winget source list: %s", strings.TrimSpace(string(output)))
+	}
+	if match := wingetSource.FindSubmatch(output); match != nil {
+		return strings.TrimSuffix(string(match[1]), "/"), nil
+	}
+	return "", nil
+}
+
+// Enable replaces the winget source with the mirror. It keeps the name
+// winget, so packages aren't found twice and manifests still match it.
+func (w *WingetMirror) Enable() error {
+	current, err := wingetSourceURL()
+	if err != nil {
+		return err
+	}
+	if current == w.sourceURL {
+		return nil
+	}
+
+	// A source the user set, e.g. a company one, stays unless Overwrite is set
+	if current != "" && current != wingetDefaultURL && ownSetting("winget", "winget", current) && w.resolve("winget", "source winget: "+current) {
+		return nil
+	}
+
+	if current != "" {
+		if output, err := logging.Run("winget", "source", "remove", "winget"); err != nil {
+			return fmt.Errorf("winget source remove winget (try running as administrator): %s", strings.TrimSpace(string(output)))
+		}
+	}
+	if output, err := logging.Run("winget", "source", "add", "winget", w.sourceURL, "--trust-level", "trusted", "--accept-source-agreements"); err != nil {
+		return fmt.Errorf("winget source add winget (try running as administrator): %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Disable resets the winget source to Microsoft's if it is the mirror or
+// one of crosh's, leaving a source the user set alone
+func (w *WingetMirror) Disable() error {
+	current, err := wingetSourceURL()
+	if err != nil {
+		return err
+	}
+	if current == "" || current == wingetDefaultURL || current != w.sourceURL && ownSetting("winget", "winget", current) {
+		return nil
+	}
+
+	if output, err := logging.Run("winget", "source", "reset", "winget", "--force"); err != nil {
+		return fmt.Errorf("winget source reset winget (try running as administrator): %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Status checks if the mirror is currently enabled
+func (w *WingetMirror) Status() (bool, string, error) {
+	current, err := wingetSourceURL()
+	if err != nil {
+		return false, "", err
+	}
+	if current != "" && current != wingetDefaultURL {
+		return true, current, nil
+	}
+	return false, "cdn.winget.microsoft.com", nil
+}
@@ -0,0 +1,235 @@
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// YarnMirror handles the registry of Yarn, both classic (1.x), which reads
+// ~/.yarnrc, and berry (2+), which reads npmRegistryServer from ~/.yarnrc.yml
+// and ignores .npmrc
+type YarnMirror struct {
+	conflicts
+	registryURL string
+}
+
+// NewYarnMirror creates a new Yarn mirror handler
+func NewYarnMirror(registryURL string) *YarnMirror {
+	return &YarnMirror{
+		registryURL: registryURL,
+	}
+}
+
+// HasYarn reports whether Yarn is installed
+func HasYarn() bool {
+	_, err := exec.LookPath("yarn")
+	return err == nil
+}
+
+// getYarnrcPaths returns the paths of Yarn classic's ~/.yarnrc and Yarn berry's ~/.yarnrc.yml
+func getYarnrcPaths() (string, string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".yarnrc"), filepath.Join(homeDir, ".yarnrc.yml"), nil
+}
+
+// yarnrcRegistry returns the registry line of a .yarnrc and the URL it
+// sets, or "" if it has none
+func yarnrcRegistry(content string) (string, string) {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if key, value, ok := strings.Cut(trimmed, " "); ok && key == "registry" {
+			return trimmed, strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return "", ""
+}
+
+// Enable sets the registry in both config files, since which Yarn runs can
+// differ per project
+func (y *YarnMirror) Enable() error {
+	yarnrcPath, yarnrcYmlPath, err := getYarnrcPaths()
+	if err != nil {
+		return err
+	}
+
+	// Read existing .yarnrc and .yarnrc.yml if they exist
+	var existingContent string
+	if data, err := os.ReadFile(yarnrcPath); err == nil {
+		existingContent = string(data)
+	}
+	mapping, err := readYAMLMapping(yarnrcYmlPath)
+	if err != nil {
+		return err
+	}
+
+	// A registry the user set, e.g. a company one, stays unless Overwrite is set
+	line, registry := yarnrcRegistry(existingContent)
+	if line != "" && registry != y.registryURL && ownSetting("npm", yarnrcPath, registry) && y.resolve(yarnrcPath, line) {
+		return nil
+	}
+	server := mappingKey(mapping, "npmRegistryServer")
+	if server != nil && server.Value != y.registryURL && ownSetting("npm", yarnrcYmlPath, server.Value) && y.resolve(yarnrcYmlPath, "npmRegistryServer: "+server.Value) {
+		return nil
+	}
+
+	registryLine := fmt.Sprintf("registry %q", y.registryURL)
+	newLines := []string{}
+	for _, l := range strings.Split(existingContent, "\n") {
+		if trimmed := strings.TrimSpace(l); trimmed != "" && trimmed != line {
+			newLines = append(newLines, l)
+		}
+	}
+	newLines = append(newLines, registryLine)
+	content := strings.Join(newLines, "\n") + "\n"
+	if err := writeConfig(yarnrcPath, []byte(content), 0644, registry == y.registryURL); err != nil {
+		return fmt.Errorf("failed to write .yarnrc: %w", err)
+	}
+
+	ours := server != nil && server.Value == y.registryURL
+	setMappingKey(mapping, "npmRegistryServer", &yaml.Node{Kind: yaml.ScalarNode, Value: y.registryURL})
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(yarnrcYmlPath, data, 0644, ours); err != nil {
+		return fmt.Errorf("failed to write .yarnrc.yml: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the mirror configuration
+func (y *YarnMirror) Disable() error {
+	yarnrcPath, yarnrcYmlPath, err := getYarnrcPaths()
+	if err != nil {
+		return err
+	}
+	if err := disableYarnrc(yarnrcPath); err != nil {
+		return err
+	}
+	return disableYarnrcYml(yarnrcYmlPath)
+}
+
+// disableYarnrc removes the registry line from Yarn classic's .yarnrc
+func disableYarnrc(yarnrcPath string) error {
+	if restored, err := restoreOriginal(yarnrcPath); restored || err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(yarnrcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing to disable
+		}
+		return fmt.Errorf("failed to read .yarnrc: %w", err)
+	}
+	line, _ := yarnrcRegistry(string(data))
+	if line == "" {
+		return nil
+	}
+
+	newLines := []string{}
+	for _, l := range strings.Split(string(data), "\n") {
+		if trimmed := strings.TrimSpace(l); trimmed != "" && trimmed != line {
+			newLines = append(newLines, l)
+		}
+	}
+
+	// Remove file if empty
+	if len(newLines) == 0 {
+		if err := logging.Remove(yarnrcPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove .yarnrc: %w", err)
+		}
+		return nil
+	}
+
+	content := strings.Join(newLines, "\n") + "\n"
+	if err := logging.WriteFile(yarnrcPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write .yarnrc: %w", err)
+	}
+
+	return nil
+}
+
+// disableYarnrcYml removes npmRegistryServer from Yarn berry's .yarnrc.yml
+func disableYarnrcYml(yarnrcYmlPath string) error {
+	if restored, err := restoreOriginal(yarnrcYmlPath); restored || err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(yarnrcYmlPath); os.IsNotExist(err) {
+		return nil // Nothing to disable
+	}
+	mapping, err := readYAMLMapping(yarnrcYmlPath)
+	if err != nil {
+		return err
+	}
+	if mappingKey(mapping, "npmRegistryServer") == nil {
+		return nil
+	}
+	deleteMappingKey(mapping, "npmRegistryServer")
+
+	// Remove file if empty
+	if len(mapping.Content) == 0 {
+		if err := logging.Remove(yarnrcYmlPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove .yarnrc.yml: %w", err)
+		}
+		return nil
+	}
+
+	data, err := marshalYAML(mapping)
+	if err != nil {
+		return err
+	}
+	if err := logging.WriteFile(yarnrcYmlPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write .yarnrc.yml: %w", err)
+	}
+
+	return nil
+}
+
+// Status checks if the mirror is currently enabled, naming the Yarn whose
+// config file doesn't have it if only one does
+func (y *YarnMirror) Status() (bool, string, error) {
+	yarnrcPath, yarnrcYmlPath, err := getYarnrcPaths()
+	if err != nil {
+		return false, "", err
+	}
+
+	var classic string
+	if data, err := os.ReadFile(yarnrcPath); err == nil {
+		_, classic = yarnrcRegistry(string(data))
+	} else if !os.IsNotExist(err) {
+		return false, "", fmt.Errorf("failed to read .yarnrc: %w", err)
+	}
+	mapping, err := readYAMLMapping(yarnrcYmlPath)
+	if err != nil {
+		return false, "", err
+	}
+	var berry string
+	if server := mappingKey(mapping, "npmRegistryServer"); server != nil {
+		berry = server.Value
+	}
+
+	switch {
+	case classic == "" && berry == "":
+		return false, "default registry", nil
+	case berry == "":
+		return true, classic + " (classic only)", nil
+	case classic == "":
+		return true, berry + " (berry only)", nil
+	case classic != berry:
+		return true, classic + " (classic), " + berry + " (berry)", nil
+	}
+	return true, classic, nil
+}
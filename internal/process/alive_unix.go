@@ -0,0 +1,23 @@
+//go:build !windows
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// Alive reports whether a process with the given PID exists
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// Signal 0 checks for existence without delivering a signal
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package process
+
+import "syscall"
+
+// stillActive is the exit code Windows reports for a running process
+const stillActive = 259
+
+// Alive reports whether a process with the given PID exists
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// CachedSubscription is the last successfully fetched subscription, stored on disk
+type CachedSubscription struct {
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Expire    time.Time `json:"expire,omitempty"`
+	Nodes     []Node    `json:"nodes"`
+}
+
+// LoadSubscriptionCache loads the cached subscription from path.
+// It returns nil without an error if there is no cache yet.
+func LoadSubscriptionCache(path string) (*CachedSubscription, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read subscription cache: %w", err)
+	}
+
+	cache := &CachedSubscription{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse subscription cache: %w", err)
+	}
+
+	return cache, nil
+}
+
+// SaveSubscriptionCache stores sub at path
+func SaveSubscriptionCache(path string, sub *Subscription) error {
+	// Test results belong in the node stats, not the cache
+	nodes := make([]Node, len(sub.Nodes))
+	for i, node := range sub.Nodes {
+		node.Latency = 0
+		node.Speed = 0
+		nodes[i] = node
+	}
+
+	data, err := json.MarshalIndent(CachedSubscription{
+		URL:       sub.URL,
+		FetchedAt: time.Now(),
+		Expire:    sub.Expire,
+		Nodes:     nodes,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// The cache holds node credentials
+	if err := logging.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write subscription cache: %w", err)
+	}
+
+	return nil
+}
+
+// Subscription returns the cached subscription
+func (c *CachedSubscription) Subscription() *Subscription {
+	return &Subscription{
+		URL:    c.URL,
+		Nodes:  c.Nodes,
+		Expire: c.Expire,
+	}
+}
@@ -0,0 +1,414 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+	"github.com/boomyao/crosh/internal/process"
+)
+
+// Proxy cores that can run the nodes
+const (
+	CoreXray    = "xray"
+	CoreSingBox = "sing-box"
+	CoreMihomo  = "mihomo"
+)
+
+// ProxyCore runs subscription nodes behind the local proxy ports
+type ProxyCore interface {
+	// Name returns the name of the core, e.g. "xray"
+	Name() string
+	// Supports reports whether the core can run nodes of the given type
+	Supports(nodeType string) bool
+	Download() error
+	GenerateConfig(node *Node) error
+	GenerateBalancerConfig(nodes []*Node, probeURL string) error
+	Start() error
+	Stop() error
+	IsRunning() bool
+	BinaryPath() string
+	ConfigPath() string
+	// RunCommand returns the command line Start runs the core with
+	RunCommand() []string
+	LogPath() string
+	// RotateLog rotates the log if it grew too big or old, reporting whether it did
+	RotateLog() (bool, error)
+	// Traffic returns the data sent and received through nodes since the core started
+	Traffic() (Traffic, error)
+	GetProxyEnvVars() map[string]string
+	CheckHealth(probeURL string) error
+	SpeedTest(node *Node, testURL string) error
+	SelectFastestNodeByURLTest(sub *Subscription, probeURL string) (*Node, error)
+	SelectFastestNodeByLatency(sub *Subscription, probeURL string) (*Node, error)
+}
+
+// Cores returns the names of the supported proxy cores
+func Cores() []string {
+	return []string{CoreXray, CoreSingBox, CoreMihomo}
+}
+
+// NewProxyCore creates the proxy core with the given name. Cores keep their
+// binary and files in the directory of xrayPath.
+func NewProxyCore(name, xrayPath string, opts Options) (ProxyCore, error) {
+	switch name {
+	case "", CoreXray:
+		return NewXrayManager(xrayPath, opts), nil
+	case CoreSingBox:
+		return NewSingBoxManager(filepath.Join(filepath.Dir(xrayPath), "sing-box"), opts), nil
+	case CoreMihomo:
+		return NewMihomoManager(filepath.Join(filepath.Dir(xrayPath), "mihomo"), opts), nil
+	default:
+		return nil, fmt.Errorf("unknown proxy core: %s (expected %s)", name, strings.Join(Cores(), ", "))
+	}
+}
+
+// Options configures the instances a proxy core runs
+type Options struct {
+	Inbound Inbound
+	// Upstream is a proxy URL that nodes, downloads and other requests go
+	// through; empty to connect directly
+	Upstream string
+	// Rules are user routing rules, applied before the built-in ones
+	Rules []Rule
+	// AlwaysProxy are domains that go through the proxy even if the built-in
+	// rules would send them direct
+	AlwaysProxy []string
+	DNS         DNS
+	Sniffing    Sniffing
+	GeoData     GeoData
+	// XrayVersion pins the Xray-core release that is downloaded, e.g. v1.8.24
+	XrayVersion string
+	// ClashRules is a Clash config whose rules and rule providers mihomo uses
+	// instead of the built-in rules
+	ClashRules string
+	Log        Log
+}
+
+// Inbound describes the local proxy ports the core listens on
+type Inbound struct {
+	Listen    string // address to bind, e.g. 127.0.0.1 or 0.0.0.0
+	SocksPort int
+	HTTPPort  int // 0 disables the HTTP inbound
+	StatsPort int // loopback port the core reports traffic on; 0 disables it
+	// Username and Password, if Username is set, are required to use the proxy
+	Username string
+	Password string
+	// TUN captures all system traffic, for tools that ignore proxy settings
+	TUN TUN
+}
+
+// userinfo returns the inbound login, or nil if no login is required
+func (i Inbound) userinfo() *url.Userinfo {
+	if i.Username == "" {
+		return nil
+	}
+	return url.UserPassword(i.Username, i.Password)
+}
+
+// host returns the address clients on this machine reach the inbound at,
+// which is loopback when it listens on all interfaces
+func (i Inbound) host() string {
+	if i.Listen == "" || i.Listen == "0.0.0.0" || i.Listen == "::" {
+		return "127.0.0.1"
+	}
+	return i.Listen
+}
+
+// Exposed reports whether the inbound is reachable from other machines
+func (i Inbound) Exposed() bool {
+	ip := net.ParseIP(i.Listen)
+	return i.Listen != "localhost" && (ip == nil || !ip.IsLoopback())
+}
+
+// coreBase holds the settings and process handling shared by the proxy cores
+type coreBase struct {
+	title       string // shown in messages, e.g. Xray-core
+	binPath     string
+	configPath  string
+	pidPath     string
+	logPath     string
+	cmd         *exec.Cmd
+	exited      chan struct{} // closed once cmd has exited
+	inbound     Inbound
+	upstream    string // upstream proxy URL, empty to connect directly
+	rules       []Rule
+	alwaysProxy []string
+	dns         DNS
+	sniffing    Sniffing
+	log         Log
+	// runArgs returns the arguments that run the core with a config file
+	runArgs func(configPath string) []string
+	// probeConfig returns a config that routes everything from a local SOCKS
+	// port through node, for probes
+	probeConfig func(node *Node, port int) ([]byte, error)
+}
+
+// newCoreBase sets up a core whose config, pid and log files are named after
+// fileName and live next to its binary
+func newCoreBase(title, binPath, fileName, configName string, opts Options) coreBase {
+	dir := filepath.Dir(binPath)
+	return coreBase{
+		title:       title,
+		binPath:     binPath,
+		configPath:  filepath.Join(dir, configName),
+		pidPath:     filepath.Join(dir, fileName+".pid"),
+		logPath:     filepath.Join(dir, fileName+".log"),
+		inbound:     opts.Inbound,
+		upstream:    opts.Upstream,
+		rules:       opts.Rules,
+		alwaysProxy: opts.AlwaysProxy,
+		dns:         opts.DNS,
+		sniffing:    opts.Sniffing,
+		log:         opts.Log,
+	}
+}
+
+// start runs the core in the background with the generated config
+func (c *coreBase) start() error {
+	// Check if the binary exists
+	if _, err := os.Stat(c.binPath); os.IsNotExist(err) {
+		return &CoreMissingError{Core: c.title, Path: c.binPath}
+	}
+
+	// Check if already running
+	if c.IsRunning() {
+		return fmt.Errorf("%s is already running", c.title)
+	}
+
+	if c.inbound.TUN.Enabled {
+		if err := checkTUNSupport(); err != nil {
+			return err
+		}
+	}
+
+	// Rotate a log that grew too big or old before appending to it
+	logFile := c.LogPath()
+	if _, err := c.RotateLog(); err != nil {
+		fmt.Printf(i18n.T("Warning: %v\n"), err)
+	}
+
+	// Create log file for background process
+	logFileHandle, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	// Start the process with output redirected to log file
+	c.cmd = logging.Command(c.binPath, c.runArgs(c.configPath)...)
+	c.cmd.Stdout = logFileHandle
+	c.cmd.Stderr = logFileHandle
+
+	if err := c.cmd.Start(); err != nil {
+		logFileHandle.Close()
+		return fmt.Errorf("failed to start %s: %w", c.title, err)
+	}
+
+	// Close the file handle in the parent process (child process keeps its copy)
+	logFileHandle.Close()
+
+	// Reap the process when it exits so long-running callers (the daemon)
+	// don't keep a zombie that still looks alive
+	cmd, exited := c.cmd, make(chan struct{})
+	c.exited = exited
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	if c.inbound.HTTPPort > 0 {
+		fmt.Printf(i18n.T("%s started on port %d (SOCKS) and %d (HTTP) (PID: %d)\n"), c.title, c.inbound.SocksPort, c.inbound.HTTPPort, c.cmd.Process.Pid)
+	} else {
+		fmt.Printf(i18n.T("%s started on port %d (PID: %d)\n"), c.title, c.inbound.SocksPort, c.cmd.Process.Pid)
+	}
+	fmt.Printf(i18n.T("Logs: %s\n"), logFile)
+	if c.inbound.Exposed() {
+		c.printLANWarning()
+	}
+
+	// Save PID to file
+	logging.WriteFile(c.pidPath, []byte(fmt.Sprintf("%d", c.cmd.Process.Pid)), 0644)
+
+	return nil
+}
+
+// installBinary writes the binary read from src to binPath
+func (c *coreBase) installBinary(src io.Reader) error {
+	tmpFile := c.binPath + ".tmp"
+	dst, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+
+	_, err = io.Copy(dst, src)
+	dst.Close()
+
+	if err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, c.binPath); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to move to final location: %w", err)
+	}
+
+	return nil
+}
+
+// printLANWarning warns that the proxy accepts connections from other devices
+// and shows the addresses they can use
+func (c *coreBase) printLANWarning() {
+	listen := c.inbound.Listen
+	if listen == "" {
+		listen = "all interfaces"
+	}
+	if c.inbound.Username == "" {
+		fmt.Printf(i18n.T("⚠ Proxy is listening on %s: anyone on your network can use it\n"), listen)
+		fmt.Println(i18n.T("  Require a login with: crosh config set proxy.auth.username <name> (and proxy.auth.password)"))
+	} else {
+		fmt.Printf(i18n.T("⚠ Proxy is listening on %s: devices on your network can use it with the proxy login\n"), listen)
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		fmt.Printf(i18n.T("  Other devices can use socks5://%s:%d"), ipNet.IP, c.inbound.SocksPort)
+		if c.inbound.HTTPPort > 0 {
+			fmt.Printf(i18n.T(" or http://%s:%d"), ipNet.IP, c.inbound.HTTPPort)
+		}
+		fmt.Println()
+	}
+}
+
+// Stop stops the core process
+func (c *coreBase) Stop() error {
+	// Try to stop via cmd object first
+	if c.cmd != nil && c.cmd.Process != nil {
+		if c.IsRunning() {
+			if err := c.cmd.Process.Kill(); err != nil {
+				return fmt.Errorf("failed to stop %s: %w", c.title, err)
+			}
+			<-c.exited
+		}
+		c.cmd = nil
+	} else {
+		// Try to stop via PID file (for processes started in previous sessions)
+		data, err := os.ReadFile(c.pidPath)
+		if err == nil {
+			var pid int
+			fmt.Sscanf(string(data), "%d", &pid)
+
+			if pid > 0 {
+				process, err := os.FindProcess(pid)
+				if err == nil {
+					// Try to kill the process
+					if err := process.Kill(); err != nil {
+						// Process might already be dead, that's ok
+						fmt.Printf(i18n.T("Note: Process %d may have already stopped\n"), pid)
+					}
+				}
+			}
+		}
+	}
+
+	// Remove PID file
+	os.Remove(c.pidPath)
+
+	fmt.Printf(i18n.T("%s stopped\n"), c.title)
+	return nil
+}
+
+// IsRunning checks if the core process is running
+func (c *coreBase) IsRunning() bool {
+	if c.cmd != nil && c.cmd.Process != nil {
+		// Check if process is still alive
+		select {
+		case <-c.exited:
+			return false
+		default:
+			return true
+		}
+	}
+
+	// Check PID file
+	data, err := os.ReadFile(c.pidPath)
+	if err != nil {
+		return false
+	}
+
+	var pid int
+	fmt.Sscanf(string(data), "%d", &pid)
+
+	// Check if process with this PID exists
+	return process.Alive(pid)
+}
+
+// ConfigPath returns the path of the generated config file
+func (c *coreBase) ConfigPath() string {
+	return c.configPath
+}
+
+// BinaryPath returns the path of the core binary
+func (c *coreBase) BinaryPath() string {
+	return c.binPath
+}
+
+// RunCommand returns the command line Start runs the core with
+func (c *coreBase) RunCommand() []string {
+	return append([]string{c.binPath}, c.runArgs(c.configPath)...)
+}
+
+// LogPath returns the path of the log file written by the core process
+func (c *coreBase) LogPath() string {
+	return c.logPath
+}
+
+// RotateLog rotates the log as configured, also while the core is running
+func (c *coreBase) RotateLog() (bool, error) {
+	return c.log.Rotate(c.logPath)
+}
+
+// GetProxyEnvVars returns environment variables for using the proxy
+func (c *coreBase) GetProxyEnvVars() map[string]string {
+	socksURL := c.proxyURL("socks5", c.inbound.SocksPort)
+
+	// Many tools (Java, Gradle, some curl builds) only understand http:// in HTTP_PROXY
+	httpURL := socksURL
+	if c.inbound.HTTPPort > 0 {
+		httpURL = c.proxyURL("http", c.inbound.HTTPPort)
+	}
+
+	return map[string]string{
+		"HTTP_PROXY":  httpURL,
+		"HTTPS_PROXY": httpURL,
+		"ALL_PROXY":   socksURL,
+		"http_proxy":  httpURL,
+		"https_proxy": httpURL,
+		"all_proxy":   socksURL,
+	}
+}
+
+// proxyURL returns the local proxy URL for port, including the login if one is required
+func (c *coreBase) proxyURL(scheme string, port int) string {
+	u := url.URL{
+		Scheme: scheme,
+		User:   c.inbound.userinfo(),
+		Host:   net.JoinHostPort(c.inbound.host(), strconv.Itoa(port)),
+	}
+	return u.String()
+}
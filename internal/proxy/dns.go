@@ -0,0 +1,70 @@
+package proxy
+
+// DNS configures the DNS servers the proxy core resolves domains with, e.g.
+// to match them against IP rules. Foreign domains are resolved through the
+// proxy so polluted answers from local resolvers don't send them to the
+// wrong place.
+type DNS struct {
+	Enabled bool
+	// Servers resolve foreign domains: DoH URLs (https://) or plain addresses
+	Servers []string
+	// Domestic servers resolve geosite:cn domains, giving nearby CDN addresses
+	Domestic []string
+}
+
+// dnsEnabled reports whether the config gets a dns section
+func (c *coreBase) dnsEnabled() bool {
+	return c.dns.Enabled && len(c.dns.Servers)+len(c.dns.Domestic) > 0
+}
+
+// addDNS adds the dns section, and the FakeDNS pool if used, to config
+func (x *XrayManager) addDNS(config map[string]interface{}) {
+	if !x.dnsEnabled() {
+		return
+	}
+
+	config["dns"] = x.generateDNS()
+	if x.fakeDNS() {
+		config["fakedns"] = []map[string]interface{}{
+			{"ipPool": fakeDNSPool, "poolSize": 65535},
+		}
+	}
+}
+
+// generateDNS generates the dns section
+func (x *XrayManager) generateDNS() map[string]interface{} {
+	servers := []interface{}{}
+	// Servers whose domains match are asked first, so geosite:cn domains still
+	// get real addresses from the domestic servers
+	if x.fakeDNS() {
+		servers = append(servers, "fakedns")
+	}
+	for _, server := range x.dns.Servers {
+		servers = append(servers, server)
+	}
+	for _, server := range x.dns.Domestic {
+		servers = append(servers, map[string]interface{}{
+			"address":   server,
+			"domains":   []string{"geosite:cn"},
+			"expectIPs": []string{"geoip:cn"},
+		})
+	}
+
+	return map[string]interface{}{
+		"servers": servers,
+	}
+}
+
+// hijackDNS reports whether DNS queries captured by the TUN inbound are
+// answered by the core's DNS instead of being forwarded
+func (c *coreBase) hijackDNS() bool {
+	return c.inbound.TUN.Enabled && c.dnsEnabled()
+}
+
+// generateDNSOutbound generates the outbound that answers DNS queries with Xray's DNS
+func (x *XrayManager) generateDNSOutbound() map[string]interface{} {
+	return map[string]interface{}{
+		"tag":      "dns-out",
+		"protocol": "dns",
+	}
+}
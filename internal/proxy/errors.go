@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoNodes is returned when there are no nodes to select from
+	ErrNoNodes = errors.New("no nodes available")
+	// ErrNoReachableNodes is returned when none of the nodes passed the latency test
+	ErrNoReachableNodes = errors.New("no reachable nodes found")
+)
+
+// NetworkError is returned when a server, such as the subscription or a
+// release download, can't be reached or doesn't answer as expected
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// networkErrorf formats an error as a NetworkError
+func networkErrorf(format string, args ...interface{}) error {
+	return &NetworkError{Err: fmt.Errorf(format, args...)}
+}
+
+// CoreMissingError is returned when the binary of a proxy core isn't installed
+type CoreMissingError struct {
+	Core string // title of the core, e.g. Xray-core
+	Path string
+}
+
+func (e *CoreMissingError) Error() string {
+	return fmt.Sprintf("%s not found, please run download first", e.Core)
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NodeFilter selects the subscription nodes that may be used
+type NodeFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	types   []string
+	regions []string
+}
+
+// NewNodeFilter creates a node filter. include and exclude are regular
+// expressions matched against node names; types and regions are matched
+// case-insensitively against the node type and detected region. Empty lists
+// don't restrict anything.
+func NewNodeFilter(include, exclude, types, regions []string) (*NodeFilter, error) {
+	filter := &NodeFilter{types: types, regions: regions}
+
+	var err error
+	if filter.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if filter.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// compilePatterns compiles name patterns case-insensitively
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node filter pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Match reports whether node passes the filter
+func (f *NodeFilter) Match(node *Node) bool {
+	if len(f.include) > 0 && !matchAny(f.include, node.Name) {
+		return false
+	}
+	if matchAny(f.exclude, node.Name) {
+		return false
+	}
+	if len(f.types) > 0 && !containsFold(f.types, node.Type) {
+		return false
+	}
+	if len(f.regions) > 0 && !containsFold(f.regions, DetectRegion(node.Name)) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the nodes that pass the filter
+func (f *NodeFilter) Apply(nodes []Node) []Node {
+	filtered := []Node{}
+	for i := range nodes {
+		if f.Match(&nodes[i]) {
+			filtered = append(filtered, nodes[i])
+		}
+	}
+	return filtered
+}
+
+// matchAny reports whether any pattern matches s
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
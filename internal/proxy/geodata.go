@@ -0,0 +1,323 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/i18n"
+)
+
+// DefaultGeoDataVariant is the rule set geo data is downloaded from by default
+const DefaultGeoDataVariant = "loyalsoldier"
+
+// geoDataVariants are the rule sets geo data can be downloaded from, with the
+// sources of each file (Cloudflare CDN first for best China access)
+var geoDataVariants = map[string]struct {
+	geoIP      []string
+	geoSite    []string
+	geoIPCodes []string // the only codes its geoip.dat has, if not all
+}{
+	// Loyalsoldier's enhanced rules, the most complete for China
+	"loyalsoldier": {
+		geoIP: []string{
+			"https://crosh.boomyao.com/xray/geoip.dat",
+			"https://github.com/Loyalsoldier/v2ray-rules-dat/releases/latest/download/geoip.dat",
+		},
+		geoSite: []string{
+			"https://crosh.boomyao.com/xray/geosite.dat",
+			"https://github.com/Loyalsoldier/v2ray-rules-dat/releases/latest/download/geosite.dat",
+		},
+	},
+	// Only the CN and private IP ranges the built-in rules use, a much smaller download
+	"lite": {
+		geoIP: []string{
+			"https://github.com/Loyalsoldier/geoip/releases/latest/download/geoip-only-cn-private.dat",
+		},
+		geoSite: []string{
+			"https://crosh.boomyao.com/xray/geosite.dat",
+			"https://github.com/Loyalsoldier/v2ray-rules-dat/releases/latest/download/geosite.dat",
+		},
+		geoIPCodes: []string{"cn", "private"},
+	},
+	// The upstream v2fly data Xray ships with
+	"v2fly": {
+		geoIP: []string{
+			"https://github.com/v2fly/geoip/releases/latest/download/geoip.dat",
+		},
+		geoSite: []string{
+			"https://github.com/v2fly/domain-list-community/releases/latest/download/dlc.dat",
+		},
+	},
+}
+
+// GeoData selects where geo data files are downloaded from
+type GeoData struct {
+	Variant string // one of the geoDataVariants, DefaultGeoDataVariant if empty
+	// GeoIP and GeoSite are mirror URLs used instead of the variant's sources
+	GeoIP   []string
+	GeoSite []string
+}
+
+// geoDataFile is a geo data file and the URLs it can be downloaded from
+type geoDataFile struct {
+	name    string
+	sources []string
+}
+
+// geoDataFiles returns the geo data files routing rules need and their sources
+func (x *XrayManager) geoDataFiles() ([]geoDataFile, error) {
+	name := x.geoData.Variant
+	if name == "" {
+		name = DefaultGeoDataVariant
+	}
+	variant, ok := geoDataVariants[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown geo data variant %q (use %s)", name, strings.Join(GeoDataVariants(), ", "))
+	}
+
+	files := []geoDataFile{
+		{name: "geoip.dat", sources: variant.geoIP},
+		{name: "geosite.dat", sources: variant.geoSite},
+	}
+	if len(x.geoData.GeoIP) > 0 {
+		files[0].sources = x.geoData.GeoIP
+	}
+	if len(x.geoData.GeoSite) > 0 {
+		files[1].sources = x.geoData.GeoSite
+	}
+	return files, nil
+}
+
+// GeoDataVariants returns the names of the geo data variants
+func GeoDataVariants() []string {
+	names := make([]string, 0, len(geoDataVariants))
+	for name := range geoDataVariants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GeoIPMissing returns the geoip: matches of rules, e.g. geoip:jp, that the
+// geoip.dat of variant doesn't have
+func GeoIPMissing(variant string, rules []Rule) []string {
+	codes := geoDataVariants[variant].geoIPCodes
+	if codes == nil {
+		return nil
+	}
+	var missing []string
+	for _, rule := range rules {
+		for _, ip := range rule.IPs {
+			code, ok := strings.CutPrefix(strings.ToLower(ip), "geoip:")
+			if ok && !slices.Contains(codes, strings.TrimPrefix(code, "!")) && !slices.Contains(missing, ip) {
+				missing = append(missing, ip)
+			}
+		}
+	}
+	return missing
+}
+
+// geoDataPath returns the path of a geo data file; Xray looks for them next to its binary
+func (x *XrayManager) geoDataPath(name string) string {
+	return filepath.Join(filepath.Dir(x.binPath), name)
+}
+
+// downloadGeoData downloads geo data files that don't exist yet
+func (x *XrayManager) downloadGeoData() error {
+	geoFiles, err := x.geoDataFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, geoFile := range geoFiles {
+		targetPath := x.geoDataPath(geoFile.name)
+
+		// Skip if file already exists
+		if _, err := os.Stat(targetPath); err == nil {
+			fmt.Printf(i18n.T("✓ %s already exists\n"), geoFile.name)
+			continue
+		}
+
+		fmt.Printf(i18n.T("Downloading %s...\n"), geoFile.name)
+		if err := x.fetchGeoFile(geoFile.sources, targetPath); err != nil {
+			return fmt.Errorf("failed to download %s: %w", geoFile.name, err)
+		}
+		fmt.Printf(i18n.T("✓ %s downloaded successfully\n"), geoFile.name)
+	}
+
+	return nil
+}
+
+// UpdateGeoData re-downloads the geo data files and returns the names of those
+// that changed. Files whose published checksum matches the local file are not
+// downloaded again.
+func (x *XrayManager) UpdateGeoData() ([]string, error) {
+	geoFiles, err := x.geoDataFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	updated := []string{}
+	for _, geoFile := range geoFiles {
+		targetPath := x.geoDataPath(geoFile.name)
+		localSum, _ := fileSHA256(targetPath)
+
+		if localSum != "" && x.remoteGeoSum(geoFile.sources) == localSum {
+			fmt.Printf(i18n.T("✓ %s is up to date\n"), geoFile.name)
+			touch(targetPath)
+			continue
+		}
+
+		fmt.Printf(i18n.T("Downloading %s...\n"), geoFile.name)
+		newPath := targetPath + ".new"
+		if err := x.fetchGeoFile(geoFile.sources, newPath); err != nil {
+			return updated, fmt.Errorf("failed to download %s: %w", geoFile.name, err)
+		}
+
+		// Mirrors may not publish checksums; compare the download instead
+		newSum, err := fileSHA256(newPath)
+		if err != nil {
+			os.Remove(newPath)
+			return updated, err
+		}
+		if newSum == localSum {
+			os.Remove(newPath)
+			fmt.Printf(i18n.T("✓ %s is up to date\n"), geoFile.name)
+			touch(targetPath)
+			continue
+		}
+
+		if err := os.Rename(newPath, targetPath); err != nil {
+			os.Remove(newPath)
+			return updated, fmt.Errorf("failed to replace %s: %w", geoFile.name, err)
+		}
+		fmt.Printf(i18n.T("✓ %s updated\n"), geoFile.name)
+		updated = append(updated, geoFile.name)
+	}
+
+	return updated, nil
+}
+
+// GeoDataAge returns how long ago the geo data files were downloaded or last
+// found up to date. It returns false if a file is missing.
+func (x *XrayManager) GeoDataAge() (time.Duration, bool) {
+	var oldest time.Time
+	for _, name := range []string{"geoip.dat", "geosite.dat"} {
+		info, err := os.Stat(x.geoDataPath(name))
+		if err != nil {
+			return 0, false
+		}
+		if oldest.IsZero() || info.ModTime().Before(oldest) {
+			oldest = info.ModTime()
+		}
+	}
+	return time.Since(oldest), true
+}
+
+// fetchGeoFile downloads a geo data file from the first source that works
+func (x *XrayManager) fetchGeoFile(sources []string, targetPath string) error {
+	var lastErr error
+	for i, source := range sources {
+		fmt.Printf(i18n.T("  Trying source %d/%d...\n"), i+1, len(sources))
+
+		err := x.downloadGeoFile(source, targetPath)
+		if err == nil {
+			return nil
+		}
+
+		fmt.Printf(i18n.T("  ✗ Failed: %v\n"), err)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// remoteGeoSum returns the SHA-256 checksum published next to a geo data
+// file (as Loyalsoldier's releases do), or empty if no source has one
+func (x *XrayManager) remoteGeoSum(sources []string) string {
+	client := NewHTTPClient(x.upstream, 30*time.Second)
+	for _, source := range sources {
+		resp, err := client.Get(source + ".sha256sum")
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		// The format is "<checksum>  <file name>"
+		if fields := strings.Fields(string(data)); len(fields) > 0 && len(fields[0]) == sha256.Size*2 {
+			return strings.ToLower(fields[0])
+		}
+	}
+	return ""
+}
+
+// downloadGeoFile downloads a single geo data file
+func (x *XrayManager) downloadGeoFile(url, targetPath string) error {
+	client := NewHTTPClient(x.upstream, 3*time.Minute)
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return networkErrorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return networkErrorf("HTTP %d", resp.StatusCode)
+	}
+
+	// Create temporary file
+	tmpFile := targetPath + ".tmp"
+	out, err := os.Create(tmpFile)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+
+	if err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	// Rename to final location
+	if err := os.Rename(tmpFile, targetPath); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to move to final location: %w", err)
+	}
+
+	return nil
+}
+
+// fileSHA256 returns the hex SHA-256 checksum of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// touch sets the modification time of a file to now
+func touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LogLevels lists the log levels crosh understands, from most to least verbose
+var LogLevels = []string{"debug", "info", "warning", "error"}
+
+// logLevelPattern finds the level in a log line of any core: Xray writes
+// "[Warning]", sing-box "WARN" and mihomo "level=warning"
+var logLevelPattern = regexp.MustCompile(`\[(?i:(debug|info|warning|error))\]|\b(DEBUG|INFO|WARN|ERROR|FATAL|PANIC)\b|level=(debug|info|warning|error|fatal)`)
+
+// ansiPattern matches the color codes sing-box may write around levels
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// LogLevel returns the level of a core log line, or "" if it has none
+func LogLevel(line string) string {
+	m := logLevelPattern.FindStringSubmatch(ansiPattern.ReplaceAllString(line, ""))
+	if m == nil {
+		return ""
+	}
+
+	switch level := strings.ToLower(m[1] + m[2] + m[3]); level {
+	case "warn":
+		return "warning"
+	case "fatal", "panic":
+		return "error"
+	default:
+		return level
+	}
+}
+
+// LogLevelRank returns the position of level in LogLevels, or -1 if unknown
+func LogLevelRank(level string) int {
+	for i, l := range LogLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Log configures the level of the core log and how crosh rotates it
+type Log struct {
+	Level   string        // debug, info, warning, error or none
+	MaxSize int64         // bytes the log may grow to before it is rotated; 0 disables
+	MaxAge  time.Duration // age of the oldest line before the log is rotated; 0 disables
+	// MaxFiles is how many rotated logs are kept, as <log>.1 (newest) to <log>.N
+	MaxFiles int
+}
+
+// level returns the configured level, or "warning" if it is unknown
+func (l Log) level() string {
+	if l.Level == "none" || LogLevelRank(l.Level) >= 0 {
+		return l.Level
+	}
+	return "warning"
+}
+
+// logTimePattern finds the timestamp the cores start their log lines with,
+// e.g. "2024/01/02 15:04:05" or "2024-01-02T15:04:05"
+var logTimePattern = regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}[ T]\d{2}:\d{2}:\d{2}`)
+
+// Rotate rotates the log at path if it is larger than MaxSize or older than
+// MaxAge. It reports whether the log was rotated. The log is copied and then
+// truncated rather than renamed, so a running core keeps writing to it.
+func (l Log) Rotate(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	tooBig := l.MaxSize > 0 && info.Size() > l.MaxSize
+	tooOld := l.MaxAge > 0 && info.Size() > 0 && time.Since(logStart(path, info)) > l.MaxAge
+	if !tooBig && !tooOld {
+		return false, nil
+	}
+
+	if l.MaxFiles > 0 {
+		// Shift <log>.1 to <log>.2 and so on, dropping the oldest
+		os.Remove(fmt.Sprintf("%s.%d", path, l.MaxFiles))
+		for i := l.MaxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		}
+		if err := copyFile(path, path+".1"); err != nil {
+			return false, fmt.Errorf("failed to rotate log: %w", err)
+		}
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		return false, fmt.Errorf("failed to truncate log: %w", err)
+	}
+	return true, nil
+}
+
+// logStart returns the time of the first timestamped line of a log, or its
+// modification time if the first lines have none
+func logStart(path string, info os.FileInfo) time.Time {
+	file, err := os.Open(path)
+	if err != nil {
+		return info.ModTime()
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < 20 && scanner.Scan(); i++ {
+		stamp := logTimePattern.FindString(scanner.Text())
+		if stamp == "" {
+			continue
+		}
+		stamp = strings.NewReplacer("/", "-", "T", " ").Replace(stamp)
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", stamp, time.Local); err == nil {
+			return t
+		}
+	}
+	return info.ModTime()
+}
+
+// copyFile copies the file at src to dst, replacing dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,768 @@
+package proxy
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// mihomo releases; there is no crosh mirror for them yet
+const (
+	mihomoReleaseAPI     = "https://api.github.com/repos/MetaCubeX/mihomo/releases/latest"
+	mihomoDownloadURL    = "https://github.com/MetaCubeX/mihomo/releases/download"
+	mihomoDefaultVersion = "v1.19.0"
+)
+
+// mihomoTargets are the Clash rule targets kept as they are when importing
+// rules; any other target is a proxy group and becomes the "proxy" group
+var mihomoTargets = map[string]bool{
+	"DIRECT":      true,
+	"REJECT":      true,
+	"REJECT-DROP": true,
+	"PASS":        true,
+	"COMPATIBLE":  true,
+}
+
+// MihomoManager runs nodes with mihomo (Clash Meta), which can also use the
+// rules and rule providers of an existing Clash config
+type MihomoManager struct {
+	coreBase
+	clashRules string // Clash config whose rules replace the built-in ones
+}
+
+// NewMihomoManager creates a mihomo manager for the binary at binPath
+func NewMihomoManager(binPath string, opts Options) *MihomoManager {
+	m := &MihomoManager{
+		coreBase:   newCoreBase("mihomo", binPath, "mihomo", "mihomo.yaml", opts),
+		clashRules: opts.ClashRules,
+	}
+	// The home directory holds the geo data files and rule provider caches
+	m.runArgs = func(configPath string) []string {
+		return []string{"-d", filepath.Dir(configPath), "-f", configPath}
+	}
+	m.probeConfig = m.generateProbeConfig
+	return m
+}
+
+// Name returns the name of the core
+func (m *MihomoManager) Name() string {
+	return CoreMihomo
+}
+
+// Supports reports whether mihomo can run nodes of the given type
+func (m *MihomoManager) Supports(nodeType string) bool {
+	switch nodeType {
+	case "vmess", "vless", "trojan", "ss", "wireguard", "hysteria2", "tuic":
+		return true
+	}
+	return false
+}
+
+// Start starts the mihomo process. In TUN mode mihomo sets up the routes itself.
+func (m *MihomoManager) Start() error {
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	if m.inbound.TUN.Enabled {
+		fmt.Printf(i18n.T("✓ TUN mode: all traffic goes through %s\n"), m.inbound.TUN.name())
+	}
+	return nil
+}
+
+// Download downloads the latest mihomo release if it isn't installed yet
+func (m *MihomoManager) Download() error {
+	if _, err := os.Stat(m.binPath); err == nil {
+		fmt.Println(i18n.T("mihomo already exists, skipping download"))
+		return nil
+	}
+
+	fmt.Println(i18n.T("Downloading mihomo..."))
+	if err := os.MkdirAll(filepath.Dir(m.binPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	version, assetName, err := m.latestRelease()
+	if err != nil {
+		fmt.Printf(i18n.T("Warning: failed to get latest release info: %v\n"), err)
+		fmt.Printf(i18n.T("Falling back to default version %s\n"), mihomoDefaultVersion)
+		version, assetName = mihomoDefaultVersion, mihomoAssetName(mihomoDefaultVersion)
+	}
+
+	fmt.Printf(i18n.T("Downloading mihomo version %s...\n"), version)
+	downloadURL := fmt.Sprintf("%s/%s/%s", mihomoDownloadURL, version, assetName)
+	if err := m.downloadFromURL(downloadURL); err != nil {
+		return fmt.Errorf("failed to download mihomo: %w", err)
+	}
+
+	fmt.Println(i18n.T("✓ mihomo downloaded successfully"))
+	return nil
+}
+
+// latestRelease returns the tag of the latest mihomo release and its archive
+// for this platform
+func (m *MihomoManager) latestRelease() (version, assetName string, err error) {
+	client := NewHTTPClient(m.upstream, 30*time.Second)
+
+	resp, err := client.Get(mihomoReleaseAPI)
+	if err != nil {
+		return "", "", &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", networkErrorf("API returned status %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", "", err
+	}
+
+	assetName = mihomoAssetName(release.TagName)
+	for _, asset := range release.Assets {
+		if asset.Name == assetName {
+			return release.TagName, assetName, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("no suitable binary found for %s/%s (looking for %s)", runtime.GOOS, runtime.GOARCH, assetName)
+}
+
+// mihomoAssetName returns the release archive for this platform, e.g.
+// mihomo-linux-amd64-compatible-v1.19.0.gz. The "compatible" amd64 builds
+// also run on older CPUs.
+func mihomoAssetName(version string) string {
+	arch := runtime.GOARCH
+	switch arch {
+	case "amd64":
+		arch = "amd64-compatible"
+	case "arm":
+		arch = "armv7"
+	}
+
+	ext := ".gz"
+	if runtime.GOOS == "windows" {
+		ext = ".zip"
+	}
+
+	return fmt.Sprintf("mihomo-%s-%s-%s%s", runtime.GOOS, arch, version, ext)
+}
+
+// downloadFromURL downloads a mihomo release archive and installs its binary.
+// Releases are the gzipped binary itself, or a zip on Windows.
+func (m *MihomoManager) downloadFromURL(downloadURL string) error {
+	client := NewHTTPClient(m.upstream, 5*time.Minute)
+
+	resp, err := client.Get(downloadURL)
+	if err != nil {
+		return networkErrorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return networkErrorf("HTTP %d", resp.StatusCode)
+	}
+
+	if !strings.HasSuffix(downloadURL, ".zip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to extract: %w", err)
+		}
+		defer gz.Close()
+
+		return m.installBinary(gz)
+	}
+
+	tmpZip := m.binPath + ".tmp.zip"
+	out, err := os.Create(tmpZip)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	defer os.Remove(tmpZip)
+
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	reader, err := zip.OpenReader(tmpZip)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if !strings.HasSuffix(file.Name, ".exe") {
+			continue
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open file in zip: %w", err)
+		}
+		defer src.Close()
+
+		return m.installBinary(src)
+	}
+
+	return fmt.Errorf("mihomo binary not found in zip")
+}
+
+// GenerateConfig generates the mihomo configuration for a node
+func (m *MihomoManager) GenerateConfig(node *Node) error {
+	return m.generateConfig([]*Node{node}, "")
+}
+
+// GenerateBalancerConfig generates a mihomo configuration whose "proxy" group
+// url-tests nodes through probeURL and uses the fastest
+func (m *MihomoManager) GenerateBalancerConfig(nodes []*Node, probeURL string) error {
+	if probeURL == "" {
+		probeURL = DefaultProbeURL
+	}
+	return m.generateConfig(nodes, probeURL)
+}
+
+// generateConfig generates and writes the mihomo configuration
+func (m *MihomoManager) generateConfig(nodes []*Node, probeURL string) error {
+	proxies, err := m.generateProxies(nodes...)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	group := map[string]interface{}{
+		"name":    "proxy",
+		"type":    "select",
+		"proxies": names,
+	}
+	if len(nodes) > 1 {
+		group["type"] = "url-test"
+		group["url"] = probeURL
+		group["interval"] = 60
+	}
+
+	config := map[string]interface{}{
+		"mode":         "rule",
+		"log-level":    m.logLevel(),
+		"socks-port":   m.inbound.SocksPort,
+		"allow-lan":    m.inbound.Exposed(),
+		"proxies":      proxies,
+		"proxy-groups": []map[string]interface{}{group},
+		// Use the geoip.dat and geosite.dat files crosh keeps up to date
+		"geodata-mode":    true,
+		"geo-auto-update": false,
+	}
+	if m.inbound.HTTPPort > 0 {
+		config["port"] = m.inbound.HTTPPort
+	}
+	if m.inbound.Listen != "" {
+		config["bind-address"] = m.inbound.Listen
+	}
+	if m.inbound.Username != "" {
+		config["authentication"] = []string{m.inbound.Username + ":" + m.inbound.Password}
+	}
+	if m.sniffing.Enabled {
+		config["sniffer"] = map[string]interface{}{
+			"enable": true,
+			"sniff": map[string]interface{}{
+				"HTTP": map[string]interface{}{"ports": []interface{}{80, "8080-8880"}},
+				"TLS":  map[string]interface{}{"ports": []interface{}{443, 8443}},
+			},
+		}
+	}
+	if m.inbound.TUN.Enabled {
+		config["tun"] = m.generateTUN()
+	}
+	if api := m.clashAPI(); api != "" {
+		config["external-controller"] = api
+	}
+	if m.dnsEnabled() {
+		config["dns"] = m.generateDNS()
+	}
+	if err := m.addRules(config); err != nil {
+		return err
+	}
+
+	return m.writeConfig(config, m.configPath)
+}
+
+// writeConfig writes a mihomo configuration to path
+func (m *MihomoManager) writeConfig(config map[string]interface{}, path string) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := logging.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+// logLevel returns the configured log level; mihomo calls "none" "silent"
+func (m *MihomoManager) logLevel() string {
+	if level := m.log.level(); level != "none" {
+		return level
+	}
+	return "silent"
+}
+
+// generateProbeConfig generates a config that sends everything from a local
+// SOCKS port through node
+func (m *MihomoManager) generateProbeConfig(node *Node, port int) ([]byte, error) {
+	proxies, err := m.generateProxies(node)
+	if err != nil {
+		return nil, err
+	}
+
+	config := map[string]interface{}{
+		"mode":         "rule",
+		"log-level":    "silent",
+		"socks-port":   port,
+		"bind-address": "127.0.0.1",
+		"proxies":      proxies,
+		"rules":        []string{"MATCH," + node.Name},
+	}
+	if m.inbound.TUN.Enabled {
+		// Test the node directly rather than through the TUN interface
+		iface, err := defaultInterface()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the default network interface: %w", err)
+		}
+		config["interface-name"] = iface
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// generateTUN generates the tun section; mihomo adds the routes itself
+func (m *MihomoManager) generateTUN() map[string]interface{} {
+	mtu := m.inbound.TUN.MTU
+	if mtu == 0 {
+		mtu = 1500
+	}
+
+	tun := map[string]interface{}{
+		"enable":                true,
+		"stack":                 "mixed",
+		"device":                m.inbound.TUN.name(),
+		"mtu":                   mtu,
+		"auto-route":            true,
+		"auto-detect-interface": true,
+	}
+	if m.hijackDNS() {
+		tun["dns-hijack"] = []string{"any:53"}
+	}
+	return tun
+}
+
+// generateDNS generates the dns section. Foreign domains are resolved through
+// the proxy group, geosite:cn domains and node addresses by the domestic servers.
+func (m *MihomoManager) generateDNS() map[string]interface{} {
+	servers := []string{}
+	for _, server := range m.dns.Servers {
+		servers = append(servers, server+"#proxy")
+	}
+
+	dns := map[string]interface{}{
+		"enable": true,
+		"ipv6":   true,
+	}
+	if len(m.dns.Domestic) > 0 {
+		dns["default-nameserver"] = m.dns.Domestic
+		dns["proxy-server-nameserver"] = m.dns.Domestic
+		dns["nameserver-policy"] = map[string]interface{}{"geosite:cn": m.dns.Domestic}
+		if len(servers) == 0 {
+			servers = m.dns.Domestic
+		}
+	}
+	dns["nameserver"] = servers
+
+	if m.fakeDNS() {
+		dns["enhanced-mode"] = "fake-ip"
+		dns["fake-ip-range"] = fakeDNSPool
+	}
+	return dns
+}
+
+// generateProxies generates the mihomo proxies for nodes, dialing through the
+// upstream proxy if one is configured
+func (m *MihomoManager) generateProxies(nodes ...*Node) ([]map[string]interface{}, error) {
+	upstream, err := ParseUpstream(m.upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies := []map[string]interface{}{}
+	for _, node := range nodes {
+		proxy, err := m.generateProxy(node)
+		if err != nil {
+			return nil, err
+		}
+
+		if upstream != nil {
+			if UDPOnly(node.Type) {
+				return nil, fmt.Errorf("%s node %s can't be reached through an upstream proxy", node.Type, node.Name)
+			}
+			proxy["dialer-proxy"] = upstreamTag
+		}
+
+		proxies = append(proxies, proxy)
+	}
+
+	if upstream != nil {
+		proxies = append(proxies, generateMihomoUpstream(upstream))
+	}
+
+	return proxies, nil
+}
+
+// generateProxy generates the mihomo proxy for a node, in Clash's proxy format
+func (m *MihomoManager) generateProxy(node *Node) (map[string]interface{}, error) {
+	proxy := map[string]interface{}{
+		"name":   node.Name,
+		"server": node.Server,
+		"port":   node.Port,
+		"udp":    true,
+	}
+
+	switch node.Type {
+	case "vmess":
+		proxy["type"] = "vmess"
+		proxy["uuid"] = node.UUID
+		proxy["alterId"] = 0
+		proxy["cipher"] = vmessSecurity(node)
+		addMihomoTransport(proxy, node)
+	case "vless":
+		proxy["type"] = "vless"
+		proxy["uuid"] = node.UUID
+		if node.Flow != "" {
+			proxy["flow"] = node.Flow
+		}
+		addMihomoTransport(proxy, node)
+	case "trojan":
+		proxy["type"] = "trojan"
+		proxy["password"] = node.Password
+		proxy["sni"] = tlsServerName(node)
+		// Like the Xray outbound, accept self-signed certificates
+		proxy["skip-cert-verify"] = true
+	case "ss":
+		proxy["type"] = "ss"
+		proxy["cipher"] = node.Security
+		proxy["password"] = node.Password
+	case "wireguard":
+		wg := node.WireGuard
+		if wg == nil {
+			return nil, fmt.Errorf("WireGuard node %s has no keys", node.Name)
+		}
+		proxy["type"] = "wireguard"
+		proxy["private-key"] = wg.PrivateKey
+		proxy["public-key"] = wg.PublicKey
+		if wg.PresharedKey != "" {
+			proxy["pre-shared-key"] = wg.PresharedKey
+		}
+		// Clash takes bare addresses
+		for _, address := range wg.Address {
+			ip, _, _ := strings.Cut(address, "/")
+			if strings.Contains(ip, ":") {
+				proxy["ipv6"] = ip
+			} else {
+				proxy["ip"] = ip
+			}
+		}
+		if len(wg.AllowedIPs) > 0 {
+			proxy["allowed-ips"] = wg.AllowedIPs
+		}
+		if len(wg.Reserved) > 0 {
+			proxy["reserved"] = wg.Reserved
+		}
+		if wg.MTU > 0 {
+			proxy["mtu"] = wg.MTU
+		}
+	case "hysteria2":
+		proxy["type"] = "hysteria2"
+		proxy["password"] = node.Password
+		if node.Obfs != "" {
+			proxy["obfs"] = node.Obfs
+			proxy["obfs-password"] = node.ObfsPassword
+		}
+		addMihomoTLS(proxy, node)
+	case "tuic":
+		proxy["type"] = "tuic"
+		proxy["uuid"] = node.UUID
+		proxy["password"] = node.Password
+		if node.CongestionControl != "" {
+			proxy["congestion-controller"] = node.CongestionControl
+		}
+		addMihomoTLS(proxy, node)
+		if len(node.ALPN) == 0 {
+			// TUIC servers expect HTTP/3 unless told otherwise
+			proxy["alpn"] = []string{"h3"}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported node type: %s", node.Type)
+	}
+
+	return proxy, nil
+}
+
+// tlsServerName returns the SNI of a node, falling back to the Host header,
+// then the server
+func tlsServerName(node *Node) string {
+	if node.SNI != "" {
+		return node.SNI
+	}
+	if host := strings.Split(node.Host, ",")[0]; host != "" {
+		return host
+	}
+	return node.Server
+}
+
+// addMihomoTLS adds the TLS settings of a Hysteria2 or TUIC node
+func addMihomoTLS(proxy map[string]interface{}, node *Node) {
+	proxy["sni"] = tlsServerName(node)
+	proxy["skip-cert-verify"] = node.SkipCertVerify
+	if len(node.ALPN) > 0 {
+		proxy["alpn"] = node.ALPN
+	}
+}
+
+// addMihomoTransport adds the transport and TLS settings of a VMess or VLESS node
+func addMihomoTransport(proxy map[string]interface{}, node *Node) {
+	switch node.Network {
+	case "ws":
+		opts := map[string]interface{}{"path": node.Path}
+		if node.Host != "" {
+			opts["headers"] = map[string]string{"Host": node.Host}
+		}
+		proxy["network"] = "ws"
+		proxy["ws-opts"] = opts
+	case "grpc":
+		proxy["network"] = "grpc"
+		proxy["grpc-opts"] = map[string]interface{}{"grpc-service-name": node.ServiceName}
+	case "h2", "http":
+		opts := map[string]interface{}{"path": node.Path}
+		if node.Host != "" {
+			opts["host"] = strings.Split(node.Host, ",")
+		}
+		proxy["network"] = "h2"
+		proxy["h2-opts"] = opts
+	}
+
+	if node.TLS == "tls" {
+		proxy["tls"] = true
+		proxy["servername"] = tlsServerName(node)
+		proxy["skip-cert-verify"] = node.SkipCertVerify
+	}
+}
+
+// generateMihomoUpstream generates the proxy that reaches the upstream proxy
+func generateMihomoUpstream(u *url.URL) map[string]interface{} {
+	port, _ := strconv.Atoi(u.Port())
+	proxy := map[string]interface{}{
+		"name":   upstreamTag,
+		"type":   "http",
+		"server": u.Hostname(),
+		"port":   port,
+	}
+	if u.Scheme == "socks5" {
+		proxy["type"] = "socks5"
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		proxy["username"] = u.User.Username()
+		proxy["password"] = password
+	}
+	if u.Scheme == "https" {
+		proxy["tls"] = true
+	}
+	return proxy
+}
+
+// addRules adds the rules to config: user rules and the always-proxy domains,
+// then the rules of the Clash config if one is set, or else the built-in rules
+// sending private and Chinese destinations direct
+func (m *MihomoManager) addRules(config map[string]interface{}) error {
+	rules := []string{}
+	for i, rule := range m.rules {
+		generated, err := generateMihomoRules(rule)
+		if err != nil {
+			return fmt.Errorf("invalid routing rule %d: %w", i+1, err)
+		}
+		rules = append(rules, generated...)
+	}
+	if len(m.alwaysProxy) > 0 {
+		generated, err := generateMihomoRules(Rule{Outbound: "proxy", Domains: m.alwaysProxy})
+		if err != nil {
+			return err
+		}
+		rules = append(rules, generated...)
+	}
+
+	if m.clashRules != "" {
+		imported, providers, err := loadClashRules(m.clashRules)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, imported...)
+		if len(providers) > 0 {
+			config["rule-providers"] = providers
+		}
+	} else {
+		rules = append(rules,
+			"GEOIP,private,DIRECT,no-resolve",
+			"GEOIP,CN,DIRECT",
+			"GEOSITE,CN,DIRECT",
+		)
+	}
+
+	// Everything else goes through the proxy, unless the imported rules end
+	// with their own catch-all
+	if len(rules) == 0 || !strings.HasPrefix(rules[len(rules)-1], "MATCH,") {
+		rules = append(rules, "MATCH,proxy")
+	}
+
+	config["rules"] = rules
+	return nil
+}
+
+// loadClashRules reads the rules and rule providers of a Clash config. Rules
+// that send traffic to one of its proxy groups go to the "proxy" group instead.
+func loadClashRules(path string) ([]string, map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Clash rules: %w", err)
+	}
+
+	var clash struct {
+		Rules         []string               `yaml:"rules"`
+		RuleProviders map[string]interface{} `yaml:"rule-providers"`
+	}
+	if err := yaml.Unmarshal(data, &clash); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse Clash rules %s: %w", path, err)
+	}
+	if len(clash.Rules) == 0 {
+		return nil, nil, fmt.Errorf("no rules found in %s", path)
+	}
+
+	rules := make([]string, len(clash.Rules))
+	for i, rule := range clash.Rules {
+		fields := strings.Split(rule, ",")
+		// The target is the last field, unless options like no-resolve follow it.
+		// Logical rules contain commas too, but still end with the target.
+		target := len(fields) - 1
+		for target > 1 && (fields[target] == "no-resolve" || fields[target] == "src") {
+			target--
+		}
+		if !mihomoTargets[strings.TrimSpace(fields[target])] {
+			fields[target] = "proxy"
+		}
+		rules[i] = strings.Join(fields, ",")
+	}
+
+	return rules, clash.RuleProviders, nil
+}
+
+// generateMihomoRules converts a user rule to mihomo rules, one per domain or
+// IP. Ports are ANDed with each of them.
+func generateMihomoRules(rule Rule) ([]string, error) {
+	if err := checkRuleOutbound(rule.Outbound); err != nil {
+		return nil, err
+	}
+
+	target := "proxy"
+	switch rule.Outbound {
+	case "direct":
+		target = "DIRECT"
+	case "block":
+		target = "REJECT"
+	}
+
+	matches := []string{}
+	for _, domain := range rule.Domains {
+		kind, value, found := strings.Cut(domain, ":")
+		if !found {
+			kind, value = "domain", domain
+		}
+		switch kind {
+		case "domain":
+			matches = append(matches, "DOMAIN-SUFFIX,"+value)
+		case "full":
+			matches = append(matches, "DOMAIN,"+value)
+		case "keyword":
+			matches = append(matches, "DOMAIN-KEYWORD,"+value)
+		case "regexp":
+			matches = append(matches, "DOMAIN-REGEX,"+value)
+		case "geosite":
+			matches = append(matches, "GEOSITE,"+value)
+		default:
+			return nil, fmt.Errorf("unsupported domain match: %s", domain)
+		}
+	}
+	for _, ip := range rule.IPs {
+		switch {
+		case strings.HasPrefix(ip, "geoip:"):
+			matches = append(matches, "GEOIP,"+strings.TrimPrefix(ip, "geoip:"))
+		case strings.Contains(ip, ":"):
+			if !strings.Contains(ip, "/") {
+				ip += "/128"
+			}
+			matches = append(matches, "IP-CIDR6,"+ip)
+		default:
+			if !strings.Contains(ip, "/") {
+				ip += "/32"
+			}
+			matches = append(matches, "IP-CIDR,"+ip)
+		}
+	}
+
+	// mihomo separates several ports with slashes
+	ports := strings.ReplaceAll(rule.Ports, ",", "/")
+	if len(matches) == 0 {
+		if ports == "" {
+			return nil, fmt.Errorf("rule needs domains, ips or ports")
+		}
+		return []string{"DST-PORT," + ports + "," + target}, nil
+	}
+
+	rules := make([]string, len(matches))
+	for i, match := range matches {
+		if ports != "" {
+			rules[i] = fmt.Sprintf("AND,((%s),(DST-PORT,%s)),%s", match, ports, target)
+		} else {
+			rules[i] = match + "," + target
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,270 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// DefaultSpeedTestURL is downloaded through a node to measure its bandwidth
+const DefaultSpeedTestURL = "https://speed.cloudflare.com/__down?bytes=25000000"
+
+// DefaultProbeURL is requested through a node to measure its HTTP delay
+const DefaultProbeURL = "https://www.gstatic.com/generate_204"
+
+// urlTestTimeout caps how long a single URL test waits for a response
+const urlTestTimeout = 5 * time.Second
+
+// urlTestConcurrency is how many nodes are URL-tested at the same time
+const urlTestConcurrency = 8
+
+// SpeedTestDuration caps how long a single speed test downloads
+const SpeedTestDuration = 10 * time.Second
+
+// Probe is a temporary proxy core instance that routes all traffic through one node,
+// so the node can be tested end to end without touching the running proxy
+type Probe struct {
+	cmd  *exec.Cmd
+	dir  string
+	port int
+}
+
+// NewProbe starts a temporary instance of the core for node on a free local port
+func (c *coreBase) NewProbe(node *Node) (*Probe, error) {
+	if _, err := os.Stat(c.binPath); os.IsNotExist(err) {
+		return nil, &CoreMissingError{Core: c.title, Path: c.binPath}
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.probeConfig(node, port)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "crosh-probe-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	configPath := filepath.Join(dir, "config.json")
+	if err := logging.WriteFile(configPath, data, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+
+	probe := &Probe{
+		cmd:  logging.Command(c.binPath, c.runArgs(configPath)...),
+		dir:  dir,
+		port: port,
+	}
+	if err := probe.cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to start %s: %w", c.title, err)
+	}
+
+	if err := waitForPort(port, 3*time.Second); err != nil {
+		probe.Close()
+		return nil, err
+	}
+
+	return probe, nil
+}
+
+// Client returns an HTTP client that sends requests through the probed node
+func (p *Probe) Client(timeout time.Duration) *http.Client {
+	return socksClient(p.port, nil, timeout)
+}
+
+// Close stops the temporary proxy core instance and removes its files
+func (p *Probe) Close() error {
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+	return os.RemoveAll(p.dir)
+}
+
+// SpeedTest downloads testURL through node and stores the throughput in node.Speed (MB/s)
+func (c *coreBase) SpeedTest(node *Node, testURL string) error {
+	if testURL == "" {
+		testURL = DefaultSpeedTestURL
+	}
+
+	probe, err := c.NewProbe(node)
+	if err != nil {
+		return err
+	}
+	defer probe.Close()
+
+	// The client timeout also covers the body, so it ends the download once
+	// SpeedTestDuration has passed; the bytes read until then still count
+	client := probe.Client(SpeedTestDuration)
+
+	start := time.Now()
+	resp, err := client.Get(testURL)
+	if err != nil {
+		node.Speed = -1
+		return fmt.Errorf("speed test request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		node.Speed = -1
+		return fmt.Errorf("speed test returned status %d", resp.StatusCode)
+	}
+
+	n, _ := io.Copy(io.Discard, resp.Body)
+	elapsed := time.Since(start).Seconds()
+	if n == 0 || elapsed <= 0 {
+		node.Speed = -1
+		return fmt.Errorf("speed test received no data")
+	}
+
+	node.Speed = float64(n) / elapsed / (1024 * 1024)
+	return nil
+}
+
+// CheckHealth sends an HTTP HEAD to probeURL through the running proxy
+func (c *coreBase) CheckHealth(probeURL string) error {
+	if probeURL == "" {
+		probeURL = DefaultProbeURL
+	}
+
+	if !c.IsRunning() {
+		return fmt.Errorf("%s is not running", c.title)
+	}
+
+	resp, err := socksClient(c.inbound.SocksPort, c.inbound.userinfo(), urlTestTimeout).Head(probeURL)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// URLTest sends an HTTP HEAD to probeURL through node and stores the delay in
+// node.Latency (-1 if the request failed)
+func (c *coreBase) URLTest(node *Node, probeURL string) error {
+	if probeURL == "" {
+		probeURL = DefaultProbeURL
+	}
+
+	probe, err := c.NewProbe(node)
+	if err != nil {
+		node.Latency = -1
+		return err
+	}
+	defer probe.Close()
+
+	client := probe.Client(urlTestTimeout)
+
+	start := time.Now()
+	resp, err := client.Head(probeURL)
+	if err != nil {
+		node.Latency = -1
+		return fmt.Errorf("url test failed: %w", err)
+	}
+	resp.Body.Close()
+
+	// Round up to 1ms: a latency of 0 means the node wasn't tested
+	node.Latency = max(int(time.Since(start).Milliseconds()), 1)
+	return nil
+}
+
+// SelectFastestNodeByURLTest URL-tests all nodes of the subscription and
+// selects the one with the lowest HTTP delay
+func (c *coreBase) SelectFastestNodeByURLTest(sub *Subscription, probeURL string) (*Node, error) {
+	if len(sub.Nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, urlTestConcurrency)
+	for i := range sub.Nodes {
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			c.URLTest(node, probeURL)
+		}(&sub.Nodes[i])
+	}
+	wg.Wait()
+
+	var fastestNode *Node
+	for i := range sub.Nodes {
+		if sub.Nodes[i].Latency > 0 && (fastestNode == nil || sub.Nodes[i].Latency < fastestNode.Latency) {
+			fastestNode = &sub.Nodes[i]
+		}
+	}
+
+	if fastestNode == nil {
+		return nil, fmt.Errorf("no node passed the url test: %w", ErrNoReachableNodes)
+	}
+
+	return fastestNode, nil
+}
+
+// SelectFastestNodeByLatency selects the node with the lowest TCP latency. If no
+// node answers over TCP but nodes may only be reachable through the proxy (UDP
+// nodes such as WireGuard, or an upstream proxy is configured), it URL-tests the
+// nodes instead.
+func (c *coreBase) SelectFastestNodeByLatency(sub *Subscription, probeURL string) (*Node, error) {
+	node, err := sub.SelectFastestNode()
+	if err == nil || (c.upstream == "" && !sub.hasUDPNodes()) {
+		return node, err
+	}
+
+	fmt.Println(i18n.T("No node answered over TCP, testing through the proxy (url-test)..."))
+	return c.SelectFastestNodeByURLTest(sub, probeURL)
+}
+
+// socksClient returns an HTTP client that uses the local SOCKS proxy on port,
+// logging in with user if it is not nil
+func socksClient(port int, user *url.Userinfo, timeout time.Duration) *http.Client {
+	proxyURL := &url.URL{Scheme: "socks5", User: user, Host: fmt.Sprintf("127.0.0.1:%d", port)}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: logging.Transport(&http.Transport{Proxy: http.ProxyURL(proxyURL)}),
+	}
+}
+
+// freePort asks the OS for an unused local TCP port
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitForPort waits until something accepts connections on the local port
+func waitForPort(port int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("proxy core did not start listening on port %d", port)
+}
@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regionPatterns maps region codes to the keywords providers use in node names
+var regionPatterns = []struct {
+	code    string
+	pattern *regexp.Regexp
+}{
+	{"HK", regionPattern(`🇭🇰|香港|港|hong\s*kong`, "hk", "hkg")},
+	{"TW", regionPattern(`🇹🇼|台湾|台灣|台北|taiwan`, "tw", "twn")},
+	{"JP", regionPattern(`🇯🇵|日本|东京|東京|大阪|japan|tokyo|osaka`, "jp", "jpn")},
+	{"SG", regionPattern(`🇸🇬|新加坡|狮城|singapore`, "sg", "sgp")},
+	{"KR", regionPattern(`🇰🇷|韩国|韓國|首尔|korea|seoul`, "kr", "kor")},
+	{"US", regionPattern(`🇺🇸|美国|美國|洛杉矶|硅谷|纽约|united\s*states|america|los\s*angeles|san\s*jose|seattle`, "us", "usa")},
+	{"GB", regionPattern(`🇬🇧|英国|英國|伦敦|united\s*kingdom|britain|london`, "uk", "gb")},
+	{"DE", regionPattern(`🇩🇪|德国|德國|法兰克福|germany|frankfurt`, "de")},
+	{"FR", regionPattern(`🇫🇷|法国|法國|巴黎|france|paris`, "fr")},
+	{"NL", regionPattern(`🇳🇱|荷兰|荷蘭|阿姆斯特丹|netherlands|amsterdam`, "nl")},
+	{"CA", regionPattern(`🇨🇦|加拿大|canada`, "CA")},
+	{"AU", regionPattern(`🇦🇺|澳大利亚|澳洲|悉尼|australia|sydney`, "au")},
+	{"IN", regionPattern(`🇮🇳|印度|india`, "IN")},
+	{"RU", regionPattern(`🇷🇺|俄罗斯|俄羅斯|莫斯科|russia|moscow`, "ru")},
+	{"TR", regionPattern(`🇹🇷|土耳其|turkey`, "tr")},
+	{"MY", regionPattern(`🇲🇾|马来西亚|malaysia`, "MY")},
+	{"TH", regionPattern(`🇹🇭|泰国|thailand`, "th")},
+	{"VN", regionPattern(`🇻🇳|越南|vietnam`, "vn")},
+	{"PH", regionPattern(`🇵🇭|菲律宾|philippines`, "ph")},
+	{"AR", regionPattern(`🇦🇷|阿根廷|argentina`, "AR")},
+}
+
+// regionPattern builds a case-insensitive pattern from free-form keywords and
+// short codes; codes only match as whole words so "us" doesn't match "russia"
+// and "gb" doesn't match "100GB". Codes given in uppercase are also English
+// words, so they only match in uppercase: "IN 01" is India, "Log in" isn't.
+func regionPattern(keywords string, codes ...string) *regexp.Regexp {
+	var folded, exact []string
+	for _, code := range codes {
+		if code == strings.ToUpper(code) {
+			exact = append(exact, code)
+		} else {
+			folded = append(folded, code)
+		}
+	}
+
+	pattern := `(?i:` + keywords
+	if len(folded) > 0 {
+		pattern += `|(^|[^a-z0-9])(` + strings.Join(folded, "|") + `)([^a-z0-9]|$)`
+	}
+	pattern += `)`
+	if len(exact) > 0 {
+		pattern += `|(^|[^A-Za-z0-9])(` + strings.Join(exact, "|") + `)([^A-Za-z0-9]|$)`
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// DetectRegion guesses the region code (e.g. "HK", "JP") of a node from its name.
+// It returns an empty string if no region is recognized.
+func DetectRegion(name string) string {
+	for _, region := range regionPatterns {
+		if region.pattern.MatchString(name) {
+			return region.code
+		}
+	}
+	return ""
+}
+
+// PreferRegions narrows nodes to those in the preferred regions, then drops
+// those in avoided regions. A step that would leave no nodes is skipped, so
+// preferences never make selection fail.
+func PreferRegions(nodes []Node, prefer, avoid []string) []Node {
+	if len(prefer) > 0 {
+		if preferred := nodesInRegions(nodes, prefer, true); len(preferred) > 0 {
+			nodes = preferred
+		}
+	}
+	if len(avoid) > 0 {
+		if kept := nodesInRegions(nodes, avoid, false); len(kept) > 0 {
+			nodes = kept
+		}
+	}
+	return nodes
+}
+
+// nodesInRegions returns the nodes whose detected region is (or, if in is
+// false, is not) one of regions
+func nodesInRegions(nodes []Node, regions []string, in bool) []Node {
+	matched := []Node{}
+	for _, node := range nodes {
+		if containsFold(regions, DetectRegion(node.Name)) == in {
+			matched = append(matched, node)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a user routing rule. Connections to any of its domains or IPs (and
+// ports, if set) go to its outbound: "direct", "proxy" or "block".
+type Rule struct {
+	Outbound string
+	Domains  []string // e.g. example.com (with subdomains), full:example.com, geosite:google
+	IPs      []string // e.g. 10.0.0.0/8, geoip:jp
+	Ports    string   // e.g. "22" or "1000-2000,3000"
+}
+
+// generateRouting generates the routing section. DNS queries captured in TUN
+// mode come first, then user rules, the always-proxy domains and finally the
+// built-in rules sending private and Chinese destinations direct. If balanced,
+// proxied traffic goes to the balancer rather than the "proxy" outbound.
+func (x *XrayManager) generateRouting(balanced bool) (map[string]interface{}, error) {
+	// Xray won't start with a geoip: code its geoip.dat lacks, which a mirror
+	// of the files may have
+	if len(x.geoData.GeoIP) == 0 {
+		if missing := GeoIPMissing(x.geoData.Variant, x.rules); len(missing) > 0 {
+			return nil, fmt.Errorf("the %s geo data has no %s, switch with: crosh geodata update --variant %s",
+				x.geoData.Variant, strings.Join(missing, ", "), DefaultGeoDataVariant)
+		}
+	}
+
+	rules := []map[string]interface{}{}
+	if x.hijackDNS() {
+		rules = append(rules, map[string]interface{}{
+			"type":        "field",
+			"inboundTag":  []string{"tun-in"},
+			"port":        "53",
+			"outboundTag": "dns-out",
+		})
+	}
+	for i, rule := range x.rules {
+		generated, err := generateRule(rule, balanced)
+		if err != nil {
+			return nil, fmt.Errorf("invalid routing rule %d: %w", i+1, err)
+		}
+		rules = append(rules, generated...)
+	}
+	if len(x.alwaysProxy) > 0 {
+		generated, err := generateRule(Rule{Outbound: "proxy", Domains: x.alwaysProxy}, balanced)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, generated...)
+	}
+
+	rules = append(rules,
+		map[string]interface{}{
+			"type":        "field",
+			"ip":          []string{"geoip:private"},
+			"outboundTag": "direct",
+		},
+		map[string]interface{}{
+			"type":        "field",
+			"ip":          []string{"geoip:cn"},
+			"outboundTag": "direct",
+		},
+		map[string]interface{}{
+			"type":        "field",
+			"domain":      []string{"geosite:cn"},
+			"outboundTag": "direct",
+		},
+	)
+
+	// The first outbound catches everything else; a balancer needs a rule
+	if balanced {
+		rules = append(rules, map[string]interface{}{
+			"type":        "field",
+			"network":     "tcp,udp",
+			"balancerTag": "balancer",
+		})
+	}
+
+	return map[string]interface{}{
+		"domainStrategy": "IPIfNonMatch",
+		"rules":          rules,
+	}, nil
+}
+
+// generateRule converts a user rule to Xray rules. Xray ANDs the conditions of
+// a rule, so domains and IPs become separate rules to match either.
+func generateRule(rule Rule, balanced bool) ([]map[string]interface{}, error) {
+	if err := checkRuleOutbound(rule.Outbound); err != nil {
+		return nil, err
+	}
+
+	target := func(r map[string]interface{}) map[string]interface{} {
+		r["type"] = "field"
+		if rule.Ports != "" {
+			r["port"] = rule.Ports
+		}
+		if rule.Outbound == "proxy" && balanced {
+			r["balancerTag"] = "balancer"
+		} else {
+			r["outboundTag"] = rule.Outbound
+		}
+		return r
+	}
+
+	rules := []map[string]interface{}{}
+	if len(rule.Domains) > 0 {
+		rules = append(rules, target(map[string]interface{}{"domain": xrayDomains(rule.Domains)}))
+	}
+	if len(rule.IPs) > 0 {
+		rules = append(rules, target(map[string]interface{}{"ip": rule.IPs}))
+	}
+	if len(rules) == 0 {
+		if rule.Ports == "" {
+			return nil, fmt.Errorf("rule needs domains, ips or ports")
+		}
+		rules = append(rules, target(map[string]interface{}{}))
+	}
+	return rules, nil
+}
+
+// checkRuleOutbound checks that a rule sends connections to a known outbound
+func checkRuleOutbound(outbound string) error {
+	switch outbound {
+	case "direct", "proxy", "block":
+		return nil
+	}
+	return fmt.Errorf("outbound must be direct, proxy or block, not %q", outbound)
+}
+
+// xrayDomains converts bare domains to Xray "domain:" matches, which include
+// subdomains; to Xray a bare domain matches as a substring anywhere in a name
+func xrayDomains(domains []string) []string {
+	converted := make([]string, len(domains))
+	for i, domain := range domains {
+		if !strings.Contains(domain, ":") {
+			domain = "domain:" + domain
+		}
+		converted[i] = domain
+	}
+	return converted
+}
@@ -0,0 +1,441 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// Service is a system service that ignores the proxy variables of the
+// user's shell, so it gets the local proxy in its own configuration
+type Service interface {
+	// Installed reports whether the service is set up on this machine
+	Installed() bool
+	// EnableProxy makes the service use proxyURL, reporting whether anything changed
+	EnableProxy(proxyURL string) (bool, error)
+	// DisableProxy removes the proxy crosh set, reporting whether there was one
+	DisableProxy() (bool, error)
+	// UsesProxy reports whether the service has the proxy crosh set
+	UsesProxy() bool
+}
+
+// Services are the services crosh can give the proxy, by the names
+// proxy.services takes
+var Services = map[string]Service{
+	"chocolatey": chocolateyService{},
+	"ollama":     systemdService{unit: "ollama.service"},
+	"scoop":      scoopService{},
+	"snapd":      snapdService{},
+}
+
+// ServiceNames returns the names of Services, in order
+func ServiceNames() []string {
+	names := make([]string, 0, len(Services))
+	for name := range Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// systemdService is a service run by systemd, which gets HTTPS_PROXY from a
+// drop-in. HTTP_PROXY is left out, since services such as Ollama would send
+// their local clients' requests through it too.
+type systemdService struct {
+	unit string
+}
+
+// dropInPath returns the path of crosh's drop-in for the unit
+func (s systemdService) dropInPath() string {
+	return filepath.Join("/etc/systemd/system", s.unit+".d", "crosh-proxy.conf")
+}
+
+// Installed reports whether the unit is installed system-wide
+func (s systemdService) Installed() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+	for _, dir := range []string{"/etc/systemd/system", "/lib/systemd/system", "/usr/lib/systemd/system"} {
+		if _, err := os.Stat(filepath.Join(dir, s.unit)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// systemdEscape escapes value for a double-quoted Environment= setting, where
+// systemd takes % as the start of a specifier, as in the percent-encoded
+// password of a proxy URL
+func systemdEscape(value string) string {
+	return strings.NewReplacer("%", "%%", `\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+// EnableProxy writes the drop-in and restarts the unit to pick it up. The
+// drop-in is only readable by root, which systemd runs as, since the proxy
+// URL carries the inbound's credentials when proxy.auth is set. Without
+// root, the unit is skipped quietly, as crosh on mostly runs without sudo.
+func (s systemdService) EnableProxy(proxyURL string) (bool, error) {
+	content := fmt.Sprintf("# Generated by crosh: the local proxy, removed by crosh off\n[Service]\nEnvironment=\"HTTPS_PROXY=%s\"\n", systemdEscape(proxyURL))
+	path := s.dropInPath()
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		// Drop-ins written before were readable by anyone
+		if err := os.Chmod(path, 0600); err != nil && !errors.Is(err, fs.ErrPermission) {
+			return false, fmt.Errorf("failed to change the mode of %s: %w", path, err)
+		}
+	}
+	if data, err := os.ReadFile(path); err == nil && string(data) == content {
+		return false, nil
+	}
+
+	if err := logging.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			logging.Debugf("skip %s: %v", s.unit, err)
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create %s (try running with sudo): %w", filepath.Dir(path), err)
+	}
+	if err := logging.WriteFile(path, []byte(content), 0600); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			logging.Debugf("skip %s: %v", s.unit, err)
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to write %s (try running with sudo): %w", path, err)
+	}
+	return true, s.restart()
+}
+
+// DisableProxy removes the drop-in and restarts the unit without it
+func (s systemdService) DisableProxy() (bool, error) {
+	path := s.dropInPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err := logging.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to remove %s (try running with sudo): %w", path, err)
+	}
+	// The directory is crosh's too once it's empty
+	os.Remove(filepath.Dir(path))
+	return true, s.restart()
+}
+
+// UsesProxy reports whether crosh's drop-in is there
+func (s systemdService) UsesProxy() bool {
+	_, err := os.Stat(s.dropInPath())
+	return err == nil
+}
+
+// restart reloads systemd's units and restarts the unit if it is running
+func (s systemdService) restart() error {
+	if output, err := logging.Run("systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("systemctl daemon-reload: %s", strings.TrimSpace(string(output)))
+	}
+	if output, err := logging.Run("systemctl", "try-restart", s.unit); err != nil {
+		return fmt.Errorf("systemctl try-restart %s: %s", s.unit, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// snapdService is snapd, which downloads snaps itself rather than from the
+// user's shell, so it gets the proxy in its system options
+type snapdService struct{}
+
+// Installed reports whether snap is installed
+func (snapdService) Installed() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	_, err := exec.LookPath("snap")
+	return err == nil
+}
+
+// snapGet returns a system option of snapd, or "" if it isn't set
+func snapGet(key string) string {
+	output, err := logging.Command("snap", "get", "system", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// isLoopback reports whether proxyURL is on this machine, as crosh's is
+func isLoopback(proxyURL string) bool {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(u.Hostname())
+	return u.Hostname() == "localhost" || (ip != nil && ip.IsLoopback())
+}
+
+// EnableProxy sets snapd's proxy.http and proxy.https options. snapd only
+// takes them from root, so without it snapd is skipped quietly, like a
+// systemd unit.
+func (s snapdService) EnableProxy(proxyURL string) (bool, error) {
+	if !strings.HasPrefix(proxyURL, "http://") {
+		return false, fmt.Errorf("snapd only takes an HTTP proxy, and proxy.http_port is 0")
+	}
+	// Any local user can read snapd's options with snap get, so the
+	// credentials of proxy.auth don't go there
+	if u, err := url.Parse(proxyURL); err == nil && u.User != nil {
+		return false, fmt.Errorf("the proxy requires a login, which any user could read with snap get")
+	}
+	current := snapGet("proxy.https")
+	if current == proxyURL && snapGet("proxy.http") == proxyURL {
+		return false, nil
+	}
+	// A proxy the user set, e.g. a company one, stays
+	if current != "" && !isLoopback(current) {
+		return false, fmt.Errorf("it already uses %s", current)
+	}
+
+	if output, err := logging.Run("snap", "set", "system", "proxy.http="+proxyURL, "proxy.https="+proxyURL); err != nil {
+		// snap answers "error: access denied (try with sudo)"
+		if strings.Contains(string(output), "access denied") {
+			logging.Debugf("skip snapd: %s", strings.TrimSpace(string(output)))
+			return false, nil
+		}
+		return false, fmt.Errorf("snap set system proxy (try running with sudo): %s", strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
+
+// DisableProxy unsets the proxy options that point at this machine, which
+// crosh set, leaving a proxy the user set alone
+func (s snapdService) DisableProxy() (bool, error) {
+	if !s.Installed() {
+		return false, nil
+	}
+	var keys []string
+	for _, key := range []string{"proxy.http", "proxy.https"} {
+		if value := snapGet(key); value != "" && isLoopback(value) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+
+	if output, err := logging.Run("snap", append([]string{"unset", "system"}, keys...)...); err != nil {
+		return false, fmt.Errorf("snap unset system proxy (try running with sudo): %s", strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
+
+// UsesProxy reports whether snapd's proxy.https option points at this machine
+func (s snapdService) UsesProxy() bool {
+	return s.Installed() && isLoopback(snapGet("proxy.https"))
+}
+
+// scoopService is Scoop, which downloads through the system proxy of
+// Windows rather than the shell's, so it gets the proxy in its config.json
+type scoopService struct{}
+
+// configPath returns the path of Scoop's config.json, under
+// $XDG_CONFIG_HOME or ~/.config like Scoop's own
+func (scoopService) configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "scoop", "config.json"), nil
+}
+
+// readConfig reads Scoop's config.json, or returns an empty one if it doesn't exist
+func (s scoopService) readConfig() (string, map[string]interface{}, error) {
+	path, err := s.configPath()
+	if err != nil {
+		return "", nil, err
+	}
+	config := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return path, config, nil
+		}
+		return "", nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return path, config, nil
+}
+
+// writeConfig writes Scoop's config.json
+func (scoopService) writeConfig(path string, config map[string]interface{}) error {
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+	if err := logging.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := logging.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Installed reports whether Scoop is installed for this user
+func (scoopService) Installed() bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	_, err := exec.LookPath("scoop")
+	return err == nil
+}
+
+// EnableProxy sets Scoop's proxy option, which takes host:port with optional
+// credentials rather than a URL
+func (s scoopService) EnableProxy(proxyURL string) (bool, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Scheme != "http" {
+		return false, fmt.Errorf("scoop only takes an HTTP proxy, and proxy.http_port is 0")
+	}
+	proxy := u.Host
+	if u.User != nil {
+		proxy = u.User.String() + "@" + u.Host
+	}
+
+	path, config, err := s.readConfig()
+	if err != nil {
+		return false, err
+	}
+	current, _ := config["proxy"].(string)
+	if current == proxy {
+		return false, nil
+	}
+	// A proxy the user set, e.g. a company one, stays; "none" turns it off
+	if current != "" && !isLoopback("http://"+current) {
+		return false, fmt.Errorf("it already uses %s", current)
+	}
+
+	config["proxy"] = proxy
+	return true, s.writeConfig(path, config)
+}
+
+// DisableProxy removes Scoop's proxy option if it points at this machine,
+// as crosh's does, leaving a proxy the user set alone
+func (s scoopService) DisableProxy() (bool, error) {
+	path, config, err := s.readConfig()
+	if err != nil {
+		return false, err
+	}
+	current, _ := config["proxy"].(string)
+	if current == "" || !isLoopback("http://"+current) {
+		return false, nil
+	}
+
+	delete(config, "proxy")
+	return true, s.writeConfig(path, config)
+}
+
+// UsesProxy reports whether Scoop's proxy option points at this machine
+func (s scoopService) UsesProxy() bool {
+	_, config, err := s.readConfig()
+	if err != nil {
+		return false
+	}
+	current, _ := config["proxy"].(string)
+	return current != "" && isLoopback("http://"+current)
+}
+
+// chocolateyService is Chocolatey, which downloads through the system proxy
+// of Windows rather than the shell's, so it gets the proxy in its config
+type chocolateyService struct{}
+
+// Installed reports whether Chocolatey is installed
+func (chocolateyService) Installed() bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	_, err := exec.LookPath("choco")
+	return err == nil
+}
+
+// chocoGet returns a setting of Chocolatey's config, or "" if it isn't set
+func chocoGet(name string) string {
+	output, err := logging.Command("choco", "config", "get", "--name="+name, "--limit-output").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// chocoSet sets settings of Chocolatey's config, unsetting those with an empty value
+func chocoSet(settings [][2]string) error {
+	for _, setting := range settings {
+		args := []string{"config", "unset", "--name=" + setting[0]}
+		if setting[1] != "" {
+			args = []string{"config", "set", "--name=" + setting[0], "--value=" + setting[1]}
+		}
+		if output, err := logging.Run("choco", args...); err != nil {
+			return fmt.Errorf("choco config %s %s (try running as administrator): %s", args[1], setting[0], strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// EnableProxy sets Chocolatey's proxy settings, which take the credentials
+// apart from the URL
+func (s chocolateyService) EnableProxy(proxyURL string) (bool, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Scheme != "http" {
+		return false, fmt.Errorf("chocolatey only takes an HTTP proxy, and proxy.http_port is 0")
+	}
+	user, password := "", ""
+	if u.User != nil {
+		user = u.User.Username()
+		password, _ = u.User.Password()
+		u.User = nil
+	}
+
+	current := chocoGet("proxy")
+	if current == u.String() && chocoGet("proxyUser") == user {
+		return false, nil
+	}
+	// A proxy the user set, e.g. a company one, stays
+	if current != "" && !isLoopback(current) {
+		return false, fmt.Errorf("it already uses %s", current)
+	}
+
+	if err := chocoSet([][2]string{{"proxy", u.String()}, {"proxyUser", user}, {"proxyPassword", password}}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DisableProxy unsets Chocolatey's proxy settings if the proxy points at
+// this machine, as crosh's does, leaving a proxy the user set alone
+func (s chocolateyService) DisableProxy() (bool, error) {
+	if !s.UsesProxy() {
+		return false, nil
+	}
+	if err := chocoSet([][2]string{{"proxy", ""}, {"proxyUser", ""}, {"proxyPassword", ""}}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UsesProxy reports whether Chocolatey's proxy points at this machine
+func (s chocolateyService) UsesProxy() bool {
+	return s.Installed() && isLoopback(chocoGet("proxy"))
+}
@@ -0,0 +1,748 @@
+package proxy
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// sing-box releases; there is no crosh mirror for them yet
+const (
+	singBoxReleaseAPI     = "https://api.github.com/repos/SagerNet/sing-box/releases/latest"
+	singBoxDownloadURL    = "https://github.com/SagerNet/sing-box/releases/download"
+	singBoxDefaultVersion = "v1.11.0"
+)
+
+// singBoxRuleSetURL is where the geoip-xx and geosite-xx rule sets that
+// replace Xray's geo data files are downloaded from
+const singBoxRuleSetURL = "https://raw.githubusercontent.com/SagerNet/sing-%s/rule-set/%s.srs"
+
+// SingBoxManager runs nodes with sing-box, which also supports Hysteria2 and TUIC
+type SingBoxManager struct {
+	coreBase
+}
+
+// NewSingBoxManager creates a sing-box manager for the binary at binPath
+func NewSingBoxManager(binPath string, opts Options) *SingBoxManager {
+	s := &SingBoxManager{
+		coreBase: newCoreBase("sing-box", binPath, "sing-box", "sing-box.json", opts),
+	}
+	s.runArgs = func(configPath string) []string { return []string{"run", "-c", configPath} }
+	s.probeConfig = s.generateProbeConfig
+	return s
+}
+
+// Name returns the name of the core
+func (s *SingBoxManager) Name() string {
+	return CoreSingBox
+}
+
+// Supports reports whether sing-box can run nodes of the given type
+func (s *SingBoxManager) Supports(nodeType string) bool {
+	switch nodeType {
+	case "vmess", "vless", "trojan", "ss", "wireguard", "hysteria2", "tuic":
+		return true
+	}
+	return false
+}
+
+// Start starts the sing-box process. In TUN mode sing-box sets up the routes
+// itself.
+func (s *SingBoxManager) Start() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	if s.inbound.TUN.Enabled {
+		fmt.Printf(i18n.T("✓ TUN mode: all traffic goes through %s\n"), s.inbound.TUN.name())
+	}
+	return nil
+}
+
+// Download downloads the latest sing-box release if it isn't installed yet
+func (s *SingBoxManager) Download() error {
+	if _, err := os.Stat(s.binPath); err == nil {
+		fmt.Println(i18n.T("sing-box already exists, skipping download"))
+		return nil
+	}
+
+	fmt.Println(i18n.T("Downloading sing-box..."))
+	if err := os.MkdirAll(filepath.Dir(s.binPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	version, err := s.latestVersion()
+	if err != nil {
+		fmt.Printf(i18n.T("Warning: failed to get latest release info: %v\n"), err)
+		fmt.Printf(i18n.T("Falling back to default version %s\n"), singBoxDefaultVersion)
+		version = singBoxDefaultVersion
+	}
+
+	fmt.Printf(i18n.T("Downloading sing-box version %s...\n"), version)
+	downloadURL := fmt.Sprintf("%s/%s/%s", singBoxDownloadURL, version, singBoxAssetName(version))
+	if err := s.downloadFromURL(downloadURL); err != nil {
+		return fmt.Errorf("failed to download sing-box: %w", err)
+	}
+
+	fmt.Println(i18n.T("✓ sing-box downloaded successfully"))
+	return nil
+}
+
+// latestVersion returns the tag of the latest sing-box release
+func (s *SingBoxManager) latestVersion() (string, error) {
+	client := NewHTTPClient(s.upstream, 30*time.Second)
+
+	resp, err := client.Get(singBoxReleaseAPI)
+	if err != nil {
+		return "", &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", networkErrorf("API returned status %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("release has no tag")
+	}
+
+	return release.TagName, nil
+}
+
+// singBoxAssetName returns the release archive for this platform, e.g.
+// sing-box-1.11.0-linux-amd64.tar.gz
+func singBoxAssetName(version string) string {
+	arch := runtime.GOARCH
+	if arch == "arm" {
+		arch = "armv7"
+	}
+
+	ext := ".tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = ".zip"
+	}
+
+	return fmt.Sprintf("sing-box-%s-%s-%s%s", strings.TrimPrefix(version, "v"), runtime.GOOS, arch, ext)
+}
+
+// downloadFromURL downloads a sing-box release archive and installs its binary
+func (s *SingBoxManager) downloadFromURL(downloadURL string) error {
+	client := NewHTTPClient(s.upstream, 5*time.Minute)
+
+	resp, err := client.Get(downloadURL)
+	if err != nil {
+		return networkErrorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return networkErrorf("HTTP %d", resp.StatusCode)
+	}
+
+	tmpArchive := s.binPath + ".tmp.archive"
+	out, err := os.Create(tmpArchive)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	defer os.Remove(tmpArchive)
+
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	if strings.HasSuffix(downloadURL, ".zip") {
+		err = s.extractFromZip(tmpArchive)
+	} else {
+		err = s.extractFromTarGz(tmpArchive)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+
+	return nil
+}
+
+// extractFromTarGz installs the sing-box binary from a .tar.gz release archive
+func (s *SingBoxManager) extractFromTarGz(archivePath string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("sing-box binary not found in archive")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == "sing-box" {
+			return s.installBinary(reader)
+		}
+	}
+}
+
+// extractFromZip installs the sing-box binary from a .zip release archive
+func (s *SingBoxManager) extractFromZip(archivePath string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		name := filepath.Base(file.Name)
+		if name != "sing-box" && name != "sing-box.exe" {
+			continue
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open file in zip: %w", err)
+		}
+		defer src.Close()
+
+		return s.installBinary(src)
+	}
+
+	return fmt.Errorf("sing-box binary not found in zip")
+}
+
+// GenerateConfig generates the sing-box configuration for a node
+func (s *SingBoxManager) GenerateConfig(node *Node) error {
+	return s.generateConfig([]*Node{node}, "")
+}
+
+// GenerateBalancerConfig generates a sing-box configuration whose "proxy"
+// outbound url-tests nodes through probeURL and uses the fastest
+func (s *SingBoxManager) GenerateBalancerConfig(nodes []*Node, probeURL string) error {
+	if probeURL == "" {
+		probeURL = DefaultProbeURL
+	}
+	return s.generateConfig(nodes, probeURL)
+}
+
+// generateConfig generates and writes the sing-box configuration
+func (s *SingBoxManager) generateConfig(nodes []*Node, probeURL string) error {
+	outbounds, err := s.generateProxyOutbounds(nodes...)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 1 {
+		tags := []string{}
+		for i := range nodes {
+			tags = append(tags, fmt.Sprintf("proxy-%d", i+1))
+		}
+		outbounds = append(outbounds, map[string]interface{}{
+			"type":      "urltest",
+			"tag":       "proxy",
+			"outbounds": tags,
+			"url":       probeURL,
+			"interval":  "1m",
+		})
+	}
+	outbounds = append(outbounds, map[string]interface{}{"type": "direct", "tag": "direct"})
+
+	route, err := s.generateRoute()
+	if err != nil {
+		return err
+	}
+
+	config := map[string]interface{}{
+		"log":       s.generateLog(),
+		"inbounds":  s.generateInbounds(),
+		"outbounds": outbounds,
+		"route":     route,
+		"experimental": map[string]interface{}{
+			// Keeps downloaded rule sets and fake addresses across restarts
+			"cache_file": map[string]interface{}{
+				"enabled": true,
+				"path":    filepath.Join(filepath.Dir(s.binPath), "sing-box.db"),
+			},
+		},
+	}
+	if api := s.clashAPI(); api != "" {
+		config["experimental"].(map[string]interface{})["clash_api"] = map[string]interface{}{"external_controller": api}
+	}
+	if s.dnsEnabled() {
+		config["dns"] = s.generateDNS()
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := logging.WriteFile(s.configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+// generateLog generates the log section; sing-box calls the warning level "warn"
+func (s *SingBoxManager) generateLog() map[string]interface{} {
+	switch level := s.log.level(); level {
+	case "none":
+		return map[string]interface{}{"disabled": true}
+	case "warning":
+		return map[string]interface{}{"level": "warn", "timestamp": true}
+	default:
+		return map[string]interface{}{"level": level, "timestamp": true}
+	}
+}
+
+// generateProbeConfig generates a config that sends everything from a local
+// SOCKS port through node
+func (s *SingBoxManager) generateProbeConfig(node *Node, port int) ([]byte, error) {
+	outbounds, err := s.generateProxyOutbounds(node)
+	if err != nil {
+		return nil, err
+	}
+
+	config := map[string]interface{}{
+		"log": map[string]interface{}{"disabled": true},
+		"inbounds": []map[string]interface{}{
+			{"type": "socks", "tag": "socks-in", "listen": "127.0.0.1", "listen_port": port},
+		},
+		"outbounds": outbounds,
+		// Test the node directly rather than through the TUN interface
+		"route": map[string]interface{}{"final": "proxy", "auto_detect_interface": true},
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// generateInbounds generates the local SOCKS inbound and, if enabled, the HTTP
+// and TUN inbounds
+func (s *SingBoxManager) generateInbounds() []map[string]interface{} {
+	listen := s.inbound.Listen
+	if listen == "" {
+		listen = "::"
+	}
+
+	inbounds := []map[string]interface{}{
+		{"type": "socks", "tag": "socks-in", "listen": listen, "listen_port": s.inbound.SocksPort},
+	}
+	if s.inbound.HTTPPort > 0 {
+		inbounds = append(inbounds, map[string]interface{}{
+			"type": "http", "tag": "http-in", "listen": listen, "listen_port": s.inbound.HTTPPort,
+		})
+	}
+	if s.inbound.Username != "" {
+		for _, inbound := range inbounds {
+			inbound["users"] = []map[string]interface{}{
+				{"username": s.inbound.Username, "password": s.inbound.Password},
+			}
+		}
+	}
+
+	if s.inbound.TUN.Enabled {
+		mtu := s.inbound.TUN.MTU
+		if mtu == 0 {
+			mtu = 1500
+		}
+		inbounds = append(inbounds, map[string]interface{}{
+			"type":           "tun",
+			"tag":            "tun-in",
+			"interface_name": s.inbound.TUN.name(),
+			"address":        []string{tunAddress + "/30"},
+			"mtu":            mtu,
+			"auto_route":     true,
+			"strict_route":   true,
+		})
+	}
+
+	return inbounds
+}
+
+// generateProxyOutbounds generates the proxy outbounds for nodes, followed by the
+// upstream proxy outbound they dial through if an upstream proxy is configured.
+// A single node is tagged "proxy"; several are tagged "proxy-1", "proxy-2", ...
+func (s *SingBoxManager) generateProxyOutbounds(nodes ...*Node) ([]map[string]interface{}, error) {
+	upstream, err := ParseUpstream(s.upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	outbounds := []map[string]interface{}{}
+	for i, node := range nodes {
+		outbound, err := s.generateOutbound(node)
+		if err != nil {
+			return nil, err
+		}
+		outbound["tag"] = "proxy"
+		if len(nodes) > 1 {
+			outbound["tag"] = fmt.Sprintf("proxy-%d", i+1)
+		}
+
+		if upstream != nil {
+			if UDPOnly(node.Type) {
+				return nil, fmt.Errorf("%s node %s can't be reached through an upstream proxy", node.Type, node.Name)
+			}
+			outbound["detour"] = upstreamTag
+		}
+
+		outbounds = append(outbounds, outbound)
+	}
+
+	if upstream != nil {
+		outbounds = append(outbounds, generateSingBoxUpstream(upstream))
+	}
+
+	return outbounds, nil
+}
+
+// generateOutbound generates the sing-box outbound for a node
+func (s *SingBoxManager) generateOutbound(node *Node) (map[string]interface{}, error) {
+	outbound := map[string]interface{}{
+		"server":      node.Server,
+		"server_port": node.Port,
+	}
+
+	switch node.Type {
+	case "vmess":
+		outbound["type"] = "vmess"
+		outbound["uuid"] = node.UUID
+		outbound["security"] = vmessSecurity(node)
+		outbound["alter_id"] = 0
+		addSingBoxTransport(outbound, node)
+	case "vless":
+		outbound["type"] = "vless"
+		outbound["uuid"] = node.UUID
+		if node.Flow != "" {
+			outbound["flow"] = node.Flow
+		}
+		addSingBoxTransport(outbound, node)
+	case "trojan":
+		outbound["type"] = "trojan"
+		outbound["password"] = node.Password
+		// Like the Xray outbound, accept self-signed certificates
+		outbound["tls"] = singBoxTLS(node, true)
+	case "ss":
+		outbound["type"] = "shadowsocks"
+		outbound["method"] = node.Security
+		outbound["password"] = node.Password
+	case "wireguard":
+		wg := node.WireGuard
+		if wg == nil {
+			return nil, fmt.Errorf("WireGuard node %s has no keys", node.Name)
+		}
+		outbound["type"] = "wireguard"
+		outbound["local_address"] = wg.Address
+		outbound["private_key"] = wg.PrivateKey
+		outbound["peer_public_key"] = wg.PublicKey
+		if wg.PresharedKey != "" {
+			outbound["pre_shared_key"] = wg.PresharedKey
+		}
+		if len(wg.Reserved) > 0 {
+			outbound["reserved"] = wg.Reserved
+		}
+		if wg.MTU > 0 {
+			outbound["mtu"] = wg.MTU
+		}
+	case "hysteria2":
+		outbound["type"] = "hysteria2"
+		outbound["password"] = node.Password
+		if node.Obfs != "" {
+			outbound["obfs"] = map[string]interface{}{"type": node.Obfs, "password": node.ObfsPassword}
+		}
+		outbound["tls"] = singBoxTLS(node, node.SkipCertVerify)
+	case "tuic":
+		outbound["type"] = "tuic"
+		outbound["uuid"] = node.UUID
+		outbound["password"] = node.Password
+		if node.CongestionControl != "" {
+			outbound["congestion_control"] = node.CongestionControl
+		}
+		tls := singBoxTLS(node, node.SkipCertVerify)
+		if len(node.ALPN) == 0 {
+			// TUIC servers expect HTTP/3 unless told otherwise
+			tls["alpn"] = []string{"h3"}
+		}
+		outbound["tls"] = tls
+	default:
+		return nil, fmt.Errorf("unsupported node type: %s", node.Type)
+	}
+
+	return outbound, nil
+}
+
+// singBoxTLS generates the TLS settings of an outbound
+func singBoxTLS(node *Node, insecure bool) map[string]interface{} {
+	tls := map[string]interface{}{
+		"enabled":     true,
+		"server_name": tlsServerName(node),
+		"insecure":    insecure,
+	}
+	if len(node.ALPN) > 0 {
+		tls["alpn"] = node.ALPN
+	}
+	return tls
+}
+
+// addSingBoxTransport adds the transport and TLS settings of a VMess or VLESS node
+func addSingBoxTransport(outbound map[string]interface{}, node *Node) {
+	switch node.Network {
+	case "ws":
+		transport := map[string]interface{}{"type": "ws", "path": node.Path}
+		if node.Host != "" {
+			transport["headers"] = map[string]string{"Host": node.Host}
+		}
+		outbound["transport"] = transport
+	case "grpc":
+		outbound["transport"] = map[string]interface{}{"type": "grpc", "service_name": node.ServiceName}
+	case "h2", "http":
+		transport := map[string]interface{}{"type": "http", "path": node.Path}
+		if node.Host != "" {
+			transport["host"] = strings.Split(node.Host, ",")
+		}
+		outbound["transport"] = transport
+	}
+
+	if node.TLS == "tls" {
+		outbound["tls"] = singBoxTLS(node, node.SkipCertVerify)
+	}
+}
+
+// generateSingBoxUpstream generates the outbound that reaches the upstream proxy
+func generateSingBoxUpstream(u *url.URL) map[string]interface{} {
+	port, _ := strconv.Atoi(u.Port())
+	outbound := map[string]interface{}{
+		"type":        "http",
+		"tag":         upstreamTag,
+		"server":      u.Hostname(),
+		"server_port": port,
+	}
+	if u.Scheme == "socks5" {
+		outbound["type"] = "socks"
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		outbound["username"] = u.User.Username()
+		outbound["password"] = password
+	}
+	if u.Scheme == "https" {
+		outbound["tls"] = map[string]interface{}{"enabled": true, "server_name": u.Hostname()}
+	}
+	return outbound
+}
+
+// generateRoute generates the route section, in the same order as the Xray
+// routing: DNS hijacking in TUN mode, user rules, the always-proxy domains and
+// the built-in rules sending private and Chinese destinations direct
+func (s *SingBoxManager) generateRoute() (map[string]interface{}, error) {
+	ruleSets := map[string]bool{"geoip-cn": true, "geosite-cn": true}
+
+	rules := []map[string]interface{}{}
+	if s.sniffing.Enabled {
+		rules = append(rules, map[string]interface{}{"action": "sniff"})
+	}
+	if s.hijackDNS() {
+		rules = append(rules, map[string]interface{}{"port": 53, "action": "hijack-dns"})
+	}
+	for i, rule := range s.rules {
+		generated, err := generateSingBoxRule(rule, ruleSets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid routing rule %d: %w", i+1, err)
+		}
+		rules = append(rules, generated)
+	}
+	if len(s.alwaysProxy) > 0 {
+		generated, err := generateSingBoxRule(Rule{Outbound: "proxy", Domains: s.alwaysProxy}, ruleSets)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, generated)
+	}
+	rules = append(rules,
+		map[string]interface{}{"ip_is_private": true, "outbound": "direct"},
+		map[string]interface{}{"rule_set": []string{"geoip-cn", "geosite-cn"}, "outbound": "direct"},
+	)
+
+	route := map[string]interface{}{
+		"rules":    rules,
+		"rule_set": s.generateRuleSets(ruleSets),
+		"final":    "proxy",
+	}
+	if s.inbound.TUN.Enabled {
+		// Keep sing-box's own connections out of the TUN interface
+		route["auto_detect_interface"] = true
+	}
+	return route, nil
+}
+
+// generateRuleSets generates the remote rule sets the rules refer to. They are
+// downloaded through the proxy, as GitHub is often slow to reach directly.
+func (s *SingBoxManager) generateRuleSets(tags map[string]bool) []map[string]interface{} {
+	ruleSets := []map[string]interface{}{}
+	for tag := range tags {
+		kind, _, _ := strings.Cut(tag, "-")
+		ruleSets = append(ruleSets, map[string]interface{}{
+			"type":            "remote",
+			"tag":             tag,
+			"format":          "binary",
+			"url":             fmt.Sprintf(singBoxRuleSetURL, kind, tag),
+			"download_detour": "proxy",
+		})
+	}
+	// Keep the config stable across runs
+	sort.Slice(ruleSets, func(i, j int) bool {
+		return ruleSets[i]["tag"].(string) < ruleSets[j]["tag"].(string)
+	})
+	return ruleSets
+}
+
+// generateSingBoxRule converts a user rule to a sing-box rule. sing-box ORs the
+// domain and IP conditions of a rule, then ANDs the ports. Rule sets used for
+// geosite: and geoip: matches are added to ruleSets.
+func generateSingBoxRule(rule Rule, ruleSets map[string]bool) (map[string]interface{}, error) {
+	if err := checkRuleOutbound(rule.Outbound); err != nil {
+		return nil, err
+	}
+	if len(rule.Domains) == 0 && len(rule.IPs) == 0 && rule.Ports == "" {
+		return nil, fmt.Errorf("rule needs domains, ips or ports")
+	}
+
+	generated := map[string]interface{}{}
+	add := func(key string, value interface{}) {
+		list, _ := generated[key].([]interface{})
+		generated[key] = append(list, value)
+	}
+	useRuleSet := func(tag string) {
+		ruleSets[tag] = true
+		add("rule_set", tag)
+	}
+
+	for _, domain := range rule.Domains {
+		kind, value, found := strings.Cut(domain, ":")
+		if !found {
+			kind, value = "domain", domain
+		}
+		switch kind {
+		case "domain":
+			add("domain_suffix", value)
+		case "full":
+			add("domain", value)
+		case "keyword":
+			add("domain_keyword", value)
+		case "regexp":
+			add("domain_regex", value)
+		case "geosite":
+			useRuleSet("geosite-" + value)
+		default:
+			return nil, fmt.Errorf("unsupported domain match: %s", domain)
+		}
+	}
+	for _, ip := range rule.IPs {
+		switch {
+		case ip == "geoip:private":
+			generated["ip_is_private"] = true
+		case strings.HasPrefix(ip, "geoip:"):
+			useRuleSet("geoip-" + strings.TrimPrefix(ip, "geoip:"))
+		case !strings.Contains(ip, "/") && strings.Contains(ip, ":"):
+			add("ip_cidr", ip+"/128")
+		case !strings.Contains(ip, "/"):
+			add("ip_cidr", ip+"/32")
+		default:
+			add("ip_cidr", ip)
+		}
+	}
+
+	if rule.Ports != "" {
+		for _, port := range strings.Split(rule.Ports, ",") {
+			port = strings.TrimSpace(port)
+			if from, to, found := strings.Cut(port, "-"); found {
+				add("port_range", from+":"+to)
+				continue
+			}
+			n, err := strconv.Atoi(port)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port: %s", port)
+			}
+			add("port", n)
+		}
+	}
+
+	if rule.Outbound == "block" {
+		generated["action"] = "reject"
+	} else {
+		generated["outbound"] = rule.Outbound
+	}
+	return generated, nil
+}
+
+// generateDNS generates the dns section. sing-box asks one server per query,
+// so only the first foreign and domestic servers are used.
+func (s *SingBoxManager) generateDNS() map[string]interface{} {
+	servers := []map[string]interface{}{}
+	dnsRules := []map[string]interface{}{}
+
+	// Node addresses are resolved without the proxy, which isn't up yet
+	bootstrap := "local"
+	if len(s.dns.Domestic) > 0 {
+		bootstrap = "domestic"
+		servers = append(servers, map[string]interface{}{"tag": "domestic", "address": s.dns.Domestic[0]})
+		dnsRules = append(dnsRules, map[string]interface{}{"rule_set": "geosite-cn", "server": "domestic"})
+	} else {
+		servers = append(servers, map[string]interface{}{"tag": "local", "address": "local"})
+	}
+	dnsRules = append([]map[string]interface{}{{"outbound": "any", "server": bootstrap}}, dnsRules...)
+
+	final := bootstrap
+	if len(s.dns.Servers) > 0 {
+		final = "remote"
+		servers = append(servers, map[string]interface{}{"tag": "remote", "address": s.dns.Servers[0], "detour": "proxy"})
+	}
+
+	dns := map[string]interface{}{
+		"servers": servers,
+		"final":   final,
+	}
+	if s.fakeDNS() {
+		servers = append(servers, map[string]interface{}{"tag": "fakeip", "address": "fakeip"})
+		dnsRules = append(dnsRules, map[string]interface{}{"query_type": []string{"A", "AAAA"}, "server": "fakeip"})
+		dns["servers"] = servers
+		dns["fakeip"] = map[string]interface{}{"enabled": true, "inet4_range": fakeDNSPool}
+	}
+	dns["rules"] = dnsRules
+
+	return dns
+}
@@ -0,0 +1,38 @@
+package proxy
+
+// fakeDNSPool is where FakeDNS addresses come from; it is next to, but
+// doesn't include, tunAddress
+const fakeDNSPool = "198.19.0.0/16"
+
+// Sniffing configures domain sniffing on the inbounds. It reads the domain
+// of connections that clients make to IP addresses from their TLS or HTTP
+// headers, so domain-based routing rules still apply to them.
+type Sniffing struct {
+	Enabled bool
+	// FakeDNS answers DNS queries captured in TUN mode with addresses from a
+	// fake pool and maps connections to them back to the queried domain,
+	// saving a real lookup before the connection starts
+	FakeDNS bool
+}
+
+// fakeDNS reports whether FakeDNS is used; fake addresses only reach
+// applications through DNS queries captured by the TUN inbound
+func (c *coreBase) fakeDNS() bool {
+	return c.sniffing.FakeDNS && c.hijackDNS()
+}
+
+// generateSniffing generates the sniffing settings of an inbound. If routeOnly,
+// sniffed domains are only used for routing and connections still go to the
+// address the client asked for.
+func (x *XrayManager) generateSniffing(routeOnly bool) map[string]interface{} {
+	destOverride := []string{"http", "tls"}
+	if x.fakeDNS() {
+		destOverride = append(destOverride, "fakedns")
+	}
+
+	return map[string]interface{}{
+		"enabled":      true,
+		"destOverride": destOverride,
+		"routeOnly":    routeOnly,
+	}
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// xrayShadowsocksMethods are the Shadowsocks ciphers Xray-core supports
+var xrayShadowsocksMethods = map[string]bool{
+	"aes-128-gcm":             true,
+	"aes-256-gcm":             true,
+	"chacha20-poly1305":       true,
+	"chacha20-ietf-poly1305":  true,
+	"xchacha20-poly1305":      true,
+	"xchacha20-ietf-poly1305": true,
+	"2022-blake3-aes-128-gcm": true,
+	"2022-blake3-aes-256-gcm": true,
+	"none":                    true,
+}
+
+// parseSSRURL parses a ssr:// URL. Xray-core has no ShadowsocksR support, so only
+// nodes using the "origin" protocol and "plain" obfs are usable, as Shadowsocks
+// nodes. For other nodes the returned Node still carries the name for reporting.
+func parseSSRURL(ssrURL string) (Node, error) {
+	// ssr://base64(server:port:protocol:method:obfs:base64(password)/?remarks=base64(name)&...)
+	decoded, err := decodeBase64(strings.TrimPrefix(ssrURL, "ssr://"))
+	if err != nil {
+		return Node{}, fmt.Errorf("failed to decode ssr URL: %w", err)
+	}
+
+	main, query, _ := strings.Cut(string(decoded), "/?")
+
+	// The server may be an IPv6 address, so split the fixed fields from the right
+	fields := strings.Split(main, ":")
+	if len(fields) < 6 {
+		return Node{}, fmt.Errorf("invalid ssr URL format")
+	}
+	n := len(fields)
+	server := strings.Join(fields[:n-5], ":")
+	protocol, method, obfs := fields[n-4], fields[n-3], fields[n-2]
+
+	port, err := strconv.Atoi(fields[n-5])
+	if err != nil {
+		return Node{}, fmt.Errorf("invalid ssr port: %s", fields[n-5])
+	}
+
+	password, err := decodeBase64(fields[n-1])
+	if err != nil {
+		return Node{}, fmt.Errorf("failed to decode ssr password: %w", err)
+	}
+
+	node := Node{
+		Type:     "ss",
+		Server:   strings.Trim(server, "[]"),
+		Port:     port,
+		Password: string(password),
+		Security: method,
+	}
+
+	params, _ := url.ParseQuery(query)
+	if remarks, err := decodeBase64(params.Get("remarks")); err == nil {
+		node.Name = string(remarks)
+	}
+
+	return node, checkSSRCompatible(protocol, method, obfs)
+}
+
+// checkSSRCompatible reports why a ShadowsocksR node can't run as a Shadowsocks node
+func checkSSRCompatible(protocol, method, obfs string) error {
+	if protocol != "origin" {
+		return fmt.Errorf("ShadowsocksR protocol %q is not supported by Xray", protocol)
+	}
+	if obfs != "plain" && obfs != "" {
+		return fmt.Errorf("ShadowsocksR obfs %q is not supported by Xray", obfs)
+	}
+	if !xrayShadowsocksMethods[method] {
+		return fmt.Errorf("cipher %q is not supported by Xray", method)
+	}
+	return nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// NodeStat holds the last test results for a node
+type NodeStat struct {
+	Latency  int       `json:"latency"` // in milliseconds, -1 if unreachable
+	TestedAt time.Time `json:"tested_at"`
+
+	Speed         float64   `json:"speed,omitempty"` // in MB/s, -1 if the test failed
+	SpeedTestedAt time.Time `json:"speed_tested_at,omitempty"`
+}
+
+// NodeStats persists node test results between runs, keyed by node name
+type NodeStats struct {
+	path  string
+	Nodes map[string]NodeStat `json:"nodes"`
+}
+
+// LoadNodeStats loads node stats from path, returning empty stats if the file doesn't exist
+func LoadNodeStats(path string) (*NodeStats, error) {
+	stats := &NodeStats{path: path, Nodes: map[string]NodeStat{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stats, nil
+		}
+		return nil, fmt.Errorf("failed to read node stats: %w", err)
+	}
+
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse node stats: %w", err)
+	}
+	if stats.Nodes == nil {
+		stats.Nodes = map[string]NodeStat{}
+	}
+
+	return stats, nil
+}
+
+// RecordLatency stores the latency of every tested node
+func (s *NodeStats) RecordLatency(nodes []Node) {
+	now := time.Now()
+	for _, node := range nodes {
+		// Latency 0 means the node wasn't tested
+		if node.Latency == 0 {
+			continue
+		}
+		stat := s.Nodes[node.Name]
+		stat.Latency = node.Latency
+		stat.TestedAt = now
+		s.Nodes[node.Name] = stat
+	}
+}
+
+// RecordSpeed stores the download speed of every speed-tested node
+func (s *NodeStats) RecordSpeed(nodes []Node) {
+	now := time.Now()
+	for _, node := range nodes {
+		// Speed 0 means the node wasn't tested
+		if node.Speed == 0 {
+			continue
+		}
+		stat := s.Nodes[node.Name]
+		stat.Speed = node.Speed
+		stat.SpeedTestedAt = now
+		s.Nodes[node.Name] = stat
+	}
+}
+
+// Get returns the stats of the named node
+func (s *NodeStats) Get(name string) (NodeStat, bool) {
+	stat, ok := s.Nodes[name]
+	return stat, ok
+}
+
+// Save writes the stats to disk
+func (s *NodeStats) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal node stats: %w", err)
+	}
+
+	if err := logging.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write node stats: %w", err)
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,7 +19,7 @@ import (
 // Node represents a proxy node
 type Node struct {
 	Name     string `json:"name"`
-	Type     string `json:"type"` // vmess, vless, trojan, ss, etc.
+	Type     string `json:"type"` // vmess, vless, trojan, ss, wireguard, hysteria2, tuic
 	Server   string `json:"server"`
 	Port     int    `json:"port"`
 	UUID     string `json:"uuid,omitempty"`
@@ -27,13 +28,51 @@ type Node struct {
 	Security string `json:"security,omitempty"`
 	TLS      string `json:"tls,omitempty"`
 	SNI      string `json:"sni,omitempty"`
-	Latency  int    `json:"latency,omitempty"` // in milliseconds
+
+	// Transport settings (ws, grpc, h2) and TLS options
+	Path           string `json:"path,omitempty"`
+	Host           string `json:"host,omitempty"`
+	ServiceName    string `json:"service_name,omitempty"`
+	Flow           string `json:"flow,omitempty"`
+	SkipCertVerify bool   `json:"skip_cert_verify,omitempty"`
+
+	// Hysteria2 and TUIC settings
+	Obfs              string   `json:"obfs,omitempty"` // Hysteria2 obfuscation, e.g. salamander
+	ObfsPassword      string   `json:"obfs_password,omitempty"`
+	CongestionControl string   `json:"congestion_control,omitempty"` // TUIC only
+	ALPN              []string `json:"alpn,omitempty"`
+
+	Latency int     `json:"latency,omitempty"` // in milliseconds
+	Speed   float64 `json:"speed,omitempty"`   // download speed in MB/s
+
+	WireGuard *WireGuardSettings `json:"wireguard,omitempty"` // WireGuard only
 }
 
 // Subscription represents a proxy subscription
 type Subscription struct {
 	URL   string
 	Nodes []Node
+	// Expire is the expiry time reported by the provider (zero if unknown)
+	Expire time.Time
+	// Skipped lists entries that couldn't be used as nodes
+	Skipped []SkippedNode
+}
+
+// SkippedNode is a subscription entry that was not turned into a node
+type SkippedNode struct {
+	Name   string
+	Reason string
+}
+
+// WireGuardSettings holds the WireGuard keys and addresses of a node
+type WireGuardSettings struct {
+	PrivateKey   string   `json:"private_key"`
+	PublicKey    string   `json:"public_key"`
+	PresharedKey string   `json:"preshared_key,omitempty"`
+	Address      []string `json:"address"`               // local tunnel addresses
+	AllowedIPs   []string `json:"allowed_ips,omitempty"` // defaults to all traffic
+	Reserved     []int    `json:"reserved,omitempty"`
+	MTU          int      `json:"mtu,omitempty"`
 }
 
 // YAMLConfig represents the YAML subscription format
@@ -50,10 +89,78 @@ type YAMLProxy struct {
 	Password       string `yaml:"password,omitempty"`
 	UUID           string `yaml:"uuid,omitempty"`
 	Cipher         string `yaml:"cipher,omitempty"`
+	Protocol       string `yaml:"protocol,omitempty"` // ShadowsocksR only
+	Obfs           string `yaml:"obfs,omitempty"`     // ShadowsocksR and Hysteria2
 	SNI            string `yaml:"sni,omitempty"`
 	Network        string `yaml:"network,omitempty"`
 	SkipCertVerify bool   `yaml:"skip-cert-verify,omitempty"`
 	UDP            bool   `yaml:"udp,omitempty"`
+
+	// VMess and VLESS transport settings
+	TLS        bool         `yaml:"tls,omitempty"`
+	ServerName string       `yaml:"servername,omitempty"`
+	Flow       string       `yaml:"flow,omitempty"`
+	WSOpts     YAMLWSOpts   `yaml:"ws-opts,omitempty"`
+	GRPCOpts   YAMLGRPCOpts `yaml:"grpc-opts,omitempty"`
+	H2Opts     YAMLH2Opts   `yaml:"h2-opts,omitempty"`
+
+	// WireGuard only
+	IP           string       `yaml:"ip,omitempty"`
+	IPv6         string       `yaml:"ipv6,omitempty"`
+	PrivateKey   string       `yaml:"private-key,omitempty"`
+	PublicKey    string       `yaml:"public-key,omitempty"`
+	PresharedKey string       `yaml:"pre-shared-key,omitempty"`
+	AllowedIPs   []string     `yaml:"allowed-ips,omitempty"`
+	Reserved     yamlReserved `yaml:"reserved,omitempty"`
+	MTU          int          `yaml:"mtu,omitempty"`
+
+	// Hysteria2 and TUIC only
+	ObfsPassword         string   `yaml:"obfs-password,omitempty"`
+	ALPN                 []string `yaml:"alpn,omitempty"`
+	CongestionController string   `yaml:"congestion-controller,omitempty"`
+}
+
+// YAMLWSOpts holds the WebSocket transport settings of a YAML proxy
+type YAMLWSOpts struct {
+	Path    string            `yaml:"path,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// YAMLGRPCOpts holds the gRPC transport settings of a YAML proxy
+type YAMLGRPCOpts struct {
+	ServiceName string `yaml:"grpc-service-name,omitempty"`
+}
+
+// YAMLH2Opts holds the HTTP/2 transport settings of a YAML proxy
+type YAMLH2Opts struct {
+	Host []string `yaml:"host,omitempty"`
+	Path string   `yaml:"path,omitempty"`
+}
+
+// yamlReserved is the WireGuard "reserved" field, written either as a list of
+// three bytes or as their base64 encoding
+type yamlReserved []int
+
+// UnmarshalYAML accepts both forms of the reserved field
+func (r *yamlReserved) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var bytes []int
+		if err := value.Decode(&bytes); err != nil {
+			return err
+		}
+		*r = bytes
+		return nil
+	}
+
+	decoded, err := decodeBase64(value.Value)
+	if err != nil {
+		return fmt.Errorf("invalid reserved value %q: %w", value.Value, err)
+	}
+	*r = make(yamlReserved, len(decoded))
+	for i, b := range decoded {
+		(*r)[i] = int(b)
+	}
+	return nil
 }
 
 // LoadFromFile loads and parses a local YAML subscription file
@@ -63,36 +170,35 @@ func LoadFromFile(filePath string) (*Subscription, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	nodes, err := parseYAMLSubscription(string(data))
+	nodes, skipped, err := parseYAMLSubscription(string(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse YAML file: %w", err)
 	}
 
 	return &Subscription{
-		URL:   filePath, // Store file path for reference
-		Nodes: nodes,
+		URL:     filePath, // Store file path for reference
+		Nodes:   nodes,
+		Skipped: skipped,
 	}, nil
 }
 
 // FetchSubscription fetches and parses a subscription URL
-func FetchSubscription(subscriptionURL string) (*Subscription, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+func FetchSubscription(subscriptionURL, upstream string) (*Subscription, error) {
+	client := NewHTTPClient(upstream, 30*time.Second)
 
 	resp, err := client.Get(subscriptionURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch subscription: %w", err)
+		return nil, networkErrorf("failed to fetch subscription: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("subscription returned status: %d", resp.StatusCode)
+		return nil, networkErrorf("subscription returned status: %d", resp.StatusCode)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read subscription data: %w", err)
+		return nil, networkErrorf("failed to read subscription data: %w", err)
 	}
 
 	// Try to decode base64
@@ -102,25 +208,45 @@ func FetchSubscription(subscriptionURL string) (*Subscription, error) {
 		decoded = data
 	}
 
-	nodes, err := parseSubscription(string(decoded))
+	nodes, skipped, err := parseSubscription(string(decoded))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Subscription{
-		URL:   subscriptionURL,
-		Nodes: nodes,
+		URL:     subscriptionURL,
+		Nodes:   nodes,
+		Expire:  parseSubscriptionExpire(resp.Header.Get("Subscription-Userinfo")),
+		Skipped: skipped,
 	}, nil
 }
 
-// parseSubscription parses subscription content
-func parseSubscription(content string) ([]Node, error) {
+// parseSubscriptionExpire extracts the expire timestamp from a
+// "upload=...; download=...; total=...; expire=..." userinfo header
+func parseSubscriptionExpire(userinfo string) time.Time {
+	for _, field := range strings.Split(userinfo, ";") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 || kv[0] != "expire" {
+			continue
+		}
+		ts, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil || ts <= 0 {
+			return time.Time{}
+		}
+		return time.Unix(ts, 0)
+	}
+	return time.Time{}
+}
+
+// parseSubscription parses subscription content. Entries that can't be used
+// are returned as skipped nodes with the reason.
+func parseSubscription(content string) ([]Node, []SkippedNode, error) {
 	// Try to detect if content is YAML format
 	// YAML format typically contains "proxies:" or starts with structured data
 	if strings.Contains(content, "proxies:") || strings.Contains(content, "- {name:") {
-		nodes, err := parseYAMLSubscription(content)
-		if err == nil && len(nodes) > 0 {
-			return nodes, nil
+		nodes, skipped, err := parseYAMLSubscription(content)
+		if err == nil {
+			return nodes, skipped, nil
 		}
 		// If YAML parsing fails, fall through to try URL format
 	}
@@ -128,6 +254,7 @@ func parseSubscription(content string) ([]Node, error) {
 	// Parse as URL format (original implementation)
 	lines := strings.Split(content, "\n")
 	nodes := []Node{}
+	skipped := []SkippedNode{}
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -135,35 +262,65 @@ func parseSubscription(content string) ([]Node, error) {
 			continue
 		}
 
+		scheme, _, ok := strings.Cut(line, "://")
+		if !ok {
+			continue
+		}
+
 		// Try to parse as different formats
-		if strings.HasPrefix(line, "vmess://") {
-			node, err := parseVMessURL(line)
-			if err == nil {
-				nodes = append(nodes, node)
-			}
-		} else if strings.HasPrefix(line, "vless://") {
-			node, err := parseVLessURL(line)
-			if err == nil {
-				nodes = append(nodes, node)
-			}
-		} else if strings.HasPrefix(line, "trojan://") {
-			node, err := parseTrojanURL(line)
-			if err == nil {
-				nodes = append(nodes, node)
+		var node Node
+		var err error
+		switch scheme {
+		case "vmess":
+			node, err = parseVMessURL(line)
+		case "vless":
+			node, err = parseVLessURL(line)
+		case "trojan":
+			node, err = parseTrojanURL(line)
+		case "ss":
+			node, err = parseShadowsocksURL(line)
+		case "ssr":
+			node, err = parseSSRURL(line)
+		case "hysteria2", "hy2":
+			node, err = parseHysteria2URL(line)
+		case "tuic":
+			node, err = parseTUICURL(line)
+		default:
+			err = fmt.Errorf("unsupported node type: %s", scheme)
+		}
+
+		if err != nil {
+			name := node.Name
+			if u, parseErr := url.Parse(line); name == "" && parseErr == nil {
+				name = u.Fragment
 			}
-		} else if strings.HasPrefix(line, "ss://") {
-			node, err := parseShadowsocksURL(line)
-			if err == nil {
-				nodes = append(nodes, node)
+			if name == "" {
+				name = scheme + "://..."
 			}
+			skipped = append(skipped, SkippedNode{Name: name, Reason: err.Error()})
+			continue
 		}
+		nodes = append(nodes, node)
 	}
 
 	if len(nodes) == 0 {
-		return nil, fmt.Errorf("no valid nodes found in subscription")
+		return nil, skipped, noNodesError(skipped)
 	}
 
-	return nodes, nil
+	return nodes, skipped, nil
+}
+
+// noNodesError explains why a subscription has no usable nodes
+func noNodesError(skipped []SkippedNode) error {
+	if len(skipped) == 0 {
+		return fmt.Errorf("no valid nodes found in subscription")
+	}
+
+	reasons := []string{}
+	for _, node := range skipped {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", node.Name, node.Reason))
+	}
+	return fmt.Errorf("no usable nodes found in subscription, %d skipped:\n  %s", len(skipped), strings.Join(reasons, "\n  "))
 }
 
 // parseVMessURL parses a vmess:// URL
@@ -184,23 +341,32 @@ func parseVMessURL(vmessURL string) (Node, error) {
 		Type: "vmess",
 	}
 
-	if v, ok := vmessConfig["ps"].(string); ok {
-		node.Name = v
-	}
-	if v, ok := vmessConfig["add"].(string); ok {
-		node.Server = v
-	}
-	if v, ok := vmessConfig["port"].(float64); ok {
-		node.Port = int(v)
-	}
-	if v, ok := vmessConfig["id"].(string); ok {
-		node.UUID = v
-	}
-	if v, ok := vmessConfig["net"].(string); ok {
-		node.Network = v
+	// Providers write numbers either as JSON numbers or as strings
+	field := func(key string) string {
+		switch v := vmessConfig[key].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.Itoa(int(v))
+		}
+		return ""
 	}
-	if v, ok := vmessConfig["tls"].(string); ok {
-		node.TLS = v
+
+	node.Name = field("ps")
+	node.Server = field("add")
+	node.Port, _ = strconv.Atoi(field("port"))
+	node.UUID = field("id")
+	node.Security = field("scy")
+	node.Network = field("net")
+	node.TLS = field("tls")
+	node.SNI = field("sni")
+	node.Host = field("host")
+	node.Path = field("path")
+
+	// gRPC nodes carry the service name in "path"
+	if node.Network == "grpc" {
+		node.ServiceName = node.Path
+		node.Path = ""
 	}
 
 	return node, nil
@@ -255,12 +421,14 @@ func parseVLessURL(vlessURL string) (Node, error) {
 		UUID:   uuid,
 	}
 
-	if v, ok := params["type"]; ok {
-		node.Network = v
-	}
-	if v, ok := params["security"]; ok {
-		node.Security = v
-	}
+	node.Network = params["type"]
+	node.TLS = params["security"]
+	node.SNI = params["sni"]
+	node.Host = params["host"]
+	node.Path = params["path"]
+	node.ServiceName = params["serviceName"]
+	node.Flow = params["flow"]
+	node.SkipCertVerify = params["allowInsecure"] == "1" || params["allowInsecure"] == "true"
 
 	return node, nil
 }
@@ -365,6 +533,12 @@ func parseShadowsocksURL(ssURL string) (Node, error) {
 
 // TestLatency tests the latency of a node
 func (n *Node) TestLatency() error {
+	if UDPOnly(n.Type) {
+		// WireGuard, Hysteria2 and TUIC run over UDP and don't answer a TCP dial
+		n.Latency = -1
+		return fmt.Errorf("%s nodes can't be tested over TCP, use url-test selection", n.Type)
+	}
+
 	start := time.Now()
 
 	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", n.Server, n.Port), 5*time.Second)
@@ -374,14 +548,15 @@ func (n *Node) TestLatency() error {
 	}
 	defer conn.Close()
 
-	n.Latency = int(time.Since(start).Milliseconds())
+	// Round up to 1ms: a latency of 0 means the node wasn't tested
+	n.Latency = max(int(time.Since(start).Milliseconds()), 1)
 	return nil
 }
 
 // SelectFastestNode selects the node with lowest latency
 func (s *Subscription) SelectFastestNode() (*Node, error) {
 	if len(s.Nodes) == 0 {
-		return nil, fmt.Errorf("no nodes available")
+		return nil, ErrNoNodes
 	}
 
 	var fastestNode *Node
@@ -399,24 +574,43 @@ func (s *Subscription) SelectFastestNode() (*Node, error) {
 	}
 
 	if fastestNode == nil {
-		return nil, fmt.Errorf("no reachable nodes found")
+		return nil, ErrNoReachableNodes
 	}
 
 	return fastestNode, nil
 }
 
+// FindNode finds a node by name or by 1-based index as shown in "crosh nodes list"
+func FindNode(nodes []Node, ref string) (*Node, error) {
+	for i := range nodes {
+		if nodes[i].Name == ref {
+			return &nodes[i], nil
+		}
+	}
+
+	if index, err := strconv.Atoi(ref); err == nil {
+		if index < 1 || index > len(nodes) {
+			return nil, fmt.Errorf("node index out of range: %d (1-%d)", index, len(nodes))
+		}
+		return &nodes[index-1], nil
+	}
+
+	return nil, fmt.Errorf("node not found: %s", ref)
+}
+
 // parseYAMLSubscription parses YAML format subscription
-func parseYAMLSubscription(content string) ([]Node, error) {
+func parseYAMLSubscription(content string) ([]Node, []SkippedNode, error) {
 	var config YAMLConfig
 	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
 	if len(config.Proxies) == 0 {
-		return nil, fmt.Errorf("no proxies found in YAML config")
+		return nil, nil, fmt.Errorf("no proxies found in YAML config")
 	}
 
 	nodes := make([]Node, 0, len(config.Proxies))
+	skipped := []SkippedNode{}
 	for _, proxy := range config.Proxies {
 		// Skip info nodes (like Traffic and Expire information)
 		if proxy.Server == "" || proxy.Port == 0 {
@@ -439,23 +633,121 @@ func parseYAMLSubscription(content string) ([]Node, error) {
 				// Use server as SNI if not specified
 				node.SNI = proxy.Server
 			}
-		case "vmess":
-			node.UUID = proxy.UUID
-			node.Network = proxy.Network
-		case "vless":
+		case "vmess", "vless":
 			node.UUID = proxy.UUID
-			node.Network = proxy.Network
+			node.Flow = proxy.Flow
+			if proxy.Type == "vmess" {
+				node.Security = proxy.Cipher
+			}
+			applyYAMLTransport(&node, proxy)
 		case "ss", "shadowsocks":
+			node.Type = "ss"
+			node.Password = proxy.Password
+			node.Security = proxy.Cipher
+		case "wireguard":
+			settings, err := parseYAMLWireGuard(proxy)
+			if err != nil {
+				skipped = append(skipped, SkippedNode{Name: proxy.Name, Reason: err.Error()})
+				continue
+			}
+			node.WireGuard = settings
+		case "hysteria2":
+			node.Password = proxy.Password
+			node.Obfs = proxy.Obfs
+			node.ObfsPassword = proxy.ObfsPassword
+			node.SNI = proxy.SNI
+			node.ALPN = proxy.ALPN
+			node.SkipCertVerify = proxy.SkipCertVerify
+		case "tuic":
+			node.UUID = proxy.UUID
+			node.Password = proxy.Password
+			node.CongestionControl = proxy.CongestionController
+			node.SNI = proxy.SNI
+			node.ALPN = proxy.ALPN
+			node.SkipCertVerify = proxy.SkipCertVerify
+		case "ssr":
+			if err := checkSSRCompatible(proxy.Protocol, proxy.Cipher, proxy.Obfs); err != nil {
+				skipped = append(skipped, SkippedNode{Name: proxy.Name, Reason: err.Error()})
+				continue
+			}
+			node.Type = "ss"
 			node.Password = proxy.Password
 			node.Security = proxy.Cipher
+		default:
+			skipped = append(skipped, SkippedNode{Name: proxy.Name, Reason: "unsupported node type: " + proxy.Type})
+			continue
 		}
 
 		nodes = append(nodes, node)
 	}
 
 	if len(nodes) == 0 {
-		return nil, fmt.Errorf("no valid proxy nodes found in YAML config")
+		if len(skipped) > 0 {
+			return nil, skipped, noNodesError(skipped)
+		}
+		return nil, nil, fmt.Errorf("no valid proxy nodes found in YAML config")
+	}
+
+	return nodes, skipped, nil
+}
+
+// parseYAMLWireGuard maps the WireGuard fields of a Clash proxy entry
+func parseYAMLWireGuard(proxy YAMLProxy) (*WireGuardSettings, error) {
+	if proxy.PrivateKey == "" || proxy.PublicKey == "" {
+		return nil, fmt.Errorf("WireGuard node needs private-key and public-key")
+	}
+
+	settings := &WireGuardSettings{
+		PrivateKey:   proxy.PrivateKey,
+		PublicKey:    proxy.PublicKey,
+		PresharedKey: proxy.PresharedKey,
+		AllowedIPs:   proxy.AllowedIPs,
+		Reserved:     proxy.Reserved,
+		MTU:          proxy.MTU,
+	}
+
+	// Clash gives bare addresses; Xray wants them in CIDR notation
+	for _, ip := range []string{proxy.IP, proxy.IPv6} {
+		if ip == "" {
+			continue
+		}
+		if !strings.Contains(ip, "/") {
+			if strings.Contains(ip, ":") {
+				ip += "/128"
+			} else {
+				ip += "/32"
+			}
+		}
+		settings.Address = append(settings.Address, ip)
+	}
+	if len(settings.Address) == 0 {
+		return nil, fmt.Errorf("WireGuard node needs an ip address")
 	}
 
-	return nodes, nil
+	return settings, nil
+}
+
+// applyYAMLTransport maps the transport and TLS settings of a YAML proxy to node
+func applyYAMLTransport(node *Node, proxy YAMLProxy) {
+	node.Network = proxy.Network
+	node.SkipCertVerify = proxy.SkipCertVerify
+
+	if proxy.TLS {
+		node.TLS = "tls"
+	}
+	node.SNI = proxy.ServerName
+	if node.SNI == "" {
+		node.SNI = proxy.SNI
+	}
+
+	switch proxy.Network {
+	case "ws":
+		node.Path = proxy.WSOpts.Path
+		node.Host = proxy.WSOpts.Headers["Host"]
+	case "grpc":
+		node.ServiceName = proxy.GRPCOpts.ServiceName
+	case "h2":
+		node.Path = proxy.H2Opts.Path
+		node.Host = strings.Join(proxy.H2Opts.Host, ",")
+	}
 }
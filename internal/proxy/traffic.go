@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// Traffic is the data the core sent and received through nodes since it started
+type Traffic struct {
+	Up   int64 `json:"up"`   // in bytes
+	Down int64 `json:"down"` // in bytes
+}
+
+// statsAddress returns the loopback address the core reports traffic on
+func (c *coreBase) statsAddress() string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(c.inbound.StatsPort))
+}
+
+// checkStats returns why traffic counters can't be read, or nil
+func (c *coreBase) checkStats() error {
+	if c.inbound.StatsPort == 0 {
+		return fmt.Errorf("traffic counters are disabled (proxy.stats_port is 0)")
+	}
+	if !c.IsRunning() {
+		return fmt.Errorf("%s is not running", c.title)
+	}
+	return nil
+}
+
+// Traffic reads the traffic counters from the Clash API that sing-box and
+// mihomo serve on the stats port
+func (c *coreBase) Traffic() (Traffic, error) {
+	if err := c.checkStats(); err != nil {
+		return Traffic{}, err
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://" + c.statsAddress() + "/connections")
+	if err != nil {
+		return Traffic{}, fmt.Errorf("failed to read traffic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Traffic{}, fmt.Errorf("failed to read traffic: HTTP %d", resp.StatusCode)
+	}
+
+	var connections struct {
+		UploadTotal   int64 `json:"uploadTotal"`
+		DownloadTotal int64 `json:"downloadTotal"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&connections); err != nil {
+		return Traffic{}, fmt.Errorf("failed to parse traffic: %w", err)
+	}
+	return Traffic{Up: connections.UploadTotal, Down: connections.DownloadTotal}, nil
+}
+
+// Traffic reads the traffic counters of the proxy outbounds from the Xray stats API
+func (x *XrayManager) Traffic() (Traffic, error) {
+	if err := x.checkStats(); err != nil {
+		return Traffic{}, err
+	}
+
+	out, err := logging.Command(x.binPath, "api", "statsquery", "--server="+x.statsAddress(), "-pattern", "outbound>>>proxy").Output()
+	if err != nil {
+		return Traffic{}, fmt.Errorf("failed to read traffic: %w", err)
+	}
+
+	// Counters are named like outbound>>>proxy-1>>>traffic>>>uplink; protobuf
+	// JSON encodes their 64-bit values as strings
+	var result struct {
+		Stat []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"stat"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return Traffic{}, fmt.Errorf("failed to parse traffic: %w", err)
+	}
+
+	var traffic Traffic
+	for _, stat := range result.Stat {
+		value, _ := strconv.ParseInt(strings.Trim(string(stat.Value), `"`), 10, 64)
+		switch {
+		case strings.HasSuffix(stat.Name, ">>>uplink"):
+			traffic.Up += value
+		case strings.HasSuffix(stat.Name, ">>>downlink"):
+			traffic.Down += value
+		}
+	}
+	return traffic, nil
+}
+
+// addStats makes Xray count the traffic of each outbound and serve the
+// counters on the stats port
+func (x *XrayManager) addStats(config map[string]interface{}) {
+	if x.inbound.StatsPort == 0 {
+		return
+	}
+
+	config["stats"] = map[string]interface{}{}
+	config["api"] = map[string]interface{}{
+		"tag":      "api",
+		"services": []string{"StatsService"},
+	}
+	config["policy"] = map[string]interface{}{
+		"system": map[string]interface{}{
+			"statsOutboundUplink":   true,
+			"statsOutboundDownlink": true,
+		},
+	}
+	config["inbounds"] = append(config["inbounds"].([]map[string]interface{}), map[string]interface{}{
+		"tag":      "api",
+		"listen":   "127.0.0.1",
+		"port":     x.inbound.StatsPort,
+		"protocol": "dokodemo-door",
+		"settings": map[string]interface{}{"address": "127.0.0.1"},
+	})
+
+	// The API rule goes first so no other rule routes its connections
+	routing := config["routing"].(map[string]interface{})
+	rules := routing["rules"].([]map[string]interface{})
+	routing["rules"] = append([]map[string]interface{}{{
+		"type":        "field",
+		"inboundTag":  []string{"api"},
+		"outboundTag": "api",
+	}}, rules...)
+}
+
+// clashAPI returns the Clash API settings for the stats port, for sing-box
+// and mihomo, or "" if traffic counters are disabled
+func (c *coreBase) clashAPI() string {
+	if c.inbound.StatsPort == 0 {
+		return ""
+	}
+	return c.statsAddress()
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// tunAddress is the address given to the TUN interface; it is in the
+// benchmarking range, so it doesn't clash with real networks
+const tunAddress = "198.18.0.1"
+
+// TUN describes the optional TUN inbound that captures all system traffic
+type TUN struct {
+	Enabled bool
+	Name    string // interface name, platform default if empty
+	MTU     int
+}
+
+// name returns the TUN interface name
+func (t TUN) name() string {
+	if t.Name == "" {
+		return defaultTUNName
+	}
+	return t.Name
+}
+
+// generateTUNInbound generates the TUN inbound. Sniffing restores the domains
+// of captured connections so domain-based routing still works.
+func (x *XrayManager) generateTUNInbound() map[string]interface{} {
+	mtu := x.inbound.TUN.MTU
+	if mtu == 0 {
+		mtu = 1500
+	}
+
+	return map[string]interface{}{
+		"tag":      "tun-in",
+		"protocol": "tun",
+		"settings": map[string]interface{}{
+			"name": x.inbound.TUN.name(),
+			"MTU":  mtu,
+		},
+		"sniffing": x.generateSniffing(false),
+	}
+}
+
+// bindOutbounds binds outbounds to the default network interface, so traffic
+// Xray sends itself doesn't loop back into the TUN interface
+func bindOutbounds(outbounds []map[string]interface{}) error {
+	iface, err := defaultInterface()
+	if err != nil {
+		return fmt.Errorf("failed to find the default network interface: %w", err)
+	}
+
+	for _, outbound := range outbounds {
+		streamSettings, ok := outbound["streamSettings"].(map[string]interface{})
+		if !ok {
+			streamSettings = map[string]interface{}{}
+			outbound["streamSettings"] = streamSettings
+		}
+		sockopt, ok := streamSettings["sockopt"].(map[string]interface{})
+		if !ok {
+			sockopt = map[string]interface{}{}
+			streamSettings["sockopt"] = sockopt
+		}
+		// Outbounds that dial through the upstream proxy are bound by it
+		if _, ok := sockopt["dialerProxy"]; !ok {
+			sockopt["interface"] = iface
+		}
+	}
+	return nil
+}
+
+// setupTUN waits for Xray to create the TUN interface and routes all traffic into it
+func (x *XrayManager) setupTUN() error {
+	name := x.inbound.TUN.name()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := net.InterfaceByName(name); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("TUN interface %s did not come up (TUN mode needs a recent Xray-core, see %s)", name, x.LogPath())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return routeTUN(name)
+}
@@ -0,0 +1,53 @@
+//go:build darwin
+
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// macOS only allows utun interface names
+const defaultTUNName = "utun9"
+
+// checkTUNSupport reports whether TUN mode can be used
+func checkTUNSupport() error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("TUN mode needs root privileges (run with sudo -E, or turn it off with: crosh proxy on --tun=false)")
+	}
+	return nil
+}
+
+// defaultInterface returns the interface of the default route
+func defaultInterface() (string, error) {
+	out, err := logging.Command("route", "-n", "get", "default").Output()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if iface, ok := strings.CutPrefix(strings.TrimSpace(line), "interface:"); ok {
+			return strings.TrimSpace(iface), nil
+		}
+	}
+	return "", fmt.Errorf("no default route")
+}
+
+// routeTUN sends all IPv4 traffic to the TUN interface. The two /1 routes are
+// more specific than the default route, which stays in place for Xray itself.
+func routeTUN(name string) error {
+	commands := [][]string{
+		{"ifconfig", name, tunAddress, tunAddress, "up"},
+		{"route", "-n", "add", "-net", "0.0.0.0/1", "-interface", name},
+		{"route", "-n", "add", "-net", "128.0.0.0/1", "-interface", name},
+	}
+	for _, args := range commands {
+		if out, err := logging.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
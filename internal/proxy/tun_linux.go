@@ -0,0 +1,55 @@
+//go:build linux
+
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const defaultTUNName = "crosh0"
+
+// checkTUNSupport reports whether TUN mode can be used
+func checkTUNSupport() error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("TUN mode needs root privileges (run with sudo -E, or turn it off with: crosh proxy on --tun=false)")
+	}
+	return nil
+}
+
+// defaultInterface returns the interface of the default route
+func defaultInterface() (string, error) {
+	out, err := logging.Command("ip", "route", "show", "default").Output()
+	if err != nil {
+		return "", err
+	}
+
+	// default via 192.168.1.1 dev eth0 proto dhcp ...
+	fields := strings.Fields(string(out))
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "dev" {
+			return fields[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("no default route")
+}
+
+// routeTUN sends all IPv4 traffic to the TUN interface. The two /1 routes are
+// more specific than the default route, which stays in place for Xray itself.
+func routeTUN(name string) error {
+	commands := [][]string{
+		{"ip", "addr", "replace", tunAddress + "/30", "dev", name},
+		{"ip", "link", "set", name, "up"},
+		{"ip", "route", "replace", "0.0.0.0/1", "dev", name},
+		{"ip", "route", "replace", "128.0.0.0/1", "dev", name},
+	}
+	for _, args := range commands {
+		if out, err := logging.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
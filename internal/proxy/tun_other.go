@@ -0,0 +1,25 @@
+//go:build !linux && !darwin
+
+package proxy
+
+import (
+	"fmt"
+	"runtime"
+)
+
+const defaultTUNName = "crosh0"
+
+// checkTUNSupport reports whether TUN mode can be used
+func checkTUNSupport() error {
+	return fmt.Errorf("TUN mode is not supported on %s", runtime.GOOS)
+}
+
+// defaultInterface returns the interface of the default route
+func defaultInterface() (string, error) {
+	return "", checkTUNSupport()
+}
+
+// routeTUN sends all traffic to the TUN interface
+func routeTUN(name string) error {
+	return checkTUNSupport()
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// InstalledVersion returns the version of the installed Xray-core, e.g. "v1.8.24"
+func (x *XrayManager) InstalledVersion() (string, error) {
+	out, err := logging.Command(x.binPath, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run Xray-core: %w", err)
+	}
+
+	// The first line reads "Xray 1.8.24 (Xray, Penetrates Everything.) ..."
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 || fields[0] != "Xray" {
+		return "", fmt.Errorf("unexpected Xray-core version output: %.40q", out)
+	}
+	return "v" + strings.TrimPrefix(fields[1], "v"), nil
+}
+
+// LatestVersion returns the newest Xray-core release
+func (x *XrayManager) LatestVersion() (string, error) {
+	version, _, err := x.getLatestReleaseInfo()
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest release info: %w", err)
+	}
+	return version, nil
+}
+
+// PinnedVersion returns the Xray-core version downloads are pinned to, or empty
+func (x *XrayManager) PinnedVersion() string {
+	return x.version
+}
+
+// Upgrade installs an Xray-core version in place of the current binary. The
+// new binary is downloaded next to the old one and renamed over it, so a
+// failed download leaves the old one intact. A running Xray is restarted on it.
+func (x *XrayManager) Upgrade(version string) error {
+	staged := x.binPath + ".new"
+	if err := x.installXray(version, x.getDefaultAssetName(), staged); err != nil {
+		return err
+	}
+
+	// Windows can't replace a running executable
+	running := x.IsRunning()
+	if running {
+		if err := x.Stop(); err != nil {
+			os.Remove(staged)
+			return fmt.Errorf("failed to stop Xray: %w", err)
+		}
+	}
+
+	if err := os.Rename(staged, x.binPath); err != nil {
+		os.Remove(staged)
+		err = fmt.Errorf("failed to replace Xray-core: %w", err)
+		if running {
+			x.Start()
+		}
+		return err
+	}
+
+	if running {
+		if err := x.Start(); err != nil {
+			return fmt.Errorf("failed to restart Xray: %w", err)
+		}
+	}
+	return nil
+}
+
+// NewerVersion reports whether version a (e.g. "v1.8.24") is newer than b
+func NewerVersion(a, b string) bool {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int
+		if i < len(as) {
+			an, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bn, _ = strconv.Atoi(bs[i])
+		}
+		if an != bn {
+			return an > bn
+		}
+	}
+	return false
+}
@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/boomyao/crosh/internal/i18n"
+	"github.com/boomyao/crosh/internal/logging"
 )
 
 // XraySource represents a download source with both API and download URLs
@@ -35,198 +39,135 @@ var xraySources = []XraySource{
 	},
 }
 
-// XrayManager manages Xray-core process
+// XrayManager runs nodes with Xray-core
 type XrayManager struct {
-	xrayPath   string
-	configPath string
-	cmd        *exec.Cmd
-	localPort  int
+	coreBase
+	geoData GeoData
+	version string // pinned Xray-core version, empty for the latest
 }
 
 // NewXrayManager creates a new Xray manager
-func NewXrayManager(xrayPath string, localPort int) *XrayManager {
-	return &XrayManager{
-		xrayPath:   xrayPath,
-		configPath: filepath.Join(filepath.Dir(xrayPath), "config.json"),
-		localPort:  localPort,
+func NewXrayManager(xrayPath string, opts Options) *XrayManager {
+	x := &XrayManager{
+		coreBase: newCoreBase("Xray-core", xrayPath, "xray", "config.json", opts),
+		geoData:  opts.GeoData,
+		version:  opts.XrayVersion,
+	}
+	x.runArgs = func(configPath string) []string { return []string{"run", "-config", configPath} }
+	x.probeConfig = x.generateProbeConfig
+	return x
+}
+
+// Name returns the name of the core
+func (x *XrayManager) Name() string {
+	return CoreXray
+}
+
+// Supports reports whether Xray-core can run nodes of the given type
+func (x *XrayManager) Supports(nodeType string) bool {
+	switch nodeType {
+	case "vmess", "vless", "trojan", "ss", "wireguard":
+		return true
 	}
+	return false
+}
+
+// Start starts the Xray-core process
+func (x *XrayManager) Start() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	if x.inbound.TUN.Enabled {
+		if err := x.setupTUN(); err != nil {
+			x.Stop()
+			return fmt.Errorf("failed to set up TUN mode: %w", err)
+		}
+		fmt.Printf(i18n.T("✓ TUN mode: all traffic goes through %s\n"), x.inbound.TUN.name())
+	}
+
+	return nil
 }
 
 // Download downloads Xray-core binary with multiple fallback sources
 func (x *XrayManager) Download() error {
 	// Check if already exists
-	if _, err := os.Stat(x.xrayPath); err == nil {
-		fmt.Println("Xray-core already exists, skipping download")
+	if _, err := os.Stat(x.binPath); err == nil {
+		fmt.Println(i18n.T("Xray-core already exists, skipping download"))
 	} else {
-		fmt.Println("Downloading Xray-core...")
+		fmt.Println(i18n.T("Downloading Xray-core..."))
 
 		// Create directory
-		if err := os.MkdirAll(filepath.Dir(x.xrayPath), 0755); err != nil {
+		if err := os.MkdirAll(filepath.Dir(x.binPath), 0755); err != nil {
 			return fmt.Errorf("failed to create directory: %w", err)
 		}
 
-		// Get latest release info
-		version, assetName, err := x.getLatestReleaseInfo()
-		if err != nil {
-			fmt.Printf("Warning: failed to get latest release info: %v\n", err)
-			fmt.Println("Falling back to default version v1.8.4")
-			version = "v1.8.4"
-			assetName = x.getDefaultAssetName()
-		}
-
-		fmt.Printf("Downloading Xray-core version %s...\n", version)
-
-		// Try multiple download sources
-		var lastErr error
-		for i, source := range xraySources {
-			downloadURL := fmt.Sprintf("%s/%s/%s", source.DownloadURL, version, assetName)
-			fmt.Printf("Trying source %d/%d: %s\n", i+1, len(xraySources), source.Name)
-
-			err := x.downloadFromURL(downloadURL)
-			if err == nil {
-				fmt.Println("✓ Xray-core downloaded successfully")
-				break
+		// Use the pinned version, or get latest release info
+		version, assetName := x.version, x.getDefaultAssetName()
+		if version == "" {
+			var err error
+			version, assetName, err = x.getLatestReleaseInfo()
+			if err != nil {
+				fmt.Printf(i18n.T("Warning: failed to get latest release info: %v\n"), err)
+				fmt.Println(i18n.T("Falling back to default version v1.8.4"))
+				version = "v1.8.4"
+				assetName = x.getDefaultAssetName()
 			}
-
-			fmt.Printf("✗ Failed: %v\n", err)
-			lastErr = err
 		}
 
-		if lastErr != nil {
-			return fmt.Errorf("failed to download from all sources: %w", lastErr)
+		if err := x.installXray(version, assetName, x.binPath); err != nil {
+			return err
 		}
 	}
 
 	// Download geoip and geosite data files
-	fmt.Println("Downloading geoip and geosite data files...")
+	fmt.Println(i18n.T("Downloading geoip and geosite data files..."))
 	if err := x.downloadGeoData(); err != nil {
-		fmt.Printf("Warning: failed to download geo data: %v\n", err)
-		fmt.Println("Routing rules may not work properly without geo data files")
+		fmt.Printf(i18n.T("Warning: failed to download geo data: %v\n"), err)
+		fmt.Println(i18n.T("Routing rules may not work properly without geo data files"))
 	}
 
 	return nil
 }
 
-// downloadGeoData downloads geoip.dat and geosite.dat files
-func (x *XrayManager) downloadGeoData() error {
-	dataDir := filepath.Dir(x.xrayPath)
-
-	// Geo data file sources (Cloudflare CDN first for best China access)
-	geoFiles := []struct {
-		name     string
-		sources  []string
-		filename string
-	}{
-		{
-			name:     "geoip.dat",
-			filename: "geoip.dat",
-			sources: []string{
-				"https://crosh.boomyao.com/xray/geoip.dat",
-				"https://github.com/Loyalsoldier/v2ray-rules-dat/releases/latest/download/geoip.dat",
-			},
-		},
-		{
-			name:     "geosite.dat",
-			filename: "geosite.dat",
-			sources: []string{
-				"https://crosh.boomyao.com/xray/geosite.dat",
-				"https://github.com/Loyalsoldier/v2ray-rules-dat/releases/latest/download/geosite.dat",
-			},
-		},
-	}
-
-	for _, geoFile := range geoFiles {
-		targetPath := filepath.Join(dataDir, geoFile.filename)
-
-		// Skip if file already exists
-		if _, err := os.Stat(targetPath); err == nil {
-			fmt.Printf("✓ %s already exists\n", geoFile.name)
-			continue
-		}
-
-		fmt.Printf("Downloading %s...\n", geoFile.name)
+// installXray downloads an Xray-core release to path, trying each source
+func (x *XrayManager) installXray(version, assetName, path string) error {
+	fmt.Printf(i18n.T("Downloading Xray-core version %s...\n"), version)
 
-		// Try multiple sources
-		var lastErr error
-		for i, source := range geoFile.sources {
-			fmt.Printf("  Trying source %d/%d...\n", i+1, len(geoFile.sources))
-
-			err := x.downloadGeoFile(source, targetPath)
-			if err == nil {
-				fmt.Printf("✓ %s downloaded successfully\n", geoFile.name)
-				break
-			}
-
-			fmt.Printf("  ✗ Failed: %v\n", err)
-			lastErr = err
-		}
+	var lastErr error
+	for i, source := range xraySources {
+		downloadURL := fmt.Sprintf("%s/%s/%s", source.DownloadURL, version, assetName)
+		fmt.Printf(i18n.T("Trying source %d/%d: %s\n"), i+1, len(xraySources), source.Name)
 
-		if lastErr != nil {
-			return fmt.Errorf("failed to download %s: %w", geoFile.name, lastErr)
+		err := x.downloadFromURL(downloadURL, path)
+		if err == nil {
+			fmt.Println(i18n.T("✓ Xray-core downloaded successfully"))
+			return nil
 		}
-	}
 
-	return nil
-}
-
-// downloadGeoFile downloads a single geo data file
-func (x *XrayManager) downloadGeoFile(url, targetPath string) error {
-	client := &http.Client{
-		Timeout: 3 * time.Minute,
-	}
-
-	resp, err := client.Get(url)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	// Create temporary file
-	tmpFile := targetPath + ".tmp"
-	out, err := os.Create(tmpFile)
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
-	}
-
-	_, err = io.Copy(out, resp.Body)
-	out.Close()
-
-	if err != nil {
-		os.Remove(tmpFile)
-		return fmt.Errorf("failed to save file: %w", err)
-	}
-
-	// Rename to final location
-	if err := os.Rename(tmpFile, targetPath); err != nil {
-		os.Remove(tmpFile)
-		return fmt.Errorf("failed to move to final location: %w", err)
+		fmt.Printf(i18n.T("✗ Failed: %v\n"), err)
+		lastErr = err
 	}
 
-	return nil
+	return fmt.Errorf("failed to download from all sources: %w", lastErr)
 }
 
-// downloadFromURL downloads Xray-core from a specific URL
-func (x *XrayManager) downloadFromURL(downloadURL string) error {
-	client := &http.Client{
-		Timeout: 5 * time.Minute,
-	}
+// downloadFromURL downloads Xray-core from a specific URL and installs it at path
+func (x *XrayManager) downloadFromURL(downloadURL, path string) error {
+	client := NewHTTPClient(x.upstream, 5*time.Minute)
 
 	resp, err := client.Get(downloadURL)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return networkErrorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
+		return networkErrorf("HTTP %d", resp.StatusCode)
 	}
 
 	// Save to temporary zip file
-	tmpZip := x.xrayPath + ".tmp.zip"
+	tmpZip := path + ".tmp.zip"
 	out, err := os.Create(tmpZip)
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
@@ -241,7 +182,7 @@ func (x *XrayManager) downloadFromURL(downloadURL string) error {
 	}
 
 	// Extract xray binary from zip
-	if err := x.extractXrayFromZip(tmpZip); err != nil {
+	if err := x.extractXrayFromZip(tmpZip, path); err != nil {
 		os.Remove(tmpZip)
 		return fmt.Errorf("failed to extract: %w", err)
 	}
@@ -252,8 +193,8 @@ func (x *XrayManager) downloadFromURL(downloadURL string) error {
 	return nil
 }
 
-// extractXrayFromZip extracts the xray binary from a zip file
-func (x *XrayManager) extractXrayFromZip(zipPath string) error {
+// extractXrayFromZip extracts the xray binary from a zip file to path
+func (x *XrayManager) extractXrayFromZip(zipPath, path string) error {
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return fmt.Errorf("failed to open zip: %w", err)
@@ -282,7 +223,7 @@ func (x *XrayManager) extractXrayFromZip(zipPath string) error {
 	defer src.Close()
 
 	// Create destination file
-	tmpFile := x.xrayPath + ".tmp"
+	tmpFile := path + ".tmp"
 	dst, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create destination file: %w", err)
@@ -297,7 +238,7 @@ func (x *XrayManager) extractXrayFromZip(zipPath string) error {
 	}
 
 	// Rename to final location
-	if err := os.Rename(tmpFile, x.xrayPath); err != nil {
+	if err := os.Rename(tmpFile, path); err != nil {
 		os.Remove(tmpFile)
 		return fmt.Errorf("failed to move to final location: %w", err)
 	}
@@ -376,16 +317,16 @@ func (x *XrayManager) getLatestReleaseInfo() (version, assetName string, err err
 
 // getVersionFromCDN fetches version info from Cloudflare CDN
 func (x *XrayManager) getVersionFromCDN(source XraySource) (string, string, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := NewHTTPClient(x.upstream, 10*time.Second)
 
 	resp, err := client.Get(source.APIURL)
 	if err != nil {
-		return "", "", err
+		return "", "", &NetworkError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		return "", "", networkErrorf("HTTP %d", resp.StatusCode)
 	}
 
 	versionBytes, err := io.ReadAll(resp.Body)
@@ -407,18 +348,16 @@ func (x *XrayManager) getVersionFromGitHub(source XraySource) (version, assetNam
 
 // fetchReleaseInfo fetches release info from a specific API endpoint
 func (x *XrayManager) fetchReleaseInfo(apiURL string) (version, assetName string, err error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := NewHTTPClient(x.upstream, 30*time.Second)
 
 	resp, err := client.Get(apiURL)
 	if err != nil {
-		return "", "", err
+		return "", "", &NetworkError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("API returned status %d", resp.StatusCode)
+		return "", "", networkErrorf("API returned status %d", resp.StatusCode)
 	}
 
 	var release struct {
@@ -449,58 +388,293 @@ func (x *XrayManager) fetchReleaseInfo(apiURL string) (version, assetName string
 
 // GenerateConfig generates Xray configuration from a node
 func (x *XrayManager) GenerateConfig(node *Node) error {
-	var config map[string]interface{}
+	config, err := x.nodeConfig(node)
+	if err != nil {
+		return err
+	}
+	return x.writeConfig(config)
+}
+
+// RemoteConfig returns an Xray configuration that runs node on another
+// machine, as crosh remote apply --proxy node does. Its proxy listens on the
+// remote's loopback ports without a login; the listen address, login, TUN,
+// stats port and upstream proxy of this machine stay here.
+func (x *XrayManager) RemoteConfig(node *Node) ([]byte, error) {
+	remote := &XrayManager{coreBase: x.coreBase}
+	remote.inbound = Inbound{
+		Listen:    "127.0.0.1",
+		SocksPort: x.inbound.SocksPort,
+		HTTPPort:  x.inbound.HTTPPort,
+	}
+	remote.upstream = ""
+
+	config, err := remote.nodeConfig(node)
+	if err != nil {
+		return nil, err
+	}
+	return remote.marshalConfig(config)
+}
+
+// nodeConfig returns the Xray configuration that runs node
+func (x *XrayManager) nodeConfig(node *Node) (map[string]interface{}, error) {
+	outbounds, err := x.generateProxyOutbounds(node)
+	if err != nil {
+		return nil, err
+	}
+
+	routing, err := x.generateRouting(false)
+	if err != nil {
+		return nil, err
+	}
+
+	config := map[string]interface{}{
+		"inbounds":  x.generateInbounds(),
+		"outbounds": append(outbounds, x.generateLocalOutbounds()...),
+		"routing":   routing,
+	}
+	x.addDNS(config)
+	return config, nil
+}
+
+// GenerateBalancerConfig generates an Xray configuration that balances traffic
+// across nodes, preferring whichever answers probeURL fastest
+func (x *XrayManager) GenerateBalancerConfig(nodes []*Node, probeURL string) error {
+	if probeURL == "" {
+		probeURL = DefaultProbeURL
+	}
+
+	outbounds, err := x.generateProxyOutbounds(nodes...)
+	if err != nil {
+		return err
+	}
+
+	routing, err := x.generateRouting(true)
+	if err != nil {
+		return err
+	}
+	routing["balancers"] = []map[string]interface{}{
+		{
+			"tag":      "balancer",
+			"selector": []string{"proxy-"},
+			"strategy": map[string]interface{}{"type": "leastPing"},
+		},
+	}
+
+	config := map[string]interface{}{
+		"inbounds":  x.generateInbounds(),
+		"outbounds": append(outbounds, x.generateLocalOutbounds()...),
+		"routing":   routing,
+		"observatory": map[string]interface{}{
+			"subjectSelector":   []string{"proxy-"},
+			"probeUrl":          probeURL,
+			"probeInterval":     "1m",
+			"enableConcurrency": true,
+		},
+	}
+	x.addDNS(config)
+
+	return x.writeConfig(config)
+}
+
+// writeConfig writes an Xray configuration to the config file
+func (x *XrayManager) writeConfig(config map[string]interface{}) error {
+	data, err := x.marshalConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if err := logging.WriteFile(x.configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+// marshalConfig completes an Xray configuration with the log, stats and
+// TUN settings and marshals it
+func (x *XrayManager) marshalConfig(config map[string]interface{}) ([]byte, error) {
+	config["log"] = map[string]interface{}{"loglevel": x.log.level()}
+	x.addStats(config)
+	if x.inbound.TUN.Enabled {
+		if err := bindOutbounds(config["outbounds"].([]map[string]interface{})); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// generateProbeConfig generates a config that sends everything from a local
+// SOCKS port through node
+func (x *XrayManager) generateProbeConfig(node *Node, port int) ([]byte, error) {
+	outbounds, err := x.generateProxyOutbounds(node)
+	if err != nil {
+		return nil, err
+	}
+	if x.inbound.TUN.Enabled {
+		// Test the node directly rather than through the TUN interface
+		if err := bindOutbounds(outbounds); err != nil {
+			return nil, err
+		}
+	}
+
+	inbound := x.generateSocksInbound(port)
+	inbound["listen"] = "127.0.0.1"
+	config := map[string]interface{}{
+		"log":       map[string]interface{}{"loglevel": "none"},
+		"inbounds":  []map[string]interface{}{inbound},
+		"outbounds": outbounds,
+	}
 
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// generateProxyOutbounds generates the proxy outbounds for nodes, followed by the
+// upstream proxy outbound they dial through if an upstream proxy is configured.
+// A single node is tagged "proxy"; several are tagged "proxy-1", "proxy-2", ...
+func (x *XrayManager) generateProxyOutbounds(nodes ...*Node) ([]map[string]interface{}, error) {
+	upstream, err := ParseUpstream(x.upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	outbounds := []map[string]interface{}{}
+	for i, node := range nodes {
+		outbound, err := x.generateOutbound(node)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) > 1 {
+			outbound["tag"] = fmt.Sprintf("proxy-%d", i+1)
+		}
+
+		if upstream != nil {
+			if node.Type == "wireguard" {
+				return nil, fmt.Errorf("WireGuard node %s can't be reached through an upstream proxy", node.Name)
+			}
+
+			streamSettings, ok := outbound["streamSettings"].(map[string]interface{})
+			if !ok {
+				streamSettings = map[string]interface{}{}
+				outbound["streamSettings"] = streamSettings
+			}
+			streamSettings["sockopt"] = map[string]interface{}{
+				"dialerProxy": upstreamTag,
+			}
+		}
+
+		outbounds = append(outbounds, outbound)
+	}
+
+	if upstream != nil {
+		outbounds = append(outbounds, generateUpstreamOutbound(upstream))
+	}
+
+	return outbounds, nil
+}
+
+// generateOutbound generates the proxy outbound for a node
+func (x *XrayManager) generateOutbound(node *Node) (map[string]interface{}, error) {
 	switch node.Type {
 	case "vmess":
-		config = x.generateVMessConfig(node)
+		return x.generateVMessOutbound(node), nil
 	case "vless":
-		config = x.generateVLessConfig(node)
+		return x.generateVLessOutbound(node), nil
 	case "trojan":
-		config = x.generateTrojanConfig(node)
+		return x.generateTrojanOutbound(node), nil
 	case "ss":
-		config = x.generateShadowsocksConfig(node)
+		return x.generateShadowsocksOutbound(node), nil
+	case "wireguard":
+		if node.WireGuard == nil {
+			return nil, fmt.Errorf("WireGuard node %s has no keys", node.Name)
+		}
+		return x.generateWireGuardOutbound(node), nil
+	case "hysteria2", "tuic":
+		return nil, fmt.Errorf("Xray-core can't run %s node %s, switch to sing-box with: crosh proxy on --core sing-box", node.Type, node.Name)
 	default:
-		return fmt.Errorf("unsupported node type: %s", node.Type)
+		return nil, fmt.Errorf("unsupported node type: %s", node.Type)
 	}
+}
 
-	// Write config to file
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+// generateInbounds generates the local SOCKS inbound and, if enabled, the HTTP inbound
+func (x *XrayManager) generateInbounds() []map[string]interface{} {
+	inbounds := []map[string]interface{}{
+		x.generateSocksInbound(x.inbound.SocksPort),
+	}
+	if x.inbound.HTTPPort > 0 {
+		inbounds = append(inbounds, x.generateHTTPInbound(x.inbound.HTTPPort))
 	}
 
-	if err := os.WriteFile(x.configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	for _, inbound := range inbounds {
+		if x.inbound.Listen != "" {
+			inbound["listen"] = x.inbound.Listen
+		}
+		if x.inbound.Username != "" {
+			settings := inbound["settings"].(map[string]interface{})
+			settings["accounts"] = []map[string]interface{}{
+				{"user": x.inbound.Username, "pass": x.inbound.Password},
+			}
+			if inbound["protocol"] == "socks" {
+				settings["auth"] = "password"
+			}
+		}
+		if x.sniffing.Enabled {
+			inbound["sniffing"] = x.generateSniffing(true)
+		}
 	}
 
-	return nil
+	if x.inbound.TUN.Enabled {
+		inbounds = append(inbounds, x.generateTUNInbound())
+	}
+	return inbounds
 }
 
-// generateRoutingRules generates routing rules for China IP direct connection
-func (x *XrayManager) generateRoutingRules() map[string]interface{} {
+// generateHTTPInbound generates the local HTTP inbound, for tools that can't use SOCKS
+func (x *XrayManager) generateHTTPInbound(port int) map[string]interface{} {
 	return map[string]interface{}{
-		"domainStrategy": "IPIfNonMatch",
-		"rules": []map[string]interface{}{
-			{
-				"type":        "field",
-				"ip":          []string{"geoip:private"},
-				"outboundTag": "direct",
-			},
-			{
-				"type":        "field",
-				"ip":          []string{"geoip:cn"},
-				"outboundTag": "direct",
-			},
-			{
-				"type":        "field",
-				"domain":      []string{"geosite:cn"},
-				"outboundTag": "direct",
-			},
+		"port":     port,
+		"protocol": "http",
+		"settings": map[string]interface{}{},
+	}
+}
+
+// generateSocksInbound generates the local SOCKS inbound
+func (x *XrayManager) generateSocksInbound(port int) map[string]interface{} {
+	return map[string]interface{}{
+		"port":     port,
+		"protocol": "socks",
+		"settings": map[string]interface{}{
+			"udp": true,
 		},
 	}
 }
 
+// generateLocalOutbounds generates the outbounds that don't use a node
+func (x *XrayManager) generateLocalOutbounds() []map[string]interface{} {
+	outbounds := []map[string]interface{}{x.generateDirectOutbound(), x.generateBlockOutbound()}
+	if x.hijackDNS() {
+		outbounds = append(outbounds, x.generateDNSOutbound())
+	}
+	return outbounds
+}
+
+// generateBlockOutbound generates the outbound that drops blocked connections
+func (x *XrayManager) generateBlockOutbound() map[string]interface{} {
+	return map[string]interface{}{
+		"tag":      "block",
+		"protocol": "blackhole",
+		"settings": map[string]interface{}{},
+	}
+}
+
 // generateDirectOutbound generates direct connection outbound
 func (x *XrayManager) generateDirectOutbound() map[string]interface{} {
 	return map[string]interface{}{
@@ -510,9 +684,9 @@ func (x *XrayManager) generateDirectOutbound() map[string]interface{} {
 	}
 }
 
-// generateVMessConfig generates VMess configuration
-func (x *XrayManager) generateVMessConfig(node *Node) map[string]interface{} {
-	proxyOutbound := map[string]interface{}{
+// generateVMessOutbound generates VMess outbound
+func (x *XrayManager) generateVMessOutbound(node *Node) map[string]interface{} {
+	return map[string]interface{}{
 		"tag":      "proxy",
 		"protocol": "vmess",
 		"settings": map[string]interface{}{
@@ -524,35 +698,27 @@ func (x *XrayManager) generateVMessConfig(node *Node) map[string]interface{} {
 						{
 							"id":       node.UUID,
 							"alterId":  0,
-							"security": "auto",
+							"security": vmessSecurity(node),
 						},
 					},
 				},
 			},
 		},
+		"streamSettings": generateStreamSettings(node),
 	}
+}
 
-	return map[string]interface{}{
-		"inbounds": []map[string]interface{}{
-			{
-				"port":     x.localPort,
-				"protocol": "socks",
-				"settings": map[string]interface{}{
-					"udp": true,
-				},
-			},
-		},
-		"outbounds": []map[string]interface{}{
-			proxyOutbound,
-			x.generateDirectOutbound(),
-		},
-		"routing": x.generateRoutingRules(),
+// vmessSecurity returns the VMess cipher of a node, defaulting to "auto"
+func vmessSecurity(node *Node) string {
+	if node.Security == "" {
+		return "auto"
 	}
+	return node.Security
 }
 
-// generateVLessConfig generates VLess configuration
-func (x *XrayManager) generateVLessConfig(node *Node) map[string]interface{} {
-	proxyOutbound := map[string]interface{}{
+// generateVLessOutbound generates VLess outbound
+func (x *XrayManager) generateVLessOutbound(node *Node) map[string]interface{} {
+	return map[string]interface{}{
 		"tag":      "proxy",
 		"protocol": "vless",
 		"settings": map[string]interface{}{
@@ -564,40 +730,78 @@ func (x *XrayManager) generateVLessConfig(node *Node) map[string]interface{} {
 						{
 							"id":         node.UUID,
 							"encryption": "none",
+							"flow":       node.Flow,
 						},
 					},
 				},
 			},
 		},
+		"streamSettings": generateStreamSettings(node),
 	}
+}
 
-	return map[string]interface{}{
-		"inbounds": []map[string]interface{}{
-			{
-				"port":     x.localPort,
-				"protocol": "socks",
-				"settings": map[string]interface{}{
-					"udp": true,
-				},
-			},
-		},
-		"outbounds": []map[string]interface{}{
-			proxyOutbound,
-			x.generateDirectOutbound(),
-		},
-		"routing": x.generateRoutingRules(),
+// generateStreamSettings generates the transport and TLS settings of a VMess or
+// VLESS node
+func generateStreamSettings(node *Node) map[string]interface{} {
+	settings := map[string]interface{}{
+		"network": "tcp",
+	}
+
+	switch node.Network {
+	case "ws":
+		ws := map[string]interface{}{
+			"path": node.Path,
+		}
+		if node.Host != "" {
+			ws["headers"] = map[string]string{"Host": node.Host}
+		}
+		settings["network"] = "ws"
+		settings["wsSettings"] = ws
+	case "grpc":
+		settings["network"] = "grpc"
+		settings["grpcSettings"] = map[string]interface{}{
+			"serviceName": node.ServiceName,
+		}
+	case "h2", "http":
+		h2 := map[string]interface{}{
+			"path": node.Path,
+		}
+		if node.Host != "" {
+			h2["host"] = strings.Split(node.Host, ",")
+		}
+		settings["network"] = "http"
+		settings["httpSettings"] = h2
 	}
+
+	if node.TLS == "tls" {
+		// Fall back to the Host header, then the server, for SNI
+		serverName := node.SNI
+		if serverName == "" {
+			serverName = strings.Split(node.Host, ",")[0]
+		}
+		if serverName == "" {
+			serverName = node.Server
+		}
+
+		settings["security"] = "tls"
+		settings["tlsSettings"] = map[string]interface{}{
+			"serverName":    serverName,
+			"allowInsecure": node.SkipCertVerify,
+		}
+	}
+
+	return settings
 }
 
-// generateTrojanConfig generates Trojan configuration
-func (x *XrayManager) generateTrojanConfig(node *Node) map[string]interface{} {
+// generateTrojanOutbound generates Trojan outbound
+func (x *XrayManager) generateTrojanOutbound(node *Node) map[string]interface{} {
 	// Determine SNI - use explicit SNI if set, otherwise use server address
 	sni := node.SNI
 	if sni == "" {
 		sni = node.Server
 	}
 
-	proxyOutbound := map[string]interface{}{
+	return map[string]interface{}{
 		"tag":      "proxy",
 		"protocol": "trojan",
 		"settings": map[string]interface{}{
@@ -621,28 +825,11 @@ func (x *XrayManager) generateTrojanConfig(node *Node) map[string]interface{} {
 			},
 		},
 	}
-
-	return map[string]interface{}{
-		"inbounds": []map[string]interface{}{
-			{
-				"port":     x.localPort,
-				"protocol": "socks",
-				"settings": map[string]interface{}{
-					"udp": true,
-				},
-			},
-		},
-		"outbounds": []map[string]interface{}{
-			proxyOutbound,
-			x.generateDirectOutbound(),
-		},
-		"routing": x.generateRoutingRules(),
-	}
 }
 
-// generateShadowsocksConfig generates Shadowsocks configuration
-func (x *XrayManager) generateShadowsocksConfig(node *Node) map[string]interface{} {
-	proxyOutbound := map[string]interface{}{
+// generateShadowsocksOutbound generates Shadowsocks outbound
+func (x *XrayManager) generateShadowsocksOutbound(node *Node) map[string]interface{} {
+	return map[string]interface{}{
 		"tag":      "proxy",
 		"protocol": "shadowsocks",
 		"settings": map[string]interface{}{
@@ -656,142 +843,41 @@ func (x *XrayManager) generateShadowsocksConfig(node *Node) map[string]interface
 			},
 		},
 	}
-
-	return map[string]interface{}{
-		"inbounds": []map[string]interface{}{
-			{
-				"port":     x.localPort,
-				"protocol": "socks",
-				"settings": map[string]interface{}{
-					"udp": true,
-				},
-			},
-		},
-		"outbounds": []map[string]interface{}{
-			proxyOutbound,
-			x.generateDirectOutbound(),
-		},
-		"routing": x.generateRoutingRules(),
-	}
 }
 
-// Start starts the Xray-core process
-func (x *XrayManager) Start() error {
-	// Check if Xray binary exists
-	if _, err := os.Stat(x.xrayPath); os.IsNotExist(err) {
-		return fmt.Errorf("xray-core not found, please run download first")
-	}
-
-	// Check if already running
-	if x.IsRunning() {
-		return fmt.Errorf("xray-core is already running")
-	}
+// generateWireGuardOutbound generates WireGuard outbound
+func (x *XrayManager) generateWireGuardOutbound(node *Node) map[string]interface{} {
+	wg := node.WireGuard
 
-	// Create log file for background process
-	logFile := filepath.Join(filepath.Dir(x.xrayPath), "xray.log")
-	logFileHandle, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to create log file: %w", err)
+	allowedIPs := wg.AllowedIPs
+	if len(allowedIPs) == 0 {
+		allowedIPs = []string{"0.0.0.0/0", "::/0"}
 	}
 
-	// Start Xray process with output redirected to log file
-	x.cmd = exec.Command(x.xrayPath, "run", "-config", x.configPath)
-	x.cmd.Stdout = logFileHandle
-	x.cmd.Stderr = logFileHandle
-
-	if err := x.cmd.Start(); err != nil {
-		logFileHandle.Close()
-		return fmt.Errorf("failed to start Xray-core: %w", err)
+	peer := map[string]interface{}{
+		"publicKey":  wg.PublicKey,
+		"endpoint":   net.JoinHostPort(node.Server, strconv.Itoa(node.Port)),
+		"allowedIPs": allowedIPs,
 	}
-
-	// Close the file handle in the parent process (child process keeps its copy)
-	logFileHandle.Close()
-
-	fmt.Printf("Xray-core started on port %d (PID: %d)\n", x.localPort, x.cmd.Process.Pid)
-	fmt.Printf("Logs: %s\n", logFile)
-
-	// Save PID to file
-	pidFile := filepath.Join(filepath.Dir(x.xrayPath), "xray.pid")
-	os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", x.cmd.Process.Pid)), 0644)
-
-	return nil
-}
-
-// Stop stops the Xray-core process
-func (x *XrayManager) Stop() error {
-	pidFile := filepath.Join(filepath.Dir(x.xrayPath), "xray.pid")
-
-	// Try to stop via cmd object first
-	if x.cmd != nil && x.cmd.Process != nil {
-		if err := x.cmd.Process.Kill(); err != nil {
-			return fmt.Errorf("failed to stop Xray-core: %w", err)
-		}
-		x.cmd.Wait()
-		x.cmd = nil
-	} else {
-		// Try to stop via PID file (for processes started in previous sessions)
-		data, err := os.ReadFile(pidFile)
-		if err == nil {
-			var pid int
-			fmt.Sscanf(string(data), "%d", &pid)
-
-			if pid > 0 {
-				process, err := os.FindProcess(pid)
-				if err == nil {
-					// Try to kill the process
-					if err := process.Kill(); err != nil {
-						// Process might already be dead, that's ok
-						fmt.Printf("Note: Process %d may have already stopped\n", pid)
-					}
-				}
-			}
-		}
+	if wg.PresharedKey != "" {
+		peer["preSharedKey"] = wg.PresharedKey
 	}
 
-	// Remove PID file
-	os.Remove(pidFile)
-
-	fmt.Println("Xray-core stopped")
-	return nil
-}
-
-// IsRunning checks if Xray-core is running
-func (x *XrayManager) IsRunning() bool {
-	if x.cmd != nil && x.cmd.Process != nil {
-		// Check if process is still alive
-		err := x.cmd.Process.Signal(os.Signal(nil))
-		return err == nil
+	settings := map[string]interface{}{
+		"secretKey": wg.PrivateKey,
+		"address":   wg.Address,
+		"peers":     []map[string]interface{}{peer},
 	}
-
-	// Check PID file
-	pidFile := filepath.Join(filepath.Dir(x.xrayPath), "xray.pid")
-	data, err := os.ReadFile(pidFile)
-	if err != nil {
-		return false
+	if len(wg.Reserved) > 0 {
+		settings["reserved"] = wg.Reserved
 	}
-
-	var pid int
-	fmt.Sscanf(string(data), "%d", &pid)
-
-	// Check if process with this PID exists
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
+	if wg.MTU > 0 {
+		settings["mtu"] = wg.MTU
 	}
 
-	err = process.Signal(os.Signal(nil))
-	return err == nil
-}
-
-// GetProxyEnvVars returns environment variables for using the proxy
-func (x *XrayManager) GetProxyEnvVars() map[string]string {
-	proxyURL := fmt.Sprintf("socks5://127.0.0.1:%d", x.localPort)
-	return map[string]string{
-		"HTTP_PROXY":  proxyURL,
-		"HTTPS_PROXY": proxyURL,
-		"ALL_PROXY":   proxyURL,
-		"http_proxy":  proxyURL,
-		"https_proxy": proxyURL,
-		"all_proxy":   proxyURL,
+	return map[string]interface{}{
+		"tag":      "proxy",
+		"protocol": "wireguard",
+		"settings": settings,
 	}
 }
@@ -0,0 +1,376 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/config"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// Proxy deployment modes for a remote machine
+const (
+	ProxyNone   = "none"   // mirrors only
+	ProxyTunnel = "tunnel" // point the remote at the local proxy via ssh -R
+	ProxyNode   = "node"   // run Xray on the remote with the current node config
+)
+
+// Provisioner applies crosh configuration to a remote Linux machine over SSH
+type Provisioner struct {
+	target  string
+	sshArgs []string
+}
+
+// NewProvisioner creates a provisioner for target (user@host).
+// sshArgs are passed to every ssh invocation (e.g. -p 2222, -i key).
+func NewProvisioner(target string, sshArgs []string) *Provisioner {
+	return &Provisioner{
+		target:  target,
+		sshArgs: sshArgs,
+	}
+}
+
+// ApplyMirrors configures the mirrors from cfg on the remote machine
+func (p *Provisioner) ApplyMirrors(cfg *config.Config) error {
+	script, err := MirrorScript(&cfg.Mirror)
+	if err != nil {
+		return err
+	}
+	return p.runScript(script)
+}
+
+// ApplyTunnelProxy points the remote shell environment at httpURL and
+// socksURL, the local proxy, reachable on the remote's loopback once Tunnel
+// opens the same ports there
+func (p *Provisioner) ApplyTunnelProxy(httpURL, socksURL string) error {
+	return p.runScript(proxyEnvScript(tunnelEnd(httpURL), tunnelEnd(socksURL)))
+}
+
+// ApplyNodeProxy uploads xrayConfig, generated for the remote, and starts Xray
+// on the remote machine, pointing the remote shell environment at httpURL and
+// socksURL
+func (p *Provisioner) ApplyNodeProxy(xrayConfig []byte, httpURL, socksURL string) error {
+	// The config carries the node's credentials
+	if err := p.run("umask 077 && mkdir -p ~/.crosh && cat > ~/.crosh/config.json", xrayConfig); err != nil {
+		return fmt.Errorf("failed to upload Xray config: %w", err)
+	}
+
+	return p.runScript(xrayInstallScript + proxyEnvScript(httpURL, socksURL))
+}
+
+// tunnelEnd returns proxyURL as the remote reaches it through Tunnel, on its
+// loopback at the same port
+func tunnelEnd(proxyURL string) string {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return proxyURL
+	}
+	u.Host = net.JoinHostPort("127.0.0.1", u.Port())
+	return u.String()
+}
+
+// Tunnel opens a reverse tunnel so the remote's 127.0.0.1 reaches the local
+// proxy at each of proxyURLs on the same port. It blocks until the ssh
+// process exits.
+func (p *Provisioner) Tunnel(proxyURLs ...string) error {
+	args := append([]string{}, p.sshArgs...)
+	forwarded := map[string]bool{}
+	for _, proxyURL := range proxyURLs {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		host := u.Hostname()
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		forward := fmt.Sprintf("%s:%s:%s", u.Port(), host, u.Port())
+		if !forwarded[forward] {
+			forwarded[forward] = true
+			args = append(args, "-R", forward)
+		}
+	}
+	args = append(args, "-N", p.target)
+
+	cmd := logging.Command("ssh", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runScript runs a shell script on the remote machine
+func (p *Provisioner) runScript(script string) error {
+	return p.run("sh -s", []byte("set -e\n"+script))
+}
+
+// run executes a remote command with stdin as its input
+func (p *Provisioner) run(remoteCmd string, stdin []byte) error {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return fmt.Errorf("ssh client not found in PATH")
+	}
+
+	args := append([]string{}, p.sshArgs...)
+	args = append(args, p.target, remoteCmd)
+
+	cmd := logging.Command("ssh", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ssh %s failed: %w", p.target, err)
+	}
+	return nil
+}
+
+// MirrorScript returns a POSIX shell script that configures the given mirrors.
+// The mirrors may come from a teammate's bundle, so each goes into the script
+// quoted; a registry the remote's user set themselves stays unless
+// m.Overwrite is set, as it does on this machine.
+func MirrorScript(m *config.MirrorConfig) (string, error) {
+	for _, value := range append([]string{m.NPM, m.Pip, m.Cargo, m.Go, m.Apt}, m.Docker...) {
+		if _, err := url.Parse(value); err != nil {
+			return "", fmt.Errorf("invalid mirror %q: %w", value, err)
+		}
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `SUDO=""
+if [ "$(id -u)" -ne 0 ]; then SUDO="sudo -n"; fi
+OVERWRITE=%t
+# crosh_own VALUE KNOWN...: whether VALUE is a setting of the user's to keep
+crosh_own() {
+  value=$1
+  shift
+  [ -n "$value" ] && [ "$OVERWRITE" != true ] || return 1
+  for known in "$@"; do
+    [ "$value" != "$known" ] || return 1
+  done
+}
+`, m.Overwrite)
+
+	if m.NPM != "" {
+		fmt.Fprintf(&b, `NPM=%s
+touch ~/.npmrc
+CURRENT=$(sed -n 's/^registry *= *//p' ~/.npmrc | tail -n 1)
+if crosh_own "$CURRENT" "$NPM" %s; then
+  printf '%%s\n' "○ npm mirror skipped: kept your registry=$CURRENT in ~/.npmrc"
+else
+  grep -v '^registry *=' ~/.npmrc > ~/.npmrc.crosh || true
+  printf 'registry=%%s\n' "$NPM" >> ~/.npmrc.crosh
+  mv ~/.npmrc.crosh ~/.npmrc
+  printf '%%s\n' "✓ NPM mirror enabled: $NPM"
+fi
+`, shellQuote(m.NPM), knownMirrors("npm", "https://registry.npmjs.org/", "https://registry.npmjs.org"))
+	}
+
+	if m.Pip != "" {
+		indexURL := sedEscape(m.Pip)
+		fmt.Fprintf(&b, `PIP=%s
+mkdir -p ~/.config/pip
+touch ~/.config/pip/pip.conf
+CURRENT=$(sed -n 's/^index-url *= *//p' ~/.config/pip/pip.conf | tail -n 1)
+if crosh_own "$CURRENT" "$PIP" %s; then
+  printf '%%s\n' "○ pip mirror skipped: kept your index-url = $CURRENT in ~/.config/pip/pip.conf"
+else
+  if [ -n "$CURRENT" ]; then
+    sed -i %s ~/.config/pip/pip.conf
+  elif grep -q '^\[global\]' ~/.config/pip/pip.conf; then
+    sed -i %s ~/.config/pip/pip.conf
+  else
+    printf '[global]\nindex-url = %%s\n' "$PIP" >> ~/.config/pip/pip.conf
+  fi
+  printf '%%s\n' "✓ Pip mirror enabled: $PIP"
+fi
+`, shellQuote(m.Pip), knownMirrors("pip", "https://pypi.org/simple", "https://pypi.org/simple/"),
+			shellQuote("s|^index-url.*|index-url = "+indexURL+"|"), shellQuote(`s|^\[global\]|[global]\nindex-url = `+indexURL+"|"))
+	}
+
+	if m.Cargo != "" {
+		fmt.Fprintf(&b, `CARGO=%s
+mkdir -p ~/.cargo
+touch ~/.cargo/config.toml
+if grep -q '^\[source\.ustc\]' ~/.cargo/config.toml; then
+  printf '%%s\n' "✓ Cargo mirror enabled: $CARGO"
+elif grep -q '^\[source\.crates-io\]' ~/.cargo/config.toml; then
+  echo "○ cargo mirror skipped: kept your [source.crates-io] in ~/.cargo/config.toml"
+else
+  printf '\n[source.crates-io]\nreplace-with = '"'"'ustc'"'"'\n\n[source.ustc]\nregistry = "%%s"\n' "$CARGO" >> ~/.cargo/config.toml
+  printf '%%s\n' "✓ Cargo mirror enabled: $CARGO"
+fi
+`, shellQuote(m.Cargo))
+	}
+
+	if m.Go != "" {
+		fmt.Fprintf(&b, `GOPROXY_MIRROR=%s
+KEPT=""
+for rc in ~/.bashrc ~/.profile; do
+  touch "$rc"
+  CURRENT=$(sed -n 's/^export GOPROXY=//p' "$rc" | tail -n 1 | tr -d "'\"")
+  if crosh_own "$CURRENT" "$GOPROXY_MIRROR" %s; then
+    KEPT="export GOPROXY=$CURRENT in $rc"
+  fi
+done
+if [ -n "$KEPT" ]; then
+  printf '%%s\n' "○ go mirror skipped: kept your $KEPT"
+else
+  for rc in ~/.bashrc ~/.profile; do
+    sed -i '/export GOPROXY=/d' "$rc"
+    printf '\n# Added by crosh\nexport GOPROXY=%%s\n' %s >> "$rc"
+  done
+  printf '%%s\n' "✓ Go proxy enabled: $GOPROXY_MIRROR"
+fi
+`, shellQuote(m.Go), knownMirrors("go", "https://proxy.golang.org,direct"), shellQuote(shellQuote(m.Go)))
+	}
+
+	if len(m.Docker) > 0 {
+		registries := make([]string, len(m.Docker))
+		for i, reg := range m.Docker {
+			if !strings.HasPrefix(reg, "http://") && !strings.HasPrefix(reg, "https://") {
+				reg = "https://" + reg
+			}
+			registries[i] = reg
+		}
+		daemonJSON, err := json.Marshal(map[string][]string{"registry-mirrors": registries})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal daemon.json: %w", err)
+		}
+		fmt.Fprintf(&b, `if command -v dockerd >/dev/null 2>&1; then
+  if [ ! -s /etc/docker/daemon.json ]; then
+    $SUDO mkdir -p /etc/docker
+    printf '%%s\n' %s | $SUDO tee /etc/docker/daemon.json >/dev/null && echo "✓ Docker mirror enabled (restart docker to apply)" || echo "⚠ Docker mirror skipped: need root or passwordless sudo"
+  else
+    echo "⚠ Docker mirror skipped: /etc/docker/daemon.json already exists"
+  fi
+fi
+`, shellQuote(string(daemonJSON)))
+	}
+
+	if m.Apt != "" {
+		fmt.Fprintf(&b, `APT=%s
+if [ -f /etc/os-release ] && . /etc/os-release && [ "$ID" = "ubuntu" ] && [ -n "$VERSION_CODENAME" ]; then
+  if $SUDO test -w /etc/apt/sources.list; then
+    $SUDO test -f /etc/apt/sources.list.crosh.backup || $SUDO cp /etc/apt/sources.list /etc/apt/sources.list.crosh.backup
+    $SUDO tee /etc/apt/sources.list >/dev/null <<EOF
+# Generated by crosh - Chinese mirror acceleration
+deb http://$APT/ubuntu/ $VERSION_CODENAME main restricted universe multiverse
+deb http://$APT/ubuntu/ $VERSION_CODENAME-updates main restricted universe multiverse
+deb http://$APT/ubuntu/ $VERSION_CODENAME-backports main restricted universe multiverse
+deb http://$APT/ubuntu/ $VERSION_CODENAME-security main restricted universe multiverse
+EOF
+    printf '%%s\n' "✓ Apt mirror enabled: $APT"
+  else
+    echo "⚠ Apt mirror skipped: need root or passwordless sudo"
+  fi
+fi
+`, shellQuote(m.Apt))
+	}
+
+	return b.String(), nil
+}
+
+// knownMirrors returns, quoted for the shell, the values of tool's setting
+// that aren't the user's own: crosh's default and preset mirrors and the
+// tool's official ones
+func knownMirrors(tool string, official ...string) string {
+	values := append([]string{config.DefaultConfig().Mirror.URL(tool)}, official...)
+	for _, preset := range config.MirrorPresets {
+		values = append(values, preset.URL(tool))
+	}
+	var quoted []string
+	for _, value := range values {
+		if value != "" {
+			quoted = append(quoted, shellQuote(value))
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// sedEscape escapes s for the replacement of a sed s|...|...| command
+func sedEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "&", `\&`).Replace(s)
+}
+
+// shellQuote quotes s as a single word for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// proxyEnvScript writes the proxy environment variables to ~/.crosh/proxy.env
+// on the remote, readable only by its user as the URLs may carry the proxy
+// login, and has the remote's shell rc files read them from there. Like on
+// this machine, HTTP_PROXY and HTTPS_PROXY get httpURL, as many tools only
+// understand http:// there, and ALL_PROXY gets socksURL.
+func proxyEnvScript(httpURL, socksURL string) string {
+	shown := "the local proxy"
+	if u, err := url.Parse(httpURL); err == nil {
+		shown = u.Redacted()
+	}
+	return fmt.Sprintf(`mkdir -p ~/.crosh
+(
+  umask 077
+  : > ~/.crosh/proxy.env
+  chmod 600 ~/.crosh/proxy.env
+  for var in HTTP_PROXY HTTPS_PROXY http_proxy https_proxy; do
+    printf 'export %%s=%%s\n' "$var" %s >> ~/.crosh/proxy.env
+  done
+  for var in ALL_PROXY all_proxy; do
+    printf 'export %%s=%%s\n' "$var" %s >> ~/.crosh/proxy.env
+  done
+)
+for rc in ~/.bashrc ~/.profile; do
+  touch "$rc"
+  sed -i '/# crosh proxy/d' "$rc"
+  echo 'if [ -f ~/.crosh/proxy.env ]; then . ~/.crosh/proxy.env; fi # crosh proxy' >> "$rc"
+done
+echo %s
+`, shellQuote(shellQuote(httpURL)), shellQuote(shellQuote(socksURL)), shellQuote("✓ Proxy environment set to "+shown+" (open a new shell to apply)"))
+}
+
+// xrayInstallScript downloads Xray-core on the remote (if missing), checked
+// against the SHA2-256 in the release's .dgst file, and starts it
+const xrayInstallScript = `cd ~/.crosh
+if [ ! -x ./xray-core ]; then
+  case "$(uname -m)" in
+    x86_64) ARCH=64 ;;
+    aarch64|arm64) ARCH=arm64-v8a ;;
+    armv7l) ARCH=arm32-v7a ;;
+    *) echo "✗ Unsupported architecture: $(uname -m)"; exit 1 ;;
+  esac
+  VERSION=$(curl -fsSL https://crosh.boomyao.com/xray/VERSION || echo v1.8.4)
+  ZIP="Xray-linux-$ARCH.zip"
+  rm -f xray.zip xray.zip.dgst
+  for BASE in "https://crosh.boomyao.com/xray/$VERSION" "https://github.com/XTLS/Xray-core/releases/download/$VERSION"; do
+    if curl -fsSL -o xray.zip "$BASE/$ZIP" && curl -fsSL -o xray.zip.dgst "$BASE/$ZIP.dgst"; then
+      break
+    fi
+    rm -f xray.zip xray.zip.dgst
+  done
+  if [ ! -f xray.zip.dgst ]; then
+    echo "✗ Failed to download Xray-core $VERSION"; exit 1
+  fi
+  EXPECTED=$(sed -n 's/^SHA2-256= *//p' xray.zip.dgst | tr -d '\r')
+  ACTUAL=$(sha256sum xray.zip | cut -d ' ' -f 1)
+  rm -f xray.zip.dgst
+  if [ -z "$EXPECTED" ] || [ "$EXPECTED" != "$ACTUAL" ]; then
+    rm -f xray.zip
+    echo "✗ Xray-core download doesn't match the SHA2-256 in $ZIP.dgst"; exit 1
+  fi
+  unzip -o -q xray.zip xray geoip.dat geosite.dat && mv xray xray-core && rm -f xray.zip
+  chmod +x xray-core
+fi
+if [ -f xray.pid ] && kill -0 "$(cat xray.pid)" 2>/dev/null; then
+  kill "$(cat xray.pid)"
+fi
+nohup ./xray-core run -config config.json >> xray.log 2>&1 &
+echo $! > xray.pid
+echo "✓ Xray-core started on remote (PID: $(cat xray.pid))"
+`
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Status describes the installed service
+type Status struct {
+	Installed bool
+	Kind      string // of service, e.g. "systemd user unit"
+	Path      string // of the unit, agent or registry value
+}
+
+// command returns the command line the service runs
+func command() ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate crosh executable: %w", err)
+	}
+	// Point at the real binary rather than a symlink a package manager may move
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return []string{exe, "daemon", "--service"}, nil
+}
@@ -0,0 +1,131 @@
+//go:build darwin
+
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	kind  = "launchd agent"
+	label = "com.boomyao.crosh"
+)
+
+// Hint tells how the service starts
+const Hint = "It starts when you log in"
+
+const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`
+
+// plistPath returns the path of the launchd agent
+func plistPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", label+".plist"), nil
+}
+
+// domain returns the launchd domain of the user's login session
+func domain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// Install registers the daemon as a launchd agent and starts it
+func Install() error {
+	args, err := command()
+	if err != nil {
+		return err
+	}
+	logPath, err := daemon.LogPath()
+	if err != nil {
+		return err
+	}
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+
+	var arguments strings.Builder
+	for _, arg := range args {
+		arguments.WriteString("\t\t<string>" + escape(arg) + "</string>\n")
+	}
+	plist := fmt.Sprintf(plistTemplate, label, arguments.String(), escape(logPath), escape(logPath))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+	if err := logging.WriteFile(path, []byte(plist), 0644); err != nil {
+		return fmt.Errorf("failed to write agent: %w", err)
+	}
+
+	// Unload an earlier install first, so the new command line is used
+	logging.Command("launchctl", "bootout", domain()+"/"+label).Run()
+	if out, err := logging.Command("launchctl", "bootstrap", domain(), path).CombinedOutput(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to load agent: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Uninstall stops and removes the launchd agent
+func Uninstall() error {
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	// Go on if launchd already unloaded the agent
+	logging.Command("launchctl", "bootout", domain()+"/"+label).Run()
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove agent: %w", err)
+	}
+	return nil
+}
+
+// GetStatus reports whether the launchd agent is installed
+func GetStatus() (*Status, error) {
+	path, err := plistPath()
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(path)
+	return &Status{Installed: err == nil, Kind: kind, Path: path}, nil
+}
+
+// escape escapes s for XML text
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
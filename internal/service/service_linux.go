@@ -0,0 +1,138 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+const (
+	kind     = "systemd user unit"
+	unitName = "crosh.service"
+)
+
+// Hint tells how the service starts
+const Hint = "It starts when you log in; to start it at boot, run: loginctl enable-linger"
+
+const unitTemplate = `[Unit]
+Description=crosh acceleration daemon
+After=network-online.target
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+RestartSec=10
+StandardOutput=append:%s
+StandardError=append:%s
+
+[Install]
+WantedBy=default.target
+`
+
+// unitPath returns the path of the systemd user unit
+func unitPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "systemd", "user", unitName), nil
+}
+
+// systemctl runs systemctl --user with args
+func systemctl(args ...string) error {
+	out, err := logging.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Install registers the daemon as a systemd user unit and starts it
+func Install() error {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return fmt.Errorf("systemctl not found, crosh service needs systemd")
+	}
+
+	args, err := command()
+	if err != nil {
+		return err
+	}
+	logPath, err := daemon.LogPath()
+	if err != nil {
+		return err
+	}
+	path, err := unitPath()
+	if err != nil {
+		return err
+	}
+
+	for i, arg := range args {
+		args[i] = quote(arg)
+	}
+	unit := fmt.Sprintf(unitTemplate, strings.Join(args, " "), logPath, logPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create unit directory: %w", err)
+	}
+	if err := logging.WriteFile(path, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write unit: %w", err)
+	}
+
+	// restart rather than start, so a reinstall runs the new command line
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", unitName}, {"restart", unitName}} {
+		if err := systemctl(args...); err != nil {
+			os.Remove(path)
+			return err
+		}
+	}
+	return nil
+}
+
+// Uninstall stops and removes the systemd user unit
+func Uninstall() error {
+	path, err := unitPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	// Go on if systemd already forgot the unit
+	systemctl("disable", "--now", unitName)
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit: %w", err)
+	}
+	systemctl("daemon-reload")
+	return nil
+}
+
+// GetStatus reports whether the systemd user unit is installed
+func GetStatus() (*Status, error) {
+	path, err := unitPath()
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(path)
+	return &Status{Installed: err == nil, Kind: kind, Path: path}, nil
+}
+
+// quote quotes arg for a systemd command line if needed; % starts a
+// systemd specifier, so it is always escaped
+func quote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Hint tells how the service starts
+const Hint = ""
+
+// Install is not supported on this platform
+func Install() error {
+	return fmt.Errorf("crosh service is not supported on %s", runtime.GOOS)
+}
+
+// Uninstall is not supported on this platform
+func Uninstall() error {
+	return Install()
+}
+
+// GetStatus is not supported on this platform
+func GetStatus() (*Status, error) {
+	return nil, Install()
+}
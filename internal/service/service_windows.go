@@ -0,0 +1,64 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boomyao/crosh/internal/daemon"
+	"github.com/boomyao/crosh/internal/logging"
+)
+
+// A Windows service needs administrator rights and the service control
+// protocol; a per-user Run key starts the daemon at logon without either
+const (
+	kind     = "logon Run key"
+	runKey   = `HKCU\Software\Microsoft\Windows\CurrentVersion\Run`
+	runValue = "crosh"
+)
+
+// Hint tells how the service starts
+const Hint = "It starts when you log in"
+
+// Install registers the daemon to start at logon and starts it
+func Install() error {
+	args, err := command()
+	if err != nil {
+		return err
+	}
+	// Started at logon from no console, so it detaches itself
+	args = append(args, "--detach")
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			args[i] = `"` + arg + `"`
+		}
+	}
+
+	out, err := logging.Command("reg", "add", runKey, "/v", runValue, "/t", "REG_SZ", "/d", strings.Join(args, " "), "/f").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to add Run key: %s", strings.TrimSpace(string(out)))
+	}
+	return daemon.Start("--service")
+}
+
+// Uninstall removes the Run key and stops the daemon
+func Uninstall() error {
+	status, err := GetStatus()
+	if err != nil || !status.Installed {
+		return err
+	}
+
+	out, err := logging.Command("reg", "delete", runKey, "/v", runValue, "/f").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove Run key: %s", strings.TrimSpace(string(out)))
+	}
+	// Unlike the daemons crosh starts itself, the service daemon doesn't exit without work
+	return daemon.Stop()
+}
+
+// GetStatus reports whether the Run key is set
+func GetStatus() (*Status, error) {
+	err := logging.Command("reg", "query", runKey, "/v", runValue).Run()
+	return &Status{Installed: err == nil, Kind: kind, Path: runKey + `\` + runValue}, nil
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package term
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// MakeRaw puts the terminal on stdin into raw mode, so keys are read as they
+// are pressed without being echoed, and returns a function restoring it.
+// It uses stty rather than ioctls, which differ between Linux and macOS.
+func MakeRaw() (restore func(), err error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	return func() { stty(saved) }, nil
+}
+
+// Size returns the width and height of the terminal on stdin, or 80x24 if
+// it can't be read
+func Size() (width, height int) {
+	out, err := stty("size")
+	if err != nil {
+		return 80, 24
+	}
+	if _, err := fmt.Sscanf(out, "%d %d", &height, &width); err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// EnableVT enables escape sequences on stdout; Unix terminals always understand them
+func EnableVT() {}
+
+// stty runs stty on the terminal on stdin. Not logged in verbose mode, which
+// would print into the UI.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,76 @@
+//go:build windows
+
+package term
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Console mode flags
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+// consoleScreenBufferInfo is CONSOLE_SCREEN_BUFFER_INFO
+type consoleScreenBufferInfo struct {
+	size, cursorPosition     [2]int16
+	attributes               uint16
+	left, top, right, bottom int16
+	maximumWindowSize        [2]int16
+}
+
+// MakeRaw puts the console on stdin into raw mode, so keys are read as they
+// are pressed without being echoed, and returns a function restoring it
+func MakeRaw() (restore func(), err error) {
+	handle := syscall.Handle(os.Stdin.Fd())
+	var saved uint32
+	if err := syscall.GetConsoleMode(handle, &saved); err != nil {
+		return nil, fmt.Errorf("failed to read console mode: %w", err)
+	}
+
+	raw := saved&^(enableProcessedInput|enableLineInput|enableEchoInput) | enableVirtualTerminalInput
+	if err := setConsoleMode(handle, raw); err != nil {
+		return nil, fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	return func() { setConsoleMode(handle, saved) }, nil
+}
+
+// Size returns the width and height of the console window, or 80x24 if it
+// can't be read
+func Size() (width, height int) {
+	var info consoleScreenBufferInfo
+	r, _, _ := procGetConsoleScreenBufferInfo.Call(os.Stdout.Fd(), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return 80, 24
+	}
+	return int(info.right-info.left) + 1, int(info.bottom-info.top) + 1
+}
+
+// EnableVT makes the console on stdout interpret escape sequences
+func EnableVT() {
+	handle := syscall.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(handle, &mode); err == nil {
+		setConsoleMode(handle, mode|enableVirtualTerminalProcessing)
+	}
+}
+
+func setConsoleMode(handle syscall.Handle, mode uint32) error {
+	if r, _, err := procSetConsoleMode.Call(uintptr(handle), uintptr(mode)); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package term
+
+import (
+	"bufio"
+	"os"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Escape sequences for drawing full-screen terminal UIs
+const (
+	EnterAltScreen = "\x1b[?1049h\x1b[?25l"
+	ExitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	Home           = "\x1b[H"
+	ClearLine      = "\x1b[K"
+	ClearBelow     = "\x1b[J"
+	Reverse        = "\x1b[7m"
+	Dim            = "\x1b[2m"
+	Reset          = "\x1b[0m"
+)
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Key is a key press read by ReadKey
+type Key struct {
+	Name string // "up", "down", "pgup", "pgdown", "enter", "esc", "backspace", "ctrl-c" etc., or "" for a character
+	Rune rune   // the character typed, if Name is ""
+}
+
+// ReadKey reads one key press from a terminal in raw mode
+func ReadKey(r *bufio.Reader) (Key, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return Key{Name: "enter"}, nil
+	case 0x7f, 0x08:
+		return Key{Name: "backspace"}, nil
+	case '\t':
+		return Key{Name: "tab"}, nil
+	case 0x1b:
+		return readEscape(r)
+	}
+	if c < 0x20 {
+		// Ctrl-A is 1, Ctrl-Z is 26
+		return Key{Name: "ctrl-" + string(rune('a'+c-1))}, nil
+	}
+	return Key{Rune: c}, nil
+}
+
+// readEscape reads the rest of an escape sequence, such as "[A" for the up arrow
+func readEscape(r *bufio.Reader) (Key, error) {
+	// A lone Esc has nothing buffered after it
+	if r.Buffered() == 0 {
+		return Key{Name: "esc"}, nil
+	}
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+	if c != '[' && c != 'O' {
+		return Key{Name: "esc"}, nil
+	}
+
+	seq := ""
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return Key{}, err
+		}
+		seq += string(c)
+		if c >= 0x40 && c <= 0x7e {
+			break
+		}
+	}
+
+	switch seq {
+	case "A":
+		return Key{Name: "up"}, nil
+	case "B":
+		return Key{Name: "down"}, nil
+	case "C":
+		return Key{Name: "right"}, nil
+	case "D":
+		return Key{Name: "left"}, nil
+	case "H", "1~":
+		return Key{Name: "home"}, nil
+	case "F", "4~":
+		return Key{Name: "end"}, nil
+	case "5~":
+		return Key{Name: "pgup"}, nil
+	case "6~":
+		return Key{Name: "pgdown"}, nil
+	}
+	return Key{Name: "unknown"}, nil
+}
+
+// Width returns how many columns s takes up, counting wide characters such
+// as CJK and emoji as two
+func Width(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// Truncate cuts s to at most width columns, ending it with "…" if it was cut
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	used := 0
+	for i, r := range s {
+		w := runeWidth(r)
+		if used+w > width-1 {
+			return s[:i] + "…"
+		}
+		used += w
+	}
+	return s
+}
+
+// Pad truncates or pads s with spaces to exactly width columns
+func Pad(s string, width int) string {
+	s = Truncate(s, width)
+	for w := Width(s); w < width; w++ {
+		s += " "
+	}
+	return s
+}
+
+// runeWidth approximates the columns a rune takes up in a terminal
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError || unicode.Is(unicode.Mn, r) || r == 0x200d || r >= 0xfe00 && r <= 0xfe0f:
+		// Combining marks, zero width joiners and variation selectors
+		return 0
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		// Regional indicators come in pairs that show as one two-column flag
+		return 1
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6, r >= 0x1f000 && r <= 0x1faff, r >= 0x20000 && r <= 0x3fffd:
+		// CJK, Hangul, full-width forms and emoji (including regional indicator flags)
+		return 2
+	default:
+		return 1
+	}
+}